@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"clicrontab/internal/config"
+)
+
+// attachPollInterval is how often attach checks for the log of a run that has not
+// written one yet.
+const attachPollInterval = 500 * time.Millisecond
+
+// runAttach implements "attach <task>": it starts a run of the task, or joins the one
+// already running, and streams its output until it finishes. The first Ctrl-C cancels
+// the run, a second one detaches and leaves the run alone. It returns the process exit
+// code, which mirrors the run's.
+func runAttach() int {
+	ref := ""
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		ref = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+	endpoint := flag.String("url", "", "Daemon base URL (default: derived from the listen address)")
+	cfg, err := config.Parse()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse config: %v\n", err)
+		return 1
+	}
+	if ref == "" {
+		fmt.Fprintln(os.Stderr, "usage: clicrontabd attach <task id, id prefix or name> [--url http://127.0.0.1:7070]")
+		return 2
+	}
+	base := *endpoint
+	if base == "" {
+		base = daemonURL(cfg.Addr)
+	}
+	client := &daemonClient{base: strings.TrimRight(base, "/"), token: cfg.AuthToken}
+	ctx := context.Background()
+
+	var task struct {
+		ID   string  `json:"id"`
+		Name *string `json:"name"`
+	}
+	if err := client.call(ctx, http.MethodGet, "/v1/tasks/"+url.PathEscape(ref), &task); err != nil {
+		fmt.Fprintf(os.Stderr, "find task %q: %v\n", ref, err)
+		return 1
+	}
+	runID, started, err := client.attachRun(ctx, task.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "start run: %v\n", err)
+		return 1
+	}
+	label := task.ID
+	if task.Name != nil {
+		label = *task.Name
+	}
+	if started {
+		fmt.Fprintf(os.Stderr, "started run %s of %s (Ctrl-C cancels it, twice detaches)\n", runID, label)
+	} else {
+		fmt.Fprintf(os.Stderr, "attached to running run %s of %s (Ctrl-C cancels it, twice detaches)\n", runID, label)
+	}
+
+	streamCtx, detach := context.WithCancel(ctx)
+	defer detach()
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		fmt.Fprintf(os.Stderr, "\ncanceling run %s...\n", runID)
+		if err := client.call(ctx, http.MethodPost, "/v1/runs/"+runID+"/cancel", nil); err != nil {
+			fmt.Fprintf(os.Stderr, "cancel run: %v\n", err)
+		}
+		<-signals
+		detach()
+	}()
+
+	if err := client.streamLog(streamCtx, runID, os.Stdout); err != nil && streamCtx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "stream log: %v\n", err)
+		return 1
+	}
+	if streamCtx.Err() != nil {
+		fmt.Fprintf(os.Stderr, "detached; run %s continues in the daemon\n", runID)
+		return 130
+	}
+
+	var run struct {
+		Status   string  `json:"status"`
+		ExitCode *int    `json:"exit_code"`
+		Error    *string `json:"error"`
+	}
+	if err := client.call(ctx, http.MethodGet, "/v1/runs/"+runID, &run); err != nil {
+		fmt.Fprintf(os.Stderr, "get run: %v\n", err)
+		return 1
+	}
+	summary := "run " + runID + " " + run.Status
+	if run.ExitCode != nil {
+		summary += fmt.Sprintf(" (exit code %d)", *run.ExitCode)
+	}
+	if run.Error != nil && *run.Error != "" {
+		summary += ": " + *run.Error
+	}
+	fmt.Fprintln(os.Stderr, summary)
+	switch {
+	case run.Status == "succeeded":
+		return 0
+	case run.ExitCode != nil && *run.ExitCode > 0:
+		return *run.ExitCode
+	default:
+		return 1
+	}
+}
+
+// daemonClient calls the HTTP API of a running daemon.
+type daemonClient struct {
+	base  string
+	token string
+}
+
+// apiError is an error response of the API.
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("HTTP %d", e.Status)
+	}
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.Status)
+}
+
+// open sends a request and returns the response, or an *apiError for a non-2xx status.
+func (c *daemonClient) open(ctx context.Context, method, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var body struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return nil, &apiError{Status: resp.StatusCode, Code: body.Error.Code, Message: body.Error.Message}
+	}
+	return resp, nil
+}
+
+// call sends a request and decodes the JSON response into out unless out is nil.
+func (c *daemonClient) call(ctx context.Context, method, path string, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	resp, err := c.open(ctx, method, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// attachRun returns the task's active run, starting one if there is none; started
+// reports which.
+func (c *daemonClient) attachRun(ctx context.Context, taskID string) (runID string, started bool, err error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		var active []struct {
+			ID     string `json:"id"`
+			TaskID string `json:"task_id"`
+		}
+		if err := c.call(ctx, http.MethodGet, "/v1/runs/active", &active); err != nil {
+			return "", false, err
+		}
+		for _, run := range active {
+			if run.TaskID == taskID {
+				return run.ID, false, nil
+			}
+		}
+		var created struct {
+			RunID string `json:"run_id"`
+		}
+		err = c.call(ctx, http.MethodPost, "/v1/tasks/"+taskID+"/run", &created)
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.Code == "already_running" {
+			// Started by its schedule between the two calls; join that run
+			continue
+		}
+		return created.RunID, true, err
+	}
+	return "", false, err
+}
+
+// streamLog copies the run's log to w as it is written, until the run finishes or ctx
+// is done. A queued run has no log yet, so a missing log is polled for.
+func (c *daemonClient) streamLog(ctx context.Context, runID string, w io.Writer) error {
+	for {
+		resp, err := c.open(ctx, http.MethodGet, "/v1/runs/"+runID+"/log?follow=1")
+		if err == nil {
+			defer resp.Body.Close()
+			_, err = io.Copy(w, resp.Body)
+			return err
+		}
+		var apiErr *apiError
+		if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+			return err
+		}
+		var run struct {
+			Status string `json:"status"`
+		}
+		if err := c.call(ctx, http.MethodGet, "/v1/runs/"+runID, &run); err != nil {
+			return err
+		}
+		if run.Status != "queued" && run.Status != "running" {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(attachPollInterval):
+		}
+	}
+}
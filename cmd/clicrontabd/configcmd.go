@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"clicrontab/internal/config"
+)
+
+// runConfig implements "config validate" and "config show". It returns the process exit code.
+func runConfig() int {
+	action := ""
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		action = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+	switch action {
+	case "show", "validate":
+	default:
+		fmt.Fprintln(os.Stderr, "usage: clicrontabd config {show|validate} [--json] [flags]")
+		return 2
+	}
+
+	jsonOutput := flag.Bool("json", false, "Print as JSON")
+	cfg, err := config.Parse()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+		return 1
+	}
+
+	if action == "show" {
+		return showConfig(cfg, *jsonOutput)
+	}
+	return validateConfig(cfg, *jsonOutput)
+}
+
+// showConfig prints the effective configuration after merging flags, env, .env files
+// and defaults, with the source of each value.
+func showConfig(cfg *config.Config, jsonOutput bool) int {
+	settings := cfg.Settings()
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(map[string]any{"env_files": cfg.EnvFiles(), "settings": settings}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	if files := cfg.EnvFiles(); len(files) > 0 {
+		fmt.Printf("# .env files: %s\n", strings.Join(files, ", "))
+	} else {
+		fmt.Println("# .env files: (none)")
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, s := range settings {
+		value := s.Value
+		if value == "" {
+			value = "(empty)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Key, value, s.Source)
+	}
+	tw.Flush()
+	return 0
+}
+
+// validateConfig reports values that were ignored and settings that look unintended.
+// It returns 1 when any value was ignored.
+func validateConfig(cfg *config.Config, jsonOutput bool) int {
+	warnings := cfg.Warnings()
+	if jsonOutput {
+		out, _ := json.MarshalIndent(map[string]any{
+			"valid":    len(cfg.Issues) == 0,
+			"errors":   nonNil(cfg.Issues),
+			"warnings": nonNil(warnings),
+		}, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		for _, issue := range cfg.Issues {
+			fmt.Printf("error: %s\n", issue)
+		}
+		for _, warning := range warnings {
+			fmt.Printf("warning: %s\n", warning)
+		}
+		if len(cfg.Issues) == 0 {
+			fmt.Printf("config ok (%d warnings)\n", len(warnings))
+		}
+	}
+	if len(cfg.Issues) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
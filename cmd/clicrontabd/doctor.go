@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"clicrontab/internal/config"
+	"clicrontab/internal/core"
+	"clicrontab/internal/notify"
+	"clicrontab/internal/store"
+)
+
+// Doctor check outcomes.
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// doctorEngines lists the AI CLI binaries tasks are commonly built around.
+var doctorEngines = []string{"claude"}
+
+// checkResult is one line of the doctor report.
+type checkResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+type doctorReport struct {
+	Version string        `json:"go_version"`
+	OS      string        `json:"os"`
+	Checks  []checkResult `json:"checks"`
+	Failed  int           `json:"failed"`
+	Warned  int           `json:"warned"`
+}
+
+func (r *doctorReport) add(name, status, detail string) {
+	r.Checks = append(r.Checks, checkResult{Name: name, Status: status, Detail: detail})
+	switch status {
+	case checkFail:
+		r.Failed++
+	case checkWarn:
+		r.Warned++
+	}
+}
+
+// runDoctor validates the environment the daemon depends on and prints a pass/fail report.
+// It returns the process exit code: 1 when any check fails.
+func runDoctor() int {
+	jsonOutput := flag.Bool("json", false, "Print the doctor report as JSON")
+	cfg, err := config.Parse()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse config: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	report := &doctorReport{Version: runtime.Version(), OS: runtime.GOOS + "/" + runtime.GOARCH}
+	checkStateDir(report, cfg.StateDir)
+	storeInst := checkDatabase(ctx, report, cfg)
+	if storeInst != nil {
+		defer storeInst.DB.Close()
+	}
+	checkShell(ctx, report)
+	checkEngines(ctx, report)
+	checkTimezone(report, cfg.UseUTC)
+	checkNotifications(ctx, report, cfg, storeInst)
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	} else {
+		printDoctorReport(report)
+	}
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func printDoctorReport(report *doctorReport) {
+	fmt.Printf("clicrontabd doctor (%s, %s)\n\n", report.OS, report.Version)
+	for _, check := range report.Checks {
+		fmt.Printf("[%s] %-22s %s\n", strings.ToUpper(check.Status), check.Name, check.Detail)
+	}
+	fmt.Printf("\n%d checks, %d failed, %d warnings\n", len(report.Checks), report.Failed, report.Warned)
+}
+
+func checkStateDir(report *doctorReport, dir string) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		report.add("state_dir", checkFail, fmt.Sprintf("%s: %v", dir, err))
+		return
+	}
+	probe, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		report.add("state_dir", checkFail, fmt.Sprintf("%s is not writable: %v", dir, err))
+		return
+	}
+	probe.Close()
+	_ = os.Remove(probe.Name())
+	report.add("state_dir", checkPass, dir+" is writable")
+}
+
+func checkDatabase(ctx context.Context, report *doctorReport, cfg *config.Config) *store.Store {
+	storeInst, err := store.Open(ctx, cfg.StateDir, cfg.RunLogKeep)
+	if err != nil {
+		report.add("sqlite", checkFail, err.Error())
+		return nil
+	}
+	result, err := storeInst.IntegrityCheck(ctx)
+	switch {
+	case err != nil:
+		report.add("sqlite", checkFail, err.Error())
+	case result != "ok":
+		report.add("sqlite", checkFail, "integrity check: "+result)
+	default:
+		report.add("sqlite", checkPass, filepath.Join(cfg.StateDir, "db.sqlite")+" integrity ok")
+	}
+	return storeInst
+}
+
+func checkShell(ctx context.Context, report *doctorReport) {
+	shellCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	cmd := core.ShellCommand(shellCtx, "echo ok")
+	out, err := cmd.CombinedOutput()
+	name := strings.Join(cmd.Args[:len(cmd.Args)-1], " ")
+	if err != nil {
+		report.add("shell", checkFail, fmt.Sprintf("%s failed: %v", name, err))
+		return
+	}
+	if !strings.Contains(string(out), "ok") {
+		report.add("shell", checkWarn, fmt.Sprintf("%s ran but printed unexpected output: %q", name, strings.TrimSpace(string(out))))
+		return
+	}
+	report.add("shell", checkPass, name+" works")
+}
+
+// checkEngines looks for engine binaries both on the daemon's PATH and on the PATH tasks
+// see through the login shell, which often differ under launchd/systemd.
+func checkEngines(ctx context.Context, report *doctorReport) {
+	for _, engine := range doctorEngines {
+		daemonPath, daemonErr := exec.LookPath(engine)
+
+		shellCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		lookup := "command -v " + engine
+		if runtime.GOOS == "windows" {
+			lookup = "where " + engine
+		}
+		out, shellErr := core.ShellCommand(shellCtx, lookup).Output()
+		cancel()
+		shellPath := strings.TrimSpace(string(out))
+
+		name := "engine:" + engine
+		switch {
+		case shellErr == nil && shellPath != "":
+			report.add(name, checkPass, "found in task shell: "+shellPath)
+		case daemonErr == nil:
+			report.add(name, checkWarn, fmt.Sprintf("found on daemon PATH (%s) but not in the task shell", daemonPath))
+		default:
+			report.add(name, checkWarn, "not found on PATH; prompt-based tasks will fail")
+		}
+	}
+}
+
+func checkTimezone(report *doctorReport, useUTC bool) {
+	if _, err := time.LoadLocation("America/New_York"); err != nil {
+		report.add("timezone", checkFail, fmt.Sprintf("timezone database unavailable: %v", err))
+		return
+	}
+	zone, offset := time.Now().Zone()
+	detail := fmt.Sprintf("local zone %s (UTC%+03d:%02d)", zone, offset/3600, abs(offset%3600)/60)
+	if useUTC {
+		detail += "; cron evaluated in UTC"
+	}
+	report.add("timezone", checkPass, detail)
+}
+
+// checkNotifications verifies that enabled channel endpoints are reachable without sending a message.
+func checkNotifications(ctx context.Context, report *doctorReport, cfg *config.Config, storeInst *store.Store) {
+	settings := notificationSettingsFromConfig(cfg)
+	if storeInst != nil {
+		var err error
+		if settings, err = loadNotificationSettings(ctx, cfg, storeInst); err != nil {
+			report.add("notifications", checkWarn, err.Error())
+		}
+	}
+	channels := []struct {
+		name     string
+		settings notify.ChannelSettings
+	}{
+		{notify.ChannelBark, settings.Bark},
+		{notify.ChannelWebhook, settings.Webhook},
+		{notify.ChannelEmail, settings.Email},
+	}
+	enabled := 0
+	for _, channel := range channels {
+		if !channel.settings.Enabled {
+			continue
+		}
+		enabled++
+		check := "notify:" + channel.name
+		raw := channel.settings.URL
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			report.add(check, checkFail, fmt.Sprintf("invalid URL %q", raw))
+			continue
+		}
+		host := u.Host
+		if u.Port() == "" {
+			port := "443"
+			switch u.Scheme {
+			case "http":
+				port = "80"
+			case "smtp":
+				port = "587"
+			case "smtps":
+				port = "465"
+			}
+			host = net.JoinHostPort(u.Hostname(), port)
+		}
+		dialer := net.Dialer{Timeout: 5 * time.Second}
+		conn, err := dialer.DialContext(ctx, "tcp", host)
+		if err != nil {
+			report.add(check, checkFail, fmt.Sprintf("cannot reach %s: %v", host, err))
+			continue
+		}
+		conn.Close()
+		report.add(check, checkPass, host+" reachable")
+	}
+	if enabled == 0 {
+		report.add("notifications", checkPass, "no channels enabled")
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
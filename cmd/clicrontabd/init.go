@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"clicrontab/internal/config"
+	"clicrontab/internal/notify"
+)
+
+// initDefaultAddr is the listen address suggested by init; local-only is the safe default.
+const initDefaultAddr = "127.0.0.1:7070"
+
+// prompter asks questions on stdin; with assumeYes it accepts every default.
+type prompter struct {
+	in        *bufio.Reader
+	out       io.Writer
+	assumeYes bool
+}
+
+func (p *prompter) ask(question, def string) string {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	if p.assumeYes {
+		fmt.Fprintln(p.out)
+		return def
+	}
+	line, _ := p.in.ReadString('\n')
+	if line = strings.TrimSpace(line); line == "" {
+		return def
+	}
+	return line
+}
+
+func (p *prompter) confirm(question string, def bool) bool {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	switch strings.ToLower(p.ask(question+" ("+hint+")", "")) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	default:
+		return def
+	}
+}
+
+// runInit interactively writes a .env file for first-time users. It returns the process exit code.
+func runInit() int {
+	output := flag.String("output", "", "File to write (default: <config dir>/clicrontab/.env)")
+	assumeYes := flag.Bool("yes", false, "Accept all defaults without prompting")
+	force := flag.Bool("force", false, "Overwrite an existing file without asking")
+	flag.Parse()
+
+	p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout, assumeYes: *assumeYes}
+	fmt.Println("clicrontab setup — press Enter to accept the value in [brackets].")
+	fmt.Println()
+
+	path := *output
+	if path == "" {
+		userFile, err := config.UserEnvFile()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "resolve config dir: %v\n", err)
+			return 1
+		}
+		path = p.ask("Config file to write", userFile)
+	}
+	if _, err := os.Stat(path); err == nil && !*force {
+		if !p.confirm(path+" exists. Overwrite it (a .bak copy is kept)?", false) {
+			fmt.Println("Nothing written.")
+			return 0
+		}
+	}
+
+	values := [][2]string{}
+	set := func(key, value string) { values = append(values, [2]string{key, value}) }
+
+	// Listen address
+	addr := p.ask("Listen address (use 0.0.0.0:7070 to allow other machines)", initDefaultAddr)
+	set("CLICRON_ADDR", addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if ip := net.ParseIP(host); host == "" || (ip != nil && !ip.IsLoopback()) {
+			if allowed := p.ask("Client IPs/CIDRs allowed to connect, comma-separated (empty allows all)", ""); allowed != "" {
+				set("CLICRON_ALLOWED_IPS", allowed)
+			}
+		}
+	}
+
+	// Auth token
+	token := ""
+	if p.confirm("Protect the API and MCP endpoint with a generated auth token?", true) {
+		var err error
+		if token, err = generateToken(); err != nil {
+			fmt.Fprintf(os.Stderr, "generate token: %v\n", err)
+			return 1
+		}
+		set("CLICRON_AUTH_TOKEN", token)
+		set("CLICRON_AUTH_QUERY_TOKEN", "false")
+	}
+
+	// State dir
+	defaultDir, err := config.DefaultStateDir("")
+	if err != nil {
+		defaultDir = ""
+	}
+	stateDir := p.ask("Directory for the database and run logs", defaultDir)
+	if err := checkWritableDir(stateDir); err != nil {
+		fmt.Printf("  ! %v\n", err)
+		if !p.confirm("Use it anyway?", false) {
+			return 1
+		}
+	} else {
+		fmt.Printf("  ✓ %s is writable\n", stateDir)
+	}
+	if stateDir != defaultDir {
+		set("CLICRON_STATE_DIR", stateDir)
+	}
+
+	// AI CLI engines
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	report := &doctorReport{}
+	checkEngines(ctx, report)
+	cancel()
+	for _, check := range report.Checks {
+		mark := "✓"
+		if check.Status != checkPass {
+			mark = "!"
+		}
+		fmt.Printf("  %s %s: %s\n", mark, check.Name, check.Detail)
+	}
+
+	// Bark notifications
+	if p.confirm("Set up Bark push notifications for failed runs?", false) {
+		barkURL := p.ask("Bark URL (e.g. https://api.day.app/<your key>)", "")
+		if barkURL != "" {
+			if err := sendSetupTest(barkURL); err != nil {
+				fmt.Printf("  ! test notification failed: %v\n", err)
+			} else {
+				fmt.Println("  ✓ test notification sent")
+			}
+			set("CLICRON_BARK_URL", barkURL)
+			set("CLICRON_BARK_ENABLED", "true")
+		}
+	}
+
+	if err := writeEnvFile(path, values); err != nil {
+		fmt.Fprintf(os.Stderr, "write %s: %v\n", path, err)
+		return 1
+	}
+
+	fmt.Println()
+	fmt.Printf("Wrote %s\n", path)
+	fmt.Println("Start the daemon with: clicrontabd")
+	fmt.Printf("Then open: http://%s\n", addr)
+	if token != "" {
+		fmt.Printf("Auth token (also needed by MCP clients as \"Authorization: Bearer <token>\"):\n  %s\n", token)
+	}
+	return 0
+}
+
+func generateToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func checkWritableDir(dir string) error {
+	if dir == "" {
+		return fmt.Errorf("no directory given")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	probe, err := os.CreateTemp(dir, ".init-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+func sendSetupTest(barkURL string) error {
+	notifier, err := notify.NewBarkNotifier(barkURL, "")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	return notifier.Send(ctx, "[clicrontab] Setup", "Notifications are working.")
+}
+
+// writeEnvFile writes values as KEY=VALUE lines with owner-only permissions, since the
+// file can hold the auth token. An existing file is kept as "<path>.bak".
+func writeEnvFile(path string, values [][2]string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := os.WriteFile(path+".bak", data, 0o600); err != nil {
+			return fmt.Errorf("back up existing file: %w", err)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by clicrontabd init on %s\n", time.Now().Format(time.RFC3339))
+	b.WriteString("# See .env.example for all settings; run `clicrontabd config show` to check what is in effect.\n")
+	for _, kv := range values {
+		fmt.Fprintf(&b, "%s=%s\n", kv[0], kv[1])
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(path, 0o600)
+}
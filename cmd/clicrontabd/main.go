@@ -2,93 +2,383 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"clicrontab/internal/api"
+	"clicrontab/internal/clock"
 	"clicrontab/internal/config"
 	"clicrontab/internal/core"
 	"clicrontab/internal/logging"
+	"clicrontab/internal/loglink"
 	clicrontabmcp "clicrontab/internal/mcp"
 	"clicrontab/internal/notify"
+	"clicrontab/internal/oidc"
+	"clicrontab/internal/report"
 	"clicrontab/internal/store"
+	"clicrontab/internal/systemd"
+	"clicrontab/internal/taskfile"
+	"clicrontab/internal/update"
+	"clicrontab/internal/version"
 )
 
 func main() {
+	// An optional leading subcommand selects a tool mode; flags after it are parsed as usual.
+	command := ""
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+	switch command {
+	case "", "serve":
+		runDaemon()
+	case "doctor":
+		os.Exit(runDoctor())
+	case "init":
+		os.Exit(runInit())
+	case "mcp":
+		os.Exit(runMCP())
+	case "config":
+		os.Exit(runConfig())
+	case "migrate":
+		os.Exit(runMigrate())
+	case "self-update":
+		os.Exit(runSelfUpdate())
+	case "attach":
+		os.Exit(runAttach())
+	case "version":
+		fmt.Println(version.Version)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q (available: serve, init, doctor, config, mcp, migrate, attach, self-update, version)\n", command)
+		os.Exit(2)
+	}
+}
+
+// runDaemon starts the scheduler, HTTP API and MCP endpoint and blocks until shutdown.
+func runDaemon() {
+	migrateDryRun := flag.Bool("migrate-dry-run", false, "Print the schema migrations startup would apply, then exit")
 	cfg, err := config.Parse()
 	if err != nil {
 		log.Fatalf("failed to parse config: %v", err)
 	}
+	var code int
+	if *migrateDryRun {
+		code = printPendingMigrations(cfg)
+	} else {
+		code = serve(cfg)
+	}
+	if cfg.Ephemeral {
+		// Nothing an ephemeral daemon stored outlives it, whether it stopped or failed to start
+		if err := os.RemoveAll(cfg.StateDir); err != nil {
+			log.Printf("remove ephemeral state dir: %v", err)
+		}
+	}
+	os.Exit(code)
+}
 
+// serve runs the daemon until it shuts down and returns the process exit code.
+func serve(cfg *config.Config) int {
 	logger := logging.New(cfg.LogLevel)
+	if cfg.Instance != "" {
+		logger = logger.With("instance", cfg.Instance)
+	}
+	logger.Info("starting clicrontabd", "version", version.Version, "state_dir", cfg.StateDir, "addr", cfg.Addr)
+	if cfg.Ephemeral {
+		logger.Warn("ephemeral mode: all tasks, runs and logs are deleted when the daemon exits")
+	}
+	// A nil clock leaves every component on the system clock
+	var simClock clock.Clock
+	if start := cfg.Scheduler.SimulateStart; !start.IsZero() {
+		simClock = clock.NewSimulated(start, cfg.Scheduler.SimulateSpeed)
+		logger.Warn("simulation mode: schedules, runs and timestamps follow a simulated clock", "start", start.Format(time.RFC3339), "speed", cfg.Scheduler.SimulateSpeed)
+	}
+	for _, issue := range cfg.Issues {
+		logger.Warn("ignored config value", "issue", issue)
+	}
+	if err := core.SetIDFormat(cfg.IDFormat); err != nil {
+		logger.Error("set id format", "err", err)
+		return 1
+	}
+
+	// Refuse to share a state dir (and so a database and run logs) with another daemon
+	stateLock, err := store.LockStateDir(cfg.StateDir, store.LockInfo{
+		PID:       os.Getpid(),
+		Instance:  cfg.Instance,
+		Addr:      cfg.Addr,
+		StartedAt: time.Now(),
+	})
+	if err != nil {
+		logger.Error("lock state dir", "err", err)
+		return 1
+	}
+	defer stateLock.Release()
 
 	baseCtx := context.Background()
 	storeInst, err := store.Open(baseCtx, cfg.StateDir, cfg.RunLogKeep)
 	if err != nil {
 		logger.Error("open store", "err", err)
-		os.Exit(1)
+		return 1
 	}
 	defer storeInst.DB.Close()
+	storeInst.SetClock(simClock)
+	storeInst.LogMaxAgeDays = cfg.Log.MaxAgeDays
+	storeInst.MaxFileBytes = int64(cfg.Server.MaxFileBytes)
+	storeInst.FilesQuotaBytes = int64(cfg.Server.FilesQuotaBytes)
+	if err := storeInst.SetUniqueTaskNames(baseCtx, cfg.UniqueTaskNames); err != nil {
+		logger.Error("enforce unique task names", "err", err)
+	}
 
 	location := time.Local
 	if cfg.UseUTC {
 		location = time.UTC
 	}
 
-	var notifier notify.Notifier = &notify.NoOpNotifier{}
-	if cfg.Notification.Bark.Enabled && cfg.Notification.Bark.URL != "" {
-		bark, err := notify.NewBarkNotifier(cfg.Notification.Bark.URL)
+	// Notification channels start from env/config and are overridden by settings saved via the API
+	notifications := notify.NewDispatcher()
+	notifySettings, err := loadNotificationSettings(baseCtx, cfg, storeInst)
+	if err != nil {
+		logger.Error("load notification settings", "err", err)
+	}
+	if err := notifications.Apply(notifySettings); err != nil {
+		logger.Error("init notifications", "err", err)
+	} else {
+		logger.Info("notifications configured", "bark_enabled", notifySettings.Bark.Enabled, "webhook_enabled", notifySettings.Webhook.Enabled, "email_enabled", notifySettings.Email.Enabled)
+	}
+	notifications.ReserveForEscalation(cfg.Notification.EscalationChannels)
+	// Alerts go through the outbox so a briefly unreachable channel is retried
+	outbox := notify.NewOutbox(notifications, storeInst, logger)
+	outbox.SetClock(simClock)
+
+	executor := core.NewCommandExecutor(storeInst, logger, outbox, core.AlertPolicy{
+		NotifyOnSuccess: cfg.Notification.NotifyOnSuccess,
+		FailureThrottle: cfg.Notification.FailureThrottle,
+		EscalateAfter:   cfg.Notification.EscalateAfter,
+		SlowRunFactor:   cfg.Notification.SlowRunFactor,
+	})
+	executor.SetOutputTailSize(cfg.Log.OutputTail)
+	executor.SetClock(simClock)
+	executor.SetLocation(location)
+	var logLinks *loglink.Signer
+	if cfg.Server.PublicURL != "" {
+		key, err := logLinkKey(baseCtx, cfg, storeInst)
 		if err != nil {
-			logger.Error("init bark notifier", "err", err)
+			logger.Error("load log link key; notifications will not link to logs", "err", err)
 		} else {
-			notifier = bark
-			logger.Info("bark notification enabled", "url", cfg.Notification.Bark.URL)
+			logLinks = loglink.New(cfg.Server.PublicURL, key, cfg.Notification.LogLinkTTL)
+			executor.SetLogLinks(logLinks)
 		}
 	}
+	if cfg.Shell.EnvCache {
+		executor.EnableShellEnvCache(cfg.Shell.EnvTTL)
+	}
+	scheduler := core.NewScheduler(storeInst, executor, logger, location, core.SchedulerOptions{
+		MaxConcurrent:    cfg.Scheduler.MaxConcurrent,
+		QueueDeadline:    cfg.Scheduler.QueueDeadline,
+		LagWarnThreshold: cfg.Scheduler.LagWarnThreshold,
+		Notifier:         outbox,
+		MisfireGrace:     cfg.Scheduler.MisfireGrace,
+		MisfirePolicy:    core.MisfirePolicy(cfg.Scheduler.MisfirePolicy),
 
-	executor := core.NewCommandExecutor(storeInst, logger, notifier)
-	scheduler := core.NewScheduler(storeInst, executor, logger, location)
+		SuspendOnClockAnomaly: cfg.Scheduler.SuspendOnClockAnomaly,
+		Clock:                 simClock,
+	})
 
 	ctx, cancel := context.WithCancel(baseCtx)
 	defer cancel()
 
+	// Handle runs and processes left behind by a previous daemon before dispatching new ones
+	// The reaper matches run times against real process start times, which simulated run
+	// times cannot be compared with, so it only finalizes stale runs in simulation mode
+	reaperMode := core.ReaperMode(cfg.Reaper.Mode)
+	if simClock != nil && reaperMode != core.ReaperModeOff {
+		logger.Warn("orphan process reaper disabled in simulation mode", "mode", reaperMode)
+		reaperMode = core.ReaperModeOff
+	}
+	reaper := core.NewReaper(storeInst, logger, reaperMode, cfg.Reaper.Interval)
+	if stoppedAt, ok, err := previousShutdown(ctx, storeInst); err != nil {
+		logger.Error("read previous shutdown", "err", err)
+	} else if ok {
+		reaper.SetPreviousShutdown(stoppedAt)
+	}
+	if err := reaper.ReapStale(ctx); err != nil {
+		logger.Error("reap stale runs", "err", err)
+	}
+	go reaper.Run(ctx)
+	go outbox.Run(ctx)
+	logJanitor := core.NewLogJanitor(storeInst, logger)
+	logJanitor.SetClock(simClock)
+	go logJanitor.Run(ctx)
+	if cfg.SelfMonitor.Enabled {
+		go core.NewSelfMonitor(storeInst, scheduler, outbox, logger, cfg.SelfMonitor.Interval).Run(ctx)
+	}
+	if cfg.Notification.EscalateUnacked > 0 {
+		escalation := core.NewEscalationMonitor(storeInst, outbox, logger, cfg.Notification.EscalateUnacked)
+		escalation.SetClock(simClock)
+		go escalation.Run(ctx)
+	}
+	if cfg.Notification.SkipAlertRate > 0 {
+		skipRate := core.NewSkipRateMonitor(storeInst, outbox, logger, cfg.Notification.SkipAlertRate, cfg.Notification.SkipAlertWindow, location)
+		skipRate.SetClock(simClock)
+		go skipRate.Run(ctx)
+	}
+	if cfg.Report.DailyAt != "" || cfg.Report.WeeklyAt != "" {
+		// Both times were validated by config.Parse
+		reporter := report.NewReporter(storeInst, logger, location)
+		reporter.SetClock(simClock)
+		if cfg.Report.DailyAt != "" {
+			at, _ := report.ParseTimeOfDay(cfg.Report.DailyAt)
+			var reportNotifier notify.Notifier
+			if cfg.Report.DailyNotify {
+				reportNotifier = outbox
+			}
+			reporter.ScheduleDaily(at, reportNotifier)
+		}
+		if cfg.Report.WeeklyAt != "" {
+			weekday, at, _ := report.ParseWeekdayTime(cfg.Report.WeeklyAt)
+			reporter.ScheduleWeekly(weekday, at, outbox)
+		}
+		go reporter.Run(ctx)
+	}
+
+	if cfg.Log.Index {
+		if err := storeInst.EnableLogIndex(ctx, logger); err != nil {
+			// Search falls back to scanning log files
+			logger.Error("enable run log index", "err", err)
+		} else {
+			logger.Info("run log full-text index enabled")
+		}
+	}
+
+	if cfg.Update.Check {
+		if version.IsRelease() {
+			checker := update.NewChecker(update.NewClient(cfg.Update.Repo), version.Version, cfg.Update.Interval, outbox, logger)
+			go checker.Run(ctx)
+		} else {
+			logger.Info("update check disabled for development build")
+		}
+	}
+
+	// The tasks file is applied before scheduling starts so its startup tasks run too
+	taskSync := taskfile.NewSyncer(cfg.TaskFile.Path, cfg.TaskFile.Interval, storeInst, scheduler, logger)
+	if cfg.TaskFile.Path != "" {
+		taskSync.Check(ctx)
+		go taskSync.Run(ctx)
+		logger.Info("declarative tasks file enabled", "path", cfg.TaskFile.Path, "interval", cfg.TaskFile.Interval)
+	}
+
 	scheduler.Start(ctx)
-	if err := scheduler.Sync(ctx); err != nil {
+	if _, err := scheduler.Sync(ctx); err != nil {
 		logger.Error("initial sync", "err", err)
 	}
+	scheduler.RunStartupTasks(ctx)
+
+	// Under systemd socket activation the listening sockets are inherited instead of bound
+	listeners, err := systemd.Listeners()
+	if err != nil {
+		logger.Error("inherit systemd sockets", "err", err)
+		return 1
+	}
+	if len(listeners) > 0 {
+		cfg.Addr = listeners[0].Addr().String()
+		logger.Info("using systemd socket activation", "sockets", len(listeners), "addr", cfg.Addr)
+	}
 
 	// Initialize MCP server handler
-	mcpServer := clicrontabmcp.NewMCPServer(storeInst, scheduler, logger, location, cfg.Addr)
+	mcpServer := clicrontabmcp.NewMCPServer(storeInst, scheduler, logger, location, cfg.Addr, clicrontabmcp.ToolFilter{
+		Allow: cfg.MCP.EnabledTools,
+		Deny:  cfg.MCP.DisabledTools,
+	})
 
 	// Initialize HTTP server (mounts MCP handler at /mcp)
-	server, err := api.NewServer(cfg.Addr, cfg.AuthToken, storeInst, scheduler, mcpServer, logger, location)
+	server, err := api.NewServer(cfg.Addr, cfg.AuthToken, storeInst, scheduler, mcpServer, notifications, logger, location)
 	if err != nil {
 		logger.Error("create server", "err", err)
-		os.Exit(1)
+		return 1
 	}
-
-	serverErr := make(chan error, 1)
-	go func() {
-		if err := server.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			serverErr <- err
+	server.SetQueryTokenAuth(cfg.Server.AuthQueryToken)
+	server.SetAllowedIPs(cfg.Server.AllowedIPs)
+	limits := api.DefaultLimits
+	limits.MaxBodyBytes = int64(cfg.Server.MaxBodyBytes)
+	limits.MaxImportBytes = int64(cfg.Server.MaxImportBytes)
+	limits.Timeout = cfg.Server.RequestTimeout
+	server.SetLimits(limits)
+	server.SetPasswordLogin(cfg.Server.AuthUsername, cfg.Server.AuthPassword)
+	sessionKey, err := storedSecret(baseCtx, storeInst, store.SettingSessionSecret)
+	if err != nil {
+		logger.Error("load session key", "err", err)
+		return 1
+	}
+	server.SetSessionKey(sessionKey)
+	if cfg.OIDC.Issuer != "" {
+		redirectURL := cfg.OIDC.RedirectURL
+		if redirectURL == "" && cfg.Server.PublicURL != "" {
+			redirectURL = strings.TrimRight(cfg.Server.PublicURL, "/") + "/login/oidc/callback"
 		}
-	}()
+		server.SetOIDC(oidc.New(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			Scopes:       cfg.OIDC.Scopes,
+			GroupsClaim:  cfg.OIDC.GroupsClaim,
+			Roles:        cfg.OIDC.Roles,
+			DefaultRole:  cfg.OIDC.DefaultRole,
+		}), redirectURL)
+		logger.Info("oidc login enabled", "issuer", cfg.OIDC.Issuer)
+	}
+	server.SetLogLinks(logLinks)
+	server.SetTaskSync(taskSync)
+	server.SetOutbox(outbox)
+
+	serverErr := make(chan error, 1+len(listeners))
+	if len(listeners) == 0 {
+		go func() {
+			if err := server.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+	}
+	for _, ln := range listeners {
+		go func(ln net.Listener) {
+			if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}(ln)
+	}
+	if err := systemd.Notify("READY=1"); err != nil {
+		logger.Warn("notify systemd", "err", err)
+	}
 
 	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	select {
-	case sig := <-sigs:
-		logger.Info("received signal", "signal", sig.String())
-	case err := <-serverErr:
-		logger.Error("server error", "err", err)
+wait:
+	for {
+		select {
+		case sig := <-sigs:
+			if sig == syscall.SIGHUP {
+				logger.Info("received signal; reloading", "signal", sig.String())
+				reload(ctx, cfg, storeInst, notifications, scheduler, taskSync, logger)
+				continue
+			}
+			logger.Info("received signal", "signal", sig.String())
+		case err := <-serverErr:
+			logger.Error("server error", "err", err)
+		}
+		break wait
 	}
 
+	_ = systemd.Notify("STOPPING=1")
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownGrace)
 	defer shutdownCancel()
 
@@ -96,12 +386,110 @@ func main() {
 		logger.Error("server shutdown", "err", err)
 	}
 
-	stopCtx := scheduler.Stop()
-	select {
-	case <-stopCtx.Done():
-	case <-time.After(cfg.ShutdownGrace):
-		logger.Warn("scheduler stop timed out")
-	}
+	// Runs get what is left of the grace period to finish before they are canceled
+	scheduler.Shutdown(shutdownCtx)
 
+	if err := storeInst.SetSetting(context.Background(), store.SettingDaemonStoppedAt, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		logger.Error("record shutdown", "err", err)
+	}
 	logger.Info("shutdown complete")
+	return 0
+}
+
+// reload re-reads what may have been changed outside the daemon, e.g. a database restored
+// from backup: notification settings, task schedules and the tasks file. Executing runs
+// are left alone.
+func reload(ctx context.Context, cfg *config.Config, storeInst *store.Store, notifications *notify.Dispatcher, scheduler *core.Scheduler, taskSync *taskfile.Syncer, logger *slog.Logger) {
+	notifySettings, err := loadNotificationSettings(ctx, cfg, storeInst)
+	if err != nil {
+		logger.Error("reload notification settings", "err", err)
+	}
+	if err := notifications.Apply(notifySettings); err != nil {
+		logger.Error("reload notifications", "err", err)
+	}
+	if cfg.TaskFile.Path != "" {
+		taskSync.Reload(ctx)
+	}
+	if _, err := scheduler.Sync(ctx); err != nil {
+		logger.Error("reload schedules", "err", err)
+		return
+	}
+	logger.Info("reload complete")
+}
+
+// previousShutdown returns when the previous daemon shut down cleanly, and clears the
+// record so it is unset should this daemon crash.
+func previousShutdown(ctx context.Context, storeInst *store.Store) (time.Time, bool, error) {
+	raw, _, err := storeInst.GetSetting(ctx, store.SettingDaemonStoppedAt)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if err := storeInst.SetSetting(ctx, store.SettingDaemonStoppedAt, ""); err != nil {
+		return time.Time{}, false, err
+	}
+	stoppedAt, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}, false, nil
+	}
+	return stoppedAt, true, nil
+}
+
+// loadNotificationSettings returns the env/config channel settings overridden by any saved via the API.
+// On error the env/config settings are still returned.
+func loadNotificationSettings(ctx context.Context, cfg *config.Config, storeInst *store.Store) (notify.Settings, error) {
+	settings := notificationSettingsFromConfig(cfg)
+	raw, ok, err := storeInst.GetSetting(ctx, store.SettingNotifications)
+	if err != nil || !ok {
+		return settings, err
+	}
+	if err := json.Unmarshal([]byte(raw), &settings); err != nil {
+		return settings, fmt.Errorf("decode notification settings: %w", err)
+	}
+	return settings, nil
+}
+
+// logLinkKey returns the configured log link secret, or the generated one stored in the
+// database, generating and storing it on first use.
+func logLinkKey(ctx context.Context, cfg *config.Config, storeInst *store.Store) ([]byte, error) {
+	if cfg.Notification.LogLinkSecret != "" {
+		return []byte(cfg.Notification.LogLinkSecret), nil
+	}
+	key, err := storedSecret(ctx, storeInst, store.SettingLogLinkSecret)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(key), nil
+}
+
+// storedSecret returns the random key stored under setting, generating and storing it on
+// first use.
+func storedSecret(ctx context.Context, storeInst *store.Store, setting string) (string, error) {
+	key, ok, err := storeInst.GetSetting(ctx, setting)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		if key, err = loglink.NewKey(); err != nil {
+			return "", fmt.Errorf("generate %s: %w", setting, err)
+		}
+		if err := storeInst.SetSetting(ctx, setting, key); err != nil {
+			return "", err
+		}
+	}
+	return key, nil
+}
+
+// notificationSettingsFromConfig returns the channel settings given by env/config alone.
+func notificationSettingsFromConfig(cfg *config.Config) notify.Settings {
+	return notify.Settings{
+		Bark: notify.ChannelSettings{
+			Enabled: cfg.Notification.Bark.Enabled, URL: cfg.Notification.Bark.URL, Proxy: cfg.Notification.Bark.Proxy,
+		},
+		Webhook: notify.ChannelSettings{
+			Enabled: cfg.Notification.Webhook.Enabled, URL: cfg.Notification.Webhook.URL, Proxy: cfg.Notification.Webhook.Proxy,
+		},
+		Email: notify.ChannelSettings{
+			Enabled: cfg.Notification.Email.Enabled, URL: cfg.Notification.Email.URL,
+		},
+	}
 }
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"clicrontab/internal/config"
+)
+
+// mcpClient describes where an MCP client keeps its server list and how an entry looks.
+type mcpClient struct {
+	name string
+	// path returns the config file; scope is "user" or "project".
+	path func(scope string) (string, error)
+	// entry builds the server entry for the endpoint URL and optional bearer token.
+	entry func(url, token string) map[string]any
+}
+
+var mcpClients = []mcpClient{
+	{name: "claude-desktop", path: claudeDesktopConfigPath, entry: remoteBridgeEntry},
+	{name: "claude-code", path: claudeCodeConfigPath, entry: httpEntry("http")},
+	{name: "cursor", path: cursorConfigPath, entry: httpEntry("")},
+}
+
+// runMCP implements "mcp install". It returns the process exit code.
+func runMCP() int {
+	action := ""
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		action = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+	if action != "install" {
+		fmt.Fprintln(os.Stderr, "usage: clicrontabd mcp install [--client claude-desktop|claude-code|cursor|all] [--dry-run]")
+		return 2
+	}
+
+	clientFlag := flag.String("client", "", "Client to configure: claude-desktop, claude-code, cursor or all (default: detected clients)")
+	serverName := flag.String("name", "", "Server name in the client config (default: clicrontab, or clicrontab-<instance>)")
+	endpoint := flag.String("url", "", "MCP endpoint URL (default: derived from the listen address)")
+	scope := flag.String("scope", "user", "Claude Code scope: user (~/.claude.json) or project (./.mcp.json)")
+	dryRun := flag.Bool("dry-run", false, "Print the entries instead of writing them")
+	cfg, err := config.Parse()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse config: %v\n", err)
+		return 1
+	}
+	if *scope != "user" && *scope != "project" {
+		fmt.Fprintf(os.Stderr, "invalid --scope %q (want user or project)\n", *scope)
+		return 2
+	}
+
+	name := *serverName
+	if name == "" {
+		name = "clicrontab"
+		if cfg.Instance != "" {
+			name += "-" + cfg.Instance
+		}
+	}
+	url := *endpoint
+	if url == "" {
+		url = mcpEndpointURL(cfg.Addr)
+	}
+
+	clients, err := selectMCPClients(*clientFlag, *scope)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if len(clients) == 0 {
+		fmt.Fprintln(os.Stderr, "no MCP clients detected; pass --client to choose one")
+		return 1
+	}
+
+	failed := 0
+	for _, client := range clients {
+		path, err := client.path(*scope)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", client.name, err)
+			failed++
+			continue
+		}
+		entry := client.entry(url, cfg.AuthToken)
+		if *dryRun {
+			out, _ := json.MarshalIndent(map[string]any{"mcpServers": map[string]any{name: entry}}, "", "  ")
+			fmt.Printf("# %s: %s\n%s\n", client.name, path, out)
+			continue
+		}
+		if err := writeMCPEntry(path, name, entry); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", client.name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: added %q to %s (restart the client to load it)\n", client.name, name, path)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// selectMCPClients resolves --client; without it, clients whose config directory exists are chosen.
+func selectMCPClients(choice, scope string) ([]mcpClient, error) {
+	switch choice {
+	case "all":
+		return mcpClients, nil
+	case "":
+		var detected []mcpClient
+		for _, client := range mcpClients {
+			path, err := client.path(scope)
+			if err != nil {
+				continue
+			}
+			if _, err := os.Stat(filepath.Dir(path)); err == nil {
+				detected = append(detected, client)
+			}
+		}
+		return detected, nil
+	}
+	for _, client := range mcpClients {
+		if client.name == choice {
+			return []mcpClient{client}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown --client %q (want claude-desktop, claude-code, cursor or all)", choice)
+}
+
+// mcpEndpointURL turns the listen address into a URL clients on this machine can reach.
+func mcpEndpointURL(addr string) string {
+	return daemonURL(addr) + "/mcp"
+}
+
+// daemonURL turns the listen address into the base URL of the daemon on this machine.
+func daemonURL(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// httpEntry builds an entry for clients that speak streamable HTTP directly.
+func httpEntry(transport string) func(url, token string) map[string]any {
+	return func(url, token string) map[string]any {
+		entry := map[string]any{"url": url}
+		if transport != "" {
+			entry["type"] = transport
+		}
+		if token != "" {
+			entry["headers"] = map[string]string{"Authorization": "Bearer " + token}
+		}
+		return entry
+	}
+}
+
+// remoteBridgeEntry builds an entry for Claude Desktop, whose config file only launches
+// stdio servers; mcp-remote bridges stdio to the HTTP endpoint. The header value is
+// passed through env because arguments containing spaces break on Windows.
+func remoteBridgeEntry(url, token string) map[string]any {
+	args := []string{"-y", "mcp-remote", url}
+	if strings.HasPrefix(url, "http://") {
+		args = append(args, "--allow-http")
+	}
+	entry := map[string]any{"command": "npx"}
+	if token != "" {
+		args = append(args, "--header", "Authorization:${CLICRON_AUTH_HEADER}")
+		entry["env"] = map[string]string{"CLICRON_AUTH_HEADER": "Bearer " + token}
+	}
+	entry["args"] = args
+	return entry
+}
+
+func claudeDesktopConfigPath(string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "Application Support", "Claude", "claude_desktop_config.json"), nil
+	default:
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "Claude", "claude_desktop_config.json"), nil
+	}
+}
+
+func claudeCodeConfigPath(scope string) (string, error) {
+	if scope == "project" {
+		return filepath.Abs(".mcp.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".claude.json"), nil
+}
+
+func cursorConfigPath(scope string) (string, error) {
+	if scope == "project" {
+		return filepath.Abs(filepath.Join(".cursor", "mcp.json"))
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cursor", "mcp.json"), nil
+}
+
+// writeMCPEntry sets mcpServers[name] in the JSON file at path, keeping every other key
+// as it was. An existing file is backed up to "<path>.bak" first.
+func writeMCPEntry(path, name string, entry map[string]any) error {
+	doc := map[string]json.RawMessage{}
+	mode := os.FileMode(0o600)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if info, statErr := os.Stat(path); statErr == nil {
+			mode = info.Mode().Perm()
+		}
+		if len(strings.TrimSpace(string(data))) > 0 {
+			if err := json.Unmarshal(data, &doc); err != nil {
+				return fmt.Errorf("parse %s: %w", path, err)
+			}
+		}
+		if err := os.WriteFile(path+".bak", data, mode); err != nil {
+			return fmt.Errorf("back up %s: %w", path, err)
+		}
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	servers := map[string]json.RawMessage{}
+	if raw, ok := doc["mcpServers"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &servers); err != nil {
+			return fmt.Errorf("parse mcpServers in %s: %w", path, err)
+		}
+	}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	servers[name] = encoded
+	if doc["mcpServers"], err = json.Marshal(servers); err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(out, '\n'), mode)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"clicrontab/internal/config"
+	"clicrontab/internal/store"
+)
+
+// runMigrate implements "migrate status|up|down". It returns the process exit code.
+func runMigrate() int {
+	action := ""
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		action = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+	switch action {
+	case "status", "up", "down":
+	default:
+		fmt.Fprintln(os.Stderr, "usage: clicrontabd migrate {status|up|down} [--dry-run] [--to VERSION] [--json] [flags]")
+		return 2
+	}
+
+	dryRun := flag.Bool("dry-run", false, "Print the migrations that would run without changing the database")
+	target := flag.String("to", "", "For down: roll back until this version is the latest applied (default: only the latest)")
+	jsonOutput := flag.Bool("json", false, "Print status as JSON")
+	cfg, err := config.Parse()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse config: %v\n", err)
+		return 1
+	}
+
+	// Schema changes must not happen under a running daemon
+	if action != "status" && !*dryRun {
+		lock, err := store.LockStateDir(cfg.StateDir, store.LockInfo{PID: os.Getpid(), Instance: cfg.Instance, StartedAt: time.Now()})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		defer lock.Release()
+	}
+
+	ctx := context.Background()
+	storeInst, err := store.OpenUnmigrated(ctx, cfg.StateDir, cfg.RunLogKeep)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open store: %v\n", err)
+		return 1
+	}
+	defer storeInst.DB.Close()
+
+	var migrations []store.Migration
+	switch action {
+	case "status":
+		return printMigrationStatus(ctx, storeInst, *jsonOutput)
+	case "up":
+		migrations, err = storeInst.Migrate(ctx, *dryRun)
+	case "down":
+		migrations, err = storeInst.MigrateDown(ctx, *target, *dryRun)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	printMigrations(os.Stdout, migrations, action == "down", *dryRun)
+	return 0
+}
+
+// printPendingMigrations implements "serve --migrate-dry-run": it lists what Open would
+// apply on startup without taking the state lock or writing to the database.
+func printPendingMigrations(cfg *config.Config) int {
+	ctx := context.Background()
+	storeInst, err := store.OpenUnmigrated(ctx, cfg.StateDir, cfg.RunLogKeep)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open store: %v\n", err)
+		return 1
+	}
+	defer storeInst.DB.Close()
+	pending, err := storeInst.Migrate(ctx, true)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	printMigrations(os.Stdout, pending, false, true)
+	return 0
+}
+
+// printMigrations reports migrations that ran or, for a dry run, would run along with their SQL.
+func printMigrations(w io.Writer, migrations []store.Migration, down, dryRun bool) {
+	if len(migrations) == 0 {
+		fmt.Fprintln(w, "nothing to do")
+		return
+	}
+	verb := "applied"
+	switch {
+	case dryRun && down:
+		verb = "would roll back"
+	case dryRun:
+		verb = "would apply"
+	case down:
+		verb = "rolled back"
+	}
+	for _, mig := range migrations {
+		fmt.Fprintf(w, "%s %s\n", verb, mig.Version)
+		if !dryRun {
+			continue
+		}
+		script := mig.Up
+		if down {
+			script = mig.Down
+		}
+		printIndented(w, script)
+	}
+}
+
+func printMigrationStatus(ctx context.Context, s *store.Store, jsonOutput bool) int {
+	states, err := s.MigrationStatus(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(states); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tSTATE\tAPPLIED AT\tDOWN")
+	for _, st := range states {
+		state, appliedAt, down := "pending", "-", "no"
+		if st.Applied {
+			state = "applied"
+		}
+		if st.AppliedAt != nil {
+			appliedAt = st.AppliedAt.Local().Format(time.DateTime)
+		}
+		if st.Reversible {
+			down = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", st.Version, state, appliedAt, down)
+	}
+	tw.Flush()
+	return 0
+}
+
+// printIndented writes script with each non-empty line indented, for dry-run output.
+func printIndented(w io.Writer, script string) {
+	for _, line := range strings.Split(strings.TrimRight(script, "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			fmt.Fprintln(w)
+			continue
+		}
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
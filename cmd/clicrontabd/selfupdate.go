@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"clicrontab/internal/config"
+	"clicrontab/internal/update"
+	"clicrontab/internal/version"
+)
+
+// runSelfUpdate replaces the running binary with the latest GitHub release.
+// It returns the process exit code.
+func runSelfUpdate() int {
+	checkOnly := flag.Bool("check", false, "Only report whether an update is available")
+	force := flag.Bool("force", false, "Install the latest release even if it is not newer (or this is a dev build)")
+	skipVerify := flag.Bool("skip-verify", false, "Install even if the release publishes no checksums.txt")
+	cfg, err := config.Parse()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse config: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	client := update.NewClient(cfg.Update.Repo)
+	release, err := client.Latest(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "check for updates: %v\n", err)
+		return 1
+	}
+
+	newer := update.IsNewer(version.Version, release.TagName)
+	fmt.Printf("current: %s\nlatest:  %s\n", version.Version, release.TagName)
+	if *checkOnly {
+		if newer {
+			fmt.Printf("update available: %s\n", release.HTMLURL)
+		} else {
+			fmt.Println("already up to date")
+		}
+		return 0
+	}
+	if !newer && !*force {
+		if !version.IsRelease() {
+			fmt.Println("development build; use --force to install the latest release")
+		} else {
+			fmt.Println("already up to date")
+		}
+		return 0
+	}
+
+	asset, err := release.FindAsset(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	exePath, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "locate executable: %v\n", err)
+		return 1
+	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+
+	fmt.Printf("downloading %s...\n", asset.Name)
+	binary, err := client.Download(ctx, release, asset)
+	if errors.Is(err, update.ErrNoChecksum) {
+		if !*skipVerify {
+			fmt.Fprintf(os.Stderr, "release %s publishes no %s; rerun with --skip-verify to install anyway\n", release.TagName, update.ChecksumsAsset)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s not verified (no %s)\n", asset.Name, update.ChecksumsAsset)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	if err := update.Replace(exePath, binary); err != nil {
+		fmt.Fprintf(os.Stderr, "replace %s: %v\n", exePath, err)
+		return 1
+	}
+
+	fmt.Printf("updated %s to %s; restart the daemon to run the new version\n", exePath, release.TagName)
+	return 0
+}
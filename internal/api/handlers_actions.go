@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"clicrontab/internal/core"
+	"clicrontab/internal/store"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// handlePauseTask pauses a task indefinitely, clearing any pause_until.
+func (s *Server) handlePauseTask(w http.ResponseWriter, r *http.Request) {
+	s.setPaused(w, r, true)
+}
+
+// handleResumeTask makes a paused (or error) task active again.
+func (s *Server) handleResumeTask(w http.ResponseWriter, r *http.Request) {
+	s.setPaused(w, r, false)
+}
+
+func (s *Server) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	taskID := chi.URLParam(r, "taskID")
+	var task *core.Task
+	err := s.store.InTx(r.Context(), func(tx *store.Tx) error {
+		var err error
+		if task, err = tx.GetTask(r.Context(), taskID); err != nil {
+			return err
+		}
+		if task.Status == core.TaskStatusArchived {
+			return &requestError{status: http.StatusConflict, code: "conflict", message: "task is archived; unarchive it first"}
+		}
+		task.PauseUntil = nil
+		if paused {
+			task.Status = core.TaskStatusPaused
+			task.NextRunAt = nil
+		} else {
+			parsed, err := core.ParseCron(task.Cron)
+			if err != nil {
+				return &requestError{status: http.StatusBadRequest, code: "invalid_cron", message: err.Error()}
+			}
+			task.Status = core.TaskStatusActive
+			next := core.NextOccurrences(parsed, s.scheduler.Now().In(s.location), 1)[0].UTC()
+			task.NextRunAt = &next
+		}
+		if err := tx.UpdateTask(r.Context(), task); err != nil {
+			return err
+		}
+		tx.OnCommit(func() { s.refreshSchedule(r.Context(), task.ID) })
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+			return
+		}
+		if writeRequestError(w, err) {
+			return
+		}
+		s.logger.Error("set task paused", "task_id", taskID, "paused", paused, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to update task")
+		return
+	}
+	writeJSON(w, http.StatusOK, taskToResponse(task))
+}
+
+type cloneTaskRequest struct {
+	Name *string `json:"name"`
+}
+
+// handleCloneTask creates a paused copy of a task's settings, without its runs, comments
+// or tasks-file ownership. The copy is named "<name> (copy)" unless the body gives a name.
+func (s *Server) handleCloneTask(w http.ResponseWriter, r *http.Request) {
+	var req cloneTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return
+	}
+	taskID := chi.URLParam(r, "taskID")
+	source, err := s.store.GetTask(r.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for clone", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return
+	}
+
+	var name *string
+	if req.Name != nil {
+		if trimmed := strings.TrimSpace(*req.Name); trimmed != "" {
+			name = &trimmed
+		}
+	} else if source.Name != nil {
+		copyName := *source.Name + " (copy)"
+		name = &copyName
+	}
+	task := &core.Task{
+		ID:                 core.NewID(),
+		Name:               name,
+		Prompt:             source.Prompt,
+		Command:            source.Command,
+		Cron:               source.Cron,
+		TimeoutSeconds:     source.TimeoutSeconds,
+		WorkingDir:         source.WorkingDir,
+		MinIntervalSeconds: source.MinIntervalSeconds,
+		PauseAfterFailures: source.PauseAfterFailures,
+		LogRetention:       source.LogRetention,
+		LogMaxAgeDays:      source.LogMaxAgeDays,
+		Executor:           source.Executor,
+		HTTP:               source.HTTP,
+		SQL:                source.SQL,
+		ScriptID:           source.ScriptID,
+		RunOnStart:         source.RunOnStart,
+		// Paused so the copy can be edited before it runs alongside the original
+		Status: core.TaskStatusPaused,
+	}
+
+	err = s.store.InTx(r.Context(), func(tx *store.Tx) error {
+		return tx.InsertTask(r.Context(), task)
+	})
+	if errors.Is(err, core.ErrTaskNameTaken) {
+		writeError(w, http.StatusConflict, "name_taken", "task name is already used by another task")
+		return
+	}
+	if err != nil {
+		s.logger.Error("insert cloned task", "source_task_id", taskID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to clone task")
+		return
+	}
+	s.logger.Info("cloned task", "source_task_id", taskID, "task_id", task.ID)
+	writeJSON(w, http.StatusCreated, taskToResponse(task))
+}
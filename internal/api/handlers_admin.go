@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"clicrontab/internal/notify"
+	"clicrontab/internal/store"
+)
+
+type channelPatch struct {
+	Enabled *bool   `json:"enabled"`
+	URL     *string `json:"url"`
+	Proxy   *string `json:"proxy"`
+}
+
+type notificationsPatchRequest struct {
+	Bark    *channelPatch `json:"bark"`
+	Webhook *channelPatch `json:"webhook"`
+	Email   *channelPatch `json:"email"`
+}
+
+type notificationTestRequest struct {
+	Channel string `json:"channel"`
+}
+
+func (s *Server) handleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.notifications.Settings())
+}
+
+func (s *Server) handleUpdateNotifications(w http.ResponseWriter, r *http.Request) {
+	var req notificationsPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return
+	}
+
+	settings := s.notifications.Settings()
+	applyChannelPatch(&settings.Bark, req.Bark)
+	applyChannelPatch(&settings.Webhook, req.Webhook)
+	applyChannelPatch(&settings.Email, req.Email)
+
+	if err := s.notifications.Apply(settings); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_input", err.Error())
+		return
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		s.logger.Error("encode notification settings", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to save notification settings")
+		return
+	}
+	if err := s.store.SetSetting(r.Context(), store.SettingNotifications, string(data)); err != nil {
+		s.logger.Error("save notification settings", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to save notification settings")
+		return
+	}
+	s.logger.Info("notification settings updated", "bark_enabled", settings.Bark.Enabled, "webhook_enabled", settings.Webhook.Enabled, "email_enabled", settings.Email.Enabled)
+
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (s *Server) handleTestNotification(w http.ResponseWriter, r *http.Request) {
+	var req notificationTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	title := "[clicrontab] Test Notification"
+	body := "Notifications are working. Sent at " + time.Now().UTC().Format(time.RFC3339)
+	channel := strings.TrimSpace(req.Channel)
+
+	var err error
+	if channel == "" {
+		// Test every enabled channel, including those reserved for escalations
+		err = s.notifications.Escalate(ctx, notify.Message{Title: title, Body: body})
+	} else {
+		err = s.notifications.SendTo(ctx, channel, title, body)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "notify_failed", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"sent": true, "channel": channel})
+}
+
+func applyChannelPatch(target *notify.ChannelSettings, patch *channelPatch) {
+	if patch == nil {
+		return
+	}
+	if patch.URL != nil {
+		target.URL = strings.TrimSpace(*patch.URL)
+	}
+	if patch.Proxy != nil {
+		target.Proxy = strings.TrimSpace(*patch.Proxy)
+	}
+	if patch.Enabled != nil {
+		target.Enabled = *patch.Enabled
+	}
+}
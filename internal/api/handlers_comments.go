@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"clicrontab/internal/core"
+	"clicrontab/internal/store"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxCommentLength caps the size of a task comment body in bytes.
+const maxCommentLength = 4096
+
+type createCommentRequest struct {
+	Author *string `json:"author"`
+	Body   string  `json:"body"`
+}
+
+type commentResponse struct {
+	ID        string `json:"id"`
+	TaskID    string `json:"task_id"`
+	Author    string `json:"author"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (s *Server) handleListTaskComments(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	if _, err := s.store.GetTask(r.Context(), taskID); err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for comments", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return
+	}
+
+	limit := parseIntDefault(r.URL.Query().Get("limit"), 50)
+	comments, err := s.store.ListTaskComments(r.Context(), taskID, limit)
+	if err != nil {
+		s.logger.Error("list task comments", "task_id", taskID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list comments")
+		return
+	}
+
+	resp := make([]commentResponse, 0, len(comments))
+	for _, comment := range comments {
+		resp = append(resp, commentToResponse(comment))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleCreateTaskComment(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	var req createCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return
+	}
+	body := strings.TrimSpace(req.Body)
+	var errs validationErrors
+	if body == "" {
+		errs.add("body", constraintRequired, "body is required")
+	} else if len(body) > maxCommentLength {
+		errs.add("body", constraintMaxLength, fmt.Sprintf("body must be at most %d bytes", maxCommentLength))
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	author := "anonymous"
+	if req.Author != nil && strings.TrimSpace(*req.Author) != "" {
+		author = strings.TrimSpace(*req.Author)
+	}
+
+	if _, err := s.store.GetTask(r.Context(), taskID); err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for comment", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return
+	}
+
+	comment := &core.TaskComment{
+		ID:     core.NewID(),
+		TaskID: taskID,
+		Author: author,
+		Body:   body,
+	}
+	if err := s.store.InsertTaskComment(r.Context(), comment); err != nil {
+		s.logger.Error("insert task comment", "task_id", taskID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to save comment")
+		return
+	}
+	writeJSON(w, http.StatusCreated, commentToResponse(comment))
+}
+
+func commentToResponse(comment *core.TaskComment) commentResponse {
+	return commentResponse{
+		ID:        comment.ID,
+		TaskID:    comment.TaskID,
+		Author:    comment.Author,
+		Body:      comment.Body,
+		CreatedAt: comment.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -43,7 +43,7 @@ func (s *Server) handleCronPreview(w http.ResponseWriter, r *http.Request) {
 		count = 5
 	}
 
-	base := time.Now().In(s.location)
+	base := s.scheduler.Now().In(s.location)
 	if req.Now != "" {
 		if parsed, err := time.Parse(time.RFC3339, req.Now); err == nil {
 			base = parsed.In(s.location)
@@ -57,3 +57,37 @@ func (s *Server) handleCronPreview(w http.ResponseWriter, r *http.Request) {
 	}
 	writeJSON(w, http.StatusOK, cronPreviewResponse{Valid: true, NextTimes: formatted})
 }
+
+type cronBuildResponse struct {
+	Valid     bool     `json:"valid"`
+	Expr      string   `json:"expr,omitempty"`
+	NextTimes []string `json:"next_times,omitempty"`
+	Message   string   `json:"message,omitempty"`
+}
+
+// handleCronBuild turns lists of minutes, hours, days, months and weekdays into a cron
+// expression, for building a schedule without writing cron syntax.
+func (s *Server) handleCronBuild(w http.ResponseWriter, r *http.Request) {
+	var spec core.CronSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		writeJSON(w, http.StatusBadRequest, cronBuildResponse{Valid: false, Message: "invalid JSON payload"})
+		return
+	}
+	expr, err := core.BuildCron(spec)
+	if err != nil {
+		writeJSON(w, http.StatusOK, cronBuildResponse{Valid: false, Message: err.Error()})
+		return
+	}
+	schedule, err := core.ParseCron(expr)
+	if err != nil {
+		writeJSON(w, http.StatusOK, cronBuildResponse{Valid: false, Message: err.Error()})
+		return
+	}
+
+	times := core.NextOccurrences(schedule, s.scheduler.Now().In(s.location), 5)
+	formatted := make([]string, 0, len(times))
+	for _, t := range times {
+		formatted = append(formatted, t.UTC().Format(time.RFC3339))
+	}
+	writeJSON(w, http.StatusOK, cronBuildResponse{Valid: true, Expr: expr, NextTimes: formatted})
+}
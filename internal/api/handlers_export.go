@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"clicrontab/internal/core"
+	"clicrontab/internal/store"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// runExportColumns is the CSV header for run exports; runToCSVRecord must match its order.
+var runExportColumns = []string{
+	"id", "task_id", "status", "trigger_type", "scheduled_at", "started_at", "ended_at", "exit_code", "error",
+	"reason", "pid", "max_rss_kb", "cpu_s", "lag_ms", "note", "created_at",
+}
+
+// exportFlushEvery controls how many records are written between flushes to the client.
+const exportFlushEvery = 100
+
+func (s *Server) handleExportRuns(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "jsonl" {
+		writeError(w, http.StatusBadRequest, "invalid_input", "format must be csv or jsonl")
+		return
+	}
+	if _, err := s.store.GetTask(r.Context(), taskID); err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for runs export", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return
+	}
+
+	filename := fmt.Sprintf("runs-%s.%s", taskID, format)
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	var write func(run *core.Run) error
+	var flush func() error
+	if format == "csv" {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(runExportColumns); err != nil {
+			return
+		}
+		write = func(run *core.Run) error { return cw.Write(runToCSVRecord(run)) }
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	} else {
+		enc := json.NewEncoder(w)
+		write = func(run *core.Run) error { return enc.Encode(runToResponse(run)) }
+		flush = func() error { return nil }
+	}
+
+	count := 0
+	err := s.store.EachRun(r.Context(), taskID, 0, func(run *core.Run) error {
+		if err := write(run); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Headers are already sent, so the client sees a truncated body.
+		s.logger.Error("export runs", "task_id", taskID, "err", err)
+		return
+	}
+	_ = flush()
+}
+
+func runToCSVRecord(run *core.Run) []string {
+	resp := runToResponse(run)
+	return []string{
+		resp.ID,
+		resp.TaskID,
+		resp.Status,
+		resp.TriggerType,
+		resp.ScheduledAt,
+		derefString(resp.StartedAt),
+		derefString(resp.EndedAt),
+		formatOptionalInt(resp.ExitCode),
+		derefString(resp.Error),
+		derefString(resp.Reason),
+		formatOptionalInt(resp.PID),
+		formatOptionalInt64(resp.MaxRSSKB),
+		formatOptionalFloat(resp.CPUSeconds),
+		formatOptionalInt64(resp.LagMS),
+		derefString(resp.Note),
+		resp.CreatedAt,
+	}
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func formatOptionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+func formatOptionalInt64(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+func formatOptionalFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
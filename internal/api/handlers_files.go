@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"clicrontab/internal/store"
+)
+
+// maxFileNameFieldBytes caps the optional name field of multipart uploads.
+const maxFileNameFieldBytes = 1024
+
+type fileResponse struct {
+	Name string `json:"name"`
+	// Path is the absolute path tasks use to read the file.
+	Path       string `json:"path"`
+	Size       int64  `json:"size"`
+	ModifiedAt string `json:"modified_at"`
+	// Replaced is set on uploads that overwrote a file of the same name.
+	Replaced bool `json:"replaced,omitempty"`
+}
+
+// handleListFiles lists the uploaded files.
+func (s *Server) handleListFiles(w http.ResponseWriter, r *http.Request) {
+	files, err := s.store.ListFiles()
+	if err != nil {
+		s.logger.Error("list files", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list files")
+		return
+	}
+	resp := make([]fileResponse, 0, len(files))
+	for _, f := range files {
+		resp = append(resp, fileToResponse(f))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleUploadFile stores the "file" part of a multipart form, under the name of the
+// "name" part when one comes first, else under the uploaded file's name.
+func (s *Server) handleUploadFile(w http.ResponseWriter, r *http.Request) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_input", "expected a multipart/form-data body with a file field")
+		return
+	}
+	var name string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			writeError(w, http.StatusBadRequest, "invalid_input", "file field is required")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_input", "invalid multipart body: "+err.Error())
+			return
+		}
+		switch part.FormName() {
+		case "name":
+			value, err := io.ReadAll(io.LimitReader(part, maxFileNameFieldBytes))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_input", "invalid multipart body: "+err.Error())
+				return
+			}
+			name = strings.TrimSpace(string(value))
+		case "file":
+			if name == "" {
+				name = part.FileName()
+			}
+			s.saveFile(w, name, part)
+			return
+		}
+	}
+}
+
+// handlePutFile stores the raw request body under the name in the path, e.g. with
+// curl -T.
+func (s *Server) handlePutFile(w http.ResponseWriter, r *http.Request) {
+	s.saveFile(w, chi.URLParam(r, "name"), r.Body)
+}
+
+func (s *Server) saveFile(w http.ResponseWriter, name string, body io.Reader) {
+	file, replaced, err := s.store.SaveFile(name, body)
+	if err != nil {
+		s.writeFileStoreError(w, "save file", name, err)
+		return
+	}
+	s.logger.Info("file uploaded", "name", file.Name, "size", file.Size, "replaced", replaced)
+	resp := fileToResponse(file)
+	resp.Replaced = replaced
+	status := http.StatusCreated
+	if replaced {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, resp)
+}
+
+// handleDownloadFile sends a file's content; ranges and conditional requests work.
+func (s *Server) handleDownloadFile(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	file, err := s.store.StatFile(name)
+	if err != nil {
+		s.writeFileStoreError(w, "open file", name, err)
+		return
+	}
+	f, err := os.Open(file.Path)
+	if err != nil {
+		s.writeFileStoreError(w, "open file", name, err)
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Disposition", `attachment; filename="`+file.Name+`"`)
+	http.ServeContent(w, r, file.Name, file.ModifiedAt, f)
+}
+
+// handleDeleteFile removes a file.
+func (s *Server) handleDeleteFile(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := s.store.DeleteFile(name); err != nil {
+		s.writeFileStoreError(w, "delete file", name, err)
+		return
+	}
+	s.logger.Info("file deleted", "name", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) writeFileStoreError(w http.ResponseWriter, action, name string, err error) {
+	switch {
+	case errors.Is(err, store.ErrFileNotFound), errors.Is(err, os.ErrNotExist):
+		writeError(w, http.StatusNotFound, "not_found", "file not found")
+	case errors.Is(err, store.ErrInvalidFileName):
+		writeError(w, http.StatusBadRequest, "invalid_input", err.Error())
+	case errors.Is(err, store.ErrFileTooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, "file_too_large", err.Error())
+	case errors.Is(err, store.ErrFilesQuotaExceeded):
+		writeError(w, http.StatusRequestEntityTooLarge, "quota_exceeded", err.Error())
+	default:
+		s.logger.Error(action, "name", name, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to "+action)
+	}
+}
+
+func fileToResponse(f *store.StoredFile) fileResponse {
+	return fileResponse{
+		Name:       f.Name,
+		Path:       f.Path,
+		Size:       f.Size,
+		ModifiedAt: f.ModifiedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,290 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"clicrontab/internal/core"
+	"clicrontab/internal/store"
+)
+
+type incidentActionRequest struct {
+	By   *string `json:"by"`
+	Note *string `json:"note"`
+}
+
+type incidentResponse struct {
+	ID             string  `json:"id"`
+	TaskID         string  `json:"task_id"`
+	TaskName       string  `json:"task_name"`
+	Status         string  `json:"status"`
+	FirstRunID     string  `json:"first_run_id"`
+	LastRunID      string  `json:"last_run_id"`
+	Failures       int     `json:"failures"`
+	OpenedAt       string  `json:"opened_at"`
+	AcknowledgedAt *string `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy *string `json:"acknowledged_by,omitempty"`
+	EscalatedAt    *string `json:"escalated_at,omitempty"`
+	ResolvedAt     *string `json:"resolved_at,omitempty"`
+	ResolvedBy     *string `json:"resolved_by,omitempty"`
+	Note           *string `json:"note,omitempty"`
+	// DurationS is how long the outage lasted, once resolved.
+	DurationS *int64 `json:"duration_s,omitempty"`
+}
+
+// handleListIncidents lists incidents across tasks, most recently opened first.
+func (s *Server) handleListIncidents(w http.ResponseWriter, r *http.Request) {
+	filter, msg := parseIncidentFilter(r)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, "invalid_input", msg)
+		return
+	}
+	filter.TaskID = strings.TrimSpace(r.URL.Query().Get("task_id"))
+	s.writeIncidents(w, r, filter)
+}
+
+// handleListTaskIncidents lists the task's incidents, its history of outages.
+func (s *Server) handleListTaskIncidents(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	if _, err := s.store.GetTask(r.Context(), taskID); err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for incidents list", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return
+	}
+	filter, msg := parseIncidentFilter(r)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, "invalid_input", msg)
+		return
+	}
+	filter.TaskID = taskID
+	s.writeIncidents(w, r, filter)
+}
+
+func (s *Server) writeIncidents(w http.ResponseWriter, r *http.Request, filter store.IncidentFilter) {
+	limit := parseIntDefault(r.URL.Query().Get("limit"), 20)
+	offset := parseIntDefault(r.URL.Query().Get("offset"), 0)
+	incidents, err := s.store.ListIncidents(r.Context(), filter, limit, offset)
+	if err != nil {
+		s.logger.Error("list incidents", "task_id", filter.TaskID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list incidents")
+		return
+	}
+	resp := make([]incidentResponse, 0, len(incidents))
+	for _, incident := range incidents {
+		resp = append(resp, incidentToResponse(incident))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func parseIncidentFilter(r *http.Request) (store.IncidentFilter, string) {
+	var filter store.IncidentFilter
+	for _, value := range strings.Split(r.URL.Query().Get("status"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		status := core.IncidentStatus(value)
+		if !status.Valid() {
+			return filter, "status must be a comma-separated list of open, acknowledged or resolved"
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	return filter, ""
+}
+
+// handleGetIncident returns one incident.
+func (s *Server) handleGetIncident(w http.ResponseWriter, r *http.Request) {
+	incident, ok := s.loadIncident(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, incidentToResponse(incident))
+}
+
+// handleAcknowledgeIncident acknowledges the incident: it is no longer escalated and
+// repeat failure alerts stop until it is resolved.
+func (s *Server) handleAcknowledgeIncident(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeIncidentAction(w, r)
+	if !ok {
+		return
+	}
+	incident, ok := s.loadIncident(w, r)
+	if !ok {
+		return
+	}
+	s.acknowledgeIncident(w, r, incident.ID, incidentActor(req.By))
+}
+
+// handleResolveIncident resolves the incident by hand, e.g. once a fix is deployed.
+func (s *Server) handleResolveIncident(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeIncidentAction(w, r)
+	if !ok {
+		return
+	}
+	incident, ok := s.loadIncident(w, r)
+	if !ok {
+		return
+	}
+	s.resolveIncident(w, r, incident.ID, incidentActor(req.By), req.Note)
+}
+
+// handleAcknowledgeFailure acknowledges the task's unresolved incident.
+func (s *Server) handleAcknowledgeFailure(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeIncidentAction(w, r)
+	if !ok {
+		return
+	}
+	incident, ok := s.loadTaskIncident(w, r)
+	if !ok {
+		return
+	}
+	s.acknowledgeIncident(w, r, incident.ID, incidentActor(req.By))
+}
+
+// handleResolveTaskIncident resolves the task's unresolved incident.
+func (s *Server) handleResolveTaskIncident(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeIncidentAction(w, r)
+	if !ok {
+		return
+	}
+	incident, ok := s.loadTaskIncident(w, r)
+	if !ok {
+		return
+	}
+	s.resolveIncident(w, r, incident.ID, incidentActor(req.By), req.Note)
+}
+
+func (s *Server) acknowledgeIncident(w http.ResponseWriter, r *http.Request, id, by string) {
+	incident, err := s.store.AcknowledgeIncident(r.Context(), id, by, s.scheduler.Now())
+	if err != nil {
+		s.writeIncidentUpdateError(w, id, "acknowledge", err)
+		return
+	}
+	s.logger.Info("incident acknowledged", "incident_id", id, "task_id", incident.TaskID, "by", *incident.AcknowledgedBy)
+	writeJSON(w, http.StatusOK, incidentToResponse(incident))
+}
+
+func (s *Server) resolveIncident(w http.ResponseWriter, r *http.Request, id, by string, note *string) {
+	if note != nil {
+		if trimmed := strings.TrimSpace(*note); trimmed != "" {
+			note = &trimmed
+		} else {
+			note = nil
+		}
+	}
+	incident, err := s.store.ResolveIncident(r.Context(), id, by, note, s.scheduler.Now())
+	if err != nil {
+		s.writeIncidentUpdateError(w, id, "resolve", err)
+		return
+	}
+	s.logger.Info("incident resolved", "incident_id", id, "task_id", incident.TaskID, "by", by)
+	writeJSON(w, http.StatusOK, incidentToResponse(incident))
+}
+
+func (s *Server) writeIncidentUpdateError(w http.ResponseWriter, id, action string, err error) {
+	switch {
+	case errors.Is(err, store.ErrIncidentNotFound):
+		writeError(w, http.StatusNotFound, "not_found", "incident not found")
+	case errors.Is(err, store.ErrIncidentResolved):
+		writeError(w, http.StatusConflict, "conflict", "incident is already resolved")
+	default:
+		s.logger.Error(action+" incident", "incident_id", id, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to "+action+" incident")
+	}
+}
+
+// loadIncident reads the {incidentID} URL parameter, writing a 404 for unknown incidents.
+func (s *Server) loadIncident(w http.ResponseWriter, r *http.Request) (*core.Incident, bool) {
+	id := chi.URLParam(r, "incidentID")
+	incident, err := s.store.GetIncident(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrIncidentNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "incident not found")
+		} else {
+			s.logger.Error("get incident", "incident_id", id, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load incident")
+		}
+		return nil, false
+	}
+	return incident, true
+}
+
+// loadTaskIncident returns the unresolved incident of the {taskID} task, writing a 409
+// when the task is not failing.
+func (s *Server) loadTaskIncident(w http.ResponseWriter, r *http.Request) (*core.Incident, bool) {
+	taskID := chi.URLParam(r, "taskID")
+	if _, err := s.store.GetTask(r.Context(), taskID); err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for incident", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return nil, false
+	}
+	incident, err := s.store.GetTaskIncident(r.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, store.ErrIncidentNotFound) {
+			writeError(w, http.StatusConflict, "conflict", "task is not failing")
+		} else {
+			s.logger.Error("get task incident", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load incident")
+		}
+		return nil, false
+	}
+	return incident, true
+}
+
+func decodeIncidentAction(w http.ResponseWriter, r *http.Request) (incidentActionRequest, bool) {
+	var req incidentActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return req, false
+	}
+	return req, true
+}
+
+// incidentActor returns who is acting on an incident, anonymous unless the request names someone.
+func incidentActor(by *string) string {
+	if by != nil && strings.TrimSpace(*by) != "" {
+		return strings.TrimSpace(*by)
+	}
+	return "anonymous"
+}
+
+func incidentToResponse(incident *core.Incident) incidentResponse {
+	resp := incidentResponse{
+		ID:             incident.ID,
+		TaskID:         incident.TaskID,
+		TaskName:       incident.TaskName,
+		Status:         string(incident.Status),
+		FirstRunID:     incident.FirstRunID,
+		LastRunID:      incident.LastRunID,
+		Failures:       incident.Failures,
+		OpenedAt:       incident.OpenedAt.UTC().Format(time.RFC3339),
+		AcknowledgedBy: incident.AcknowledgedBy,
+		ResolvedBy:     incident.ResolvedBy,
+		Note:           incident.Note,
+	}
+	if incident.AcknowledgedAt != nil {
+		resp.AcknowledgedAt = formatOptionalTime(*incident.AcknowledgedAt)
+	}
+	if incident.EscalatedAt != nil {
+		resp.EscalatedAt = formatOptionalTime(*incident.EscalatedAt)
+	}
+	if incident.ResolvedAt != nil {
+		resp.ResolvedAt = formatOptionalTime(*incident.ResolvedAt)
+		duration := int64(incident.ResolvedAt.Sub(incident.OpenedAt).Seconds())
+		resp.DurationS = &duration
+	}
+	return resp
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"clicrontab/internal/core"
+	"clicrontab/internal/store"
+)
+
+// maxMaintenanceReasonLength caps the size of a maintenance window's reason in bytes.
+const maxMaintenanceReasonLength = 1024
+
+type createMaintenanceWindowRequest struct {
+	TaskID   *string `json:"task_id"`
+	StartsAt *string `json:"starts_at"`
+	EndsAt   *string `json:"ends_at"`
+	// Duration is an alternative to EndsAt, e.g. "2h".
+	Duration *string `json:"duration"`
+	Reason   *string `json:"reason"`
+}
+
+type maintenanceWindowResponse struct {
+	ID        string  `json:"id"`
+	TaskID    *string `json:"task_id"`
+	StartsAt  string  `json:"starts_at"`
+	EndsAt    string  `json:"ends_at"`
+	Reason    *string `json:"reason,omitempty"`
+	Active    bool    `json:"active"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// handleListMaintenanceWindows lists the current and upcoming maintenance windows, and
+// past ones too with all=true.
+func (s *Server) handleListMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	now := s.scheduler.Now()
+	endsAfter := &now
+	switch value := r.URL.Query().Get("all"); value {
+	case "", "false":
+	case "true":
+		endsAfter = nil
+	default:
+		writeError(w, http.StatusBadRequest, "invalid_input", "all must be true or false")
+		return
+	}
+	windows, err := s.store.ListMaintenanceWindows(r.Context(), endsAfter)
+	if err != nil {
+		s.logger.Error("list maintenance windows", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list maintenance windows")
+		return
+	}
+	resp := make([]maintenanceWindowResponse, 0, len(windows))
+	for _, window := range windows {
+		resp = append(resp, maintenanceWindowToResponse(window, now))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleCreateMaintenanceWindow schedules a maintenance window, for one task or all of
+// them, during which alerts are silenced but runs go on.
+func (s *Server) handleCreateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	var req createMaintenanceWindowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return
+	}
+
+	now := s.scheduler.Now()
+	window := &core.MaintenanceWindow{ID: core.NewID(), StartsAt: now.UTC()}
+	var errs validationErrors
+	if req.StartsAt != nil && strings.TrimSpace(*req.StartsAt) != "" {
+		startsAt, err := core.ParseTime(strings.TrimSpace(*req.StartsAt), s.location)
+		if err != nil {
+			errs.add("starts_at", constraintFormat, err.Error())
+		} else {
+			window.StartsAt = startsAt
+		}
+	}
+	hasEnd := req.EndsAt != nil && strings.TrimSpace(*req.EndsAt) != ""
+	hasDuration := req.Duration != nil && strings.TrimSpace(*req.Duration) != ""
+	switch {
+	case hasEnd && hasDuration:
+		errs.add("duration", constraintConflict, "give either ends_at or duration, not both")
+	case hasEnd:
+		endsAt, err := core.ParseTime(strings.TrimSpace(*req.EndsAt), s.location)
+		if err != nil {
+			errs.add("ends_at", constraintFormat, err.Error())
+		} else {
+			window.EndsAt = endsAt
+		}
+	case hasDuration:
+		duration, err := time.ParseDuration(strings.TrimSpace(*req.Duration))
+		if err != nil || duration <= 0 {
+			errs.add("duration", constraintFormat, "duration must be a positive Go duration such as 90m or 2h")
+		} else {
+			window.EndsAt = window.StartsAt.Add(duration)
+		}
+	default:
+		errs.add("ends_at", constraintRequired, "ends_at or duration is required")
+	}
+	if !window.EndsAt.IsZero() {
+		if !window.EndsAt.After(window.StartsAt) {
+			errs.add("ends_at", constraintConflict, "ends_at must be after starts_at")
+		} else if !window.EndsAt.After(now) {
+			errs.add("ends_at", constraintFuture, "ends_at must be in the future")
+		}
+	}
+	if req.Reason != nil {
+		if reason := strings.TrimSpace(*req.Reason); len(reason) > maxMaintenanceReasonLength {
+			errs.add("reason", constraintMaxLength, fmt.Sprintf("reason must be at most %d bytes", maxMaintenanceReasonLength))
+		} else if reason != "" {
+			window.Reason = &reason
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	if req.TaskID != nil && strings.TrimSpace(*req.TaskID) != "" {
+		taskID, err := s.store.ResolveTaskRef(r.Context(), strings.TrimSpace(*req.TaskID))
+		switch {
+		case errors.Is(err, core.ErrAmbiguousTaskRef):
+			writeError(w, http.StatusConflict, "ambiguous", err.Error())
+			return
+		case errors.Is(err, store.ErrTaskNotFound):
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+			return
+		case err != nil:
+			s.logger.Error("resolve task reference", "ref", *req.TaskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to resolve task")
+			return
+		}
+		window.TaskID = &taskID
+	}
+
+	if err := s.store.CreateMaintenanceWindow(r.Context(), window); err != nil {
+		s.logger.Error("create maintenance window", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to create maintenance window")
+		return
+	}
+	scope := "all"
+	if window.TaskID != nil {
+		scope = *window.TaskID
+	}
+	s.logger.Info("maintenance window scheduled", "window_id", window.ID, "task_id", scope, "starts_at", window.StartsAt, "ends_at", window.EndsAt)
+	writeJSON(w, http.StatusCreated, maintenanceWindowToResponse(window, now))
+}
+
+// handleDeleteMaintenanceWindow cancels a maintenance window, or ends it early.
+func (s *Server) handleDeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "windowID")
+	if err := s.store.DeleteMaintenanceWindow(r.Context(), id); err != nil {
+		if errors.Is(err, store.ErrMaintenanceWindowNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "maintenance window not found")
+		} else {
+			s.logger.Error("delete maintenance window", "window_id", id, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to delete maintenance window")
+		}
+		return
+	}
+	s.logger.Info("maintenance window deleted", "window_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func maintenanceWindowToResponse(window *core.MaintenanceWindow, now time.Time) maintenanceWindowResponse {
+	return maintenanceWindowResponse{
+		ID:        window.ID,
+		TaskID:    window.TaskID,
+		StartsAt:  window.StartsAt.UTC().Format(time.RFC3339),
+		EndsAt:    window.EndsAt.UTC().Format(time.RFC3339),
+		Reason:    window.Reason,
+		Active:    window.Active(now),
+		CreatedAt: window.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
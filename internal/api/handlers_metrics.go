@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+type healthSampleResponse struct {
+	RecordedAt  string   `json:"recorded_at"`
+	Goroutines  int      `json:"goroutines"`
+	HeapBytes   uint64   `json:"heap_bytes"`
+	SysBytes    uint64   `json:"sys_bytes"`
+	DBLatencyMS float64  `json:"db_latency_ms"`
+	QueueDepth  int      `json:"queue_depth"`
+	ActiveRuns  int      `json:"active_runs"`
+	Anomalies   []string `json:"anomalies,omitempty"`
+}
+
+type metricsResponse struct {
+	Window  string                 `json:"window"`
+	Since   string                 `json:"since"`
+	Samples []healthSampleResponse `json:"samples"`
+}
+
+// handleDaemonMetrics lists the self-monitor's readings within the window, newest first.
+func (s *Server) handleDaemonMetrics(w http.ResponseWriter, r *http.Request) {
+	window := time.Hour
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_input", "window must be a positive Go duration such as 1h")
+			return
+		}
+		window = parsed
+	}
+	since := s.scheduler.Now().UTC().Add(-window)
+	limit := parseIntDefault(r.URL.Query().Get("limit"), 500)
+
+	samples, err := s.store.ListHealthSamples(r.Context(), since, limit)
+	if err != nil {
+		s.logger.Error("list daemon metrics", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list metrics")
+		return
+	}
+	resp := metricsResponse{
+		Window:  window.String(),
+		Since:   since.Format(time.RFC3339),
+		Samples: make([]healthSampleResponse, 0, len(samples)),
+	}
+	for _, sample := range samples {
+		resp.Samples = append(resp.Samples, healthSampleToResponse(sample))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func healthSampleToResponse(sample *core.HealthSample) healthSampleResponse {
+	resp := healthSampleResponse{
+		RecordedAt:  sample.RecordedAt.UTC().Format(time.RFC3339),
+		Goroutines:  sample.Goroutines,
+		HeapBytes:   sample.HeapBytes,
+		SysBytes:    sample.SysBytes,
+		DBLatencyMS: float64(sample.DBLatency) / float64(time.Millisecond),
+		QueueDepth:  sample.QueueDepth,
+		ActiveRuns:  sample.ActiveRuns,
+	}
+	for _, anomaly := range sample.Anomalies() {
+		resp.Anomalies = append(resp.Anomalies, anomaly.Detail)
+	}
+	return resp
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"clicrontab/internal/oidc"
+)
+
+const (
+	// oidcCookieName carries the state, nonce and PKCE verifier of a login in progress.
+	oidcCookieName = "clicron_oidc"
+	// oidcCallbackPath is where the provider redirects back to.
+	oidcCallbackPath = "/login/oidc/callback"
+)
+
+// handleOIDCLogin sends the browser to the provider's sign-in page.
+func (s *Server) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if s.oidc == nil {
+		writeError(w, http.StatusNotFound, "not_found", "OIDC login is not configured")
+		return
+	}
+	var secrets [3]string // state, nonce, verifier
+	for i := range secrets {
+		secret, err := oidc.NewSecret()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to start login")
+			return
+		}
+		secrets[i] = secret
+	}
+	authURL, err := s.oidc.AuthURL(r.Context(), s.oidcRedirectURL(r), secrets[0], secrets[1], secrets[2])
+	if err != nil {
+		s.logger.Error("start oidc login", "err", err)
+		writeError(w, http.StatusBadGateway, "oidc_unavailable", "identity provider is unavailable")
+		return
+	}
+
+	payload := strings.Join(secrets[:], "|")
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.sessionMAC("oidc:"+payload),
+		Path:     "/login/oidc",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		// Lax so the cookie comes back on the provider's top-level redirect
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// handleOIDCCallback completes the login and starts a session with the role the user's
+// groups map to. Failures go back to the UI as ?login_error=.
+func (s *Server) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if s.oidc == nil {
+		writeError(w, http.StatusNotFound, "not_found", "OIDC login is not configured")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcCookieName, Path: "/login/oidc", MaxAge: -1, HttpOnly: true})
+	fail := func(msg string) {
+		http.Redirect(w, r, "/?login_error="+url.QueryEscape(msg), http.StatusFound)
+	}
+
+	query := r.URL.Query()
+	if e := query.Get("error"); e != "" {
+		fail("identity provider: " + e)
+		return
+	}
+	state, nonce, verifier, ok := s.oidcLoginState(r)
+	if !ok || query.Get("state") == "" || !tokenEqual(query.Get("state"), state) {
+		fail("login expired or was started elsewhere; try again")
+		return
+	}
+	id, err := s.oidc.Exchange(r.Context(), s.oidcRedirectURL(r), query.Get("code"), nonce, verifier)
+	if errors.Is(err, oidc.ErrNoRole) {
+		s.logger.Warn("oidc user has no role", "user", id.Name, "groups", id.Groups)
+		fail(id.Name + " is not allowed to use clicrontab")
+		return
+	}
+	if err != nil {
+		s.logger.Error("oidc login", "err", err)
+		fail("sign-in failed")
+		return
+	}
+	s.startSession(w, r, id.Name, id.Role)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+func (s *Server) oidcLoginState(r *http.Request) (state, nonce, verifier string, ok bool) {
+	cookie, err := r.Cookie(oidcCookieName)
+	if err != nil {
+		return "", "", "", false
+	}
+	encoded, sig, found := strings.Cut(cookie.Value, ".")
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if !found || err != nil || !tokenEqual(sig, s.sessionMAC("oidc:"+string(raw))) {
+		return "", "", "", false
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
+
+// oidcRedirectURL returns the configured callback URL, or one built from the request.
+func (s *Server) oidcRedirectURL(r *http.Request) string {
+	if s.oidcRedirect != "" {
+		return s.oidcRedirect
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + oidcCallbackPath
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"clicrontab/internal/notify"
+	"clicrontab/internal/store"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type deliveryResponse struct {
+	ID            string  `json:"id"`
+	Channel       string  `json:"channel"`
+	Title         string  `json:"title"`
+	TaskID        string  `json:"task_id,omitempty"`
+	RunID         string  `json:"run_id,omitempty"`
+	Status        string  `json:"status"`
+	Attempts      int     `json:"attempts"`
+	LastError     *string `json:"last_error,omitempty"`
+	NextAttemptAt *string `json:"next_attempt_at,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+	DeliveredAt   *string `json:"delivered_at,omitempty"`
+}
+
+type deliveriesResponse struct {
+	Counts     map[notify.DeliveryStatus]int `json:"counts"`
+	Deliveries []deliveryResponse            `json:"deliveries"`
+}
+
+// handleListDeliveries lists outbox entries, newest first, with per-status counts.
+func (s *Server) handleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	status := notify.DeliveryStatus(strings.TrimSpace(r.URL.Query().Get("status")))
+	switch status {
+	case "", notify.DeliveryPending, notify.DeliveryDelivered, notify.DeliveryFailed:
+	default:
+		writeError(w, http.StatusBadRequest, "invalid_input", "status must be pending, delivered or failed")
+		return
+	}
+	limit := parseIntDefault(r.URL.Query().Get("limit"), 50)
+
+	counts, err := s.store.DeliveryCounts(r.Context())
+	if err != nil {
+		s.logger.Error("count notification deliveries", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list notifications")
+		return
+	}
+	deliveries, err := s.store.ListDeliveries(r.Context(), status, limit)
+	if err != nil {
+		s.logger.Error("list notification deliveries", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list notifications")
+		return
+	}
+	resp := deliveriesResponse{Counts: counts, Deliveries: make([]deliveryResponse, 0, len(deliveries))}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, deliveryToResponse(d))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleRetryDelivery queues a failed delivery for another round of attempts.
+func (s *Server) handleRetryDelivery(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "deliveryID")
+	if err := s.store.RequeueDelivery(r.Context(), id, time.Now()); err != nil {
+		if errors.Is(err, store.ErrDeliveryNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "no failed notification with this id")
+			return
+		}
+		s.logger.Error("requeue notification delivery", "id", id, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to retry notification")
+		return
+	}
+	s.outbox.Wake()
+	writeJSON(w, http.StatusAccepted, map[string]string{"id": id, "status": string(notify.DeliveryPending)})
+}
+
+func deliveryToResponse(d *notify.Delivery) deliveryResponse {
+	resp := deliveryResponse{
+		ID:        d.ID,
+		Channel:   d.Channel,
+		Title:     d.Message.Title,
+		TaskID:    d.Message.TaskID,
+		RunID:     d.Message.RunID,
+		Status:    string(d.Status),
+		Attempts:  d.Attempts,
+		LastError: d.LastError,
+		CreatedAt: d.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if d.Status == notify.DeliveryPending {
+		next := d.NextAttemptAt.UTC().Format(time.RFC3339)
+		resp.NextAttemptAt = &next
+	}
+	if d.DeliveredAt != nil {
+		delivered := d.DeliveredAt.UTC().Format(time.RFC3339)
+		resp.DeliveredAt = &delivered
+	}
+	return resp
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"clicrontab/internal/report"
+)
+
+// reportKind reads the {kind} URL parameter, writing a 404 for unknown kinds.
+func reportKind(w http.ResponseWriter, r *http.Request) (report.Kind, bool) {
+	kind := report.Kind(chi.URLParam(r, "kind"))
+	if !kind.Valid() {
+		writeError(w, http.StatusNotFound, "not_found", "report kind must be daily or weekly")
+		return "", false
+	}
+	return kind, true
+}
+
+// handleListReports lists the stored reports of a kind, latest period first.
+func (s *Server) handleListReports(w http.ResponseWriter, r *http.Request) {
+	kind, ok := reportKind(w, r)
+	if !ok {
+		return
+	}
+	limit := parseIntDefault(r.URL.Query().Get("limit"), 7)
+	if limit > 366 {
+		limit = 366
+	}
+	reports, err := s.store.ListReports(r.Context(), kind, limit)
+	if err != nil {
+		s.logger.Error("list reports", "kind", kind, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list reports")
+		return
+	}
+	if reports == nil {
+		reports = []*report.Report{}
+	}
+	writeJSON(w, http.StatusOK, reports)
+}
+
+// handleGetReport returns the stored report for a period. Periods without one, including
+// the current one so far, are aggregated on the fly and not stored. format=html renders
+// the report as it is mailed.
+func (s *Server) handleGetReport(w http.ResponseWriter, r *http.Request) {
+	kind, ok := reportKind(w, r)
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "html" {
+		writeError(w, http.StatusBadRequest, "invalid_input", "format must be json or html")
+		return
+	}
+	start, err := report.ParsePeriod(kind, chi.URLParam(r, "period"), s.location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_input", err.Error())
+		return
+	}
+	if start.After(s.scheduler.Now()) {
+		writeError(w, http.StatusBadRequest, "invalid_input", "period is in the future")
+		return
+	}
+
+	period := report.Period(kind, start)
+	rep, err := s.store.GetReport(r.Context(), kind, period)
+	if errors.Is(err, report.ErrNotFound) {
+		rep, err = report.Build(r.Context(), s.store, kind, start, s.location, s.scheduler.Now())
+	}
+	if err != nil {
+		s.logger.Error("get report", "kind", kind, "period", period, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to load report")
+		return
+	}
+	if format != "html" {
+		writeJSON(w, http.StatusOK, rep)
+		return
+	}
+	html, err := report.RenderHTML(rep, s.location)
+	if err != nil {
+		s.logger.Error("render report", "kind", kind, "period", period, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to render report")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(html))
+}
@@ -1,29 +1,69 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
 	"clicrontab/internal/core"
+	"clicrontab/internal/loglink"
 	"clicrontab/internal/store"
 
 	"github.com/go-chi/chi/v5"
 )
 
 type runResponse struct {
-	ID          string  `json:"id"`
-	TaskID      string  `json:"task_id"`
-	Status      string  `json:"status"`
-	ScheduledAt string  `json:"scheduled_at"`
-	StartedAt   *string `json:"started_at,omitempty"`
-	EndedAt     *string `json:"ended_at,omitempty"`
-	ExitCode    *int    `json:"exit_code,omitempty"`
-	Error       *string `json:"error,omitempty"`
-	CreatedAt   string  `json:"created_at"`
+	ID          string   `json:"id"`
+	TaskID      string   `json:"task_id"`
+	Status      string   `json:"status"`
+	TriggerType string   `json:"trigger_type"`
+	ScheduledAt string   `json:"scheduled_at"`
+	StartedAt   *string  `json:"started_at,omitempty"`
+	EndedAt     *string  `json:"ended_at,omitempty"`
+	ExitCode    *int     `json:"exit_code,omitempty"`
+	Error       *string  `json:"error,omitempty"`
+	Reason      *string  `json:"reason,omitempty"`
+	PID         *int     `json:"pid,omitempty"`
+	MaxRSSKB    *int64   `json:"max_rss_kb,omitempty"`
+	CPUSeconds  *float64 `json:"cpu_s,omitempty"`
+	LagMS       *int64   `json:"lag_ms,omitempty"`
+	Note        *string  `json:"note,omitempty"`
+	OutputTail  *string  `json:"output_tail,omitempty"`
+	Warning     *string  `json:"warning,omitempty"`
+	Pinned      bool     `json:"pinned"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+// maxRunNoteLength caps the size of a run note in bytes.
+const maxRunNoteLength = 4096
+
+type updateRunRequest struct {
+	Note   *string `json:"note"`
+	Pinned *bool   `json:"pinned"`
+}
+
+type runEventResponse struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail,omitempty"`
+	At     string `json:"at"`
+}
+
+// runDetailResponse is a single run with its lifecycle events.
+type runDetailResponse struct {
+	runResponse
+	Events []runEventResponse `json:"events"`
+}
+
+type activeRunResponse struct {
+	runResponse
+	ElapsedSecs int64  `json:"elapsed_s"`
+	CancelURL   string `json:"cancel_url"`
 }
 
 func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
@@ -38,9 +78,137 @@ func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	events, err := s.store.ListRunEvents(r.Context(), runID)
+	if err != nil {
+		s.logger.Error("list run events", "run_id", runID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to load run events")
+		return
+	}
+	resp := runDetailResponse{runResponse: runToResponse(run), Events: make([]runEventResponse, 0, len(events))}
+	for _, event := range events {
+		resp.Events = append(resp.Events, runEventResponse{
+			Type:   string(event.Type),
+			Detail: event.Detail,
+			At:     event.At.UTC().Format(time.RFC3339Nano),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleUpdateRun(w http.ResponseWriter, r *http.Request) {
+	runID := chi.URLParam(r, "runID")
+	var req updateRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return
+	}
+	var errs validationErrors
+	if req.Note == nil && req.Pinned == nil {
+		errs.add("note", constraintRequired, "note or pinned is required")
+	} else if req.Note != nil && len(strings.TrimSpace(*req.Note)) > maxRunNoteLength {
+		errs.add("note", constraintMaxLength, fmt.Sprintf("note must be at most %d bytes", maxRunNoteLength))
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	if req.Note != nil {
+		var note *string
+		if trimmed := strings.TrimSpace(*req.Note); trimmed != "" {
+			note = &trimmed
+		}
+		if err := s.store.SetRunNote(r.Context(), runID, note); err != nil {
+			s.writeRunUpdateError(w, runID, "set run note", err)
+			return
+		}
+	}
+	if req.Pinned != nil {
+		if err := s.store.SetRunPinned(r.Context(), runID, *req.Pinned); err != nil {
+			s.writeRunUpdateError(w, runID, "set run pinned", err)
+			return
+		}
+	}
+	run, err := s.store.GetRun(r.Context(), runID)
+	if err != nil {
+		s.logger.Error("reload run", "run_id", runID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to load run")
+		return
+	}
 	writeJSON(w, http.StatusOK, runToResponse(run))
 }
 
+// writeRunUpdateError reports a failed run update, logging unexpected errors as op.
+func (s *Server) writeRunUpdateError(w http.ResponseWriter, runID, op string, err error) {
+	if errors.Is(err, store.ErrRunNotFound) {
+		writeError(w, http.StatusNotFound, "not_found", "run not found")
+		return
+	}
+	s.logger.Error(op, "run_id", runID, "err", err)
+	writeError(w, http.StatusInternalServerError, "internal_error", "failed to update run")
+}
+
+func (s *Server) handleListActiveRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := s.store.ListActiveRuns(r.Context())
+	if err != nil {
+		s.logger.Error("list active runs", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list active runs")
+		return
+	}
+	now := s.scheduler.Now().UTC()
+	resp := make([]activeRunResponse, 0, len(runs))
+	for _, run := range runs {
+		since := run.CreatedAt
+		if run.StartedAt != nil {
+			since = *run.StartedAt
+		}
+		resp = append(resp, activeRunResponse{
+			runResponse: runToResponse(run),
+			ElapsedSecs: int64(now.Sub(since).Seconds()),
+			CancelURL:   "/v1/runs/" + run.ID + "/cancel",
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleCancelRun(w http.ResponseWriter, r *http.Request) {
+	runID := chi.URLParam(r, "runID")
+	run, err := s.store.GetRun(r.Context(), runID)
+	if err != nil {
+		if errors.Is(err, store.ErrRunNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "run not found")
+		} else {
+			s.logger.Error("get run for cancel", "run_id", runID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load run")
+		}
+		return
+	}
+	if isRunFinished(run.Status) || !s.scheduler.CancelRun(runID) {
+		writeError(w, http.StatusConflict, "conflict", "run is not active")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"run_id": runID, "status": "canceling"})
+}
+
+// handleSharedRunLog serves a run log to a signed link from a notification, which
+// stands in for the API token.
+func (s *Server) handleSharedRunLog(w http.ResponseWriter, r *http.Request) {
+	if s.logLinks == nil {
+		writeError(w, http.StatusNotFound, "not_found", "log links are not enabled")
+		return
+	}
+	query := r.URL.Query()
+	err := s.logLinks.Verify(chi.URLParam(r, "runID"), query.Get("expires"), query.Get("sig"), time.Now())
+	switch {
+	case errors.Is(err, loglink.ErrLinkExpired):
+		writeError(w, http.StatusGone, "link_expired", "log link has expired")
+		return
+	case err != nil:
+		writeError(w, http.StatusForbidden, "forbidden", "invalid log link")
+		return
+	}
+	s.handleRunLog(w, r)
+}
+
 func (s *Server) handleRunLog(w http.ResponseWriter, r *http.Request) {
 	runID := chi.URLParam(r, "runID")
 	run, err := s.store.GetRun(r.Context(), runID)
@@ -136,11 +304,114 @@ func (s *Server) handleRunLog(w http.ResponseWriter, r *http.Request) {
 	writeError(w, http.StatusBadRequest, "unsupported", "streaming not supported")
 }
 
+const (
+	defaultLogGrepContext = 2
+	maxLogGrepContext     = 20
+	defaultLogGrepLimit   = 100
+	maxLogGrepLimit       = 1000
+)
+
+type logMatchResponse struct {
+	Line   int      `json:"line"`
+	Offset int64    `json:"offset"`
+	Spans  [][2]int `json:"spans"`
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+type logGrepResponse struct {
+	RunID     string             `json:"run_id"`
+	Pattern   string             `json:"pattern"`
+	Context   int                `json:"context"`
+	Matches   []logMatchResponse `json:"matches"`
+	Total     int                `json:"total_matches"`
+	Lines     int                `json:"lines"`
+	Truncated bool               `json:"truncated"`
+}
+
+// handleGrepRunLog searches a run log with a regular expression, so clients can find an
+// error line without downloading the whole log.
+func (s *Server) handleGrepRunLog(w http.ResponseWriter, r *http.Request) {
+	runID := chi.URLParam(r, "runID")
+	query := r.URL.Query()
+	expr := query.Get("pattern")
+	if expr == "" {
+		writeError(w, http.StatusBadRequest, "invalid_input", "pattern is required")
+		return
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("invalid pattern: %v", err))
+		return
+	}
+	context := parseIntDefault(query.Get("context"), defaultLogGrepContext)
+	if context < 0 || context > maxLogGrepContext {
+		writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("context must be between 0 and %d", maxLogGrepContext))
+		return
+	}
+	limit := parseIntDefault(query.Get("limit"), defaultLogGrepLimit)
+	if limit <= 0 {
+		limit = defaultLogGrepLimit
+	}
+	if limit > maxLogGrepLimit {
+		limit = maxLogGrepLimit
+	}
+
+	if _, err := s.store.GetRun(r.Context(), runID); err != nil {
+		if errors.Is(err, store.ErrRunNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "run not found")
+		} else {
+			s.logger.Error("get run for log grep", "run_id", runID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load run")
+		}
+		return
+	}
+	result, err := s.store.GrepRunLog(r.Context(), runID, pattern, context, limit)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			writeError(w, http.StatusNotFound, "not_found", "log not found")
+		} else {
+			s.logger.Error("grep log", "run_id", runID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to search log")
+		}
+		return
+	}
+
+	resp := logGrepResponse{
+		RunID:     runID,
+		Pattern:   expr,
+		Context:   context,
+		Matches:   make([]logMatchResponse, 0, len(result.Matches)),
+		Total:     result.Total,
+		Lines:     result.Lines,
+		Truncated: result.Total > len(result.Matches),
+	}
+	for _, m := range result.Matches {
+		spans := m.Spans
+		if spans == nil {
+			spans = [][2]int{}
+		}
+		resp.Matches = append(resp.Matches, logMatchResponse{
+			Line:   m.Line,
+			Offset: m.Offset,
+			Spans:  spans,
+			Text:   m.Text,
+			Before: m.Before,
+			After:  m.After,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func runToResponse(run *core.Run) runResponse {
 	var started, ended *string
+	var lag *int64
 	if run.StartedAt != nil {
 		formatted := run.StartedAt.UTC().Format(time.RFC3339)
 		started = &formatted
+		ms := run.StartedAt.Sub(run.ScheduledAt).Milliseconds()
+		lag = &ms
 	}
 	if run.EndedAt != nil {
 		formatted := run.EndedAt.UTC().Format(time.RFC3339)
@@ -150,11 +421,21 @@ func runToResponse(run *core.Run) runResponse {
 		ID:          run.ID,
 		TaskID:      run.TaskID,
 		Status:      string(run.Status),
+		TriggerType: string(run.TriggerType),
 		ScheduledAt: run.ScheduledAt.UTC().Format(time.RFC3339),
 		StartedAt:   started,
 		EndedAt:     ended,
 		ExitCode:    run.ExitCode,
 		Error:       run.Error,
+		Reason:      run.Reason,
+		PID:         run.PID,
+		MaxRSSKB:    run.MaxRSSKB,
+		CPUSeconds:  run.CPUSeconds,
+		LagMS:       lag,
+		Note:        run.Note,
+		OutputTail:  run.OutputTail,
+		Warning:     run.Warning,
+		Pinned:      run.Pinned,
 		CreatedAt:   run.CreatedAt.UTC().Format(time.RFC3339),
 	}
 }
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+type schedulerSyncResponse struct {
+	// InSync is true when the sync found nothing to correct.
+	InSync      bool              `json:"in_sync"`
+	Scheduled   int               `json:"scheduled"`
+	Deleted     []string          `json:"deleted"`
+	Inactive    []string          `json:"inactive"`
+	Unscheduled []string          `json:"unscheduled"`
+	Invalid     map[string]string `json:"invalid"`
+	Recovered   []string          `json:"recovered"`
+}
+
+// handleSchedulerSync rebuilds the cron entries from the store and reports the
+// discrepancies it corrected.
+func (s *Server) handleSchedulerSync(w http.ResponseWriter, r *http.Request) {
+	report, err := s.scheduler.Sync(r.Context())
+	if err != nil {
+		s.logger.Error("sync scheduler", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to sync scheduler")
+		return
+	}
+	resp := schedulerSyncResponse{
+		Scheduled:   report.Scheduled,
+		Deleted:     nonNilStrings(report.Deleted),
+		Inactive:    nonNilStrings(report.Inactive),
+		Unscheduled: nonNilStrings(report.Unscheduled),
+		Invalid:     report.Invalid,
+		Recovered:   nonNilStrings(report.Recovered),
+	}
+	resp.InSync = len(resp.Deleted)+len(resp.Inactive)+len(resp.Unscheduled)+len(resp.Invalid)+len(resp.Recovered) == 0
+	if !resp.InSync {
+		s.logger.Warn("scheduler was out of sync with the store", "deleted", resp.Deleted, "inactive", resp.Inactive,
+			"unscheduled", resp.Unscheduled, "invalid", len(resp.Invalid), "recovered", resp.Recovered)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+type scheduleEntryResponse struct {
+	EntryID int     `json:"entry_id"`
+	TaskID  string  `json:"task_id"`
+	Next    *string `json:"next,omitempty"`
+	Prev    *string `json:"prev,omitempty"`
+}
+
+// handleSchedulerEntries lists the cron engine's in-memory entries, soonest first, for
+// comparing the live schedule with the tasks in the store.
+func (s *Server) handleSchedulerEntries(w http.ResponseWriter, r *http.Request) {
+	entries := s.scheduler.Entries()
+	resp := make([]scheduleEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		resp = append(resp, scheduleEntryResponse{
+			EntryID: entry.EntryID,
+			TaskID:  entry.TaskID,
+			Next:    formatOptionalTime(entry.Next),
+			Prev:    formatOptionalTime(entry.Prev),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"entries": resp})
+}
+
+func formatOptionalTime(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	formatted := t.UTC().Format(time.RFC3339)
+	return &formatted
+}
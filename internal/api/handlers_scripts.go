@@ -0,0 +1,228 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"clicrontab/internal/core"
+	"clicrontab/internal/store"
+)
+
+const (
+	// maxScriptNameLength caps the size of a script's name in bytes.
+	maxScriptNameLength = 128
+	// maxScriptContentLength caps the size of a script in bytes.
+	maxScriptContentLength = 256 << 10
+)
+
+type scriptRequest struct {
+	Name        *string `json:"name"`
+	Type        *string `json:"type"`
+	Content     *string `json:"content"`
+	Description *string `json:"description"`
+}
+
+type scriptResponse struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Type        string  `json:"type"`
+	Content     string  `json:"content"`
+	Description *string `json:"description,omitempty"`
+	// Tasks counts the tasks running the script, archived ones included.
+	Tasks     int    `json:"tasks"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// handleListScripts lists the script library.
+func (s *Server) handleListScripts(w http.ResponseWriter, r *http.Request) {
+	scripts, err := s.store.ListScripts(r.Context())
+	if err != nil {
+		s.logger.Error("list scripts", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list scripts")
+		return
+	}
+	usage, err := s.store.ScriptUsage(r.Context())
+	if err != nil {
+		s.logger.Error("count script usage", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list scripts")
+		return
+	}
+	resp := make([]scriptResponse, 0, len(scripts))
+	for _, script := range scripts {
+		resp = append(resp, scriptToResponse(script, usage[script.ID]))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleCreateScript adds a script to the library.
+func (s *Server) handleCreateScript(w http.ResponseWriter, r *http.Request) {
+	var req scriptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return
+	}
+	script := &core.Script{ID: core.NewID()}
+	if errs := applyScriptRequest(script, &req, true); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	if err := s.store.CreateScript(r.Context(), script); err != nil {
+		s.writeScriptStoreError(w, "create script", script.ID, err)
+		return
+	}
+	s.logger.Info("script created", "script_id", script.ID, "name", script.Name, "type", script.Type)
+	writeJSON(w, http.StatusCreated, scriptToResponse(script, 0))
+}
+
+// handleGetScript returns a script with its content.
+func (s *Server) handleGetScript(w http.ResponseWriter, r *http.Request) {
+	script, ok := s.loadScript(w, r)
+	if !ok {
+		return
+	}
+	usage, err := s.store.ScriptUsage(r.Context())
+	if err != nil {
+		s.logger.Error("count script usage", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to get script")
+		return
+	}
+	writeJSON(w, http.StatusOK, scriptToResponse(script, usage[script.ID]))
+}
+
+// handleUpdateScript changes a script; tasks running it use the new version from their
+// next run on.
+func (s *Server) handleUpdateScript(w http.ResponseWriter, r *http.Request) {
+	script, ok := s.loadScript(w, r)
+	if !ok {
+		return
+	}
+	var req scriptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return
+	}
+	if errs := applyScriptRequest(script, &req, false); len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	if err := s.store.UpdateScript(r.Context(), script); err != nil {
+		s.writeScriptStoreError(w, "update script", script.ID, err)
+		return
+	}
+	usage, err := s.store.ScriptUsage(r.Context())
+	if err != nil {
+		s.logger.Error("count script usage", "err", err)
+	}
+	s.logger.Info("script updated", "script_id", script.ID, "name", script.Name)
+	writeJSON(w, http.StatusOK, scriptToResponse(script, usage[script.ID]))
+}
+
+// handleDeleteScript removes a script no task runs anymore.
+func (s *Server) handleDeleteScript(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "scriptID")
+	if err := s.store.DeleteScript(r.Context(), id); err != nil {
+		s.writeScriptStoreError(w, "delete script", id, err)
+		return
+	}
+	s.logger.Info("script deleted", "script_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) loadScript(w http.ResponseWriter, r *http.Request) (*core.Script, bool) {
+	id := chi.URLParam(r, "scriptID")
+	script, err := s.store.GetScript(r.Context(), id)
+	if err != nil {
+		s.writeScriptStoreError(w, "get script", id, err)
+		return nil, false
+	}
+	return script, true
+}
+
+func (s *Server) writeScriptStoreError(w http.ResponseWriter, action, id string, err error) {
+	switch {
+	case errors.Is(err, store.ErrScriptNotFound):
+		writeError(w, http.StatusNotFound, "not_found", "script not found")
+	case errors.Is(err, core.ErrScriptNameTaken):
+		writeError(w, http.StatusConflict, "name_taken", err.Error())
+	case errors.Is(err, core.ErrScriptInUse):
+		writeError(w, http.StatusConflict, "in_use", err.Error()+"; point them at another script or delete them first")
+	default:
+		s.logger.Error(action, "script_id", id, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to "+action)
+	}
+}
+
+// applyScriptRequest validates the request and applies it to script. On create, name,
+// type and content are required; on update, omitted fields are kept.
+func applyScriptRequest(script *core.Script, req *scriptRequest, create bool) validationErrors {
+	var errs validationErrors
+	if req.Name != nil || create {
+		name := ""
+		if req.Name != nil {
+			name = strings.TrimSpace(*req.Name)
+		}
+		switch {
+		case name == "":
+			errs.add("name", constraintRequired, "name is required")
+		case len(name) > maxScriptNameLength:
+			errs.add("name", constraintMaxLength, fmt.Sprintf("name must be at most %d bytes", maxScriptNameLength))
+		default:
+			script.Name = name
+		}
+	}
+	if req.Type != nil || create {
+		kind := ""
+		if req.Type != nil {
+			kind = strings.TrimSpace(*req.Type)
+		}
+		switch {
+		case kind == "":
+			errs.add("type", constraintRequired, "type is required")
+		case !core.IsScriptType(kind):
+			errs.add("type", constraintOneOf, "type must be one of: "+strings.Join(core.ScriptTypes(), ", "))
+		default:
+			script.Type = kind
+		}
+	}
+	if req.Content != nil || create {
+		content := ""
+		if req.Content != nil {
+			content = *req.Content
+		}
+		switch {
+		case strings.TrimSpace(content) == "":
+			errs.add("content", constraintRequired, "content is required")
+		case len(content) > maxScriptContentLength:
+			errs.add("content", constraintMaxLength, fmt.Sprintf("content must be at most %d bytes", maxScriptContentLength))
+		default:
+			script.Content = content
+		}
+	}
+	if req.Description != nil {
+		script.Description = nil
+		if description := strings.TrimSpace(*req.Description); description != "" {
+			script.Description = &description
+		}
+	}
+	return errs
+}
+
+func scriptToResponse(script *core.Script, tasks int) scriptResponse {
+	return scriptResponse{
+		ID:          script.ID,
+		Name:        script.Name,
+		Type:        script.Type,
+		Content:     script.Content,
+		Description: script.Description,
+		Tasks:       tasks,
+		CreatedAt:   script.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   script.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSearchRunLimit = 200
+	maxSearchRunLimit     = 2000
+	searchSnippetsPerRun  = 3
+)
+
+type searchLogHit struct {
+	RunID     string   `json:"run_id"`
+	TaskID    string   `json:"task_id"`
+	CreatedAt string   `json:"created_at"`
+	Snippets  []string `json:"snippets"`
+}
+
+type searchResponse struct {
+	Query   string         `json:"query"`
+	Tasks   []taskResponse `json:"tasks"`
+	LogHits []searchLogHit `json:"log_hits,omitempty"`
+	// LogSource is "index" when the full-text index served the log search, "grep" otherwise.
+	LogSource string `json:"log_source,omitempty"`
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "invalid_input", "q is required")
+		return
+	}
+	logs := r.URL.Query().Get("logs")
+	searchLogs := strings.EqualFold(logs, "1") || strings.EqualFold(logs, "true")
+
+	tasks, err := s.store.SearchTasks(r.Context(), query)
+	if err != nil {
+		s.logger.Error("search tasks", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to search tasks")
+		return
+	}
+	resp := searchResponse{Query: query, Tasks: make([]taskResponse, 0, len(tasks))}
+	for _, task := range tasks {
+		resp.Tasks = append(resp.Tasks, taskToResponse(task))
+	}
+
+	if searchLogs {
+		runLimit := parseIntDefault(r.URL.Query().Get("runs"), defaultSearchRunLimit)
+		if runLimit <= 0 {
+			runLimit = defaultSearchRunLimit
+		}
+		if runLimit > maxSearchRunLimit {
+			runLimit = maxSearchRunLimit
+		}
+		hits, err := s.store.SearchRunLogs(r.Context(), query, runLimit, searchSnippetsPerRun)
+		if err != nil {
+			s.logger.Error("search run logs", "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to search run logs")
+			return
+		}
+		resp.LogSource = "grep"
+		if s.store.LogIndexEnabled() {
+			resp.LogSource = "index"
+		}
+		resp.LogHits = make([]searchLogHit, 0, len(hits))
+		for _, hit := range hits {
+			resp.LogHits = append(resp.LogHits, searchLogHit{
+				RunID:     hit.RunID,
+				TaskID:    hit.TaskID,
+				CreatedAt: hit.CreatedAt.UTC().Format(time.RFC3339),
+				Snippets:  hit.Snippets,
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
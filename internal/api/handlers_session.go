@@ -0,0 +1,188 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clicrontab/internal/oidc"
+)
+
+const (
+	// sessionCookieName is the HttpOnly cookie the web UI authenticates with.
+	sessionCookieName = "clicron_session"
+	// csrfCookieName holds the CSRF token for the session. It is readable by scripts so
+	// the UI can echo it in csrfHeader; another origin can neither read it nor set it.
+	csrfCookieName = "clicron_csrf"
+	// csrfHeader must carry the CSRF token on unsafe requests authenticated by cookie.
+	csrfHeader = "X-CSRF-Token"
+	// sessionTTL is how long a web session lasts before signing in again.
+	sessionTTL = 12 * time.Hour
+)
+
+// loginRequest signs in with either the auth token or the username/password pair.
+type loginRequest struct {
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// loginResponse describes a session issued by POST /login.
+type loginResponse struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	CSRFToken string    `json:"csrf_token"`
+}
+
+// session is a signed-in web user.
+type session struct {
+	User    string
+	Role    string
+	Expires time.Time
+}
+
+// handleLogin exchanges the auth token or the username/password pair for an HttpOnly
+// session cookie, so the web UI does not have to keep credentials in browser storage.
+// With OIDC configured the token is left to machine clients and not accepted here.
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return
+	}
+	if !s.authEnabled() {
+		writeJSON(w, http.StatusOK, loginResponse{})
+		return
+	}
+	if req.Token != "" && s.oidc != nil {
+		writeError(w, http.StatusForbidden, "token_login_disabled", "the auth token is for API clients; sign in with OIDC")
+		return
+	}
+	user, ok := s.validCredentials(req)
+	if !ok {
+		s.logger.Warn("failed login", "remote_addr", r.RemoteAddr, "username", req.Username)
+		writeError(w, http.StatusUnauthorized, "invalid_credentials", "invalid token or username/password")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.startSession(w, r, user, oidc.RoleAdmin))
+}
+
+// handleLoginOptions tells the login dialog which sign-in methods are available.
+func (s *Server) handleLoginOptions(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]bool{
+		"token":    s.authToken != "" && s.oidc == nil,
+		"password": s.authPassword != "",
+		"oidc":     s.oidc != nil,
+	})
+}
+
+// startSession sets the session and CSRF cookies for user.
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, user, role string) loginResponse {
+	sess := session{User: user, Role: role, Expires: time.Now().Add(sessionTTL)}
+	value := s.signSession(sess)
+	csrf := s.csrfToken(value)
+	secure := r.TLS != nil
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    value,
+		Path:     "/",
+		Expires:  sess.Expires,
+		MaxAge:   int(sessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    csrf,
+		Path:     "/",
+		Expires:  sess.Expires,
+		MaxAge:   int(sessionTTL.Seconds()),
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+	s.logger.Info("web session started", "user", user, "role", role, "remote_addr", r.RemoteAddr)
+	return loginResponse{ExpiresAt: sess.Expires.UTC(), CSRFToken: csrf}
+}
+
+// handleLogout clears the session cookies.
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	for _, name := range []string{sessionCookieName, csrfCookieName} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			HttpOnly: name == sessionCookieName,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authEnabled reports whether any credential is configured.
+func (s *Server) authEnabled() bool {
+	return s.authToken != "" || s.authPassword != "" || s.oidc != nil
+}
+
+// validCredentials checks a login request and returns the user it signs in.
+func (s *Server) validCredentials(req loginRequest) (string, bool) {
+	if req.Token != "" {
+		return "token", s.authToken != "" && tokenEqual(req.Token, s.authToken)
+	}
+	if s.authPassword == "" || req.Password == "" {
+		return "", false
+	}
+	// Compare both so a wrong username takes as long as a wrong password
+	userOK := tokenEqual(req.Username, s.authUsername)
+	passOK := tokenEqual(req.Password, s.authPassword)
+	return s.authUsername, userOK && passOK
+}
+
+// signSession returns a cookie value "<payload>.<signature>" with the payload
+// "<expires unix>|<role>|<user>" base64 encoded. The key mixes the stored session secret
+// with the configured credentials, so changing any of them ends all sessions.
+func (s *Server) signSession(sess session) string {
+	payload := strconv.FormatInt(sess.Expires.Unix(), 10) + "|" + sess.Role + "|" + sess.User
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.sessionMAC("session:"+payload)
+}
+
+// parseSession returns the session in value if it is signed for the current
+// credentials and not expired.
+func (s *Server) parseSession(value string, now time.Time) (*session, bool) {
+	encoded, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || !hmac.Equal([]byte(sig), []byte(s.sessionMAC("session:"+string(raw)))) {
+		return nil, false
+	}
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 {
+		return nil, false
+	}
+	unix, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || !now.Before(time.Unix(unix, 0)) {
+		return nil, false
+	}
+	return &session{User: parts[2], Role: parts[1], Expires: time.Unix(unix, 0)}, true
+}
+
+// csrfToken returns the CSRF token bound to a session cookie value.
+func (s *Server) csrfToken(session string) string {
+	return s.sessionMAC("csrf:" + session)
+}
+
+func (s *Server) sessionMAC(data string) string {
+	key := sha256.Sum256([]byte("clicron-session\x00" + s.sessionKey + "\x00" + s.authToken + "\x00" + s.authUsername + "\x00" + s.authPassword))
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
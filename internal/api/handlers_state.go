@@ -0,0 +1,208 @@
+package api
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"clicrontab/internal/core"
+	"clicrontab/internal/store"
+)
+
+// Entry names inside a state archive; state.json always comes first.
+const (
+	stateArchiveManifest = "state.json"
+	stateArchiveLogDir   = "logs/"
+)
+
+// handleExportState writes every task, run and comment as JSON, or with logs=1 as a
+// tar.gz holding state.json plus one logs/<run_id>.log per run that has a log.
+func (s *Server) handleExportState(w http.ResponseWriter, r *http.Request) {
+	snap, err := s.store.ExportSnapshot(r.Context())
+	if err != nil {
+		s.logger.Error("export state", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to export state")
+		return
+	}
+	stamp := snap.ExportedAt.Format("20060102-150405")
+
+	withLogs := r.URL.Query().Get("logs")
+	if withLogs != "1" && withLogs != "true" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "clicrontab-state-"+stamp+".json"))
+		writeJSON(w, http.StatusOK, snap)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "clicrontab-state-"+stamp+".tar.gz"))
+	w.WriteHeader(http.StatusOK)
+	if err := s.writeStateArchive(w, snap); err != nil {
+		// Headers are already sent, so the client sees a truncated archive.
+		s.logger.Error("export state archive", "err", err)
+	}
+}
+
+func (s *Server) writeStateArchive(w io.Writer, snap *store.Snapshot) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifest, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: stateArchiveManifest, Mode: 0o644, Size: int64(len(manifest)), ModTime: snap.ExportedAt}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+
+	for _, run := range snap.Runs {
+		if err := addLogToArchive(tw, s.store.RunLogPath(run.ID), stateArchiveLogDir+run.ID+".log"); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// addLogToArchive copies the log at path into the archive; missing logs are skipped.
+func addLogToArchive(tw *tar.Writer, logPath, name string) error {
+	file, err := os.Open(logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	// A running command may still be appending; archive the size seen now
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.CopyN(tw, file, info.Size())
+	return err
+}
+
+// handleImportState restores an export produced by handleExportState, either the JSON
+// document or the tar.gz archive, then reschedules the imported tasks.
+func (s *Server) handleImportState(w http.ResponseWriter, r *http.Request) {
+	body := bufio.NewReader(r.Body)
+	magic, _ := body.Peek(2)
+	isArchive := len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b
+
+	var tr *tar.Reader
+	var snap store.Snapshot
+	if isArchive {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_input", "invalid gzip archive")
+			return
+		}
+		defer gz.Close()
+		tr = tar.NewReader(gz)
+		header, err := tr.Next()
+		if err != nil || header.Name != stateArchiveManifest {
+			writeError(w, http.StatusBadRequest, "invalid_input", "archive must start with "+stateArchiveManifest)
+			return
+		}
+		if err := json.NewDecoder(tr).Decode(&snap); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_json", "invalid "+stateArchiveManifest)
+			return
+		}
+	} else if err := json.NewDecoder(body).Decode(&snap); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return
+	}
+
+	result, err := s.store.ImportSnapshot(r.Context(), &snap)
+	if err != nil {
+		s.logger.Error("import state", "err", err)
+		writeError(w, http.StatusBadRequest, "invalid_input", err.Error())
+		return
+	}
+
+	logs := 0
+	var logErr error
+	if tr != nil {
+		if logs, logErr = s.restoreRunLogs(tr, result.RunIDs); logErr != nil {
+			// The database import is committed; report the partial log restore
+			s.logger.Error("restore run logs", "err", logErr)
+		}
+	}
+
+	if _, err := s.scheduler.Sync(r.Context()); err != nil {
+		s.logger.Error("sync after import", "err", err)
+	}
+	s.logger.Info("imported state", "scripts", result.Scripts, "tasks", result.Tasks, "runs", result.Runs, "comments", result.Comments, "skipped", result.Skipped, "logs", logs)
+
+	resp := map[string]any{
+		"scripts":  result.Scripts,
+		"tasks":    result.Tasks,
+		"runs":     result.Runs,
+		"comments": result.Comments,
+		"skipped":  result.Skipped,
+		"logs":     logs,
+	}
+	if logErr != nil {
+		resp["log_error"] = logErr.Error()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// restoreRunLogs writes logs/<run_id>.log entries for the given (newly imported) runs and
+// ignores everything else, so an import never overwrites logs of existing runs.
+func (s *Server) restoreRunLogs(tr *tar.Reader, runIDs []string) (int, error) {
+	wanted := make(map[string]bool, len(runIDs))
+	for _, id := range runIDs {
+		wanted[id] = true
+	}
+	restored := 0
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return restored, nil
+		}
+		if err != nil {
+			return restored, err
+		}
+		name := path.Clean(header.Name)
+		if header.Typeflag != tar.TypeReg || path.Dir(name)+"/" != stateArchiveLogDir || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		runID := strings.TrimSuffix(path.Base(name), ".log")
+		if !wanted[runID] || !core.ValidID(runID) {
+			continue
+		}
+		if err := s.store.EnsureRunLogDir(runID); err != nil {
+			return restored, err
+		}
+		file, err := os.OpenFile(s.store.RunLogPath(runID), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		if err != nil {
+			return restored, err
+		}
+		_, err = io.Copy(file, tr)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return restored, err
+		}
+		_ = os.Chtimes(s.store.RunLogPath(runID), s.scheduler.Now(), header.ModTime)
+		restored++
+	}
+}
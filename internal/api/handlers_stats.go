@@ -0,0 +1,174 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"clicrontab/internal/store"
+)
+
+type failuresResponse struct {
+	NonzeroExit int `json:"nonzero_exit"`
+	Timeout     int `json:"timeout"`
+	StartError  int `json:"start_error"`
+	Canceled    int `json:"canceled"`
+}
+
+func (f *failuresResponse) add(counts store.FailureCounts) {
+	f.NonzeroExit += counts.NonzeroExit
+	f.Timeout += counts.Timeout
+	f.StartError += counts.StartError
+	f.Canceled += counts.Canceled
+}
+
+type taskStatsResponse struct {
+	TaskID    string           `json:"task_id"`
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Skipped   int              `json:"skipped"`
+	AvgLagMS  float64          `json:"avg_lag_ms"`
+	MaxLagMS  float64          `json:"max_lag_ms"`
+	Failures  failuresResponse `json:"failures"`
+	// ExitCodes maps exit codes, as strings for JSON keys, to run counts.
+	ExitCodes map[string]int `json:"exit_codes"`
+}
+
+type statsResponse struct {
+	Window    string              `json:"window"`
+	Since     string              `json:"since"`
+	Total     int                 `json:"total"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+	Skipped   int                 `json:"skipped"`
+	AvgLagMS  float64             `json:"avg_lag_ms"`
+	MaxLagMS  float64             `json:"max_lag_ms"`
+	Failures  failuresResponse    `json:"failures"`
+	ExitCodes map[string]int      `json:"exit_codes"`
+	Tasks     []taskStatsResponse `json:"tasks"`
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	window := 24 * time.Hour
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_input", "window must be a positive Go duration such as 24h")
+			return
+		}
+		window = parsed
+	}
+	since := s.scheduler.Now().UTC().Add(-window)
+
+	stats, err := s.store.RunStatsSince(r.Context(), since)
+	if err != nil {
+		s.logger.Error("run stats", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to compute stats")
+		return
+	}
+
+	resp := statsResponse{
+		Window:    window.String(),
+		Since:     since.Format(time.RFC3339),
+		Tasks:     make([]taskStatsResponse, 0, len(stats)),
+		ExitCodes: make(map[string]int),
+	}
+	var lagSum float64
+	var lagSamples int
+	for _, st := range stats {
+		resp.Total += st.Total
+		resp.Succeeded += st.Succeeded
+		resp.Failed += st.Failed
+		resp.Skipped += st.Skipped
+		lagSum += st.AvgLagMS * float64(st.Started)
+		lagSamples += st.Started
+		if st.MaxLagMS > resp.MaxLagMS {
+			resp.MaxLagMS = st.MaxLagMS
+		}
+		task := taskStatsResponse{
+			TaskID:    st.TaskID,
+			Total:     st.Total,
+			Succeeded: st.Succeeded,
+			Failed:    st.Failed,
+			Skipped:   st.Skipped,
+			AvgLagMS:  st.AvgLagMS,
+			MaxLagMS:  st.MaxLagMS,
+			ExitCodes: make(map[string]int, len(st.ExitCodes)),
+		}
+		task.Failures.add(st.Failures)
+		resp.Failures.add(st.Failures)
+		for code, count := range st.ExitCodes {
+			task.ExitCodes[strconv.Itoa(code)] = count
+			resp.ExitCodes[strconv.Itoa(code)] += count
+		}
+		resp.Tasks = append(resp.Tasks, task)
+	}
+	if lagSamples > 0 {
+		resp.AvgLagMS = lagSum / float64(lagSamples)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+const (
+	defaultHeatmapMonths = 12
+	maxHeatmapMonths     = 24
+)
+
+// heatmapResponse holds a task's daily run outcomes for an activity heatmap. Days maps
+// dates with finished runs to [succeeded, failed]; days missing from it had none.
+type heatmapResponse struct {
+	TaskID   string            `json:"task_id"`
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Timezone string            `json:"timezone"`
+	Max      int               `json:"max"`
+	Days     map[string][2]int `json:"days"`
+}
+
+// handleTaskHeatmap returns the task's succeeded and failed runs per day, in the server
+// time zone, for the last "months" months including today.
+func (s *Server) handleTaskHeatmap(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	if _, err := s.store.GetTask(r.Context(), taskID); err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for heatmap", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return
+	}
+	months := parseIntDefault(r.URL.Query().Get("months"), defaultHeatmapMonths)
+	if months <= 0 || months > maxHeatmapMonths {
+		writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("months must be between 1 and %d", maxHeatmapMonths))
+		return
+	}
+
+	now := s.scheduler.Now().In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	from := today.AddDate(0, -months, 1)
+	days, err := s.store.RunDayCounts(r.Context(), taskID, from, s.location)
+	if err != nil {
+		s.logger.Error("run day counts", "task_id", taskID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to compute heatmap")
+		return
+	}
+
+	resp := heatmapResponse{
+		TaskID:   taskID,
+		From:     from.Format("2006-01-02"),
+		To:       today.Format("2006-01-02"),
+		Timezone: timezoneAt(s.location, now).Name,
+		Days:     make(map[string][2]int, len(days)),
+	}
+	for _, day := range days {
+		resp.Days[day.Date] = [2]int{day.Succeeded, day.Failed}
+		resp.Max = max(resp.Max, day.Succeeded+day.Failed)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"clicrontab/internal/taskfile"
+)
+
+// maxTaskFileBytes bounds the tasks file accepted in a sync request body.
+const maxTaskFileBytes = 1 << 20
+
+type syncFieldResponse struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+type syncChangeResponse struct {
+	Action string              `json:"action"`
+	Name   string              `json:"name"`
+	TaskID string              `json:"task_id,omitempty"` // unset for planned creates
+	Fields []syncFieldResponse `json:"fields,omitempty"`
+	// Task is the task as stored; only set when the change was applied.
+	Task *taskResponse `json:"task,omitempty"`
+}
+
+type syncResponse struct {
+	DryRun bool `json:"dry_run"`
+	// Source is "request" for a tasks file in the body, "file" for CLICRON_TASKS_FILE.
+	Source  string               `json:"source"`
+	Summary map[string]int       `json:"summary"`
+	Changes []syncChangeResponse `json:"changes"`
+}
+
+// handleSyncTasks reconciles the tasks with a declarative tasks file: the request body
+// (YAML or JSON) or, when the body is empty, the configured CLICRON_TASKS_FILE. With
+// dry_run=1 it only returns the plan.
+func (s *Server) handleSyncTasks(w http.ResponseWriter, r *http.Request) {
+	dryRun := strings.EqualFold(r.URL.Query().Get("dry_run"), "1") || strings.EqualFold(r.URL.Query().Get("dry_run"), "true")
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxTaskFileBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_input", "failed to read request body")
+		return
+	}
+	if len(data) > maxTaskFileBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, "invalid_input", "tasks file is larger than 1 MiB")
+		return
+	}
+
+	var file *taskfile.File
+	source := "request"
+	if strings.TrimSpace(string(data)) == "" {
+		source = "file"
+		file, err = s.taskSync.Load()
+	} else {
+		file, err = taskfile.Parse(data)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_input", err.Error())
+		return
+	}
+
+	var changes []taskfile.Change
+	if dryRun {
+		changes, err = s.taskSync.Plan(r.Context(), file)
+	} else {
+		changes, err = s.taskSync.Apply(r.Context(), file)
+	}
+	if err != nil {
+		if errors.Is(err, taskfile.ErrUnmanagedTask) {
+			writeError(w, http.StatusConflict, "conflict", err.Error())
+			return
+		}
+		s.logger.Error("sync tasks", "dry_run", dryRun, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to sync tasks")
+		return
+	}
+
+	resp := syncResponse{
+		DryRun: dryRun,
+		Source: source,
+		Summary: map[string]int{
+			string(taskfile.ActionCreate):  0,
+			string(taskfile.ActionUpdate):  0,
+			string(taskfile.ActionArchive): 0,
+		},
+		Changes: make([]syncChangeResponse, 0, len(changes)),
+	}
+	for _, change := range changes {
+		resp.Summary[string(change.Action)]++
+		item := syncChangeResponse{
+			Action: string(change.Action),
+			Name:   change.Name,
+		}
+		if change.Action != taskfile.ActionCreate || !dryRun {
+			item.TaskID = change.Task.ID
+		}
+		if !dryRun {
+			task := taskToResponse(change.Task)
+			item.Task = &task
+		}
+		for _, field := range change.Fields {
+			item.Fields = append(item.Fields, syncFieldResponse{Field: field.Field, Old: field.Old, New: field.New})
+		}
+		resp.Changes = append(resp.Changes, item)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -1,9 +1,14 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -12,38 +17,88 @@ import (
 	"clicrontab/internal/store"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/robfig/cron/v3"
 )
 
 type createTaskRequest struct {
-	Name        *string `json:"name"`
-	Command     string  `json:"command"`
-	Cron        string  `json:"cron"`
-	TimeoutSecs *int    `json:"timeout_s"`
-	WorkingDir  *string `json:"working_dir"`
-	Paused      bool    `json:"paused"`
+	Name            *string `json:"name"`
+	Command         string  `json:"command"`
+	Cron            string  `json:"cron"`
+	TimeoutSecs     *int    `json:"timeout_s"`
+	WorkingDir      *string `json:"working_dir"`
+	MinIntervalSecs *int    `json:"min_interval_s"`
+	PauseAfterFails *int    `json:"pause_after_failures"`
+	LogRetention    *int    `json:"log_retention"`
+	LogMaxAgeDays   *int    `json:"log_max_age_days"`
+	Executor        *string `json:"executor"`
+	// HTTP is the request of an http task; it selects the http executor when executor is unset.
+	HTTP *core.HTTPRequest `json:"http"`
+	// SQL is the query of a sql task; it selects the sql executor when executor is unset.
+	SQL *core.SQLQuery `json:"sql"`
+	// ScriptID runs a script of the library in place of the command.
+	ScriptID   *string `json:"script_id"`
+	RunOnStart bool    `json:"run_on_start"`
+	Paused     bool    `json:"paused"`
+	PauseUntil *string `json:"pause_until"`
 }
 
 type updateTaskRequest struct {
-	Name        *string `json:"name"`
-	Command     *string `json:"command"`
-	Cron        *string `json:"cron"`
-	TimeoutSecs *int    `json:"timeout_s"`
-	WorkingDir  *string `json:"working_dir"`
-	Paused      *bool   `json:"paused"`
+	Name            *string `json:"name"`
+	Command         *string `json:"command"`
+	Cron            *string `json:"cron"`
+	TimeoutSecs     *int    `json:"timeout_s"`
+	WorkingDir      *string `json:"working_dir"`
+	MinIntervalSecs *int    `json:"min_interval_s"`
+	PauseAfterFails *int    `json:"pause_after_failures"`
+	LogRetention    *int    `json:"log_retention"`
+	LogMaxAgeDays   *int    `json:"log_max_age_days"`
+	Executor        *string `json:"executor"`
+	// HTTP replaces the request of an http task, switching the task to the http executor
+	// when executor is unset.
+	HTTP *core.HTTPRequest `json:"http"`
+	// SQL replaces the query of a sql task, switching the task to the sql executor when
+	// executor is unset.
+	SQL *core.SQLQuery `json:"sql"`
+	// ScriptID switches the task to a script of the library; "" goes back to the command,
+	// which must then be given.
+	ScriptID   *string `json:"script_id"`
+	RunOnStart *bool   `json:"run_on_start"`
+	Paused     *bool   `json:"paused"`
+	PauseUntil *string `json:"pause_until"`
+}
+
+// runTaskRequest is the optional body of a manual run; fields override the task for this run only.
+type runTaskRequest struct {
+	TimeoutSecs *int `json:"timeout_s"`
 }
 
 type taskResponse struct {
-	ID          string  `json:"id"`
-	Name        *string `json:"name,omitempty"`
-	Command     string  `json:"command"`
-	Cron        string  `json:"cron"`
-	TimeoutSecs *int    `json:"timeout_s,omitempty"`
-	WorkingDir  *string `json:"working_dir,omitempty"`
-	Status      string  `json:"status"`
-	LastRunAt   *string `json:"last_run_at,omitempty"`
-	NextRunAt   *string `json:"next_run_at,omitempty"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	ID              string            `json:"id"`
+	Name            *string           `json:"name,omitempty"`
+	Command         string            `json:"command"`
+	Cron            string            `json:"cron"`
+	TimeoutSecs     *int              `json:"timeout_s,omitempty"`
+	WorkingDir      *string           `json:"working_dir,omitempty"`
+	MinIntervalSecs *int              `json:"min_interval_s,omitempty"`
+	PauseAfterFails *int              `json:"pause_after_failures,omitempty"`
+	LogRetention    *int              `json:"log_retention,omitempty"`
+	LogMaxAgeDays   *int              `json:"log_max_age_days,omitempty"`
+	Executor        *string           `json:"executor,omitempty"`
+	HTTP            *core.HTTPRequest `json:"http,omitempty"`
+	SQL             *core.SQLQuery    `json:"sql,omitempty"`
+	ScriptID        *string           `json:"script_id,omitempty"`
+	RunOnStart      bool              `json:"run_on_start"`
+	Status          string            `json:"status"`
+	Health          string            `json:"health,omitempty"`
+	PauseUntil      *string           `json:"pause_until,omitempty"`
+	SkipNextAt      *string           `json:"skip_next_at,omitempty"`
+	Source          string            `json:"source,omitempty"`
+	LastRunAt       *string           `json:"last_run_at,omitempty"`
+	NextRunAt       *string           `json:"next_run_at,omitempty"`
+	CreatedAt       string            `json:"created_at"`
+	UpdatedAt       string            `json:"updated_at"`
+	// Warnings flags questionable but accepted settings; only set on create and update.
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 func (s *Server) handleCreateTask(w http.ResponseWriter, r *http.Request) {
@@ -55,27 +110,43 @@ func (s *Server) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 
 	req.Command = strings.TrimSpace(req.Command)
 	req.Cron = strings.TrimSpace(req.Cron)
-	if req.Command == "" {
-		writeError(w, http.StatusBadRequest, "invalid_input", "command is required")
-		return
+	var errs validationErrors
+	if req.Executor == nil {
+		req.Executor = configuredExecutor(req.HTTP, req.SQL)
 	}
-	if req.Cron == "" {
-		writeError(w, http.StatusBadRequest, "invalid_input", "cron expression is required")
-		return
+	executor := s.parseExecutor(&errs, req.Executor)
+	configured := &core.Task{Executor: executor, HTTP: req.HTTP, SQL: req.SQL}
+	checkExecutorConfig(&errs, configured, req.HTTP != nil, req.SQL != nil)
+	script := s.parseScriptID(r.Context(), s.store, &errs, req.ScriptID, configured.ExecutorName())
+	switch {
+	case script != nil && req.Command != "":
+		errs.add("command", constraintConflict, "give either command or script_id, not both")
+	case req.Command == "" && (req.ScriptID == nil || strings.TrimSpace(*req.ScriptID) == "") && core.ExecutorConfigField(configured.ExecutorName()) == "":
+		errs.add("command", constraintRequired, "command is required")
 	}
-	if req.TimeoutSecs != nil && *req.TimeoutSecs < 0 {
-		writeError(w, http.StatusBadRequest, "invalid_input", "timeout_s must be non-negative")
-		return
+	var schedule cron.Schedule
+	if req.Cron == "" {
+		errs.add("cron", constraintRequired, "cron expression is required")
+	} else if parsed, err := core.ParseCron(req.Cron); err != nil {
+		errs.add("cron", constraintCron, err.Error())
+	} else {
+		schedule = parsed
 	}
-
-	schedule, err := core.ParseCron(req.Cron)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid_cron", err.Error())
+	errs.nonNegative("timeout_s", req.TimeoutSecs)
+	errs.nonNegative("min_interval_s", req.MinIntervalSecs)
+	errs.nonNegative("pause_after_failures", req.PauseAfterFails)
+	errs.nonNegative("log_retention", req.LogRetention)
+	errs.nonNegative("log_max_age_days", req.LogMaxAgeDays)
+	checkTemplate(&errs, "command", &req.Command, core.ValidateTemplate)
+	checkTemplate(&errs, "working_dir", req.WorkingDir, core.ValidateWorkingDir)
+	pauseUntil := s.parsePauseUntil(&errs, req.PauseUntil)
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
 		return
 	}
 
 	status := core.TaskStatusActive
-	if req.Paused {
+	if req.Paused || pauseUntil != nil {
 		status = core.TaskStatusPaused
 	}
 
@@ -101,33 +172,80 @@ func (s *Server) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	var minIntervalPtr *int
+	if req.MinIntervalSecs != nil && *req.MinIntervalSecs > 0 {
+		minInterval := *req.MinIntervalSecs
+		minIntervalPtr = &minInterval
+	}
+
+	var pauseAfterPtr *int
+	if req.PauseAfterFails != nil && *req.PauseAfterFails > 0 {
+		pauseAfter := *req.PauseAfterFails
+		pauseAfterPtr = &pauseAfter
+	}
+
+	var logRetentionPtr *int
+	if req.LogRetention != nil && *req.LogRetention > 0 {
+		logRetention := *req.LogRetention
+		logRetentionPtr = &logRetention
+	}
+
+	var logMaxAgePtr *int
+	if req.LogMaxAgeDays != nil && *req.LogMaxAgeDays > 0 {
+		logMaxAge := *req.LogMaxAgeDays
+		logMaxAgePtr = &logMaxAge
+	}
+
 	task := &core.Task{
-		ID:             core.NewID(),
-		Name:           namePtr,
-		Command:        req.Command,
-		Cron:           req.Cron,
-		TimeoutSeconds: timeoutPtr,
-		WorkingDir:     workingDirPtr,
-		Status:         status,
+		ID:                 core.NewID(),
+		Name:               namePtr,
+		Command:            req.Command,
+		Cron:               req.Cron,
+		TimeoutSeconds:     timeoutPtr,
+		WorkingDir:         workingDirPtr,
+		MinIntervalSeconds: minIntervalPtr,
+		PauseAfterFailures: pauseAfterPtr,
+		LogRetention:       logRetentionPtr,
+		LogMaxAgeDays:      logMaxAgePtr,
+		Executor:           executor,
+		HTTP:               req.HTTP,
+		SQL:                req.SQL,
+		RunOnStart:         req.RunOnStart,
+		Status:             status,
+		PauseUntil:         pauseUntil,
+	}
+	core.PrepareExecutorConfig(task)
+	if script != nil {
+		task.ScriptID = &script.ID
+		task.Command = core.ScriptCommand(script.Name)
+		task.Prompt = task.Command
 	}
 
 	if status == core.TaskStatusActive {
-		next := core.NextOccurrences(schedule, time.Now().In(s.location), 1)[0].UTC()
+		next := core.NextOccurrences(schedule, s.scheduler.Now().In(s.location), 1)[0].UTC()
 		task.NextRunAt = &next
 	}
 
-	if err := s.store.InsertTask(r.Context(), task); err != nil {
+	err := s.store.InTx(r.Context(), func(tx *store.Tx) error {
+		if err := tx.InsertTask(r.Context(), task); err != nil {
+			return err
+		}
+		tx.OnCommit(func() { s.refreshSchedule(r.Context(), task.ID) })
+		return nil
+	})
+	if errors.Is(err, core.ErrTaskNameTaken) {
+		writeError(w, http.StatusConflict, "name_taken", "task name is already used by another task")
+		return
+	}
+	if err != nil {
 		s.logger.Error("insert task", "err", err)
 		writeError(w, http.StatusInternalServerError, "internal_error", "failed to insert task")
 		return
 	}
-	if task.Status == core.TaskStatusActive {
-		if err := s.scheduler.AddOrUpdateTask(r.Context(), task); err != nil {
-			s.logger.Error("schedule task", "task_id", task.ID, "err", err)
-		}
-	}
 
-	writeJSON(w, http.StatusCreated, taskToResponse(task))
+	resp := taskToResponse(task)
+	resp.Warnings = s.taskWarnings(task)
+	writeJSON(w, http.StatusCreated, resp)
 }
 
 func (s *Server) handleListTasks(w http.ResponseWriter, r *http.Request) {
@@ -135,10 +253,10 @@ func (s *Server) handleListTasks(w http.ResponseWriter, r *http.Request) {
 	if status := strings.TrimSpace(r.URL.Query().Get("status")); status != "" {
 		st := core.TaskStatus(status)
 		switch st {
-		case core.TaskStatusActive, core.TaskStatusPaused:
+		case core.TaskStatusActive, core.TaskStatusPaused, core.TaskStatusArchived, core.TaskStatusError:
 			statusFilter = &st
 		default:
-			writeError(w, http.StatusBadRequest, "invalid_input", "status must be active or paused")
+			writeError(w, http.StatusBadRequest, "invalid_input", "status must be active, paused, archived or error")
 			return
 		}
 	}
@@ -148,9 +266,18 @@ func (s *Server) handleListTasks(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list tasks")
 		return
 	}
+	lastStatuses, err := s.store.LastRunStatuses(r.Context())
+	if err != nil {
+		s.logger.Error("load last run statuses", "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list tasks")
+		return
+	}
+	now := s.scheduler.Now()
 	res := make([]taskResponse, 0, len(tasks))
 	for _, t := range tasks {
-		res = append(res, taskToResponse(t))
+		resp := taskToResponse(t)
+		resp.Health = string(core.DeriveTaskHealth(t, lastStatuses[t.ID], now))
+		res = append(res, resp)
 	}
 	writeJSON(w, http.StatusOK, res)
 }
@@ -167,28 +294,71 @@ func (s *Server) handleGetTask(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	writeJSON(w, http.StatusOK, taskToResponse(task))
+	recent, err := s.store.RecentRunStatuses(r.Context(), task.ID, 1)
+	if err != nil {
+		s.logger.Error("load last run status", "task_id", taskID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		return
+	}
+	var lastStatus core.RunStatus
+	if len(recent) > 0 {
+		lastStatus = recent[0]
+	}
+	resp := taskToResponse(task)
+	resp.Health = string(core.DeriveTaskHealth(task, lastStatus, s.scheduler.Now()))
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func (s *Server) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 	taskID := chi.URLParam(r, "taskID")
-	task, err := s.store.GetTask(r.Context(), taskID)
+	var req updateTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return
+	}
+
+	// The task is read in the transaction that writes it, so the update cannot undo a
+	// change committed in between
+	var task *core.Task
+	err := s.store.InTx(r.Context(), func(tx *store.Tx) error {
+		var err error
+		if task, err = tx.GetTask(r.Context(), taskID); err != nil {
+			return err
+		}
+		if err := s.applyTaskUpdate(r.Context(), tx, task, &req); err != nil {
+			return err
+		}
+		if err := tx.UpdateTask(r.Context(), task); err != nil {
+			return err
+		}
+		tx.OnCommit(func() { s.refreshSchedule(r.Context(), task.ID) })
+		return nil
+	})
 	if err != nil {
 		if errors.Is(err, store.ErrTaskNotFound) {
 			writeError(w, http.StatusNotFound, "not_found", "task not found")
-		} else {
-			s.logger.Error("get task for update", "task_id", taskID, "err", err)
-			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+			return
 		}
+		if errors.Is(err, core.ErrTaskNameTaken) {
+			writeError(w, http.StatusConflict, "name_taken", "task name is already used by another task")
+			return
+		}
+		if writeRequestError(w, err) {
+			return
+		}
+		s.logger.Error("update task", "task_id", taskID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to update task")
 		return
 	}
 
-	var req updateTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
-		return
-	}
+	resp := taskToResponse(task)
+	resp.Warnings = s.taskWarnings(task)
+	writeJSON(w, http.StatusOK, resp)
+}
 
+// applyTaskUpdate validates req against the task as stored and applies it to task.
+// Rejections are validationErrors or *requestError.
+func (s *Server) applyTaskUpdate(ctx context.Context, tx *store.Tx, task *core.Task, req *updateTaskRequest) error {
 	if req.Name != nil {
 		trimmed := strings.TrimSpace(*req.Name)
 		if trimmed == "" {
@@ -197,35 +367,91 @@ func (s *Server) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 			task.Name = &trimmed
 		}
 	}
-	if req.Command != nil {
-		cmd := strings.TrimSpace(*req.Command)
-		if cmd == "" {
-			writeError(w, http.StatusBadRequest, "invalid_input", "command cannot be empty")
-			return
+	var errs validationErrors
+	if req.Command != nil && strings.TrimSpace(*req.Command) == "" {
+		errs.add("command", constraintRequired, "command cannot be empty")
+	}
+	if req.Cron != nil {
+		if cronExpr := strings.TrimSpace(*req.Cron); cronExpr == "" {
+			errs.add("cron", constraintRequired, "cron expression cannot be empty")
+		} else if _, err := core.ParseCron(cronExpr); err != nil {
+			errs.add("cron", constraintCron, err.Error())
+		}
+	}
+	errs.nonNegative("timeout_s", req.TimeoutSecs)
+	errs.nonNegative("min_interval_s", req.MinIntervalSecs)
+	errs.nonNegative("pause_after_failures", req.PauseAfterFails)
+	errs.nonNegative("log_retention", req.LogRetention)
+	errs.nonNegative("log_max_age_days", req.LogMaxAgeDays)
+	checkTemplate(&errs, "command", req.Command, core.ValidateTemplate)
+	checkTemplate(&errs, "working_dir", req.WorkingDir, core.ValidateWorkingDir)
+	if req.Executor == nil {
+		if given := configuredExecutor(req.HTTP, req.SQL); given != nil && *given != task.ExecutorName() {
+			req.Executor = given
 		}
-		task.Command = cmd
+	}
+	executor := s.parseExecutor(&errs, req.Executor)
+	// Check the executor and configuration the task ends up with
+	updated := &core.Task{Executor: task.Executor, HTTP: task.HTTP, SQL: task.SQL}
+	if req.Executor != nil {
+		updated.Executor = executor
+	}
+	if req.HTTP != nil {
+		updated.HTTP = req.HTTP
+	}
+	if req.SQL != nil {
+		req.SQL.KeepDSN(task.SQL)
+		updated.SQL = req.SQL
+	}
+	checkExecutorConfig(&errs, updated, req.HTTP != nil, req.SQL != nil)
+	givesScript := req.ScriptID != nil && strings.TrimSpace(*req.ScriptID) != ""
+	if req.Command == nil && !givesScript && core.ExecutorConfigField(task.ExecutorName()) != "" && core.ExecutorConfigField(updated.ExecutorName()) == "" {
+		errs.add("command", constraintRequired, "command is required when leaving the "+task.ExecutorName()+" executor")
+	}
+	var script *core.Script
+	if req.ScriptID != nil {
+		script = s.parseScriptID(ctx, tx, &errs, req.ScriptID, updated.ExecutorName())
+		switch {
+		case script != nil && req.Command != nil:
+			errs.add("command", constraintConflict, "give either command or script_id, not both")
+		case strings.TrimSpace(*req.ScriptID) == "" && task.ScriptID != nil && req.Command == nil:
+			errs.add("command", constraintRequired, "command is required when leaving the script")
+		}
+	} else if task.ScriptID != nil {
+		if req.Command != nil {
+			errs.add("command", constraintConflict, "task runs a script; set script_id to \"\" to use a command")
+		}
+		if updated.ExecutorName() != core.ExecutorShell {
+			errs.add("script_id", constraintConflict, "script_id is only used by the shell executor; set it to \"\" first")
+		}
+	}
+	pauseUntil := s.parsePauseUntil(&errs, req.PauseUntil)
+	if pauseUntil != nil && req.Paused != nil && !*req.Paused {
+		errs.add("pause_until", constraintConflict, "pause_until cannot be combined with paused=false")
+	}
+	if task.Status == core.TaskStatusArchived {
+		if req.Paused != nil {
+			errs.add("paused", constraintConflict, "task is archived; unarchive it first")
+		}
+		if pauseUntil != nil {
+			errs.add("pause_until", constraintConflict, "task is archived; unarchive it first")
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if req.Command != nil {
+		task.Command = strings.TrimSpace(*req.Command)
 	}
 
 	cronChanged := false
 	if req.Cron != nil {
-		cronExpr := strings.TrimSpace(*req.Cron)
-		if cronExpr == "" {
-			writeError(w, http.StatusBadRequest, "invalid_input", "cron expression cannot be empty")
-			return
-		}
-		if _, err := core.ParseCron(cronExpr); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid_cron", err.Error())
-			return
-		}
-		task.Cron = cronExpr
+		task.Cron = strings.TrimSpace(*req.Cron)
 		cronChanged = true
 	}
 
 	if req.TimeoutSecs != nil {
-		if *req.TimeoutSecs < 0 {
-			writeError(w, http.StatusBadRequest, "invalid_input", "timeout_s must be non-negative")
-			return
-		}
 		if *req.TimeoutSecs == 0 {
 			task.TimeoutSeconds = nil
 		} else {
@@ -243,10 +469,75 @@ func (s *Server) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if req.MinIntervalSecs != nil {
+		if *req.MinIntervalSecs == 0 {
+			task.MinIntervalSeconds = nil
+		} else {
+			minInterval := *req.MinIntervalSecs
+			task.MinIntervalSeconds = &minInterval
+		}
+	}
+
+	if req.PauseAfterFails != nil {
+		if *req.PauseAfterFails == 0 {
+			task.PauseAfterFailures = nil
+		} else {
+			pauseAfter := *req.PauseAfterFails
+			task.PauseAfterFailures = &pauseAfter
+		}
+	}
+
+	if req.LogRetention != nil {
+		if *req.LogRetention == 0 {
+			task.LogRetention = nil
+		} else {
+			logRetention := *req.LogRetention
+			task.LogRetention = &logRetention
+		}
+	}
+
+	if req.LogMaxAgeDays != nil {
+		if *req.LogMaxAgeDays == 0 {
+			task.LogMaxAgeDays = nil
+		} else {
+			logMaxAge := *req.LogMaxAgeDays
+			task.LogMaxAgeDays = &logMaxAge
+		}
+	}
+
+	if req.Executor != nil {
+		task.Executor = executor
+	}
+	if req.HTTP != nil {
+		task.HTTP = req.HTTP
+	}
+	if req.SQL != nil {
+		task.SQL = req.SQL
+	}
+	core.PrepareExecutorConfig(task)
+	if req.ScriptID != nil {
+		task.ScriptID = nil
+		if script != nil {
+			task.ScriptID = &script.ID
+			task.Command = core.ScriptCommand(script.Name)
+			task.Prompt = task.Command
+		}
+	}
+
+	if req.RunOnStart != nil {
+		task.RunOnStart = *req.RunOnStart
+	}
+
 	statusChanged := false
+	// A valid new cron expression takes a task out of the error status
+	if cronChanged && task.Status == core.TaskStatusError {
+		task.Status = core.TaskStatusActive
+		statusChanged = true
+	}
 	if req.Paused != nil {
 		if *req.Paused && task.Status != core.TaskStatusPaused {
 			task.Status = core.TaskStatusPaused
+			task.PauseUntil = nil
 			statusChanged = true
 		}
 		if !*req.Paused && task.Status != core.TaskStatusActive {
@@ -254,40 +545,57 @@ func (s *Server) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 			statusChanged = true
 		}
 	}
+	// A pause_until pauses the task if needed; an empty value keeps it paused indefinitely
+	if req.PauseUntil != nil {
+		task.PauseUntil = pauseUntil
+		if pauseUntil != nil && task.Status != core.TaskStatusPaused {
+			task.Status = core.TaskStatusPaused
+			statusChanged = true
+		}
+	}
+	if task.Status == core.TaskStatusActive {
+		task.PauseUntil = nil
+	}
 
 	if task.Status == core.TaskStatusActive && (cronChanged || statusChanged) {
 		parsed, err := core.ParseCron(task.Cron)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, "invalid_cron", err.Error())
-			return
+			return &requestError{status: http.StatusBadRequest, code: "invalid_cron", message: err.Error()}
 		}
-		next := core.NextOccurrences(parsed, time.Now().In(s.location), 1)[0].UTC()
+		next := core.NextOccurrences(parsed, s.scheduler.Now().In(s.location), 1)[0].UTC()
 		task.NextRunAt = &next
 	}
 	if task.Status == core.TaskStatusPaused {
 		task.NextRunAt = nil
 	}
+	return nil
+}
 
-	if err := s.store.UpdateTask(r.Context(), task); err != nil {
-		if errors.Is(err, store.ErrTaskNotFound) {
-			writeError(w, http.StatusNotFound, "not_found", "task not found")
-			return
-		}
-		s.logger.Error("update task", "task_id", taskID, "err", err)
-		writeError(w, http.StatusInternalServerError, "internal_error", "failed to update task")
-		return
+// taskWarnings lists configuration problems that are allowed but likely unintended.
+func (s *Server) taskWarnings(task *core.Task) []string {
+	schedule, err := core.ParseCron(task.Cron)
+	if err != nil {
+		return nil
 	}
-
-	if err := s.scheduler.AddOrUpdateTask(r.Context(), task); err != nil {
-		s.logger.Error("reschedule task", "task_id", task.ID, "err", err)
+	var warnings []string
+	if interval, exceeds := core.TimeoutExceedsInterval(schedule, task.TimeoutSeconds, s.scheduler.Now().In(s.location)); exceeds {
+		warnings = append(warnings, fmt.Sprintf(
+			"timeout_s (%ds) exceeds the shortest interval between scheduled runs (%s); runs that approach the timeout will cause the next triggers to be skipped",
+			*task.TimeoutSeconds, interval))
 	}
-
-	writeJSON(w, http.StatusOK, taskToResponse(task))
+	return warnings
 }
 
 func (s *Server) handleDeleteTask(w http.ResponseWriter, r *http.Request) {
 	taskID := chi.URLParam(r, "taskID")
-	if err := s.store.DeleteTask(r.Context(), taskID); err != nil {
+	err := s.store.InTx(r.Context(), func(tx *store.Tx) error {
+		if err := tx.DeleteTask(r.Context(), taskID); err != nil {
+			return err
+		}
+		tx.OnCommit(func() { s.scheduler.RemoveTask(taskID) })
+		return nil
+	})
+	if err != nil {
 		if errors.Is(err, store.ErrTaskNotFound) {
 			writeError(w, http.StatusNotFound, "not_found", "task not found")
 		} else {
@@ -296,10 +604,17 @@ func (s *Server) handleDeleteTask(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	s.scheduler.RemoveTask(taskID)
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// refreshSchedule brings the scheduler in line with the task's committed state; it runs
+// from store.Tx.OnCommit after create and update.
+func (s *Server) refreshSchedule(ctx context.Context, taskID string) {
+	if err := s.scheduler.RefreshTask(ctx, taskID); err != nil {
+		s.logger.Error("reschedule task", "task_id", taskID, "err", err)
+	}
+}
+
 func (s *Server) handleRunTask(w http.ResponseWriter, r *http.Request) {
 	taskID := chi.URLParam(r, "taskID")
 	task, err := s.store.GetTask(r.Context(), taskID)
@@ -312,19 +627,173 @@ func (s *Server) handleRunTask(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+
+	var req runTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid JSON payload")
+		return
+	}
+	var errs validationErrors
+	errs.nonNegative("timeout_s", req.TimeoutSecs)
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	if req.TimeoutSecs != nil {
+		// Run a copy so the stored task definition keeps its own timeout; 0 disables it
+		taskCopy := *task
+		taskCopy.TimeoutSeconds = nil
+		if *req.TimeoutSecs > 0 {
+			timeout := *req.TimeoutSecs
+			taskCopy.TimeoutSeconds = &timeout
+		}
+		task = &taskCopy
+	}
+
 	run, err := s.scheduler.RunTaskNow(r.Context(), task)
 	if err != nil {
-		if strings.Contains(err.Error(), "already running") {
-			writeError(w, http.StatusConflict, "conflict", "task is already running")
-			return
+		switch {
+		case errors.Is(err, core.ErrTaskArchived):
+			writeError(w, http.StatusConflict, "conflict", "task is archived")
+		case errors.Is(err, core.ErrTaskAlreadyRunning):
+			writeError(w, http.StatusConflict, "already_running", "task is already running")
+		case errors.Is(err, core.ErrTaskRateLimited):
+			writeError(w, http.StatusTooManyRequests, "rate_limited", "min_interval_s has not elapsed since the last run")
+		default:
+			s.logger.Error("run task now", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to start task")
 		}
-		s.logger.Error("run task now", "task_id", taskID, "err", err)
-		writeError(w, http.StatusInternalServerError, "internal_error", "failed to start task")
 		return
 	}
 	writeJSON(w, http.StatusAccepted, map[string]string{"run_id": run.ID})
 }
 
+type commandCheckResponse struct {
+	Executable string `json:"executable"`
+	Found      bool   `json:"found"`
+	Resolved   string `json:"resolved,omitempty"`
+	Shell      string `json:"shell"`
+	WorkingDir string `json:"working_dir,omitempty"`
+	Path       string `json:"path"`
+	Problem    string `json:"problem,omitempty"`
+}
+
+// handleCheckTask verifies that the first word of the task's command resolves in the
+// shell, PATH and working directory its runs get.
+func (s *Server) handleCheckTask(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	task, err := s.store.GetTask(r.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for check", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return
+	}
+	check, err := s.scheduler.CheckCommand(r.Context(), task)
+	if errors.Is(err, core.ErrNotShellTask) {
+		writeError(w, http.StatusConflict, "conflict", "task runs with the "+task.ExecutorName()+" executor, not a shell command")
+		return
+	}
+	if err != nil {
+		s.logger.Error("check task command", "task_id", taskID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to check command")
+		return
+	}
+	writeJSON(w, http.StatusOK, commandCheckResponse{
+		Executable: check.Executable,
+		Found:      check.Found,
+		Resolved:   check.Resolved,
+		Shell:      check.Shell,
+		WorkingDir: check.WorkingDir,
+		Path:       check.Path,
+		Problem:    check.Problem,
+	})
+}
+
+// handleSkipNext marks the next scheduled occurrence to be recorded as skipped (reason "manual").
+func (s *Server) handleSkipNext(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	task, err := s.store.GetTask(r.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for skip-next", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return
+	}
+	if _, err := s.scheduler.SkipNext(r.Context(), task); err != nil {
+		if errors.Is(err, core.ErrTaskNotActive) {
+			writeError(w, http.StatusConflict, "conflict", "only active tasks have a next run to skip")
+			return
+		}
+		s.logger.Error("skip next run", "task_id", taskID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to skip next run")
+		return
+	}
+	writeJSON(w, http.StatusOK, taskToResponse(task))
+}
+
+// handleCancelSkipNext clears a pending skip-next.
+func (s *Server) handleCancelSkipNext(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	task, err := s.store.GetTask(r.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for skip-next", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return
+	}
+	if err := s.scheduler.CancelSkipNext(r.Context(), task); err != nil {
+		s.logger.Error("cancel skip-next", "task_id", taskID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to cancel skip-next")
+		return
+	}
+	writeJSON(w, http.StatusOK, taskToResponse(task))
+}
+
+// handleArchiveTask stops scheduling the task and hides it from default listings and stats.
+func (s *Server) handleArchiveTask(w http.ResponseWriter, r *http.Request) {
+	s.setArchived(w, r, true)
+}
+
+// handleUnarchiveTask restores an archived task as paused.
+func (s *Server) handleUnarchiveTask(w http.ResponseWriter, r *http.Request) {
+	s.setArchived(w, r, false)
+}
+
+func (s *Server) setArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	taskID := chi.URLParam(r, "taskID")
+	task, err := s.store.GetTask(r.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for archive", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return
+	}
+	if archived {
+		err = s.scheduler.ArchiveTask(r.Context(), task)
+	} else {
+		err = s.scheduler.UnarchiveTask(r.Context(), task)
+	}
+	if err != nil {
+		s.logger.Error("set task archived", "task_id", taskID, "archived", archived, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to update task")
+		return
+	}
+	writeJSON(w, http.StatusOK, taskToResponse(task))
+}
+
 func (s *Server) handleListRuns(w http.ResponseWriter, r *http.Request) {
 	taskID := chi.URLParam(r, "taskID")
 	if _, err := s.store.GetTask(r.Context(), taskID); err != nil {
@@ -337,9 +806,15 @@ func (s *Server) handleListRuns(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filter, msg := s.parseRunFilter(r)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, "invalid_input", msg)
+		return
+	}
+
 	limit := parseIntDefault(r.URL.Query().Get("limit"), 20)
 	offset := parseIntDefault(r.URL.Query().Get("offset"), 0)
-	runs, err := s.store.ListRuns(r.Context(), taskID, limit, offset)
+	runs, err := s.store.ListRuns(r.Context(), taskID, filter, limit, offset)
 	if err != nil {
 		s.logger.Error("list runs", "task_id", taskID, "err", err)
 		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list runs")
@@ -353,8 +828,296 @@ func (s *Server) handleListRuns(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, resp)
 }
 
+const (
+	defaultTaskLogRuns = 5
+	maxTaskLogRuns     = 50
+	defaultTaskLogTail = 50
+	maxTaskLogTail     = 1000
+)
+
+// handleTaskLogs returns the log tails of a task's latest runs as one plain-text document,
+// oldest run first, each under a header line, so an intermittent failure can be compared
+// across runs in one request. Skipped runs have no log and are left out unless status
+// asks for them.
+func (s *Server) handleTaskLogs(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	if _, err := s.store.GetTask(r.Context(), taskID); err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for logs", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return
+	}
+
+	filter, msg := s.parseRunFilter(r)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, "invalid_input", msg)
+		return
+	}
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []core.RunStatus{core.RunStatusRunning, core.RunStatusSucceeded, core.RunStatusFailed, core.RunStatusTimedOut, core.RunStatusCanceled}
+	}
+	runCount := parseIntDefault(r.URL.Query().Get("runs"), defaultTaskLogRuns)
+	if runCount <= 0 || runCount > maxTaskLogRuns {
+		writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("runs must be between 1 and %d", maxTaskLogRuns))
+		return
+	}
+	tail := parseIntDefault(r.URL.Query().Get("tail"), defaultTaskLogTail)
+	if tail <= 0 || tail > maxTaskLogTail {
+		writeError(w, http.StatusBadRequest, "invalid_input", fmt.Sprintf("tail must be between 1 and %d", maxTaskLogTail))
+		return
+	}
+
+	runs, err := s.store.ListRuns(r.Context(), taskID, filter, runCount, 0)
+	if err != nil {
+		s.logger.Error("list runs for logs", "task_id", taskID, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "failed to list runs")
+		return
+	}
+
+	var buf bytes.Buffer
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		fmt.Fprintf(&buf, "==> run %s | %s | %s", run.ID, run.TriggerType, run.Status)
+		if run.ExitCode != nil {
+			fmt.Fprintf(&buf, " (exit %d)", *run.ExitCode)
+		}
+		started := run.CreatedAt
+		if run.StartedAt != nil {
+			started = *run.StartedAt
+		}
+		fmt.Fprintf(&buf, " | %s <==\n", started.In(s.location).Format(time.RFC3339))
+
+		data, err := s.readRunLogTail(run.ID, tail)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			buf.WriteString("(no log)\n")
+		case err != nil:
+			s.logger.Error("read log", "run_id", run.ID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to read log")
+			return
+		default:
+			buf.Write(data)
+			if len(data) > 0 && data[len(data)-1] != '\n' {
+				buf.WriteByte('\n')
+			}
+		}
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// readRunLogTail returns the last tail lines of a run's log.
+func (s *Server) readRunLogTail(runID string, tail int) ([]byte, error) {
+	file, err := os.Open(s.store.RunLogPath(runID))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readTailLines(file, tail)
+}
+
+// parseRunFilter reads the trigger_type, status, since, until and pinned query parameters
+// of a runs listing. It returns a client-facing message when one of them is invalid.
+func (s *Server) parseRunFilter(r *http.Request) (store.RunFilter, string) {
+	var filter store.RunFilter
+	query := r.URL.Query()
+	if trigger := strings.TrimSpace(query.Get("trigger_type")); trigger != "" {
+		filter.TriggerType = core.TriggerType(trigger)
+		if !filter.TriggerType.Valid() {
+			return filter, "trigger_type must be scheduled, manual, retry, dependency, webhook or startup"
+		}
+	}
+	for _, value := range strings.Split(query.Get("status"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		status := core.RunStatus(value)
+		if !status.Valid() {
+			return filter, "status must be a comma-separated list of queued, running, succeeded, failed, canceled, timed_out or skipped"
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	if value := strings.TrimSpace(query.Get("since")); value != "" {
+		since, err := core.ParseTime(value, s.location)
+		if err != nil {
+			return filter, "since: " + err.Error()
+		}
+		filter.Since = &since
+	}
+	if value := strings.TrimSpace(query.Get("until")); value != "" {
+		until, err := core.ParseTime(value, s.location)
+		if err != nil {
+			return filter, "until: " + err.Error()
+		}
+		filter.Until = &until
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Until.After(*filter.Since) {
+		return filter, "until must be after since"
+	}
+	switch value := strings.TrimSpace(query.Get("pinned")); value {
+	case "":
+	case "true", "false":
+		pinned := value == "true"
+		filter.Pinned = &pinned
+	default:
+		return filter, "pinned must be true or false"
+	}
+	return filter, ""
+}
+
+// handlePurgeRuns deletes a task's finished runs and their logs, optionally only those
+// created before the "before" query parameter.
+func (s *Server) handlePurgeRuns(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "taskID")
+	if _, err := s.store.GetTask(r.Context(), taskID); err != nil {
+		if errors.Is(err, store.ErrTaskNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "task not found")
+		} else {
+			s.logger.Error("get task for runs purge", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to load task")
+		}
+		return
+	}
+
+	var before *time.Time
+	if value := strings.TrimSpace(r.URL.Query().Get("before")); value != "" {
+		parsed, err := core.ParseTime(value, s.location)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_input", "before: "+err.Error())
+			return
+		}
+		before = &parsed
+	}
+
+	deleted, err := s.store.PurgeRuns(r.Context(), taskID, before)
+	if err != nil {
+		if deleted == 0 {
+			s.logger.Error("purge runs", "task_id", taskID, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to purge runs")
+			return
+		}
+		// The rows are gone; only some log directories could not be removed
+		s.logger.Warn("purge runs", "task_id", taskID, "deleted", deleted, "err", err)
+	}
+	s.logger.Info("purged run history", "task_id", taskID, "deleted", deleted)
+	writeJSON(w, http.StatusOK, map[string]int{"deleted": deleted})
+}
+
+// parseExecutor validates an optional executor field; nil, "" and the shell yield nil.
+func (s *Server) parseExecutor(errs *validationErrors, value *string) *string {
+	if value == nil {
+		return nil
+	}
+	name := strings.TrimSpace(*value)
+	if name == "" || name == core.ExecutorShell {
+		return nil
+	}
+	if !s.scheduler.HasExecutor(name) {
+		errs.add("executor", constraintOneOf, "executor must be one of: "+strings.Join(s.scheduler.Executors(), ", "))
+		return nil
+	}
+	return &name
+}
+
+// configuredExecutor returns the built-in executor selected by a payload giving its
+// configuration without naming an executor, or nil.
+func configuredExecutor(request *core.HTTPRequest, query *core.SQLQuery) *string {
+	var name string
+	switch {
+	case request != nil:
+		name = core.ExecutorHTTP
+	case query != nil:
+		name = core.ExecutorSQL
+	default:
+		return nil
+	}
+	return &name
+}
+
+// checkExecutorConfig validates the http and sql fields against the executor and
+// configuration the task ends up with; httpGiven and sqlGiven report whether the payload
+// had those fields.
+func checkExecutorConfig(errs *validationErrors, task *core.Task, httpGiven, sqlGiven bool) {
+	field := core.ExecutorConfigField(task.ExecutorName())
+	if httpGiven && field != "http" {
+		errs.add("http", constraintConflict, "http is only used by the http executor")
+	}
+	if sqlGiven && field != "sql" {
+		errs.add("sql", constraintConflict, "sql is only used by the sql executor")
+	}
+	var configErr *core.ExecutorConfigError
+	if err := core.ValidateExecutorConfig(task); errors.As(err, &configErr) {
+		constraint := constraintFormat
+		if errors.Is(err, core.ErrHTTPRequestRequired) || errors.Is(err, core.ErrSQLQueryRequired) {
+			constraint = constraintRequired
+		}
+		errs.add(configErr.Field, constraint, configErr.Error())
+	}
+}
+
+// scriptGetter reads scripts from the store or, inside a transaction, from a store.Tx.
+type scriptGetter interface {
+	GetScript(ctx context.Context, id string) (*core.Script, error)
+}
+
+// parseScriptID resolves the script_id field of a task run by executor; nil or "" yields
+// nil.
+func (s *Server) parseScriptID(ctx context.Context, scripts scriptGetter, errs *validationErrors, value *string, executor string) *core.Script {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil
+	}
+	if executor != core.ExecutorShell {
+		errs.add("script_id", constraintConflict, "script_id is only used by the shell executor")
+		return nil
+	}
+	script, err := scripts.GetScript(ctx, strings.TrimSpace(*value))
+	switch {
+	case errors.Is(err, store.ErrScriptNotFound):
+		errs.add("script_id", constraintExists, "script not found")
+		return nil
+	case err != nil:
+		s.logger.Error("get script", "script_id", *value, "err", err)
+		errs.add("script_id", constraintExists, "failed to load script")
+		return nil
+	}
+	return script
+}
+
+// checkTemplate reports an optional command or working_dir field that validate rejects.
+func checkTemplate(errs *validationErrors, field string, value *string, validate func(string) error) {
+	if value == nil {
+		return
+	}
+	if err := validate(*value); err != nil {
+		errs.add(field, constraintFormat, err.Error())
+	}
+}
+
+// parsePauseUntil parses an optional pause_until field; nil or "" yields nil.
+func (s *Server) parsePauseUntil(errs *validationErrors, value *string) *time.Time {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil
+	}
+	until, err := core.ParsePauseUntil(strings.TrimSpace(*value), s.location, s.scheduler.Now())
+	if err != nil {
+		constraint := constraintFormat
+		if errors.Is(err, core.ErrPauseUntilPast) {
+			constraint = constraintFuture
+		}
+		errs.add("pause_until", constraint, err.Error())
+		return nil
+	}
+	return &until
+}
+
 func taskToResponse(task *core.Task) taskResponse {
-	var last, next *string
+	var last, next, pauseUntil, skipNext *string
 	if task.LastRunAt != nil {
 		formatted := task.LastRunAt.UTC().Format(time.RFC3339)
 		last = &formatted
@@ -363,18 +1126,38 @@ func taskToResponse(task *core.Task) taskResponse {
 		formatted := task.NextRunAt.UTC().Format(time.RFC3339)
 		next = &formatted
 	}
+	if task.PauseUntil != nil {
+		formatted := task.PauseUntil.UTC().Format(time.RFC3339)
+		pauseUntil = &formatted
+	}
+	if task.SkipNextAt != nil {
+		formatted := task.SkipNextAt.UTC().Format(time.RFC3339)
+		skipNext = &formatted
+	}
 	return taskResponse{
-		ID:          task.ID,
-		Name:        task.Name,
-		Command:     task.Command,
-		Cron:        task.Cron,
-		TimeoutSecs: task.TimeoutSeconds,
-		WorkingDir:  task.WorkingDir,
-		Status:      string(task.Status),
-		LastRunAt:   last,
-		NextRunAt:   next,
-		CreatedAt:   task.CreatedAt.UTC().Format(time.RFC3339),
-		UpdatedAt:   task.UpdatedAt.UTC().Format(time.RFC3339),
+		ID:              task.ID,
+		Name:            task.Name,
+		Command:         task.Command,
+		Cron:            task.Cron,
+		TimeoutSecs:     task.TimeoutSeconds,
+		WorkingDir:      task.WorkingDir,
+		MinIntervalSecs: task.MinIntervalSeconds,
+		PauseAfterFails: task.PauseAfterFailures,
+		LogRetention:    task.LogRetention,
+		LogMaxAgeDays:   task.LogMaxAgeDays,
+		Executor:        task.Executor,
+		HTTP:            task.HTTP,
+		SQL:             task.SQL.Redacted(),
+		ScriptID:        task.ScriptID,
+		RunOnStart:      task.RunOnStart,
+		Status:          string(task.Status),
+		PauseUntil:      pauseUntil,
+		SkipNextAt:      skipNext,
+		Source:          task.Source,
+		LastRunAt:       last,
+		NextRunAt:       next,
+		CreatedAt:       task.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:       task.UpdatedAt.UTC().Format(time.RFC3339),
 	}
 }
 
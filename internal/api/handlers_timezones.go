@@ -0,0 +1,152 @@
+package api
+
+import (
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// zoneinfoDirs are where the IANA time zone database is looked for, in the order Go's
+// time package uses them.
+var zoneinfoDirs = []string{
+	"/usr/share/zoneinfo",
+	"/usr/share/lib/zoneinfo",
+	"/usr/lib/locale/TZ",
+	"/etc/zoneinfo",
+}
+
+var (
+	zoneNamesOnce sync.Once
+	zoneNames     []string
+)
+
+// timezoneNames returns the zone names of the first time zone database found, sorted.
+// Without a database only UTC is known.
+func timezoneNames() []string {
+	zoneNamesOnce.Do(func() {
+		dirs := zoneinfoDirs
+		if dir := os.Getenv("ZONEINFO"); dir != "" {
+			dirs = append([]string{dir}, dirs...)
+		}
+		for _, dir := range dirs {
+			if names := scanZoneinfo(dir); len(names) > 0 {
+				zoneNames = names
+				break
+			}
+		}
+		if zoneNames == nil {
+			zoneNames = []string{"UTC"}
+		}
+	})
+	return zoneNames
+}
+
+// scanZoneinfo lists the loadable zones below dir. The posix/ and right/ trees duplicate
+// the main zones with other leap second handling and are left out, as are the tables
+// (zone.tab, tzdata.zi, ...) that sit next to the zone files.
+func scanZoneinfo(dir string) []string {
+	var names []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name, _ := filepath.Rel(dir, path)
+		if d.IsDir() {
+			if name == "posix" || name == "right" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !unicode.IsUpper(rune(name[0])) || strings.Contains(name, ".") || name == "Factory" {
+			return nil
+		}
+		if _, err := time.LoadLocation(name); err == nil {
+			names = append(names, name)
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names
+}
+
+type timezoneResponse struct {
+	Name          string `json:"name"`
+	Abbreviation  string `json:"abbreviation"`
+	Offset        string `json:"offset"`
+	OffsetSeconds int    `json:"offset_seconds"`
+}
+
+// timezoneAt describes loc as it is at now; offsets change with daylight saving time.
+func timezoneAt(loc *time.Location, now time.Time) timezoneResponse {
+	abbreviation, offset := now.In(loc).Zone()
+	name := loc.String()
+	if name == "Local" {
+		name = localZoneName()
+	}
+	return timezoneResponse{
+		Name:          name,
+		Abbreviation:  abbreviation,
+		Offset:        now.In(loc).Format("-07:00"),
+		OffsetSeconds: offset,
+	}
+}
+
+// handleListTimezones lists the IANA time zones with their current offsets. ?q= keeps
+// the zones whose name contains it, ignoring case.
+func (s *Server) handleListTimezones(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	now := time.Now()
+	zones := make([]timezoneResponse, 0)
+	for _, name := range timezoneNames() {
+		if query != "" && !strings.Contains(strings.ToLower(name), query) {
+			continue
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			continue
+		}
+		zones = append(zones, timezoneAt(loc, now))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"server":    timezoneAt(s.location, now),
+		"timezones": zones,
+	})
+}
+
+// handleGetTimezone validates one zone name, e.g. GET /v1/timezones/Europe/Berlin.
+func (s *Server) handleGetTimezone(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "*")
+	if name == "" || name == "Local" {
+		writeError(w, http.StatusNotFound, "not_found", "unknown time zone")
+		return
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "unknown time zone: "+name)
+		return
+	}
+	writeJSON(w, http.StatusOK, timezoneAt(loc, time.Now()))
+}
+
+// localZoneName names the system time zone the way time.Local finds it: from $TZ, else
+// from where /etc/localtime links into the zone database. It returns "Local" when neither
+// names a zone.
+func localZoneName() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" && !filepath.IsAbs(tz) {
+		return tz
+	}
+	if target, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+			return name
+		}
+	}
+	return "Local"
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// timeoutWriteGrace lets a handler that gave up at the route timeout still write its
+// error response before the write deadline closes the connection.
+const timeoutWriteGrace = 5 * time.Second
+
+// Limits bounds request bodies and handler time, protecting the single-connection
+// database from huge or stuck requests. Zero values disable a limit.
+type Limits struct {
+	// MaxBodyBytes caps the body of ordinary API, login and MCP requests.
+	MaxBodyBytes int64
+	// Timeout bounds ordinary API requests. Streaming routes (log follow, exports, MCP)
+	// are exempt.
+	Timeout time.Duration
+	// MaxImportBytes and ImportTimeout apply to POST /v1/admin/import, whose snapshot
+	// archives can carry run logs.
+	MaxImportBytes int64
+	ImportTimeout  time.Duration
+}
+
+// DefaultLimits are used until SetLimits is called.
+var DefaultLimits = Limits{
+	MaxBodyBytes:   1 << 20,
+	Timeout:        30 * time.Second,
+	MaxImportBytes: 256 << 20,
+	ImportTimeout:  10 * time.Minute,
+}
+
+// limitRequest applies the body size limit and timeout of ordinary API routes.
+func (s *Server) limitRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveLimited(w, r, next, s.limits.MaxBodyBytes, s.limits.Timeout)
+	})
+}
+
+// limitImport applies the larger limits of snapshot imports.
+func (s *Server) limitImport(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveLimited(w, r, next, s.limits.MaxImportBytes, s.limits.ImportTimeout)
+	})
+}
+
+// limitUpload applies the import timeout to file uploads; their size is capped by the
+// store, which stops reading past the file size limit.
+func (s *Server) limitUpload(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveLimited(w, r, next, 0, s.limits.ImportTimeout)
+	})
+}
+
+// limitBody applies only the body size limit, for routes whose responses may stream.
+func (s *Server) limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveLimited(w, r, next, s.limits.MaxBodyBytes, 0)
+	})
+}
+
+func serveLimited(w http.ResponseWriter, r *http.Request, next http.Handler, maxBody int64, timeout time.Duration) {
+	if maxBody > 0 {
+		if r.ContentLength > maxBody {
+			writeError(w, http.StatusRequestEntityTooLarge, "body_too_large", fmt.Sprintf("request body exceeds %d bytes", maxBody))
+			return
+		}
+		// Chunked bodies fail to decode once they pass the limit
+		r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+	}
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+		// The server sets no write timeout because of streaming routes; bound this one.
+		// The read deadline replaces the server's, so large imports can finish uploading.
+		rc := http.NewResponseController(w)
+		_ = rc.SetReadDeadline(time.Now().Add(timeout))
+		_ = rc.SetWriteDeadline(time.Now().Add(timeout + timeoutWriteGrace))
+	}
+	next.ServeHTTP(w, r)
+}
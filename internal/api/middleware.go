@@ -1,35 +1,143 @@
 package api
 
 import (
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"net/netip"
 	"strings"
+	"time"
+
+	"clicrontab/internal/core"
+	"clicrontab/internal/oidc"
+	"clicrontab/internal/store"
+
+	"github.com/go-chi/chi/v5"
 )
 
-// AuthMiddleware creates a middleware that checks for a bearer token or query param token.
-func AuthMiddleware(token string) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if token == "" {
+// requireAuth checks the bearer token, a session cookie issued by POST /login or OIDC
+// sign-in and, when query-token auth is enabled, a ?token= query parameter. Unsafe
+// requests authenticated by the cookie must also carry the session's CSRF token in the
+// X-CSRF-Token header, and are refused for read-only roles.
+func (s *Server) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authEnabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Check Authorization header
+		authHeader := r.Header.Get("Authorization")
+		if strings.HasPrefix(authHeader, "Bearer ") && s.authToken != "" && tokenEqual(authHeader[7:], s.authToken) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Check the web UI session cookie
+		if cookie, err := r.Cookie(sessionCookieName); err == nil {
+			if sess, ok := s.parseSession(cookie.Value, time.Now()); ok {
+				switch r.Method {
+				case http.MethodGet, http.MethodHead, http.MethodOptions:
+				default:
+					if !tokenEqual(r.Header.Get(csrfHeader), s.csrfToken(cookie.Value)) {
+						http.Error(w, "Forbidden: missing or invalid CSRF token", http.StatusForbidden)
+						return
+					}
+					if sess.Role != oidc.RoleAdmin {
+						http.Error(w, "Forbidden: role "+sess.Role+" is read-only", http.StatusForbidden)
+						return
+					}
+				}
 				next.ServeHTTP(w, r)
 				return
 			}
+		}
 
-			// Check query param (convenient for quick testing)
-			if qToken := r.URL.Query().Get("token"); qToken == token {
+		// Check query param; it ends up in access logs and browser history, so it can be
+		// switched off with CLICRON_AUTH_QUERY_TOKEN=false
+		if s.queryTokenAuth && s.authToken != "" {
+			if qToken := r.URL.Query().Get("token"); qToken != "" && tokenEqual(qToken, s.authToken) {
 				next.ServeHTTP(w, r)
 				return
 			}
+		}
 
-			// Check Authorization header
-			authHeader := r.Header.Get("Authorization")
-			if strings.HasPrefix(authHeader, "Bearer ") {
-				if authHeader[7:] == token {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// requireAdmin refuses read-only sessions on routes that return secrets, such as the
+// state export. It runs after requireAuth; token-authenticated requests act as admin.
+func (s *Server) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		bearer := strings.HasPrefix(authHeader, "Bearer ") && s.authToken != "" && tokenEqual(authHeader[7:], s.authToken)
+		if cookie, err := r.Cookie(sessionCookieName); err == nil && s.authEnabled() && !bearer {
+			if sess, ok := s.parseSession(cookie.Value, time.Now()); ok && sess.Role != oidc.RoleAdmin {
+				http.Error(w, "Forbidden: role "+sess.Role+" cannot export secrets", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkClientIP refuses connections from addresses outside the allowlist. It looks at
+// the connection's address, not X-Forwarded-For, so a proxy in front of the daemon is
+// allowed or refused as a whole.
+func (s *Server) checkClientIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.allowedIPs) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		addr, err := netip.ParseAddrPort(r.RemoteAddr)
+		if err == nil {
+			ip := addr.Addr().Unmap()
+			if ip.IsLoopback() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			for _, prefix := range s.allowedIPs {
+				if prefix.Contains(ip) {
 					next.ServeHTTP(w, r)
 					return
 				}
 			}
+		}
+		s.logger.Debug("client address not allowed", "remote_addr", r.RemoteAddr, "path", r.URL.Path)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	})
+}
 
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		})
-	}
+func tokenEqual(given, token string) bool {
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// resolveTaskRef lets the {taskID} path segment be a task name or a unique ID prefix as
+// well as a full ID: it rewrites the parameter to the full ID before the handler runs.
+// References that match nothing are passed through for the handler to report.
+func (s *Server) resolveTaskRef(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rctx := chi.RouteContext(r.Context())
+		ref := chi.URLParam(r, "taskID")
+		id, err := s.store.ResolveTaskRef(r.Context(), ref)
+		switch {
+		case errors.Is(err, core.ErrAmbiguousTaskRef):
+			writeError(w, http.StatusConflict, "ambiguous", err.Error())
+			return
+		case errors.Is(err, store.ErrTaskNotFound):
+		case err != nil:
+			s.logger.Error("resolve task reference", "ref", ref, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to resolve task")
+			return
+		default:
+			for i, key := range rctx.URLParams.Keys {
+				if key == "taskID" {
+					rctx.URLParams.Values[i] = id
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
 }
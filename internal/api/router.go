@@ -3,15 +3,20 @@ package api
 import (
 	"bytes"
 	"context"
-	"io"
-	"io/fs"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"time"
 
 	"clicrontab/internal/core"
+	"clicrontab/internal/loglink"
 	clicrontabmcp "clicrontab/internal/mcp"
+	"clicrontab/internal/notify"
+	"clicrontab/internal/oidc"
 	"clicrontab/internal/store"
+	"clicrontab/internal/taskfile"
 	"clicrontab/web"
 
 	"github.com/go-chi/chi/v5"
@@ -20,35 +25,75 @@ import (
 
 // Server holds the HTTP server state.
 type Server struct {
-	httpServer *http.Server
-	router     *chi.Mux
-	store      *store.Store
-	scheduler  *core.Scheduler
-	mcpServer  *clicrontabmcp.MCPServer
-	logger     *slog.Logger
-	location   *time.Location
-	authToken  string
+	httpServer    *http.Server
+	router        *chi.Mux
+	store         *store.Store
+	scheduler     *core.Scheduler
+	mcpServer     *clicrontabmcp.MCPServer
+	notifications *notify.Dispatcher
+	logger        *slog.Logger
+	location      *time.Location
+	authToken     string
+	// queryTokenAuth accepts the token as a ?token= query parameter.
+	queryTokenAuth bool
+	// authUsername and authPassword sign in to the web UI through POST /login.
+	authUsername string
+	authPassword string
+	// sessionKey is mixed into the key signing web sessions.
+	sessionKey   string
+	oidc         *oidc.Provider
+	oidcRedirect string
+	// allowedIPs restricts which client addresses may connect; empty allows all.
+	allowedIPs []netip.Prefix
+	limits     Limits
+	assets     map[string]*web.Asset
+	logLinks   *loglink.Signer
+	taskSync   *taskfile.Syncer
+	outbox     *notify.Outbox
+}
+
+// compressibleTypes are the response types gzip-compressed for clients that accept it:
+// API JSON, run logs and exports, and the web UI. Event streams and the already
+// compressed state archive are sent as is.
+var compressibleTypes = []string{
+	"application/json",
+	"text/plain",
+	"text/csv",
+	"application/x-ndjson",
+	"text/html",
+	"text/css",
+	"application/javascript",
+	"text/javascript",
 }
 
 // NewServer constructs the HTTP API server.
-func NewServer(addr string, authToken string, store *store.Store, scheduler *core.Scheduler, mcpServer *clicrontabmcp.MCPServer, logger *slog.Logger, location *time.Location) (*Server, error) {
+func NewServer(addr string, authToken string, store *store.Store, scheduler *core.Scheduler, mcpServer *clicrontabmcp.MCPServer, notifications *notify.Dispatcher, logger *slog.Logger, location *time.Location) (*Server, error) {
 	router := chi.NewRouter()
-	router.Use(middleware.RequestID)
-	router.Use(middleware.RealIP)
-	router.Use(middleware.Recoverer)
-
-	staticFS := web.Files()
+	assets, err := web.Assets()
+	if err != nil {
+		return nil, fmt.Errorf("load web assets: %w", err)
+	}
 
 	s := &Server{
-		router:    router,
-		store:     store,
-		scheduler: scheduler,
-		mcpServer: mcpServer,
-		logger:    logger,
-		location:  location,
-		authToken: authToken,
+		router:        router,
+		store:         store,
+		scheduler:     scheduler,
+		mcpServer:     mcpServer,
+		notifications: notifications,
+		logger:        logger,
+		location:      location,
+		authToken:     authToken,
+		limits:        DefaultLimits,
+		assets:        assets,
 	}
-	s.registerRoutes(staticFS)
+
+	router.Use(middleware.RequestID)
+	// Before RealIP, which trusts X-Forwarded-For
+	router.Use(s.checkClientIP)
+	router.Use(middleware.RealIP)
+	router.Use(middleware.Recoverer)
+	router.Use(middleware.Compress(5, compressibleTypes...))
+	s.registerRoutes()
 
 	httpServer := &http.Server{
 		Addr:         addr,
@@ -61,80 +106,249 @@ func NewServer(addr string, authToken string, store *store.Store, scheduler *cor
 	return s, nil
 }
 
+// SetQueryTokenAuth sets whether the auth token is accepted as a ?token= query
+// parameter. It must be called before the server starts.
+func (s *Server) SetQueryTokenAuth(enabled bool) {
+	s.queryTokenAuth = enabled
+}
+
+// SetPasswordLogin enables signing in through POST /login with a username and
+// password; an empty password disables it. It must be called before the server starts.
+func (s *Server) SetPasswordLogin(username, password string) {
+	s.authUsername = username
+	s.authPassword = password
+}
+
+// SetSessionKey sets the secret web sessions are signed with, so sessions stay valid
+// across restarts. It must be called before the server starts.
+func (s *Server) SetSessionKey(key string) {
+	s.sessionKey = key
+}
+
+// SetOIDC enables signing in to the web UI through an OIDC provider. redirectURL is the
+// callback registered with the provider; empty derives it from each request. It must be
+// called before the server starts.
+func (s *Server) SetOIDC(provider *oidc.Provider, redirectURL string) {
+	s.oidc = provider
+	s.oidcRedirect = redirectURL
+}
+
+// SetAllowedIPs restricts clients to the given networks; loopback clients are always
+// allowed and an empty list allows everyone. It must be called before the server starts.
+func (s *Server) SetAllowedIPs(prefixes []netip.Prefix) {
+	s.allowedIPs = prefixes
+}
+
+// SetLimits sets the request body and timeout limits. It must be called before the
+// server starts.
+func (s *Server) SetLimits(limits Limits) {
+	s.limits = limits
+}
+
+// SetLogLinks enables the signed log links sent in notifications; nil disables them.
+// It must be called before the server starts.
+func (s *Server) SetLogLinks(links *loglink.Signer) {
+	s.logLinks = links
+}
+
+// SetTaskSync sets the Syncer used by POST /v1/tasks/sync. It must be called before the
+// server starts.
+func (s *Server) SetTaskSync(syncer *taskfile.Syncer) {
+	s.taskSync = syncer
+}
+
+// SetOutbox sets the notification outbox whose deliveries /v1/notifications lists. It
+// must be called before the server starts.
+func (s *Server) SetOutbox(outbox *notify.Outbox) {
+	s.outbox = outbox
+}
+
 // Start begins serving HTTP requests.
 func (s *Server) Start() error {
 	s.logger.Info("http server listening", "addr", s.httpServer.Addr)
 	return s.httpServer.ListenAndServe()
 }
 
+// Serve serves HTTP requests on an existing listener, e.g. one passed by systemd.
+// It may be called concurrently for several listeners.
+func (s *Server) Serve(ln net.Listener) error {
+	s.logger.Info("http server listening", "addr", ln.Addr().String(), "inherited", true)
+	return s.httpServer.Serve(ln)
+}
+
 // Shutdown gracefully shuts down the server.
 func (s *Server) Shutdown(ctx context.Context) error {
 	return s.httpServer.Shutdown(ctx)
 }
 
-func (s *Server) registerRoutes(staticFS fs.FS) {
-	fileServer := http.StripPrefix("/assets/", http.FileServer(http.FS(staticFS)))
+func (s *Server) registerRoutes() {
+	s.router.Get("/", s.handleIndex)
+	s.router.HandleFunc(web.AssetPrefix+"*", s.handleAsset)
 
-	s.router.Get("/", s.handleIndex(staticFS))
-	s.router.Handle("/assets/*", fileServer)
+	// Web UI sessions; credentials are checked by the handlers
+	s.router.With(s.limitRequest).Post("/login", s.handleLogin)
+	s.router.Get("/login/options", s.handleLoginOptions)
+	s.router.Get("/login/oidc", s.handleOIDCLogin)
+	s.router.With(s.limitRequest).Get(oidcCallbackPath, s.handleOIDCCallback)
+	s.router.Post("/logout", s.handleLogout)
 
-	// Mount MCP endpoint with optional authentication
-	var mcpHandler http.Handler = s.mcpServer
-	if s.authToken != "" {
-		mcpHandler = AuthMiddleware(s.authToken)(mcpHandler)
-	}
-	s.router.Handle("/mcp", mcpHandler)
+	// Mount MCP endpoint with optional authentication; responses may stream, so only the
+	// body is limited
+	s.router.Handle("/mcp", s.requireAuth(s.limitBody(s.mcpServer)))
 
+	// Signed log links carry their own authorization
+	s.router.Get(loglink.PathPrefix+"{runID}/log", s.handleSharedRunLog)
+
+	// Routes that stream (log follow, exports) are registered outside the limitRequest
+	// groups so they are not cut off by the request timeout.
 	s.router.Route("/v1", func(r chi.Router) {
 		// Apply authentication to all API endpoints
-		if s.authToken != "" {
-			r.Use(AuthMiddleware(s.authToken))
-		}
+		r.Use(s.requireAuth)
 
-		r.Post("/cron/preview", s.handleCronPreview)
+		r.Group(func(r chi.Router) {
+			r.Use(s.limitRequest)
+			r.Post("/cron/preview", s.handleCronPreview)
+			r.Post("/cron/build", s.handleCronBuild)
+			r.Get("/stats", s.handleStats)
+			r.Get("/reports/{kind}", s.handleListReports)
+			r.Get("/reports/{kind}/{period}", s.handleGetReport)
+			r.Get("/search", s.handleSearch)
+			r.Get("/timezones", s.handleListTimezones)
+			r.Get("/timezones/*", s.handleGetTimezone)
+
+			r.Route("/scheduler", func(r chi.Router) {
+				r.Get("/entries", s.handleSchedulerEntries)
+				r.Post("/sync", s.handleSchedulerSync)
+			})
+
+			r.Get("/notifications", s.handleListDeliveries)
+			r.Post("/notifications/{deliveryID}/retry", s.handleRetryDelivery)
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			r.With(s.requireAdmin).Get("/export", s.handleExportState)
+			r.With(s.limitImport).Post("/import", s.handleImportState)
+			r.Group(func(r chi.Router) {
+				r.Use(s.limitRequest)
+				r.Get("/notifications", s.handleGetNotifications)
+				r.Patch("/notifications", s.handleUpdateNotifications)
+				r.Post("/notifications/test", s.handleTestNotification)
+				r.Get("/metrics", s.handleDaemonMetrics)
+			})
+		})
 
 		r.Route("/tasks", func(r chi.Router) {
-			r.Get("/", s.handleListTasks)
-			r.Post("/", s.handleCreateTask)
+			r.Group(func(r chi.Router) {
+				r.Use(s.limitRequest)
+				r.Get("/", s.handleListTasks)
+				r.Post("/", s.handleCreateTask)
+				r.Post("/sync", s.handleSyncTasks)
+			})
 
 			r.Route("/{taskID}", func(r chi.Router) {
-				r.Get("/", s.handleGetTask)
-				r.Patch("/", s.handleUpdateTask)
-				r.Delete("/", s.handleDeleteTask)
-				r.Post("/run", s.handleRunTask)
-				r.Get("/runs", s.handleListRuns)
+				r.Use(s.resolveTaskRef)
+				r.Get("/runs/export", s.handleExportRuns)
+				r.Group(func(r chi.Router) {
+					r.Use(s.limitRequest)
+					r.Get("/", s.handleGetTask)
+					r.Patch("/", s.handleUpdateTask)
+					r.Delete("/", s.handleDeleteTask)
+					r.Post("/run", s.handleRunTask)
+					r.Post("/pause", s.handlePauseTask)
+					r.Post("/resume", s.handleResumeTask)
+					r.Post("/clone", s.handleCloneTask)
+					r.Get("/check", s.handleCheckTask)
+					r.Post("/archive", s.handleArchiveTask)
+					r.Post("/unarchive", s.handleUnarchiveTask)
+					r.Post("/skip-next", s.handleSkipNext)
+					r.Post("/acknowledge", s.handleAcknowledgeFailure)
+					r.Post("/resolve", s.handleResolveTaskIncident)
+					r.Get("/incidents", s.handleListTaskIncidents)
+					r.Delete("/skip-next", s.handleCancelSkipNext)
+					r.Get("/runs", s.handleListRuns)
+					r.Get("/logs", s.handleTaskLogs)
+					r.Get("/heatmap", s.handleTaskHeatmap)
+					r.Delete("/runs", s.handlePurgeRuns)
+					r.Get("/comments", s.handleListTaskComments)
+					r.Post("/comments", s.handleCreateTaskComment)
+				})
 			})
 		})
 
+		r.Route("/scripts", func(r chi.Router) {
+			r.Use(s.limitRequest)
+			r.Get("/", s.handleListScripts)
+			r.Post("/", s.handleCreateScript)
+			r.Get("/{scriptID}", s.handleGetScript)
+			r.Patch("/{scriptID}", s.handleUpdateScript)
+			r.Delete("/{scriptID}", s.handleDeleteScript)
+		})
+
+		r.Route("/files", func(r chi.Router) {
+			r.With(s.limitRequest).Get("/", s.handleListFiles)
+			r.With(s.limitUpload).Post("/", s.handleUploadFile)
+			r.With(s.limitUpload).Put("/{name}", s.handlePutFile)
+			r.Get("/{name}", s.handleDownloadFile)
+			r.With(s.limitRequest).Delete("/{name}", s.handleDeleteFile)
+		})
+
+		r.Route("/maintenance-windows", func(r chi.Router) {
+			r.Use(s.limitRequest)
+			r.Get("/", s.handleListMaintenanceWindows)
+			r.Post("/", s.handleCreateMaintenanceWindow)
+			r.Delete("/{windowID}", s.handleDeleteMaintenanceWindow)
+		})
+
+		r.Route("/incidents", func(r chi.Router) {
+			r.Use(s.limitRequest)
+			r.Get("/", s.handleListIncidents)
+			r.Get("/{incidentID}", s.handleGetIncident)
+			r.Post("/{incidentID}/acknowledge", s.handleAcknowledgeIncident)
+			r.Post("/{incidentID}/resolve", s.handleResolveIncident)
+		})
+
 		r.Route("/runs", func(r chi.Router) {
-			r.Get("/{runID}", s.handleGetRun)
 			r.Get("/{runID}/log", s.handleRunLog)
+			r.Group(func(r chi.Router) {
+				r.Use(s.limitRequest)
+				r.Get("/active", s.handleListActiveRuns)
+				r.Get("/{runID}", s.handleGetRun)
+				r.Get("/{runID}/log/grep", s.handleGrepRunLog)
+				r.Patch("/{runID}", s.handleUpdateRun)
+				r.Post("/{runID}/cancel", s.handleCancelRun)
+			})
 		})
 	})
 }
 
-func (s *Server) handleIndex(staticFS fs.FS) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		file, err := staticFS.Open("index.html")
-		if err != nil {
-			http.Error(w, "index not found", http.StatusInternalServerError)
-			return
-		}
-		defer file.Close()
-		info, err := fs.Stat(staticFS, "index.html")
-		modTime := time.Now()
-		if err == nil {
-			modTime = info.ModTime()
-		}
-		if reader, ok := file.(io.ReadSeeker); ok {
-			http.ServeContent(w, r, "index.html", modTime, reader)
+// handleIndex serves the web UI page. It must be revalidated on every load so it
+// picks up the fingerprinted asset URLs of a new build.
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	s.serveAsset(w, r, s.assets["index.html"], "no-cache")
+}
+
+// handleAsset serves /assets/. Fingerprinted URLs are cached for a year; the plain
+// file names keep working for old pages but are revalidated.
+func (s *Server) handleAsset(w http.ResponseWriter, r *http.Request) {
+	for _, asset := range s.assets {
+		switch r.URL.Path {
+		case asset.URL:
+			s.serveAsset(w, r, asset, "public, max-age=31536000, immutable")
 			return
-		}
-		data, err := io.ReadAll(file)
-		if err != nil {
-			http.Error(w, "failed to load index", http.StatusInternalServerError)
+		case web.AssetPrefix + asset.Name:
+			s.serveAsset(w, r, asset, "no-cache")
 			return
 		}
-		http.ServeContent(w, r, "index.html", modTime, bytes.NewReader(data))
 	}
+	http.NotFound(w, r)
+}
+
+func (s *Server) serveAsset(w http.ResponseWriter, r *http.Request, asset *web.Asset, cacheControl string) {
+	if asset == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", asset.ETag)
+	http.ServeContent(w, r, asset.Name, time.Time{}, bytes.NewReader(asset.Content))
 }
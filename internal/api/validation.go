@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Constraint names reported in field-level validation errors.
+const (
+	constraintRequired  = "required"
+	constraintMin       = "min"
+	constraintMaxLength = "max_length"
+	constraintCron      = "cron"
+	constraintFormat    = "format"
+	constraintFuture    = "future"
+	constraintConflict  = "conflict"
+	constraintOneOf     = "one_of"
+	constraintExists    = "exists"
+)
+
+// fieldError describes a single invalid request body field.
+type fieldError struct {
+	Field      string `json:"field"`
+	Constraint string `json:"constraint"`
+	Message    string `json:"message"`
+}
+
+// validationErrors collects every invalid field of a request so clients can fix them in one pass.
+type validationErrors []fieldError
+
+// Error lets a transaction return the errors to roll back; see writeRequestError.
+func (v validationErrors) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, e := range v {
+		messages = append(messages, e.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (v *validationErrors) add(field, constraint, message string) {
+	*v = append(*v, fieldError{Field: field, Constraint: constraint, Message: message})
+}
+
+// nonNegative records a min violation when value is set and negative.
+func (v *validationErrors) nonNegative(field string, value *int) {
+	if value != nil && *value < 0 {
+		v.add(field, constraintMin, field+" must be non-negative")
+	}
+}
+
+// writeValidationError responds with 422 and the offending fields.
+func writeValidationError(w http.ResponseWriter, errs validationErrors) {
+	payload := map[string]any{
+		"error": map[string]any{
+			"code":    "validation_failed",
+			"message": errs.Error(),
+			"fields":  errs,
+		},
+	}
+	writeJSON(w, http.StatusUnprocessableEntity, payload)
+}
+
+// requestError is a client error found inside a transaction, returned to roll it back.
+type requestError struct {
+	status        int
+	code, message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// writeRequestError writes err when it is validationErrors or a *requestError and
+// reports whether it did.
+func writeRequestError(w http.ResponseWriter, err error) bool {
+	var invalid validationErrors
+	if errors.As(err, &invalid) {
+		writeValidationError(w, invalid)
+		return true
+	}
+	var rejected *requestError
+	if errors.As(err, &rejected) {
+		writeError(w, rejected.status, rejected.code, rejected.message)
+		return true
+	}
+	return false
+}
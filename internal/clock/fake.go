@@ -0,0 +1,178 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a clock that only moves when told to. Timers and tickers fire as Set or Advance
+// moves the time past their deadlines, in deadline order, with Now reading each deadline
+// as it fires; that makes runs across DST changes or leap days reproducible.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	changed chan struct{} // closed and replaced whenever a waiter is added or removed
+}
+
+// fakeWaiter is a pending timer, or a ticker with its next tick.
+type fakeWaiter struct {
+	clock  *Fake
+	at     time.Time
+	period time.Duration // 0 for timers
+	c      chan time.Time
+}
+
+// NewFake returns a fake clock reading now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, changed: make(chan struct{})}
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Advance moves the clock forward by d, firing what comes due on the way.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to t, firing what comes due on the way. Moving it backwards fires
+// nothing, like a system clock being set back.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for {
+		w := f.nextDueLocked(t)
+		if w == nil {
+			break
+		}
+		f.now = w.at
+		select {
+		case w.c <- w.at:
+		default: // like time.Timer, a tick nobody took yet is not queued twice
+		}
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+		} else {
+			f.removeLocked(w)
+		}
+	}
+	f.now = t
+}
+
+// Waiters returns how many timers and tickers are pending.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil waits until n timers and tickers are pending, so a caller can advance the
+// clock only once a loop under test has armed its next timer.
+func (f *Fake) BlockUntil(n int) {
+	for {
+		f.mu.Lock()
+		if len(f.waiters) == n {
+			f.mu.Unlock()
+			return
+		}
+		changed := f.changed
+		f.mu.Unlock()
+		<-changed
+	}
+}
+
+// NewTimer returns a timer firing once the clock reaches Now()+d; one for a time that
+// already passed fires at once.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	w := &fakeWaiter{clock: f, c: make(chan time.Time, 1)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.arm(d)
+	return w
+}
+
+// NewTicker returns a ticker firing every d of fake time.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	w := &fakeWaiter{clock: f, period: d, c: make(chan time.Time, 1)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.at = f.now.Add(d)
+	f.addLocked(w)
+	return fakeTicker{w}
+}
+
+// nextDueLocked returns the waiter with the earliest deadline at or before t.
+func (f *Fake) nextDueLocked(t time.Time) *fakeWaiter {
+	sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+	if len(f.waiters) == 0 || f.waiters[0].at.After(t) {
+		return nil
+	}
+	return f.waiters[0]
+}
+
+func (f *Fake) addLocked(w *fakeWaiter) {
+	f.waiters = append(f.waiters, w)
+	f.notifyLocked()
+}
+
+// removeLocked removes w, reporting whether it was pending.
+func (f *Fake) removeLocked(w *fakeWaiter) bool {
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.notifyLocked()
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fake) notifyLocked() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (w *fakeWaiter) C() <-chan time.Time { return w.c }
+
+func (w *fakeWaiter) Stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	return w.clock.removeLocked(w)
+}
+
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	pending := w.clock.removeLocked(w)
+	w.arm(d)
+	return pending
+}
+
+// arm schedules the timer d from now, firing it at once when d is not positive. The
+// clock's lock must be held.
+func (w *fakeWaiter) arm(d time.Duration) {
+	w.at = w.clock.now.Add(d)
+	if d > 0 {
+		w.clock.addLocked(w)
+		return
+	}
+	select {
+	case w.c <- w.at:
+	default:
+	}
+}
+
+// fakeTicker hides the Timer methods of its waiter.
+type fakeTicker struct{ w *fakeWaiter }
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.c }
+func (t fakeTicker) Stop()               { t.w.Stop() }
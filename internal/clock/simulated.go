@@ -0,0 +1,101 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Simulated is a clock that starts at a chosen instant and runs speed times faster than
+// real time, e.g. to watch a daemon go through a DST change or a month end in minutes.
+type Simulated struct {
+	start  time.Time
+	origin time.Time // real time at start
+	speed  float64
+}
+
+// NewSimulated returns a clock reading start now and advancing speed times faster than
+// real time; speed must be positive.
+func NewSimulated(start time.Time, speed float64) *Simulated {
+	if speed <= 0 {
+		panic("clock: non-positive simulation speed")
+	}
+	// Round(0) drops the monotonic reading: simulated times are wall-clock only
+	return &Simulated{start: start.Round(0), origin: time.Now(), speed: speed}
+}
+
+// Now returns the simulated time.
+func (s *Simulated) Now() time.Time {
+	return s.start.Add(s.scaleUp(time.Since(s.origin)))
+}
+
+// Speed returns how many times faster than real time the clock runs.
+func (s *Simulated) Speed() float64 {
+	return s.speed
+}
+
+func (s *Simulated) scaleUp(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * s.speed)
+}
+
+// real converts a duration of simulated time into real time.
+func (s *Simulated) real(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / s.speed)
+}
+
+// NewTimer returns a timer firing after d of simulated time.
+func (s *Simulated) NewTimer(d time.Duration) Timer {
+	t := &simulatedTimer{clock: s, c: make(chan time.Time, 1)}
+	t.t = time.AfterFunc(s.real(d), t.fire)
+	return t
+}
+
+// NewTicker returns a ticker firing every d of simulated time.
+func (s *Simulated) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	t := &simulatedTicker{c: make(chan time.Time, 1), done: make(chan struct{})}
+	ticker := time.NewTicker(max(s.real(d), 1))
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.done:
+				return
+			case <-ticker.C:
+				select {
+				case t.c <- s.Now():
+				default:
+				}
+			}
+		}
+	}()
+	return t
+}
+
+// simulatedTimer delivers simulated times instead of the real ones its timer fires at.
+type simulatedTimer struct {
+	clock *Simulated
+	t     *time.Timer
+	c     chan time.Time
+}
+
+func (t *simulatedTimer) fire() {
+	select {
+	case t.c <- t.clock.Now():
+	default:
+	}
+}
+
+func (t *simulatedTimer) C() <-chan time.Time        { return t.c }
+func (t *simulatedTimer) Stop() bool                 { return t.t.Stop() }
+func (t *simulatedTimer) Reset(d time.Duration) bool { return t.t.Reset(t.clock.real(d)) }
+
+type simulatedTicker struct {
+	c    chan time.Time
+	done chan struct{}
+	once sync.Once
+}
+
+func (t *simulatedTicker) C() <-chan time.Time { return t.c }
+func (t *simulatedTicker) Stop()               { t.once.Do(func() { close(t.done) }) }
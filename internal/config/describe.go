@@ -0,0 +1,274 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Setting sources, in order of precedence.
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceEnvFile = ".env"
+	SourceDerived = "derived"
+	SourceDefault = "default"
+)
+
+const maskedSecret = "********"
+
+// Setting is one effective configuration value and where it came from.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// flagEnv maps CLI flags to the environment variable they override.
+var flagEnv = map[string]string{
+	"addr":           "CLICRON_ADDR",
+	"state-dir":      "CLICRON_STATE_DIR",
+	"log-level":      "CLICRON_LOG_LEVEL",
+	"use-utc":        "CLICRON_USE_UTC",
+	"run-log-keep":   "CLICRON_LOG_RETENTION",
+	"shutdown-grace": "CLICRON_SHUTDOWN_GRACE",
+	"max-concurrent": "CLICRON_MAX_CONCURRENT",
+	"instance":       "CLICRON_INSTANCE",
+	"ephemeral":      "CLICRON_EPHEMERAL",
+	"simulate-start": "CLICRON_SIMULATE_START",
+	"simulate-speed": "CLICRON_SIMULATE_SPEED",
+}
+
+// EnvFiles returns the .env files that were loaded, in load order.
+func (c *Config) EnvFiles() []string {
+	return c.envFiles
+}
+
+// Settings returns the effective configuration keyed by environment variable name,
+// with secrets masked.
+func (c *Config) Settings() []Setting {
+	list := func(items []string) string { return strings.Join(items, ",") }
+	settings := []Setting{
+		{Key: "CLICRON_INSTANCE", Value: c.Instance},
+		{Key: "CLICRON_ADDR", Value: c.Server.Addr},
+		{Key: "CLICRON_AUTH_TOKEN", Value: maskSecret(c.Server.AuthToken)},
+		{Key: "CLICRON_AUTH_QUERY_TOKEN", Value: strconv.FormatBool(c.Server.AuthQueryToken)},
+		{Key: "CLICRON_AUTH_USERNAME", Value: c.Server.AuthUsername},
+		{Key: "CLICRON_AUTH_PASSWORD", Value: maskSecret(c.Server.AuthPassword)},
+		{Key: "CLICRON_ALLOWED_IPS", Value: list(prefixList(c.Server.AllowedIPs))},
+		{Key: "CLICRON_MAX_BODY_BYTES", Value: strconv.Itoa(c.Server.MaxBodyBytes)},
+		{Key: "CLICRON_MAX_IMPORT_BYTES", Value: strconv.Itoa(c.Server.MaxImportBytes)},
+		{Key: "CLICRON_MAX_FILE_BYTES", Value: strconv.Itoa(c.Server.MaxFileBytes)},
+		{Key: "CLICRON_FILES_QUOTA_BYTES", Value: strconv.Itoa(c.Server.FilesQuotaBytes)},
+		{Key: "CLICRON_REQUEST_TIMEOUT", Value: c.Server.RequestTimeout.String()},
+		{Key: "CLICRON_PUBLIC_URL", Value: c.Server.PublicURL},
+		{Key: "CLICRON_OIDC_ISSUER", Value: c.OIDC.Issuer},
+		{Key: "CLICRON_OIDC_CLIENT_ID", Value: c.OIDC.ClientID},
+		{Key: "CLICRON_OIDC_CLIENT_SECRET", Value: maskSecret(c.OIDC.ClientSecret)},
+		{Key: "CLICRON_OIDC_REDIRECT_URL", Value: c.OIDC.RedirectURL},
+		{Key: "CLICRON_OIDC_SCOPES", Value: list(c.OIDC.Scopes)},
+		{Key: "CLICRON_OIDC_GROUPS_CLAIM", Value: c.OIDC.GroupsClaim},
+		{Key: "CLICRON_OIDC_ROLES", Value: list(roleList(c.OIDC.Roles))},
+		{Key: "CLICRON_OIDC_DEFAULT_ROLE", Value: c.OIDC.DefaultRole},
+		{Key: "CLICRON_STATE_DIR", Value: c.StateDir},
+		{Key: "CLICRON_EPHEMERAL", Value: strconv.FormatBool(c.Ephemeral)},
+		{Key: "CLICRON_USE_UTC", Value: strconv.FormatBool(c.UseUTC)},
+		{Key: "CLICRON_SHUTDOWN_GRACE", Value: c.ShutdownGrace.String()},
+		{Key: "CLICRON_UNIQUE_TASK_NAMES", Value: strconv.FormatBool(c.UniqueTaskNames)},
+		{Key: "CLICRON_ID_FORMAT", Value: c.IDFormat},
+		{Key: "CLICRON_LOG_LEVEL", Value: c.Log.Level},
+		{Key: "CLICRON_LOG_RETENTION", Value: strconv.Itoa(c.Log.Retention)},
+		{Key: "CLICRON_LOG_MAX_AGE_DAYS", Value: strconv.Itoa(c.Log.MaxAgeDays)},
+		{Key: "CLICRON_LOG_INDEX", Value: strconv.FormatBool(c.Log.Index)},
+		{Key: "CLICRON_OUTPUT_TAIL_BYTES", Value: strconv.Itoa(c.Log.OutputTail)},
+		{Key: "CLICRON_BARK_ENABLED", Value: strconv.FormatBool(c.Notification.Bark.Enabled)},
+		{Key: "CLICRON_BARK_URL", Value: maskURL(c.Notification.Bark.URL)},
+		{Key: "CLICRON_BARK_PROXY", Value: maskProxy(c.Notification.Bark.Proxy)},
+		{Key: "CLICRON_WEBHOOK_ENABLED", Value: strconv.FormatBool(c.Notification.Webhook.Enabled)},
+		{Key: "CLICRON_WEBHOOK_URL", Value: maskURL(c.Notification.Webhook.URL)},
+		{Key: "CLICRON_WEBHOOK_PROXY", Value: maskProxy(c.Notification.Webhook.Proxy)},
+		{Key: "CLICRON_EMAIL_ENABLED", Value: strconv.FormatBool(c.Notification.Email.Enabled)},
+		{Key: "CLICRON_EMAIL_URL", Value: maskURL(c.Notification.Email.URL)},
+		{Key: "CLICRON_NOTIFY_ON_SUCCESS", Value: strconv.FormatBool(c.Notification.NotifyOnSuccess)},
+		{Key: "CLICRON_NOTIFY_FAILURE_THROTTLE", Value: c.Notification.FailureThrottle.String()},
+		{Key: "CLICRON_NOTIFY_ESCALATE_AFTER", Value: strconv.Itoa(c.Notification.EscalateAfter)},
+		{Key: "CLICRON_NOTIFY_ESCALATION_CHANNELS", Value: list(c.Notification.EscalationChannels)},
+		{Key: "CLICRON_NOTIFY_ESCALATE_UNACKED", Value: c.Notification.EscalateUnacked.String()},
+		{Key: "CLICRON_SLOW_RUN_FACTOR", Value: strconv.FormatFloat(c.Notification.SlowRunFactor, 'g', -1, 64)},
+		{Key: "CLICRON_SKIP_ALERT_RATE", Value: strconv.FormatFloat(c.Notification.SkipAlertRate, 'g', -1, 64)},
+		{Key: "CLICRON_SKIP_ALERT_WINDOW", Value: c.Notification.SkipAlertWindow.String()},
+		{Key: "CLICRON_LOG_LINK_SECRET", Value: maskSecret(c.Notification.LogLinkSecret)},
+		{Key: "CLICRON_LOG_LINK_TTL", Value: c.Notification.LogLinkTTL.String()},
+		{Key: "CLICRON_REAPER_MODE", Value: c.Reaper.Mode},
+		{Key: "CLICRON_REAPER_INTERVAL", Value: c.Reaper.Interval.String()},
+		{Key: "CLICRON_SELF_MONITOR", Value: strconv.FormatBool(c.SelfMonitor.Enabled)},
+		{Key: "CLICRON_SELF_MONITOR_INTERVAL", Value: c.SelfMonitor.Interval.String()},
+		{Key: "CLICRON_DAILY_REPORT_AT", Value: c.Report.DailyAt},
+		{Key: "CLICRON_DAILY_REPORT_NOTIFY", Value: strconv.FormatBool(c.Report.DailyNotify)},
+		{Key: "CLICRON_WEEKLY_REPORT_AT", Value: c.Report.WeeklyAt},
+		{Key: "CLICRON_MAX_CONCURRENT", Value: strconv.Itoa(c.Scheduler.MaxConcurrent)},
+		{Key: "CLICRON_QUEUE_DEADLINE", Value: c.Scheduler.QueueDeadline.String()},
+		{Key: "CLICRON_LAG_WARN_THRESHOLD", Value: c.Scheduler.LagWarnThreshold.String()},
+		{Key: "CLICRON_MISFIRE_GRACE", Value: c.Scheduler.MisfireGrace.String()},
+		{Key: "CLICRON_MISFIRE_POLICY", Value: c.Scheduler.MisfirePolicy},
+		{Key: "CLICRON_CLOCK_SUSPEND_DISPATCH", Value: strconv.FormatBool(c.Scheduler.SuspendOnClockAnomaly)},
+		{Key: "CLICRON_SIMULATE_START", Value: simulateStart(c.Scheduler.SimulateStart)},
+		{Key: "CLICRON_SIMULATE_SPEED", Value: strconv.FormatFloat(c.Scheduler.SimulateSpeed, 'g', -1, 64)},
+		{Key: "CLICRON_SHELL_ENV_CACHE", Value: strconv.FormatBool(c.Shell.EnvCache)},
+		{Key: "CLICRON_SHELL_ENV_TTL", Value: c.Shell.EnvTTL.String()},
+		{Key: "CLICRON_TASKS_FILE", Value: c.TaskFile.Path},
+		{Key: "CLICRON_TASKS_FILE_INTERVAL", Value: c.TaskFile.Interval.String()},
+		{Key: "CLICRON_MCP_TOOLS", Value: list(c.MCP.EnabledTools)},
+		{Key: "CLICRON_MCP_DISABLED_TOOLS", Value: list(c.MCP.DisabledTools)},
+		{Key: "CLICRON_UPDATE_CHECK", Value: strconv.FormatBool(c.Update.Check)},
+		{Key: "CLICRON_UPDATE_CHECK_INTERVAL", Value: c.Update.Interval.String()},
+		{Key: "CLICRON_UPDATE_REPO", Value: c.Update.Repo},
+	}
+	for i := range settings {
+		settings[i].Source = c.source(settings[i].Key)
+	}
+	return settings
+}
+
+// source reports which layer supplied the env key's effective value.
+func (c *Config) source(key string) string {
+	for flagName, envKey := range flagEnv {
+		if envKey == key && c.flagsSet[flagName] {
+			return SourceFlag
+		}
+	}
+	if c.fromShell[key] {
+		return SourceEnv
+	}
+	if _, ok := os.LookupEnv(key); ok {
+		return SourceEnvFile
+	}
+	switch key {
+	case "CLICRON_ADDR":
+		if c.Instance != "" {
+			return SourceDerived
+		}
+	case "CLICRON_STATE_DIR":
+		return SourceDerived
+	}
+	return SourceDefault
+}
+
+func prefixList(prefixes []netip.Prefix) []string {
+	items := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		items[i] = prefix.String()
+	}
+	return items
+}
+
+// roleList formats OIDC role mappings as sorted "group=role" items.
+func roleList(roles map[string]string) []string {
+	items := make([]string, 0, len(roles))
+	for group, role := range roles {
+		items = append(items, group+"="+role)
+	}
+	sort.Strings(items)
+	return items
+}
+
+// simulateStart formats the simulation start, empty when not simulating.
+func simulateStart(start time.Time) string {
+	if start.IsZero() {
+		return ""
+	}
+	return start.Format(time.RFC3339)
+}
+
+// Warnings returns advisories about settings that are valid but likely not intended.
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if host, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		warnings = append(warnings, fmt.Sprintf("CLICRON_ADDR %q is not host:port: %v", c.Server.Addr, err))
+	} else if ip := net.ParseIP(host); host == "" || (ip != nil && !ip.IsLoopback()) {
+		if c.Server.AuthToken == "" && c.Server.AuthPassword == "" && c.OIDC.Issuer == "" {
+			warnings = append(warnings, fmt.Sprintf("CLICRON_ADDR %s accepts remote connections but CLICRON_AUTH_TOKEN is empty", c.Server.Addr))
+		}
+		if len(c.Server.AllowedIPs) == 0 {
+			warnings = append(warnings, fmt.Sprintf("CLICRON_ADDR %s accepts connections from any address; set CLICRON_ALLOWED_IPS to restrict clients", c.Server.Addr))
+		}
+	}
+	if c.Server.AuthToken != "" && c.Server.AuthQueryToken {
+		warnings = append(warnings, "CLICRON_AUTH_QUERY_TOKEN is true: a ?token= query parameter is accepted and leaks into logs and browser history")
+	}
+	if c.OIDC.Issuer != "" && len(c.OIDC.Roles) == 0 && c.OIDC.DefaultRole == "" {
+		warnings = append(warnings, "CLICRON_OIDC_ISSUER is set but neither CLICRON_OIDC_ROLES nor CLICRON_OIDC_DEFAULT_ROLE is; nobody can sign in through OIDC")
+	}
+	if c.Notification.Bark.Enabled && c.Notification.Bark.URL == "" {
+		warnings = append(warnings, "CLICRON_BARK_ENABLED is true but CLICRON_BARK_URL is empty")
+	}
+	if c.Notification.Webhook.Enabled && c.Notification.Webhook.URL == "" {
+		warnings = append(warnings, "CLICRON_WEBHOOK_ENABLED is true but CLICRON_WEBHOOK_URL is empty")
+	}
+	if c.Notification.Email.Enabled && c.Notification.Email.URL == "" {
+		warnings = append(warnings, "CLICRON_EMAIL_ENABLED is true but CLICRON_EMAIL_URL is empty")
+	}
+	if c.Report.WeeklyAt != "" && !c.Notification.Email.Enabled {
+		warnings = append(warnings, "CLICRON_WEEKLY_REPORT_AT is set but email notifications are not enabled in the environment; weekly reports are only stored unless email is enabled through the API")
+	}
+	if !c.Scheduler.SimulateStart.IsZero() && !c.Ephemeral {
+		warnings = append(warnings, "CLICRON_SIMULATE_START is set without CLICRON_EPHEMERAL: runs with simulated timestamps are written to the real state dir")
+	}
+	if c.Scheduler.MaxConcurrent < 0 {
+		warnings = append(warnings, "CLICRON_MAX_CONCURRENT is negative; treated as unlimited")
+	}
+	for _, key := range unknownEnvKeys() {
+		warnings = append(warnings, fmt.Sprintf("%s is not a recognised setting (typo?)", key))
+	}
+	return warnings
+}
+
+// unknownEnvKeys returns CLICRON_* variables in the environment that no setting reads.
+func unknownEnvKeys() []string {
+	known := make(map[string]bool)
+	for _, s := range (&Config{}).Settings() {
+		known[s.Key] = true
+	}
+	var unknown []string
+	for _, key := range envKeys() {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	return unknown
+}
+
+func maskSecret(val string) string {
+	if val == "" {
+		return ""
+	}
+	return maskedSecret
+}
+
+// maskURL keeps the scheme and host of a notification URL and hides the path, which
+// usually carries the device key or token.
+func maskURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return maskedSecret
+	}
+	return u.Scheme + "://" + u.Host + "/" + maskedSecret
+}
+
+// maskProxy hides the credentials of a proxy URL but keeps where it points.
+func maskProxy(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	return u.Scheme + "://" + maskedSecret + "@" + u.Host
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// AlertPolicy controls which run completions produce notifications.
+type AlertPolicy struct {
+	// NotifyOnSuccess sends a notification for every non-failing completion.
+	NotifyOnSuccess bool
+	// FailureThrottle sends at most one failure alert per task within this window; 0 disables throttling.
+	FailureThrottle time.Duration
+	// EscalateAfter sends an escalation alert (bypassing the throttle) when a task reaches
+	// this many consecutive failures; 0 disables escalation.
+	EscalateAfter int
+	// SlowRunFactor flags a succeeded run that took more than this many times the median
+	// of the task's recent runs; 0 disables the check.
+	SlowRunFactor float64
+}
+
+// alertKind classifies a notification about a finished run.
+type alertKind string
+
+const (
+	alertNone      alertKind = ""
+	alertFinished  alertKind = "finished"
+	alertFailed    alertKind = "failed"
+	alertEscalated alertKind = "escalated"
+	alertRecovered alertKind = "recovered"
+)
+
+// alerter applies an AlertPolicy using per-task throttle state.
+type alerter struct {
+	policy AlertPolicy
+
+	mu        sync.Mutex
+	lastAlert map[string]time.Time // taskID -> last failure alert
+}
+
+func newAlerter(policy AlertPolicy) *alerter {
+	return &alerter{policy: policy, lastAlert: make(map[string]time.Time)}
+}
+
+// decide picks the alert for a completed run. recent lists the task's most recent
+// terminal run statuses, newest first, starting with the run that just finished.
+func (a *alerter) decide(taskID string, recent []RunStatus, now time.Time) alertKind {
+	if len(recent) == 0 {
+		return alertNone
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !isFailureStatus(recent[0]) {
+		if recent[0] == RunStatusSucceeded && countConsecutiveFailures(recent[1:]) > 0 {
+			delete(a.lastAlert, taskID)
+			return alertRecovered
+		}
+		if a.policy.NotifyOnSuccess {
+			return alertFinished
+		}
+		return alertNone
+	}
+
+	failures := countConsecutiveFailures(recent)
+	if a.policy.EscalateAfter > 0 && failures == a.policy.EscalateAfter {
+		a.lastAlert[taskID] = now
+		return alertEscalated
+	}
+	if last, ok := a.lastAlert[taskID]; ok && a.policy.FailureThrottle > 0 && now.Sub(last) < a.policy.FailureThrottle {
+		return alertNone
+	}
+	a.lastAlert[taskID] = now
+	return alertFailed
+}
+
+// isFailureStatus reports whether a terminal status counts as a failed run.
+func isFailureStatus(status RunStatus) bool {
+	return status == RunStatusFailed || status == RunStatusTimedOut
+}
+
+// countConsecutiveFailures counts leading failures in statuses ordered newest first.
+func countConsecutiveFailures(statuses []RunStatus) int {
+	n := 0
+	for _, st := range statuses {
+		if !isFailureStatus(st) {
+			break
+		}
+		n++
+	}
+	return n
+}
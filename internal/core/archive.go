@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTaskArchived is returned for actions that are not allowed on archived tasks.
+var ErrTaskArchived = errors.New("task is archived")
+
+// ArchiveTask stops scheduling the task and marks it archived. Its runs and logs are kept,
+// but it no longer appears in default listings or stats.
+func (s *Scheduler) ArchiveTask(ctx context.Context, task *Task) error {
+	if task.Status == TaskStatusArchived {
+		return nil
+	}
+	s.unscheduleTask(task.ID)
+	if err := s.store.UpdateTaskStatus(ctx, task.ID, TaskStatusArchived); err != nil {
+		return err
+	}
+	if err := s.store.UpdateTaskNextRun(ctx, task.ID, nil); err != nil {
+		s.logger.Warn("clear next_run_at after archive", "task_id", task.ID, "err", err)
+	}
+	if err := s.store.SetTaskSkipNext(ctx, task.ID, nil); err != nil {
+		s.logger.Warn("clear skip_next_at after archive", "task_id", task.ID, "err", err)
+	}
+	task.Status = TaskStatusArchived
+	task.PauseUntil = nil
+	task.NextRunAt = nil
+	task.SkipNextAt = nil
+	return nil
+}
+
+// UnarchiveTask restores an archived task as paused, so it only runs again once resumed.
+func (s *Scheduler) UnarchiveTask(ctx context.Context, task *Task) error {
+	if task.Status != TaskStatusArchived {
+		return nil
+	}
+	if err := s.store.UpdateTaskStatus(ctx, task.ID, TaskStatusPaused); err != nil {
+		return err
+	}
+	task.Status = TaskStatusPaused
+	return nil
+}
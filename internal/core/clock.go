@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SkipReasonClockAnomaly marks runs skipped while dispatch is suspended after the system
+// clock jumped backwards.
+const SkipReasonClockAnomaly = "clock_anomaly"
+
+const (
+	// clockCheckInterval is how often the scheduler compares wall-clock and monotonic time.
+	clockCheckInterval = 15 * time.Second
+	// clockJumpThreshold is the wall-clock drift beyond which the scheduler assumes the
+	// process was suspended or the system clock was changed.
+	clockJumpThreshold = time.Minute
+	// clockDriftWarn is the per-check drift that is logged without being treated as a jump,
+	// e.g. aggressive NTP slewing or a misbehaving RTC.
+	clockDriftWarn = 2 * time.Second
+)
+
+// watchClock compares wall-clock and monotonic time to detect suspend/resume and clock changes.
+//
+// Timers run on the monotonic clock, which stops while the machine sleeps, so after a forward
+// jump the cron loop may wait far past the next slot; resyncing wakes it so due entries fire
+// once and the misfire policy applies. A backward jump would make slots that already ran come
+// due again; it is reported, and when SuspendOnClockAnomaly is set, triggers are skipped until
+// the wall clock is back past the latest time seen before the jump.
+func (s *Scheduler) watchClock(ctx context.Context) {
+	ticker := s.clock.NewTicker(clockCheckInterval)
+	defer ticker.Stop()
+	last := s.clock.Now()
+	highWater := last.Round(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			now := s.clock.Now()
+			monotonic := now.Sub(last)
+			wall := now.Round(0).Sub(last.Round(0))
+			last = now
+			drift := wall - monotonic
+
+			switch {
+			case drift >= clockJumpThreshold:
+				s.logger.Warn("clock jumped forward (system sleep/wake or clock change); resynchronizing schedules",
+					"drift", drift.Truncate(time.Second))
+				s.resyncAfterClockJump(ctx)
+			case drift <= -clockJumpThreshold:
+				s.handleBackwardJump(ctx, -drift, highWater)
+				s.resyncAfterClockJump(ctx)
+			case drift >= clockDriftWarn || drift <= -clockDriftWarn:
+				s.logger.Warn("system clock drift detected", "drift", drift.Truncate(time.Millisecond), "interval", clockCheckInterval)
+			}
+
+			if wallNow := now.Round(0); wallNow.After(highWater) {
+				highWater = wallNow
+			}
+		}
+	}
+}
+
+func (s *Scheduler) handleBackwardJump(ctx context.Context, jump time.Duration, resumeAt time.Time) {
+	s.logger.Error("system clock jumped backwards; scheduled runs may repeat",
+		"jump", jump.Truncate(time.Second), "suspend_dispatch", s.suspendOnClockAnomaly)
+	body := fmt.Sprintf("The system clock moved back by %s.", jump.Truncate(time.Second))
+	if s.suspendOnClockAnomaly {
+		s.clockHoldUntil.Store(resumeAt.UnixNano())
+		body += fmt.Sprintf("\nScheduled runs are suspended until %s.", resumeAt.In(s.location).Format(time.RFC3339))
+	} else {
+		body += "\nSchedule slots that already ran may run again."
+	}
+	if s.notifier == nil {
+		return
+	}
+	notifyCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := s.notifier.Send(notifyCtx, "[clicrontab] Clock Anomaly", body); err != nil {
+		s.logger.Error("failed to send clock anomaly notification", "err", err)
+	}
+}
+
+func (s *Scheduler) resyncAfterClockJump(ctx context.Context) {
+	if _, err := s.Sync(ctx); err != nil {
+		s.logger.Error("resync after clock jump", "err", err)
+	}
+}
+
+// isDispatchSuspended reports whether triggers at now fall inside a clock-anomaly hold.
+func (s *Scheduler) isDispatchSuspended(now time.Time) bool {
+	until := s.clockHoldUntil.Load()
+	return until != 0 && now.UnixNano() < until
+}
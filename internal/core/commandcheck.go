@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// commandCheckTimeout bounds the shell started to resolve a command.
+const commandCheckTimeout = 30 * time.Second
+
+// CommandCheck reports whether the program a task command starts can be found in the
+// environment runs of the task get.
+type CommandCheck struct {
+	// Executable is the first word of the command, after any VAR=value assignments.
+	Executable string
+	Found      bool
+	// Resolved is what the task shell resolves Executable to: a path, or a builtin,
+	// function or alias name.
+	Resolved   string
+	Shell      string
+	WorkingDir string
+	// Path is PATH as the task shell sees it.
+	Path string
+	// Problem explains why the command cannot start, when it cannot.
+	Problem string
+}
+
+// String summarizes the check for run error messages.
+func (c *CommandCheck) String() string {
+	parts := []string{"shell: " + c.Shell}
+	if c.WorkingDir != "" {
+		parts = append(parts, "working_dir: "+c.WorkingDir)
+	}
+	parts = append(parts, "PATH: "+c.Path)
+	return strings.Join(parts, ", ")
+}
+
+// CommandChecker is implemented by executors that can verify a task's command resolves.
+type CommandChecker interface {
+	CheckCommand(ctx context.Context, task *Task) (*CommandCheck, error)
+}
+
+// CheckCommand resolves the first word of the task's command with the same shell,
+// environment and working directory its runs use.
+func (e *CommandExecutor) CheckCommand(ctx context.Context, task *Task) (*CommandCheck, error) {
+	if task.ExecutorName() != ExecutorShell {
+		return nil, ErrNotShellTask
+	}
+	command := task.Command
+	if task.ScriptID != nil {
+		interpreter, err := e.scriptInterpreter(ctx, *task.ScriptID)
+		if err != nil {
+			return nil, err
+		}
+		command = interpreter
+	}
+	check := &CommandCheck{Executable: commandExecutable(command)}
+	// Working directories with placeholders are created by the run, so the check runs
+	// in the daemon's directory instead
+	if task.WorkingDir != nil && *task.WorkingDir != "" && !HasPlaceholders(*task.WorkingDir) {
+		check.WorkingDir = *task.WorkingDir
+		if info, err := os.Stat(check.WorkingDir); err != nil || !info.IsDir() {
+			check.Problem = "working directory does not exist: " + check.WorkingDir
+		}
+	}
+	if check.Executable == "" {
+		check.Problem = "command is empty"
+		return check, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, commandCheckTimeout)
+	defer cancel()
+	// The marker separates anything the dotfiles print from the lookup's output
+	var script string
+	if runtime.GOOS == "windows" {
+		script = "echo " + shellEnvMarker + "& echo %PATH%& where " + check.Executable
+	} else {
+		script = `printf '%s\n%s\n' ` + shellEnvMarker + ` "$PATH"; command -v ` + shellQuote(check.Executable)
+	}
+	cmd := e.taskCommand(ctx, script)
+	if check.Problem == "" {
+		cmd.Dir = check.WorkingDir
+	}
+	check.Shell = strings.Join(cmd.Args[:len(cmd.Args)-1], " ")
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("run %s: %w", check.Shell, err)
+	}
+	_, lookup, ok := strings.Cut(strings.ReplaceAll(string(out), "\r\n", "\n"), shellEnvMarker+"\n")
+	if !ok {
+		return nil, fmt.Errorf("%s printed no lookup result", check.Shell)
+	}
+	lines := strings.Split(strings.TrimRight(lookup, "\n"), "\n")
+	check.Path = lines[0]
+	if len(lines) > 1 && err == nil {
+		check.Found = true
+		check.Resolved = strings.TrimSpace(lines[1])
+	} else if check.Problem == "" {
+		check.Problem = "executable not found in the task shell's PATH: " + check.Executable
+	}
+	return check, nil
+}
+
+// commandExecutable returns the first word of command, skipping leading VAR=value
+// assignments and unquoting it.
+func commandExecutable(command string) string {
+	for _, word := range strings.Fields(command) {
+		if name, _, ok := strings.Cut(word, "="); ok && name != "" && !strings.ContainsAny(name, `/\'"`) {
+			continue
+		}
+		return strings.Trim(word, `'"`)
+	}
+	return ""
+}
+
+// shellQuote quotes s as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isCommandNotFound reports whether a run failed because its program could not be
+// found: cmd.Start reporting a missing executable, or a shell exiting with 127.
+func isCommandNotFound(err error) bool {
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+		return true
+	}
+	var exitErr *exec.ExitError
+	return runtime.GOOS != "windows" && errors.As(err, &exitErr) && exitErr.ExitCode() == 127
+}
+
+// commandNotFoundDetail describes where the task's command was looked up, for run
+// errors caused by a missing executable.
+func (e *CommandExecutor) commandNotFoundDetail(ctx context.Context, task *Task) string {
+	check, err := e.CheckCommand(ctx, task)
+	if err != nil {
+		e.logger.Warn("check command after run failure", "task_id", task.ID, "err", err)
+		return ""
+	}
+	detail := check.String()
+	if check.Problem != "" {
+		detail = check.Problem + "; " + detail
+	}
+	return " (" + detail + ")"
+}
@@ -12,6 +12,9 @@ var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month
 
 // ParseCron ensures the expression is a valid 5-field cron definition and returns the underlying schedule.
 func ParseCron(expr string) (cron.Schedule, error) {
+	if strings.TrimSpace(expr) == "@reboot" {
+		return nil, fmt.Errorf("@reboot is not supported; keep a regular schedule and set run_on_start instead")
+	}
 	if strings.HasPrefix(strings.TrimSpace(expr), "@") {
 		return nil, fmt.Errorf("only 5-field cron expressions are supported")
 	}
@@ -22,6 +25,37 @@ func ParseCron(expr string) (cron.Schedule, error) {
 	return schedule, nil
 }
 
+// intervalSampleSize is how many upcoming occurrences ShortestInterval inspects; enough to
+// cover a day of quarter-hourly runs or several weeks of daily ones.
+const intervalSampleSize = 100
+
+// ShortestInterval returns the smallest gap between consecutive upcoming occurrences of the
+// schedule after base.
+func ShortestInterval(schedule cron.Schedule, base time.Time) time.Duration {
+	times := NextOccurrences(schedule, base, intervalSampleSize)
+	var shortest time.Duration
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap > 0 && (shortest == 0 || gap < shortest) {
+			shortest = gap
+		}
+	}
+	return shortest
+}
+
+// TimeoutExceedsInterval reports whether a timeout is longer than the shortest gap between the
+// schedule's runs, which makes overlapping triggers (and therefore skipped runs) inevitable
+// whenever a run uses most of its timeout. It also returns that shortest gap.
+func TimeoutExceedsInterval(schedule cron.Schedule, timeoutSeconds *int, base time.Time) (time.Duration, bool) {
+	if timeoutSeconds == nil || *timeoutSeconds <= 0 {
+		return 0, false
+	}
+	interval := ShortestInterval(schedule, base)
+	if interval <= 0 {
+		return 0, false
+	}
+	return interval, time.Duration(*timeoutSeconds)*time.Second > interval
+}
+
 // NextOccurrences returns the next n execution times from a base time.
 func NextOccurrences(schedule cron.Schedule, base time.Time, n int) []time.Time {
 	times := make([]time.Time, 0, n)
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CronSpec describes a schedule as lists of values instead of a cron expression. A task
+// fires at every combination of the listed values. Minutes is required; an empty list
+// in any other field means every value.
+type CronSpec struct {
+	Minutes []int `json:"minutes"`
+	Hours   []int `json:"hours,omitempty"`
+	// Days are days of the month, 1-31.
+	Days   []int `json:"days,omitempty"`
+	Months []int `json:"months,omitempty"`
+	// Weekdays run from 0 (Sunday) to 6 (Saturday).
+	Weekdays []int `json:"weekdays,omitempty"`
+}
+
+// cronFields lists the fields of a cron expression in order with their value ranges.
+var cronFields = []struct {
+	name     string
+	min, max int
+	values   func(*CronSpec) []int
+}{
+	{"minutes", 0, 59, func(s *CronSpec) []int { return s.Minutes }},
+	{"hours", 0, 23, func(s *CronSpec) []int { return s.Hours }},
+	{"days", 1, 31, func(s *CronSpec) []int { return s.Days }},
+	{"months", 1, 12, func(s *CronSpec) []int { return s.Months }},
+	{"weekdays", 0, 6, func(s *CronSpec) []int { return s.Weekdays }},
+}
+
+// BuildCron converts spec into a validated five-field cron expression, writing runs of
+// consecutive values as ranges and evenly spaced values covering a field as steps, e.g.
+// minutes 0,15,30,45 as "*/15".
+func BuildCron(spec CronSpec) (string, error) {
+	if len(spec.Minutes) == 0 {
+		return "", fmt.Errorf("minutes is required")
+	}
+	parts := make([]string, 0, len(cronFields))
+	for _, field := range cronFields {
+		values := field.values(&spec)
+		for _, v := range values {
+			if v < field.min || v > field.max {
+				return "", fmt.Errorf("%s: %d is out of range %d-%d", field.name, v, field.min, field.max)
+			}
+		}
+		parts = append(parts, cronFieldExpr(values, field.min, field.max))
+	}
+	expr := strings.Join(parts, " ")
+	if _, err := ParseCron(expr); err != nil {
+		return "", err
+	}
+	return expr, nil
+}
+
+// cronFieldExpr writes one field's values, which are within min-max, as cron syntax.
+func cronFieldExpr(values []int, min, max int) string {
+	seen := make(map[int]bool, len(values))
+	var sorted []int
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			sorted = append(sorted, v)
+		}
+	}
+	sort.Ints(sorted)
+	if len(sorted) == 0 || len(sorted) == max-min+1 {
+		return "*"
+	}
+	if len(sorted) > 1 && sorted[0] == min {
+		step := sorted[1] - sorted[0]
+		even := step > 1 && sorted[len(sorted)-1]+step > max
+		for i := 1; i < len(sorted); i++ {
+			if sorted[i]-sorted[i-1] != step {
+				even = false
+				break
+			}
+		}
+		if even {
+			return "*/" + strconv.Itoa(step)
+		}
+	}
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		switch {
+		case j-i >= 2:
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		case j > i:
+			parts = append(parts, strconv.Itoa(sorted[i]), strconv.Itoa(sorted[j]))
+		default:
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
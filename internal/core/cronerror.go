@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// markCronInvalid moves an active task whose stored cron expression no longer parses
+// (e.g. after a schema edit or a restore from an older format) to the error status, so
+// listings show why it never runs, and notifies. Fixing the expression reactivates it.
+func (s *Scheduler) markCronInvalid(ctx context.Context, task *Task, cause error) {
+	if err := s.store.UpdateTaskStatus(ctx, task.ID, TaskStatusError); err != nil {
+		s.logger.Error("mark task with invalid cron expression", "task_id", task.ID, "err", err)
+		return
+	}
+	if err := s.store.UpdateTaskNextRun(ctx, task.ID, nil); err != nil {
+		s.logger.Warn("clear next_run_at of task with invalid cron expression", "task_id", task.ID, "err", err)
+	}
+	task.Status = TaskStatusError
+	task.NextRunAt = nil
+	s.logger.Error("task has an invalid cron expression; it will not run until fixed",
+		"task_id", task.ID, "cron", task.Cron, "err", cause)
+
+	if s.notifier == nil {
+		return
+	}
+	taskName := task.ID
+	if task.Name != nil {
+		taskName = *task.Name
+	}
+	title := fmt.Sprintf("[%s] Invalid Cron Expression", taskName)
+	body := fmt.Sprintf("Task %s has a cron expression that cannot be parsed: %q (%v).\nIt will not run until the expression is fixed.",
+		task.ID, task.Cron, cause)
+	notifyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := s.notifier.Send(notifyCtx, title, body); err != nil {
+		s.logger.Error("failed to send invalid cron notification", "task_id", task.ID, "err", err)
+	}
+}
+
+// reactivateIfCronValid returns a task in the error status to active once its cron
+// expression parses again, e.g. after it was fixed directly in the database. It reports
+// whether the task was reactivated.
+func (s *Scheduler) reactivateIfCronValid(ctx context.Context, task *Task) bool {
+	if _, err := ParseCron(task.Cron); err != nil {
+		return false
+	}
+	if err := s.store.UpdateTaskStatus(ctx, task.ID, TaskStatusActive); err != nil {
+		s.logger.Error("reactivate task with fixed cron expression", "task_id", task.ID, "err", err)
+		return false
+	}
+	task.Status = TaskStatusActive
+	s.logger.Info("cron expression is valid again; task reactivated", "task_id", task.ID, "cron", task.Cron)
+	return true
+}
@@ -0,0 +1,234 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// dispatchPollInterval is how often idle workers look at the queue without being woken,
+// and how often queued runs are checked against the queue deadline.
+const dispatchPollInterval = 5 * time.Second
+
+// QueueEntry holds the dispatch attributes of an enqueued run.
+type QueueEntry struct {
+	// Priority orders waiting runs; higher values are claimed first.
+	Priority int
+	// ExpiresAt fails the run with reason "expired" if no worker claimed it by then; nil never expires.
+	ExpiresAt *time.Time
+}
+
+// queuePriority ranks runs a user is waiting on ahead of background triggers.
+func queuePriority(trigger TriggerType) int {
+	if trigger == TriggerManual {
+		return 1
+	}
+	return 0
+}
+
+// enqueue stores the run as queued in the run queue and wakes a worker. Triggers only
+// enqueue; the dispatcher decides when the run executes. task is kept in memory so the
+// worker runs exactly the configuration the trigger saw, including one-off overrides.
+func (s *Scheduler) enqueue(ctx context.Context, task *Task, run *Run) error {
+	entry := QueueEntry{Priority: queuePriority(run.TriggerType)}
+	if s.queueDeadline > 0 {
+		// A run queued late (e.g. a misfired slot run once) still gets the full window
+		from := run.ScheduledAt
+		if now := s.clock.Now().UTC(); now.After(from) {
+			from = now
+		}
+		expires := from.Add(s.queueDeadline)
+		entry.ExpiresAt = &expires
+	}
+
+	s.markTaskRunning(task.ID, true)
+	s.pending.Store(run.ID, task)
+	if err := s.runs.Enqueue(ctx, run, entry); err != nil {
+		s.pending.Delete(run.ID)
+		s.markTaskRunning(task.ID, false)
+		return err
+	}
+	s.wakeWorker()
+	return nil
+}
+
+func (s *Scheduler) wakeWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// startDispatcher starts the worker pool and the expiry sweep. With a worker count each
+// worker executes one run at a time; without one a single worker claims runs and starts
+// each in its own goroutine.
+func (s *Scheduler) startDispatcher(ctx context.Context) {
+	ctx, s.stopDispatch = context.WithCancel(ctx)
+	if s.workers > 0 {
+		for i := 0; i < s.workers; i++ {
+			go s.worker(ctx, false)
+		}
+	} else {
+		go s.worker(ctx, true)
+	}
+	go s.sweepQueue(ctx)
+}
+
+func (s *Scheduler) worker(ctx context.Context, detach bool) {
+	poll := s.clock.NewTicker(dispatchPollInterval)
+	defer poll.Stop()
+	for {
+		run, err := s.store.ClaimQueuedRun(ctx, s.clock.Now())
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error("claim queued run", "err", err)
+		}
+		if run == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.wake:
+			case <-poll.C():
+			}
+			continue
+		}
+		// More runs may be waiting; let another idle worker look too
+		s.wakeWorker()
+		// Registered before any goroutine starts so Shutdown always waits for it
+		runCtx, release := s.active.start(ctx, run.ID)
+		if detach {
+			go s.execute(runCtx, run, release)
+		} else {
+			s.execute(runCtx, run, release)
+		}
+	}
+}
+
+// execute runs a claimed run in its registered context and removes its queue entry when
+// it ends; release unregisters the run.
+func (s *Scheduler) execute(runCtx context.Context, run *Run, release func()) {
+	defer release()
+	ctx := context.WithoutCancel(runCtx)
+	defer func() {
+		if _, err := s.store.RemoveQueuedRun(ctx, run.ID, false); err != nil {
+			s.logger.Warn("remove queue entry", "run_id", run.ID, "err", err)
+		}
+	}()
+	task, err := s.pendingTask(ctx, run)
+	if err != nil {
+		s.logger.Error("load task for queued run", "task_id", run.TaskID, "run_id", run.ID, "err", err)
+		errMsg := "task could not be loaded: " + err.Error()
+		if err := s.runs.Finish(ctx, run.ID, RunStatusFailed, s.clock.Now(), nil, &errMsg); err != nil {
+			s.logger.Error("fail queued run", "run_id", run.ID, "err", err)
+		}
+		return
+	}
+	defer s.markTaskRunning(task.ID, false)
+
+	if lag := s.clock.Now().Sub(run.ScheduledAt); s.lagWarn > 0 && lag > s.lagWarn {
+		s.logger.Warn("run started late; the machine may be oversubscribed",
+			"task_id", task.ID, "run_id", run.ID, "lag", lag.Truncate(time.Millisecond), "threshold", s.lagWarn)
+	}
+
+	if err := s.executor.Execute(runCtx, task, run); err != nil {
+		s.logger.Error("execute task", "task_id", task.ID, "run_id", run.ID, "err", err)
+
+		// If execution failed due to context cancellation (e.g., system shutdown),
+		// try to save the run status using an independent context with a short timeout.
+		// This ensures the database state reflects that the run was canceled.
+		if errors.Is(err, context.Canceled) {
+			saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			errMsg := cancelMessage(runCtx)
+			updateErr := s.runs.Finish(saveCtx, run.ID, RunStatusCanceled, s.clock.Now().UTC(), nil, &errMsg)
+			switch {
+			case errors.Is(updateErr, ErrInvalidRunTransition):
+				// The executor already recorded how the run ended
+			case updateErr != nil:
+				s.logger.Error("failed to mark run as canceled", "run_id", run.ID, "err", updateErr)
+			default:
+				s.logger.Info("marked run as canceled", "run_id", run.ID, "reason", errMsg)
+			}
+		}
+	}
+
+	s.checkAutoPause(task)
+	s.store.QueueRunLogIndex(run.ID, task.ID)
+
+	// Clean up old run logs (best effort, don't block on errors)
+	if err := s.store.PruneOldRunLogs(s.ctxOrBackground(), task.ID); err != nil {
+		s.logger.Warn("prune run logs", "task_id", task.ID, "err", err)
+	}
+}
+
+// pendingTask returns the task the run was enqueued with, or the stored task for runs
+// enqueued elsewhere.
+func (s *Scheduler) pendingTask(ctx context.Context, run *Run) (*Task, error) {
+	if value, ok := s.pending.LoadAndDelete(run.ID); ok {
+		return value.(*Task), nil
+	}
+	task, err := s.store.GetTask(ctx, run.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	s.markTaskRunning(task.ID, true)
+	return task, nil
+}
+
+// dropQueued removes a run that no worker has claimed yet and forgets its pending task.
+// It reports false when the run was not waiting (already claimed or never queued).
+func (s *Scheduler) dropQueued(ctx context.Context, runID string) bool {
+	removed, err := s.store.RemoveQueuedRun(ctx, runID, true)
+	if err != nil {
+		s.logger.Error("remove queued run", "run_id", runID, "err", err)
+		return false
+	}
+	if !removed {
+		return false
+	}
+	if value, ok := s.pending.LoadAndDelete(runID); ok {
+		s.markTaskRunning(value.(*Task).ID, false)
+	}
+	return true
+}
+
+// cancelQueued cancels a run still waiting for a worker.
+func (s *Scheduler) cancelQueued(runID string) bool {
+	ctx := context.WithoutCancel(s.ctxOrBackground())
+	if !s.dropQueued(ctx, runID) {
+		return false
+	}
+	errMsg := "run canceled: " + ErrRunCanceled.Error()
+	if err := s.runs.Finish(ctx, runID, RunStatusCanceled, s.clock.Now(), nil, &errMsg); err != nil {
+		s.logger.Error("mark queued run canceled", "run_id", runID, "err", err)
+	}
+	return true
+}
+
+// sweepQueue fails queued runs that passed their expiry without being claimed.
+func (s *Scheduler) sweepQueue(ctx context.Context) {
+	ticker := s.clock.NewTicker(dispatchPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+		ids, err := s.store.ExpiredQueuedRuns(ctx, s.clock.Now())
+		if err != nil {
+			s.logger.Warn("list expired queued runs", "err", err)
+			continue
+		}
+		for _, runID := range ids {
+			if !s.dropQueued(ctx, runID) {
+				continue
+			}
+			errMsg := "run expired: could not start within " + s.queueDeadline.String() + " of its scheduled time"
+			s.logger.Warn("queued run expired", "run_id", runID, "deadline", s.queueDeadline)
+			if err := s.runs.Expire(ctx, runID, s.clock.Now(), errMsg); err != nil {
+				s.logger.Error("mark run expired", "run_id", runID, "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"clicrontab/internal/notify"
+)
+
+const (
+	// durationBaselineRuns is how many recent succeeded runs make up a task's baseline.
+	durationBaselineRuns = 20
+	// durationMinSamples is the fewest baseline runs a run is compared against.
+	durationMinSamples = 5
+	// slowRunMinExcess keeps short tasks quiet: a run must also be this much slower than
+	// the median, so a 2s task taking 7s is not flagged.
+	slowRunMinExcess = 10 * time.Second
+)
+
+// checkDuration flags a succeeded run that took more than the policy's SlowRunFactor
+// times the median of the task's recent succeeded runs: it stores a warning on the run
+// and sends a "Slow Run" notification, catching slowdowns before they become timeouts.
+func (e *CommandExecutor) checkDuration(ctx context.Context, task *Task, run *Run, took time.Duration) {
+	factor := e.alerts.policy.SlowRunFactor
+	if factor <= 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	baseline, err := e.store.RecentRunDurations(ctx, task.ID, run.ID, durationBaselineRuns)
+	if err != nil {
+		e.logger.Warn("load run duration baseline", "task_id", task.ID, "err", err)
+		return
+	}
+	if len(baseline) < durationMinSamples {
+		return
+	}
+	median := medianDuration(baseline)
+	if float64(took) <= factor*float64(median) || took-median < slowRunMinExcess {
+		return
+	}
+
+	detail := fmt.Sprintf("took %s, %.1fx the median of %s over the last %d succeeded runs",
+		took.Round(time.Second), float64(took)/float64(median), median.Round(time.Second), len(baseline))
+	e.logger.Warn("run took much longer than usual", "task_id", task.ID, "run_id", run.ID,
+		"took", took.Round(time.Second), "median", median.Round(time.Second))
+	if err := e.store.SetRunWarning(ctx, run.ID, "slow run: "+detail); err != nil {
+		e.logger.Warn("record run warning", "run_id", run.ID, "err", err)
+	}
+	if e.notifier == nil {
+		return
+	}
+	if window := inMaintenance(ctx, e.store, e.logger, task.ID, e.clock.Now()); window != nil {
+		e.logger.Info("slow run notification suppressed by maintenance window", "task_id", task.ID, "run_id", run.ID, "window_id", window.ID)
+		return
+	}
+
+	taskName := task.ID
+	if task.Name != nil {
+		taskName = *task.Name
+	}
+	msg := notify.Message{
+		Title:  fmt.Sprintf("[%s] Slow Run", taskName),
+		Body:   fmt.Sprintf("Run ID: %s\nThe run succeeded but %s", run.ID, detail),
+		TaskID: task.ID,
+		RunID:  run.ID,
+		Status: string(RunStatusSucceeded),
+	}
+	if e.logLinks != nil {
+		msg.LogURL = e.logLinks.URL(run.ID, e.clock.Now())
+	}
+	notifyCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := notify.SendMessage(notifyCtx, e.notifier, msg); err != nil {
+		e.logger.Error("failed to send slow run notification", "err", err)
+	}
+}
+
+// medianDuration returns the median of durations, which must not be empty.
+func medianDuration(durations []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
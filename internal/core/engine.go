@@ -0,0 +1,175 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"clicrontab/internal/clock"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronIdleWait is how long the engine sleeps when nothing is scheduled; any change wakes it.
+const cronIdleWait = 100000 * time.Hour
+
+// cronEngine fires cron entries on time as read from a clock.Clock. It follows the
+// semantics of robfig/cron's Cron, whose schedules and entries it uses, but that reads
+// time.Now directly, so a fake or simulated clock could not drive it.
+type cronEngine struct {
+	clock    clock.Clock
+	location *time.Location
+
+	mu      sync.Mutex
+	entries []*cron.Entry
+	lastID  cron.EntryID
+	stop    chan struct{} // nil while the engine is stopped
+	changed chan struct{} // wakes the loop to recompute its timer
+	jobs    sync.WaitGroup
+}
+
+func newCronEngine(c clock.Clock, location *time.Location) *cronEngine {
+	return &cronEngine{clock: c, location: location, changed: make(chan struct{}, 1)}
+}
+
+func (e *cronEngine) now() time.Time {
+	return e.clock.Now().In(e.location)
+}
+
+// Schedule adds a job run on the schedule and returns its entry ID.
+func (e *cronEngine) Schedule(schedule cron.Schedule, job cron.Job) cron.EntryID {
+	e.mu.Lock()
+	e.lastID++
+	entry := &cron.Entry{ID: e.lastID, Schedule: schedule, Job: job, WrappedJob: job}
+	if e.stop != nil {
+		entry.Next = schedule.Next(e.now())
+	}
+	e.entries = append(e.entries, entry)
+	e.mu.Unlock()
+	e.wake()
+	return entry.ID
+}
+
+// Remove deletes an entry; it is a no-op for unknown IDs.
+func (e *cronEngine) Remove(id cron.EntryID) {
+	e.mu.Lock()
+	for i, entry := range e.entries {
+		if entry.ID == id {
+			e.entries = append(e.entries[:i], e.entries[i+1:]...)
+			break
+		}
+	}
+	e.mu.Unlock()
+	e.wake()
+}
+
+// Entry returns a copy of the entry, or the zero Entry for unknown IDs.
+func (e *cronEngine) Entry(id cron.EntryID) cron.Entry {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, entry := range e.entries {
+		if entry.ID == id {
+			return *entry
+		}
+	}
+	return cron.Entry{}
+}
+
+// Entries returns copies of all entries.
+func (e *cronEngine) Entries() []cron.Entry {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	entries := make([]cron.Entry, 0, len(e.entries))
+	for _, entry := range e.entries {
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+// Start places every entry and starts firing them; it is a no-op when already started.
+func (e *cronEngine) Start() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.stop != nil {
+		return
+	}
+	now := e.now()
+	for _, entry := range e.entries {
+		entry.Next = entry.Schedule.Next(now)
+	}
+	e.stop = make(chan struct{})
+	go e.run(e.stop)
+}
+
+// Stop stops firing entries. The returned context is done once jobs already fired return.
+func (e *cronEngine) Stop() context.Context {
+	e.mu.Lock()
+	if e.stop != nil {
+		close(e.stop)
+		e.stop = nil
+	}
+	e.mu.Unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		e.jobs.Wait()
+		cancel()
+	}()
+	return ctx
+}
+
+func (e *cronEngine) wake() {
+	select {
+	case e.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (e *cronEngine) run(stop <-chan struct{}) {
+	for {
+		timer := e.clock.NewTimer(e.untilNext())
+		select {
+		case <-timer.C():
+			e.fireDue(e.now())
+		case <-e.changed:
+			timer.Stop()
+		case <-stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// untilNext returns how long to wait for the soonest entry.
+func (e *cronEngine) untilNext() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var next time.Time
+	for _, entry := range e.entries {
+		if !entry.Next.IsZero() && (next.IsZero() || entry.Next.Before(next)) {
+			next = entry.Next
+		}
+	}
+	if next.IsZero() {
+		return cronIdleWait
+	}
+	return next.Sub(e.now())
+}
+
+// fireDue runs, each in its own goroutine, the jobs of entries due at now and places
+// them at their next occurrence. An entry several occurrences behind fires once.
+func (e *cronEngine) fireDue(now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, entry := range e.entries {
+		if entry.Next.IsZero() || entry.Next.After(now) {
+			continue
+		}
+		entry.Prev = entry.Next
+		entry.Next = entry.Schedule.Next(now)
+		e.jobs.Add(1)
+		go func(job cron.Job) {
+			defer e.jobs.Done()
+			job.Run()
+		}(entry.WrappedJob)
+	}
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"clicrontab/internal/clock"
+
+	"github.com/robfig/cron/v3"
+)
+
+// fireTimes drives a cron engine with a fake clock from start to end in steps, and
+// returns the scheduled times its entry fired at.
+func fireTimes(t *testing.T, loc *time.Location, spec string, start, end time.Time, step time.Duration) []time.Time {
+	t.Helper()
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		t.Fatalf("ParseCron(%q) error = %v", spec, err)
+	}
+	fake := clock.NewFake(start)
+	engine := newCronEngine(fake, loc)
+	var (
+		mu    sync.Mutex
+		fired []time.Time
+		id    cron.EntryID
+	)
+	id = engine.Schedule(schedule, cron.FuncJob(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		fired = append(fired, engine.Entry(id).Prev)
+	}))
+	engine.Start()
+	for now := start; now.Before(end); now = now.Add(step) {
+		// Wait for the engine to arm its timer and for the jobs the last step fired,
+		// which read their entry before the next step moves it on
+		fake.BlockUntil(1)
+		engine.jobs.Wait()
+		fake.Set(now.Add(step))
+	}
+	fake.BlockUntil(1)
+	<-engine.Stop().Done()
+	mu.Lock()
+	defer mu.Unlock()
+	return fired
+}
+
+func TestCronEngineFakeClock(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	tests := []struct {
+		name       string
+		spec       string
+		start, end time.Time
+		step       time.Duration
+		want       []time.Time
+	}{
+		{
+			// 2026-03-08 02:00 EST jumps to 03:00 EDT, so 02:30 does not exist that day
+			name:  "skipped by spring forward",
+			spec:  "30 2 * * *",
+			start: time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			end:   time.Date(2026, 3, 10, 0, 0, 0, 0, newYork),
+			step:  15 * time.Minute,
+			want:  []time.Time{time.Date(2026, 3, 9, 6, 30, 0, 0, time.UTC)},
+		},
+		{
+			// 2026-11-01 02:00 EDT falls back to 01:00 EST, so 01:30 happens twice
+			name:  "repeated by fall back",
+			spec:  "30 1 * * *",
+			start: time.Date(2026, 10, 31, 12, 0, 0, 0, newYork),
+			end:   time.Date(2026, 11, 2, 0, 0, 0, 0, newYork),
+			step:  15 * time.Minute,
+			want:  []time.Time{time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC)},
+		},
+		{
+			name:  "leap day",
+			spec:  "0 12 29 2 *",
+			start: time.Date(2026, 1, 1, 0, 0, 0, 0, newYork),
+			end:   time.Date(2029, 1, 1, 0, 0, 0, 0, newYork),
+			step:  6 * time.Hour,
+			want:  []time.Time{time.Date(2028, 2, 29, 17, 0, 0, 0, time.UTC)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fireTimes(t, newYork, tt.spec, tt.start, tt.end, tt.step)
+			if len(got) != len(tt.want) {
+				t.Fatalf("fired at %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("firing %d at %v, want %v", i, got[i].UTC(), tt.want[i])
+				}
+			}
+		})
+	}
+}
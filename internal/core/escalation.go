@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"clicrontab/internal/clock"
+	"clicrontab/internal/notify"
+)
+
+// escalationCheckInterval is how often unacknowledged failures are looked for.
+const escalationCheckInterval = time.Minute
+
+// EscalationStore reads and updates what the escalation monitor needs.
+type EscalationStore interface {
+	// ActiveMaintenanceWindow returns a window covering the task at the given time,
+	// or nil when there is none.
+	ActiveMaintenanceWindow(ctx context.Context, taskID string, at time.Time) (*MaintenanceWindow, error)
+	// UnacknowledgedIncidents returns the open incidents of unarchived tasks opened at
+	// or before openedBefore that were not escalated yet.
+	UnacknowledgedIncidents(ctx context.Context, openedBefore time.Time) ([]*Incident, error)
+	// MarkIncidentEscalated records the escalation unless the incident was already
+	// escalated, reporting whether it did.
+	MarkIncidentEscalated(ctx context.Context, id string, at time.Time) (bool, error)
+}
+
+// EscalationMonitor escalates incidents that stay unacknowledged for longer than
+// a deadline, reaching the channels reserved for escalations.
+type EscalationMonitor struct {
+	store    EscalationStore
+	notifier notify.Notifier
+	logger   *slog.Logger
+	after    time.Duration
+	clock    clock.Clock
+}
+
+// NewEscalationMonitor constructs a monitor escalating failures left unacknowledged
+// for after.
+func NewEscalationMonitor(store EscalationStore, notifier notify.Notifier, logger *slog.Logger, after time.Duration) *EscalationMonitor {
+	return &EscalationMonitor{store: store, notifier: notifier, logger: logger, after: after, clock: clock.System}
+}
+
+// SetClock makes the deadline follow c instead of the system clock.
+func (m *EscalationMonitor) SetClock(c clock.Clock) {
+	m.clock = clock.OrSystem(c)
+}
+
+// Run checks for overdue failures every escalationCheckInterval until ctx is done.
+func (m *EscalationMonitor) Run(ctx context.Context) {
+	ticker := m.clock.NewTicker(escalationCheckInterval)
+	defer ticker.Stop()
+	for {
+		m.check(ctx, m.clock.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+func (m *EscalationMonitor) check(ctx context.Context, now time.Time) {
+	incidents, err := m.store.UnacknowledgedIncidents(ctx, now.Add(-m.after))
+	if err != nil {
+		m.logger.Warn("list unacknowledged incidents", "err", err)
+		return
+	}
+	for _, incident := range incidents {
+		if inMaintenance(ctx, m.store, m.logger, incident.TaskID, now) != nil {
+			// Checked again once the window ends, when a failure that outlasts it escalates
+			continue
+		}
+		marked, err := m.store.MarkIncidentEscalated(ctx, incident.ID, now.UTC())
+		if err != nil {
+			m.logger.Warn("mark incident escalated", "incident_id", incident.ID, "err", err)
+			continue
+		}
+		if !marked {
+			continue
+		}
+		unacked := now.Sub(incident.OpenedAt).Round(time.Minute)
+		m.logger.Warn("incident unacknowledged; escalating", "task_id", incident.TaskID, "incident_id", incident.ID, "failures", incident.Failures, "unacknowledged_for", unacked)
+		notifyCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err = notify.Escalate(notifyCtx, m.notifier, notify.Message{
+			Title: fmt.Sprintf("[%s] Failure Unacknowledged", incident.TaskName),
+			Body: fmt.Sprintf("Failing since %s and not acknowledged for %s.\nConsecutive failures: %d\nFirst failed run: %s\nIncident: %s",
+				incident.OpenedAt.UTC().Format(time.RFC3339), unacked, incident.Failures, incident.FirstRunID, incident.ID),
+			TaskID: incident.TaskID,
+			RunID:  incident.FirstRunID,
+		})
+		cancel()
+		if err != nil {
+			m.logger.Error("failed to send escalation", "task_id", incident.TaskID, "err", err)
+		}
+	}
+}
@@ -6,34 +6,99 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/exec"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
-	"syscall"
 	"time"
+	"unicode/utf8"
 
+	"clicrontab/internal/clock"
+	"clicrontab/internal/loglink"
 	"clicrontab/internal/notify"
 )
 
 // CommandExecutor executes task commands and records their results.
 type CommandExecutor struct {
 	store    Store
+	runs     *RunStateMachine
 	logger   *slog.Logger
 	notifier notify.Notifier
+	alerts   *alerter
+	shellEnv *shellEnvCache
+	// tailSize is how many trailing output bytes are stored on the run; 0 stores none.
+	tailSize int
+	logLinks *loglink.Signer
+	clock    clock.Clock
+	// location is the time zone date placeholders are expanded in.
+	location *time.Location
+
+	runtimeMu sync.RWMutex
+	runtimes  map[string]Runtime // executors other than the shell, by name
 }
 
+// defaultOutputTailSize is the output tail kept for logs and notifications, and stored on
+// runs unless SetOutputTailSize says otherwise.
+const defaultOutputTailSize = 8 * 1024
+
 // NewCommandExecutor creates a new executor.
-func NewCommandExecutor(store Store, logger *slog.Logger, notifier notify.Notifier) *CommandExecutor {
+func NewCommandExecutor(store Store, logger *slog.Logger, notifier notify.Notifier, policy AlertPolicy) *CommandExecutor {
 	return &CommandExecutor{
 		store:    store,
+		runs:     NewRunStateMachine(store),
 		logger:   logger,
 		notifier: notifier,
+		alerts:   newAlerter(policy),
+		tailSize: defaultOutputTailSize,
+		clock:    clock.System,
+		location: time.Local,
+		runtimes: map[string]Runtime{
+			ExecutorHTTP: httpRuntime{client: &http.Client{}},
+			ExecutorSQL:  sqlRuntime{},
+		},
+	}
+}
+
+// SetClock makes run timestamps and alert decisions follow c instead of the system clock.
+func (e *CommandExecutor) SetClock(c clock.Clock) {
+	e.clock = clock.OrSystem(c)
+}
+
+// SetLocation sets the time zone date placeholders in commands and working directories
+// are expanded in; nil keeps the local time zone.
+func (e *CommandExecutor) SetLocation(location *time.Location) {
+	if location != nil {
+		e.location = location
+	}
+}
+
+// SetLogLinks makes notifications link to the run's full log with links signed by links.
+func (e *CommandExecutor) SetLogLinks(links *loglink.Signer) {
+	e.logLinks = links
+}
+
+// SetOutputTailSize sets how many trailing bytes of output are stored on each run; 0
+// stops storing them.
+func (e *CommandExecutor) SetOutputTailSize(bytes int) {
+	e.tailSize = max(bytes, 0)
+}
+
+// EnableShellEnvCache makes runs reuse a snapshot of the login shell's environment with a
+// plain (non-login) shell, refreshed when older than ttl (0 never refreshes). It has no
+// effect on Windows, where commands run with cmd /C.
+func (e *CommandExecutor) EnableShellEnvCache(ttl time.Duration) {
+	if runtime.GOOS == "windows" {
+		return
 	}
+	e.shellEnv = newShellEnvCache(loginShell(), ttl, e.logger)
 }
 
-// Execute runs the task command according to timeout and records run status.
+// Execute runs the task with its executor, the shell unless the task names a registered
+// runtime, according to timeout and records run status.
 func (e *CommandExecutor) Execute(ctx context.Context, task *Task, run *Run) error {
 	if err := e.store.EnsureRunLogDir(run.ID); err != nil {
 		return fmt.Errorf("ensure run log dir: %w", err)
@@ -47,14 +112,18 @@ func (e *CommandExecutor) Execute(ctx context.Context, task *Task, run *Run) err
 
 	runLogWriter := &syncWriter{w: logFile}
 
-	startedAt := time.Now().UTC()
-	if err := e.store.MarkRunStarted(ctx, run.ID, startedAt); err != nil {
+	startedAt := e.clock.Now().UTC()
+	if err := e.runs.Start(ctx, run.ID, startedAt); err != nil {
 		return fmt.Errorf("mark run started: %w", err)
 	}
 	if err := e.store.UpdateTaskScheduleInfo(ctx, task.ID, &startedAt, task.NextRunAt); err != nil {
 		e.logger.Warn("update task schedule info", "task_id", task.ID, "err", err)
 	}
 
+	if name := task.ExecutorName(); name != ExecutorShell {
+		return e.executeRuntime(ctx, task, run, name, runLogWriter, startedAt)
+	}
+
 	// Setup command context with timeout if configured
 	cmdCtx := ctx
 	cancel := func() {}
@@ -67,29 +136,84 @@ func (e *CommandExecutor) Execute(ctx context.Context, task *Task, run *Run) err
 	}
 	defer cancel()
 
-	cmd := commandForTask(cmdCtx, task.Command)
+	fail := func(err error) error {
+		errMsg := err.Error()
+		e.logger.Warn("prepare task command", "task_id", task.ID, "run_id", run.ID, "err", err)
+		return e.complete(ctx, task, run, RunStatusFailed, startedAt, e.clock.Now().UTC(), nil, &errMsg, newTailBuffer(max(defaultOutputTailSize, e.tailSize)))
+	}
+	data := e.templateData(task, run, startedAt)
+	command, err := ExpandCommand(task.Command, data)
+	if err != nil {
+		return fail(fmt.Errorf("expand command: %w", err))
+	}
+	var workingDir string
+	if task.WorkingDir != nil && *task.WorkingDir != "" {
+		if workingDir, err = ExpandTemplate(*task.WorkingDir, data); err != nil {
+			return fail(fmt.Errorf("expand working_dir: %w", err))
+		}
+		// Dated directories are created on first use
+		if workingDir != *task.WorkingDir {
+			if err := prepareWorkingDir(workingDir); err != nil {
+				return fail(err)
+			}
+		}
+	}
+	if task.ScriptID != nil {
+		scriptCommand, cleanup, err := e.materializeScript(ctx, *task.ScriptID)
+		if err != nil {
+			return fail(err)
+		}
+		defer cleanup()
+		command = scriptCommand
+	}
+
+	cmd := e.taskCommand(cmdCtx, command)
+	cmd.Env = append(taskEnv(cmd), runEnv(data, e.store.RunLogPath(run.ID))...)
+	configureProcessGroup(cmd)
+	killTree := cmd.Cancel
+	cmd.Cancel = func() error {
+		e.recordEvent(ctx, run.ID, RunEventCanceling, cancelMessage(ctx)+"; killing the process tree")
+		return killTree()
+	}
 
 	// Capture a tail of combined output for easier troubleshooting in service logs
 	// while also writing full output to the run log file.
-	outputTail := newTailBuffer(8 * 1024) // keep last 8KB
+	outputTail := newTailBuffer(max(defaultOutputTailSize, e.tailSize))
 	multi := io.MultiWriter(runLogWriter, outputTail)
 	cmd.Stdout = multi
 	cmd.Stderr = multi
 
 	// Set working directory if specified
-	if task.WorkingDir != nil && *task.WorkingDir != "" {
-		cmd.Dir = *task.WorkingDir
-		e.logger.Debug("using working directory", "task_id", task.ID, "working_dir", *task.WorkingDir)
+	if workingDir != "" {
+		cmd.Dir = workingDir
+		e.logger.Debug("using working directory", "task_id", task.ID, "working_dir", workingDir)
 	}
 
 	err = cmd.Start()
 	if err != nil {
-		e.store.MarkRunCompleted(ctx, run.ID, RunStatusFailed, time.Now().UTC(), nil, ptrString(fmt.Sprintf("failed to start command: %v", err)))
+		errMsg := fmt.Sprintf("failed to start command: %v", err)
+		if isCommandNotFound(err) {
+			errMsg += e.commandNotFoundDetail(ctx, task)
+		}
+		e.runs.Finish(ctx, run.ID, RunStatusFailed, e.clock.Now().UTC(), nil, &errMsg)
 		return fmt.Errorf("start command: %w", err)
 	}
 
 	// Log process start with PID for debugging
 	e.logger.Info("task process started", "task_id", task.ID, "run_id", run.ID, "pid", cmd.Process.Pid)
+	if err := e.store.SetRunPID(ctx, run.ID, cmd.Process.Pid); err != nil {
+		e.logger.Warn("record run pid", "run_id", run.ID, "err", err)
+	}
+	e.recordEvent(ctx, run.ID, RunEventProcess, fmt.Sprintf("pid=%d", cmd.Process.Pid))
+	releaseTree, err := attachProcessTree(cmd.Process)
+	if err != nil {
+		e.logger.Warn("track process tree; only the task process can be stopped", "run_id", run.ID, "err", err)
+	}
+	defer releaseTree()
+
+	// Periodically sample CPU/RSS of the process until it exits
+	samplerDone := make(chan struct{})
+	go e.sampleUsage(ctx, run.ID, cmd.Process.Pid, samplerDone)
 
 	// Start timeout watchdog after process has started
 	if task.TimeoutSeconds != nil && *task.TimeoutSeconds > 0 {
@@ -97,21 +221,24 @@ func (e *CommandExecutor) Execute(ctx context.Context, task *Task, run *Run) err
 		watchdog = time.AfterFunc(duration, func() {
 			timeoutTriggered.Store(true)
 			e.logger.Warn("task exceeded timeout, sending termination", "task_id", task.ID, "run_id", run.ID, "timeout", duration)
+			e.recordEvent(ctx, run.ID, RunEventTimeout, fmt.Sprintf("exceeded %s; sent %s", duration, terminationSignal))
 
-			// First attempt: graceful termination (SIGTERM on Unix, Kill on Windows)
+			// First attempt: graceful termination (SIGTERM to the process group on Unix, CTRL_BREAK on Windows)
 			sendTermination(cmd.Process)
 
 			// Second attempt: force kill after 5 seconds if process still alive
 			killTimer = time.AfterFunc(5*time.Second, func() {
 				if cmd.Process != nil {
 					e.logger.Warn("force killing task after grace period", "task_id", task.ID, "run_id", run.ID)
-					_ = cmd.Process.Kill()
+					e.recordEvent(ctx, run.ID, RunEventKilled, "still running 5s after termination; killed the process tree")
+					_ = killProcessTree(cmd.Process)
 				}
 			})
 		})
 	}
 
 	waitErr := cmd.Wait()
+	close(samplerDone)
 
 	// Stop timers if they exist and haven't fired yet
 	if watchdog != nil {
@@ -121,7 +248,7 @@ func (e *CommandExecutor) Execute(ctx context.Context, task *Task, run *Run) err
 		killTimer.Stop()
 	}
 
-	endedAt := time.Now().UTC()
+	endedAt := e.clock.Now().UTC()
 	var exitCode *int
 	var status RunStatus
 	var errMsg *string
@@ -137,6 +264,17 @@ func (e *CommandExecutor) Execute(ctx context.Context, task *Task, run *Run) err
 			"output_tail", outputTail.String(),
 			"log_path", e.store.RunLogPath(run.ID),
 		)
+	} else if ctx.Err() != nil {
+		status = RunStatusCanceled
+		errMsg = ptrString(cancelMessage(ctx))
+		e.logger.Info(
+			"task canceled",
+			"task_id", task.ID,
+			"run_id", run.ID,
+			"pid", cmd.Process.Pid,
+			"reason", *errMsg,
+			"log_path", e.store.RunLogPath(run.ID),
+		)
 	} else if waitErr == nil {
 		status = RunStatusSucceeded
 		code := 0
@@ -158,6 +296,9 @@ func (e *CommandExecutor) Execute(ctx context.Context, task *Task, run *Run) err
 		}
 		status = RunStatusFailed
 		errMsg = ptrString(waitErr.Error())
+		if isCommandNotFound(waitErr) {
+			*errMsg += ": command not found" + e.commandNotFoundDetail(ctx, task)
+		}
 		e.logger.Warn(
 			"task failed",
 			"task_id", task.ID,
@@ -175,45 +316,226 @@ func (e *CommandExecutor) Execute(ctx context.Context, task *Task, run *Run) err
 		)
 	}
 
-	if err := e.store.MarkRunCompleted(ctx, run.ID, status, endedAt, exitCode, errMsg); err != nil {
+	return e.complete(ctx, task, run, status, startedAt, endedAt, exitCode, errMsg, outputTail)
+}
+
+// complete records the outcome of a run, then notifies and checks its duration.
+func (e *CommandExecutor) complete(ctx context.Context, task *Task, run *Run, status RunStatus, startedAt, endedAt time.Time, exitCode *int, errMsg *string, outputTail *tailBuffer) error {
+	// The run context may already be canceled; completion must still be recorded.
+	if tail := outputTail.Last(e.tailSize); tail != "" {
+		if err := e.store.SetRunOutputTail(context.WithoutCancel(ctx), run.ID, tail); err != nil {
+			e.logger.Warn("record run output tail", "run_id", run.ID, "err", err)
+		}
+	}
+	if err := e.runs.Finish(context.WithoutCancel(ctx), run.ID, status, endedAt, exitCode, errMsg); err != nil {
 		return fmt.Errorf("mark run completed: %w", err)
 	}
 
 	if e.notifier != nil {
-		taskName := task.ID
-		if task.Name != nil {
-			taskName = *task.Name
-		}
+		e.notifyCompletion(ctx, task, run, status, exitCode, errMsg, outputTail.String())
+	}
+	if status == RunStatusSucceeded {
+		e.checkDuration(ctx, task, run, endedAt.Sub(startedAt))
+	}
+
+	return nil
+}
 
-		title := fmt.Sprintf("[%s] Task Finished", taskName)
-		body := fmt.Sprintf("Status: %s\nRun ID: %s", status, run.ID)
-		if exitCode != nil {
-			body += fmt.Sprintf("\nExit Code: %d", *exitCode)
+// recordEvent appends a lifecycle event to the run. The run context may already be
+// canceled, and a lost event is not worth failing the run for.
+func (e *CommandExecutor) recordEvent(ctx context.Context, runID string, eventType RunEventType, detail string) {
+	event := RunEvent{RunID: runID, Type: eventType, Detail: detail, At: e.clock.Now().UTC()}
+	if err := e.store.AddRunEvent(context.WithoutCancel(ctx), event); err != nil {
+		e.logger.Warn("record run event", "run_id", runID, "event", eventType, "err", err)
+	}
+}
+
+// alertHistoryDepth bounds how many past runs are inspected to count consecutive failures.
+const alertHistoryDepth = 50
+
+// notifyCompletion sends a notification for a finished run according to the alert policy.
+func (e *CommandExecutor) notifyCompletion(ctx context.Context, task *Task, run *Run, status RunStatus, exitCode *int, errMsg *string, output string) {
+	recent, err := e.store.RecentRunStatuses(context.WithoutCancel(ctx), task.ID, alertHistoryDepth)
+	if err != nil {
+		e.logger.Warn("load recent run statuses", "task_id", task.ID, "err", err)
+		recent = []RunStatus{status}
+	}
+	now := e.clock.Now()
+	incident := e.trackIncident(ctx, task.ID, run.ID, status, now)
+	kind := e.alerts.decide(task.ID, recent, now)
+	if kind != alertNone {
+		if window := inMaintenance(ctx, e.store, e.logger, task.ID, now); window != nil {
+			e.logger.Info("notification suppressed by maintenance window", "task_id", task.ID, "run_id", run.ID, "status", status, "window_id", window.ID)
+			return
 		}
-		if errMsg != nil {
-			body += fmt.Sprintf("\nError: %s", *errMsg)
+	}
+	if incident != nil && incident.Status == IncidentStatusAcknowledged {
+		// Someone is on it; repeat alerts wait until the incident is resolved
+		kind = alertNone
+	} else if kind == alertEscalated && incident != nil {
+		if _, err := e.store.MarkIncidentEscalated(context.WithoutCancel(ctx), incident.ID, now.UTC()); err != nil {
+			e.logger.Warn("mark incident escalated", "incident_id", incident.ID, "err", err)
 		}
+	}
+	if kind == alertNone {
+		e.logger.Debug("notification suppressed by alert policy", "task_id", task.ID, "run_id", run.ID, "status", status)
+		return
+	}
 
-		// Append output tail
-		output := outputTail.String()
-		if len(output) > 0 {
-			const maxLen = 500
-			if len(output) > maxLen {
-				output = "..." + output[len(output)-maxLen:]
-			}
-			body += fmt.Sprintf("\n\nOutput:\n%s", output)
+	taskName := task.ID
+	if task.Name != nil {
+		taskName = *task.Name
+	}
+
+	var title string
+	switch kind {
+	case alertFailed:
+		title = fmt.Sprintf("[%s] Task Failed", taskName)
+	case alertEscalated:
+		title = fmt.Sprintf("[%s] Task Failing (%d consecutive failures)", taskName, countConsecutiveFailures(recent))
+	case alertRecovered:
+		title = fmt.Sprintf("[%s] Task Recovered", taskName)
+	default:
+		title = fmt.Sprintf("[%s] Task Finished", taskName)
+	}
+	body := fmt.Sprintf("Status: %s\nRun ID: %s", status, run.ID)
+	if exitCode != nil {
+		body += fmt.Sprintf("\nExit Code: %d", *exitCode)
+	}
+	if errMsg != nil {
+		body += fmt.Sprintf("\nError: %s", *errMsg)
+	}
+	msg := notify.Message{
+		Title:    title,
+		Body:     body,
+		TaskID:   task.ID,
+		RunID:    run.ID,
+		Status:   string(status),
+		ExitCode: exitCode,
+		Excerpt:  lastLines(output, notifyExcerptLines),
+	}
+	if e.logLinks != nil {
+		msg.LogURL = e.logLinks.URL(run.ID, e.clock.Now())
+	}
+
+	// Use a detached context for notification
+	notifyCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if kind == alertEscalated {
+		err = notify.Escalate(notifyCtx, e.notifier, msg)
+	} else {
+		err = notify.SendMessage(notifyCtx, e.notifier, msg)
+	}
+	if err != nil {
+		e.logger.Error("failed to send notification", "err", err)
+	}
+}
+
+// trackIncident keeps the task's incident in step with the run that just finished: a
+// failure opens it or counts against it and returns it, a success resolves it. Store
+// errors are logged and leave alerting as if there were no incident.
+func (e *CommandExecutor) trackIncident(ctx context.Context, taskID, runID string, status RunStatus, now time.Time) *Incident {
+	ctx = context.WithoutCancel(ctx)
+	if status == RunStatusSucceeded {
+		note := fmt.Sprintf("run %s succeeded", runID)
+		if err := e.store.ResolveTaskIncident(ctx, taskID, IncidentAutoResolver, &note, now.UTC()); err != nil {
+			e.logger.Warn("resolve incident", "task_id", taskID, "err", err)
 		}
+		return nil
+	}
+	if !isFailureStatus(status) {
+		return nil
+	}
+	incident, err := e.store.OpenIncident(ctx, taskID, runID, now.UTC())
+	if err != nil {
+		e.logger.Warn("open incident", "task_id", taskID, "err", err)
+		return nil
+	}
+	if incident.Failures == 1 {
+		e.logger.Info("incident opened", "task_id", taskID, "incident_id", incident.ID, "run_id", runID)
+	}
+	return incident
+}
 
-		// Use a detached context for notification
-		notifyCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+// notifyExcerptLines is how many trailing output lines notifications include.
+const notifyExcerptLines = 20
+
+// lastLines returns the last n lines of output, without trailing blank lines.
+func lastLines(output string, n int) string {
+	lines := strings.Split(strings.TrimRight(output, "\r\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
 
-		if err := e.notifier.Send(notifyCtx, title, body); err != nil {
-			e.logger.Error("failed to send notification", "err", err)
+// usageSampleInterval controls how often a running process's resource usage is recorded.
+const usageSampleInterval = 5 * time.Second
+
+// sampleUsage records resource usage of pid every usageSampleInterval until done is closed.
+func (e *CommandExecutor) sampleUsage(ctx context.Context, runID string, pid int, done <-chan struct{}) {
+	ticker := e.clock.NewTicker(usageSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			usage, err := sampleProcess(ctx, pid)
+			if err != nil {
+				e.logger.Debug("sample process usage", "run_id", runID, "pid", pid, "err", err)
+				continue
+			}
+			if err := e.store.UpdateRunUsage(ctx, runID, usage); err != nil {
+				e.logger.Warn("record run usage", "run_id", runID, "err", err)
+			}
 		}
 	}
+}
 
-	return nil
+// ShellCommand returns the exec.Cmd used to run command, with the same shell setup as task runs.
+func ShellCommand(ctx context.Context, command string) *exec.Cmd {
+	return commandForTask(ctx, command)
+}
+
+// taskCommand creates the exec.Cmd for a run: with the shell environment cache enabled a
+// plain shell with the cached environment, otherwise a login shell as commandForTask.
+func (e *CommandExecutor) taskCommand(ctx context.Context, command string) *exec.Cmd {
+	if e.shellEnv == nil {
+		return commandForTask(ctx, command)
+	}
+	env, err := e.shellEnv.get(ctx)
+	if err != nil {
+		e.logger.Warn("snapshot login shell environment; using a login shell for this run", "err", err)
+		return commandForTask(ctx, command)
+	}
+	cmd := exec.CommandContext(ctx, e.shellEnv.shell, "-c", command) // #nosec G204
+	cmd.Env = env
+	return cmd
+}
+
+// taskEnv returns a copy of the environment cmd runs with, the daemon's when unset.
+func taskEnv(cmd *exec.Cmd) []string {
+	if cmd.Env == nil {
+		return os.Environ()
+	}
+	// The cached shell environment is shared between runs
+	return slices.Clone(cmd.Env)
+}
+
+// runEnv describes the run to its process, so scripts can reference it, e.g. to call
+// the API back or tag the artifacts they produce.
+func runEnv(data TemplateData, logPath string) []string {
+	return []string{
+		"CLICRON_TASK_ID=" + data.TaskID,
+		"CLICRON_TASK_NAME=" + data.TaskName,
+		"CLICRON_RUN_ID=" + data.RunID,
+		"CLICRON_SCHEDULED_AT=" + data.Time.UTC().Format(time.RFC3339),
+		"CLICRON_LOG_PATH=" + logPath,
+	}
 }
 
 // commandForTask creates an exec.Cmd for the given command.
@@ -226,14 +548,17 @@ func commandForTask(ctx context.Context, command string) *exec.Cmd {
 	}
 
 	// Use user's default shell with login mode to load configuration files
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "/bin/sh" // fallback to POSIX shell
-	}
-
 	// -l: login shell (loads .bash_profile, .zshrc, etc.)
 	// -c: execute command string
-	return exec.CommandContext(ctx, shell, "-l", "-c", command) // #nosec G204
+	return exec.CommandContext(ctx, loginShell(), "-l", "-c", command) // #nosec G204
+}
+
+// loginShell returns the user's default shell.
+func loginShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh" // fallback to POSIX shell
 }
 
 type syncWriter struct {
@@ -289,22 +614,29 @@ func (t *tailBuffer) String() string {
 	return string(t.buf)
 }
 
-// sendTermination attempts to gracefully terminate a process.
-// On Unix systems, it sends SIGTERM to allow the process to clean up resources.
-// On Windows, graceful termination via signals is not supported, so it directly
-// kills the process. This means Windows processes cannot perform cleanup operations
-// when terminated due to timeout.
-func sendTermination(process *os.Process) {
-	if process == nil {
-		return
+// Last returns at most the last n bytes kept, starting at a UTF-8 character boundary.
+func (t *tailBuffer) Last(n int) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n <= 0 {
+		return ""
 	}
-	if runtime.GOOS == "windows" {
-		// Windows doesn't support SIGTERM, must use Kill directly
-		_ = process.Kill()
-		return
+	buf := t.buf
+	if len(buf) > n {
+		buf = buf[len(buf)-n:]
+	}
+	for len(buf) > 0 && !utf8.RuneStart(buf[0]) {
+		buf = buf[1:]
+	}
+	return string(buf)
+}
+
+// cancelMessage describes why a run context was canceled.
+func cancelMessage(ctx context.Context) string {
+	if errors.Is(context.Cause(ctx), ErrRunCanceled) {
+		return "run canceled: " + ErrRunCanceled.Error()
 	}
-	// Unix: send SIGTERM for graceful shutdown
-	_ = process.Signal(syscall.SIGTERM)
+	return "run canceled: " + ErrShutdown.Error()
 }
 
 func ptrString(v string) *string {
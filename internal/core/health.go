@@ -0,0 +1,34 @@
+package core
+
+import "time"
+
+// TaskHealth summarizes how a task is doing, derived from its latest run and schedule.
+type TaskHealth string
+
+const (
+	TaskHealthy TaskHealth = "healthy"
+	// TaskFailing means the latest finished run failed or timed out.
+	TaskFailing TaskHealth = "failing"
+	// TaskStalled means an active task's next run is overdue: its schedule is not firing.
+	TaskStalled TaskHealth = "stalled"
+	// TaskHealthError means the task is in the error status and cannot be scheduled.
+	TaskHealthError TaskHealth = "error"
+)
+
+// taskStalledGrace is how far past next_run_at an active task may be before it counts
+// as stalled; a due trigger updates next_run_at well within it.
+const taskStalledGrace = 5 * time.Minute
+
+// DeriveTaskHealth returns the task's health at now. lastStatus is the status of its
+// latest finished run, skipped runs excluded, or "" if it has none.
+func DeriveTaskHealth(task *Task, lastStatus RunStatus, now time.Time) TaskHealth {
+	switch {
+	case task.Status == TaskStatusError:
+		return TaskHealthError
+	case task.Status == TaskStatusActive && (task.NextRunAt == nil || now.Sub(*task.NextRunAt) > taskStalledGrace):
+		return TaskStalled
+	case isFailureStatus(lastStatus):
+		return TaskFailing
+	}
+	return TaskHealthy
+}
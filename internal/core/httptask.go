@@ -0,0 +1,189 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExecutorHTTP names the built-in executor sending a task's HTTP request instead of
+// running a shell command.
+const ExecutorHTTP = "http"
+
+// httpLogBodyLimit caps how much of a response body is written to the run log.
+const httpLogBodyLimit = 1 << 20
+
+// ErrHTTPRequestRequired is returned by ValidateExecutorConfig for http tasks without a
+// request.
+var ErrHTTPRequestRequired = errors.New("http request is required for the http executor")
+
+// HTTPRequest is the request an http task sends on each run.
+type HTTPRequest struct {
+	// Method defaults to GET.
+	Method  string            `json:"method,omitempty"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// ExpectStatus lists the status codes that make the run succeed; empty accepts any 2xx.
+	ExpectStatus []int `json:"expect_status,omitempty"`
+}
+
+// MethodName returns the request method, GET when unset.
+func (r *HTTPRequest) MethodName() string {
+	if r.Method == "" {
+		return http.MethodGet
+	}
+	return r.Method
+}
+
+// Summary describes the request in one line, e.g. "POST https://example.com/hook"; it
+// is what http tasks show as their command, so query values and the password of the
+// URL, which often carry tokens, are masked.
+func (r *HTTPRequest) Summary() string {
+	return r.MethodName() + " " + redactURL(r.URL)
+}
+
+// redactURL masks the query values and password of a URL with DSNMask.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key, values := range query {
+			for i := range values {
+				values[i] = DSNMask
+			}
+			query[key] = values
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
+
+// Validate checks that the request can be sent: an absolute http(s) URL, a method made
+// of letters, header names without separators and expected codes between 100 and 599.
+func (r *HTTPRequest) Validate() error {
+	if strings.TrimSpace(r.URL) == "" {
+		return errors.New("url is required")
+	}
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	for _, c := range r.Method {
+		if c < 'A' || c > 'Z' {
+			return fmt.Errorf("invalid method %q; use an uppercase method such as GET or POST", r.Method)
+		}
+	}
+	for name := range r.Headers {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	for _, code := range r.ExpectStatus {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("expected status %d is not between 100 and 599", code)
+		}
+	}
+	return nil
+}
+
+// Succeeded reports whether a response status counts as success.
+func (r *HTTPRequest) Succeeded(status int) bool {
+	if len(r.ExpectStatus) == 0 {
+		return status >= 200 && status < 300
+	}
+	for _, code := range r.ExpectStatus {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// httpRuntime sends the task's request and logs the exchange: the request line and
+// headers, then the status, latency, response headers and body.
+type httpRuntime struct {
+	client *http.Client
+}
+
+func (rt httpRuntime) Run(ctx context.Context, task *Task, run *Run, out io.Writer) (*int, error) {
+	spec := task.HTTP
+	if spec == nil {
+		return nil, ErrHTTPRequestRequired
+	}
+	req, err := http.NewRequestWithContext(ctx, spec.MethodName(), spec.URL, strings.NewReader(spec.Body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	for name, value := range spec.Headers {
+		req.Header.Set(name, value)
+	}
+	fmt.Fprintf(out, "> %s\n", spec.Summary())
+	writeHTTPHeaders(out, ">", req.Header, true)
+
+	start := time.Now()
+	resp, err := rt.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	fmt.Fprintf(out, "\n< %s (%dms)\n", resp.Status, time.Since(start).Milliseconds())
+	writeHTTPHeaders(out, "<", resp.Header, false)
+	fmt.Fprintln(out)
+	// One byte past the limit tells a truncated body from one of exactly the limit
+	body := io.LimitReader(resp.Body, httpLogBodyLimit+1)
+	n, err := io.CopyN(out, body, httpLogBodyLimit)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if n == httpLogBodyLimit {
+		if more, _ := io.ReadFull(body, make([]byte, 1)); more > 0 {
+			fmt.Fprintf(out, "\n[response body truncated at %d bytes]", httpLogBodyLimit)
+		}
+	}
+	fmt.Fprintln(out)
+
+	if !spec.Succeeded(resp.StatusCode) {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil, nil
+}
+
+// writeHTTPHeaders logs headers sorted by name. With redact, credentials sent by the
+// task are masked so API tokens do not end up in run logs and notifications.
+func writeHTTPHeaders(out io.Writer, prefix string, header http.Header, redact bool) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			if redact && sensitiveHTTPHeader(name) {
+				value = "[redacted]"
+			}
+			fmt.Fprintf(out, "%s %s: %s\n", prefix, name, value)
+		}
+	}
+}
+
+func sensitiveHTTPHeader(name string) bool {
+	switch name {
+	case "Authorization", "Proxy-Authorization", "Cookie":
+		return true
+	}
+	lower := strings.ToLower(name)
+	return strings.Contains(lower, "token") || strings.Contains(lower, "secret") || strings.Contains(lower, "key")
+}
@@ -2,17 +2,122 @@ package core
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 )
 
-// NewID returns a random 128-bit identifier encoded as lowercase hex.
-// Falls back to a timestamp string if the random source fails.
+// ID formats accepted by SetIDFormat.
+const (
+	// IDFormatHex is 32 random lowercase hex characters.
+	IDFormatHex = "hex"
+	// IDFormatULID is a 26-character lowercase ULID: a millisecond timestamp followed by
+	// randomness, so IDs sort by creation time.
+	IDFormatULID = "ulid"
+)
+
+var newID = NewHexID
+
+// SetIDFormat selects how NewID generates identifiers for tasks, runs and other
+// records. Existing IDs of either format stay valid; IDs are opaque strings. It must be
+// called before any ID is generated.
+func SetIDFormat(format string) error {
+	switch format {
+	case IDFormatHex, "":
+		newID = NewHexID
+	case IDFormatULID:
+		newID = NewULID
+	default:
+		return fmt.Errorf("unknown ID format %q", format)
+	}
+	return nil
+}
+
+// NewID returns a new identifier in the format chosen by SetIDFormat (hex by default).
 func NewID() string {
+	return newID()
+}
+
+// NewHexID returns a random 128-bit identifier encoded as lowercase hex.
+// Falls back to a timestamp string if the random source fails.
+func NewHexID() string {
 	buf := make([]byte, 16)
 	if _, err := rand.Read(buf); err == nil {
 		return hex.EncodeToString(buf)
 	}
 	return fmt.Sprintf("%d", time.Now().UTC().UnixNano())
 }
+
+// ValidID reports whether id is one NewID could have returned: 32 hex characters, a
+// ULID, or the decimal timestamp NewHexID falls back to. IDs from elsewhere, such as an
+// imported backup, are checked with it before they name files.
+func ValidID(id string) bool {
+	var alphabet string
+	switch {
+	case len(id) == 32:
+		alphabet = "0123456789abcdef"
+	case len(id) == 26:
+		alphabet = crockford
+	case len(id) > 0 && len(id) <= 20:
+		alphabet = "0123456789"
+	default:
+		return false
+	}
+	for _, c := range id {
+		if !strings.ContainsRune(alphabet, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// crockford is the ULID alphabet, lowercased.
+const crockford = "0123456789abcdefghjkmnpqrstvwxyz"
+
+var ulidState struct {
+	sync.Mutex
+	ms   uint64
+	rand [10]byte
+}
+
+// NewULID returns a lowercase ULID. IDs created within the same millisecond increment
+// the random part, so they still sort in creation order.
+func NewULID() string {
+	ms := uint64(time.Now().UnixMilli())
+
+	ulidState.Lock()
+	if ms <= ulidState.ms {
+		// Same millisecond, or the clock went backwards: keep the last timestamp and
+		// increment the randomness
+		ms = ulidState.ms
+		for i := len(ulidState.rand) - 1; i >= 0; i-- {
+			ulidState.rand[i]++
+			if ulidState.rand[i] != 0 {
+				break
+			}
+		}
+	} else if _, err := rand.Read(ulidState.rand[:]); err != nil {
+		ulidState.Unlock()
+		return NewHexID()
+	}
+	ulidState.ms = ms
+	var raw [16]byte
+	binary.BigEndian.PutUint16(raw[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(raw[2:6], uint32(ms))
+	copy(raw[6:], ulidState.rand[:])
+	ulidState.Unlock()
+
+	// 128 bits as 26 base32 digits, the first carrying the top 3 bits
+	hi := binary.BigEndian.Uint64(raw[0:8])
+	lo := binary.BigEndian.Uint64(raw[8:16])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
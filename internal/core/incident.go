@@ -0,0 +1,46 @@
+package core
+
+import "time"
+
+// IncidentStatus is where an incident stands in the acknowledge/resolve workflow.
+type IncidentStatus string
+
+const (
+	IncidentStatusOpen         IncidentStatus = "open"
+	IncidentStatusAcknowledged IncidentStatus = "acknowledged"
+	IncidentStatusResolved     IncidentStatus = "resolved"
+)
+
+// Valid reports whether s is a known incident status.
+func (s IncidentStatus) Valid() bool {
+	switch s {
+	case IncidentStatusOpen, IncidentStatusAcknowledged, IncidentStatusResolved:
+		return true
+	}
+	return false
+}
+
+// IncidentAutoResolver is recorded as the resolver of incidents closed by a run that
+// succeeded.
+const IncidentAutoResolver = "auto"
+
+// Incident is an outage of a task: it opens with the first failed run, counts the
+// consecutive failures that follow and ends when the task succeeds again or someone
+// resolves it. A task has at most one unresolved incident.
+type Incident struct {
+	ID     string
+	TaskID string
+	// TaskName is the task's name, or its ID when it has none or was deleted.
+	TaskName       string
+	Status         IncidentStatus
+	FirstRunID     string
+	LastRunID      string
+	Failures       int // consecutive failed runs so far
+	OpenedAt       time.Time
+	AcknowledgedAt *time.Time
+	AcknowledgedBy *string
+	EscalatedAt    *time.Time
+	ResolvedAt     *time.Time
+	ResolvedBy     *string
+	Note           *string // why it was resolved
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"clicrontab/internal/clock"
+)
+
+// logJanitorInterval is how often the janitor applies log retention to every task.
+const logJanitorInterval = time.Hour
+
+// LogJanitorStore removes run logs beyond their retention limits.
+type LogJanitorStore interface {
+	PruneExpiredRunLogs(ctx context.Context) (int, error)
+}
+
+// LogJanitor periodically applies log retention to every task. Runs prune their own
+// task's logs as they finish, so the janitor is what ages logs out of tasks that rarely
+// or no longer run.
+type LogJanitor struct {
+	store  LogJanitorStore
+	logger *slog.Logger
+	clock  clock.Clock
+}
+
+// NewLogJanitor constructs a log janitor.
+func NewLogJanitor(store LogJanitorStore, logger *slog.Logger) *LogJanitor {
+	return &LogJanitor{store: store, logger: logger, clock: clock.System}
+}
+
+// SetClock makes the sweeps follow c instead of the system clock.
+func (j *LogJanitor) SetClock(c clock.Clock) {
+	j.clock = clock.OrSystem(c)
+}
+
+// Run sweeps once at startup and then every logJanitorInterval until ctx is done.
+func (j *LogJanitor) Run(ctx context.Context) {
+	ticker := j.clock.NewTicker(logJanitorInterval)
+	defer ticker.Stop()
+	for {
+		j.Sweep(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// Sweep removes the run logs of every task that are beyond its retention limits.
+func (j *LogJanitor) Sweep(ctx context.Context) {
+	n, err := j.store.PruneExpiredRunLogs(ctx)
+	if err != nil {
+		j.logger.Warn("prune run logs", "err", err)
+	}
+	if n > 0 {
+		j.logger.Info("pruned run logs", "runs", n)
+	}
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// MaintenanceWindow silences alerts while a planned outage, e.g. of an upstream
+// service, is expected to make runs fail. Runs still execute and are recorded.
+type MaintenanceWindow struct {
+	ID string
+	// TaskID limits the window to one task; nil covers every task.
+	TaskID    *string
+	StartsAt  time.Time
+	EndsAt    time.Time
+	Reason    *string
+	CreatedAt time.Time
+}
+
+// Active reports whether the window is in effect at t.
+func (w *MaintenanceWindow) Active(t time.Time) bool {
+	return !t.Before(w.StartsAt) && t.Before(w.EndsAt)
+}
+
+// MaintenanceStore finds the maintenance window in effect for a task.
+type MaintenanceStore interface {
+	// ActiveMaintenanceWindow returns a window covering the task at the given time,
+	// or nil when there is none.
+	ActiveMaintenanceWindow(ctx context.Context, taskID string, at time.Time) (*MaintenanceWindow, error)
+}
+
+// inMaintenance returns the window silencing the task's alerts at t, if any. Store
+// errors are logged and the alert goes out as usual.
+func inMaintenance(ctx context.Context, store MaintenanceStore, logger *slog.Logger, taskID string, t time.Time) *MaintenanceWindow {
+	window, err := store.ActiveMaintenanceWindow(context.WithoutCancel(ctx), taskID, t)
+	if err != nil {
+		logger.Warn("look up maintenance window", "task_id", taskID, "err", err)
+		return nil
+	}
+	return window
+}
@@ -0,0 +1,23 @@
+package core
+
+import "time"
+
+// MisfirePolicy decides what happens to a trigger that fires long after its scheduled time,
+// typically because the machine was asleep.
+type MisfirePolicy string
+
+const (
+	// MisfireSkip records the late trigger as a skipped run and waits for the next slot.
+	MisfireSkip MisfirePolicy = "skip"
+	// MisfireRunOnce runs the task once, however many slots were missed.
+	MisfireRunOnce MisfirePolicy = "run_once"
+)
+
+// SkipReasonMisfired marks runs skipped by MisfireSkip.
+const SkipReasonMisfired = "misfired"
+
+// isMisfire reports whether a trigger for scheduledAt observed at now is too late to run
+// under the configured policy.
+func (s *Scheduler) isMisfire(scheduledAt, now time.Time) bool {
+	return s.misfireGrace > 0 && s.misfirePolicy == MisfireSkip && now.Sub(scheduledAt) > s.misfireGrace
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// nextRunCheckInterval is how often stored next_run_at values are compared with the
+	// cron entries.
+	nextRunCheckInterval = 5 * time.Minute
+	// nextRunTolerance ignores differences below the precision next_run_at is shown with.
+	nextRunTolerance = time.Second
+	// nextRunSettle leaves alone entries that fire or just fired within this window; their
+	// job updates next_run_at itself.
+	nextRunSettle = 5 * time.Second
+)
+
+// watchNextRuns periodically repairs stored next_run_at values that drifted from the
+// cron entries.
+func (s *Scheduler) watchNextRuns(ctx context.Context) {
+	ticker := s.clock.NewTicker(nextRunCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			s.repairNextRuns(ctx)
+		}
+	}
+}
+
+// repairNextRuns sets the stored next_run_at of each active task to when its cron entry
+// actually fires next. The entry decides when the task runs; next_run_at only reports it
+// and can diverge, e.g. after a time zone change or a write from a stale task copy.
+func (s *Scheduler) repairNextRuns(ctx context.Context) {
+	active := TaskStatusActive
+	tasks, err := s.store.ListTasks(ctx, &active)
+	if err != nil {
+		s.logger.Warn("list tasks for next_run_at check", "err", err)
+		return
+	}
+	entries := make(map[string]ScheduleEntry)
+	for _, entry := range s.Entries() {
+		if entry.TaskID != "" {
+			entries[entry.TaskID] = entry
+		}
+	}
+	now := s.clock.Now()
+	for _, task := range tasks {
+		entry, ok := entries[task.ID]
+		if !ok || entry.Next.IsZero() || entry.Next.Sub(now) < nextRunSettle || now.Sub(entry.Prev) < nextRunSettle {
+			continue
+		}
+		next := entry.Next.UTC()
+		if task.NextRunAt != nil {
+			if drift := task.NextRunAt.Sub(next); drift > -nextRunTolerance && drift < nextRunTolerance {
+				continue
+			}
+		}
+		if err := s.store.UpdateTaskNextRun(ctx, task.ID, &next); err != nil {
+			s.logger.Warn("repair next_run_at", "task_id", task.ID, "err", err)
+			continue
+		}
+		stored := "none"
+		if task.NextRunAt != nil {
+			stored = task.NextRunAt.UTC().Format(time.RFC3339)
+		}
+		s.logger.Warn("corrected next_run_at that drifted from the cron schedule",
+			"task_id", task.ID, "stored", stored, "next", next.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPauseUntilPast is returned by ParsePauseUntil for a time that is not in the future.
+var ErrPauseUntilPast = errors.New("pause_until must be in the future")
+
+// pauseCheckInterval is how often the scheduler looks for paused tasks whose pause_until has passed.
+const pauseCheckInterval = 30 * time.Second
+
+// localTimeLayouts are the accepted time formats besides RFC 3339; they are
+// interpreted in the scheduler's location.
+var localTimeLayouts = []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"}
+
+// ParseTime parses a time given as RFC 3339, "YYYY-MM-DD HH:MM" or a date (midnight) in loc.
+func ParseTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	for _, layout := range localTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use RFC 3339, YYYY-MM-DD HH:MM or YYYY-MM-DD)", value)
+}
+
+// ParsePauseUntil parses an auto-resume time in any format accepted by ParseTime.
+// The time must be after now.
+func ParsePauseUntil(value string, loc *time.Location, now time.Time) (time.Time, error) {
+	t, err := ParseTime(value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !t.After(now) {
+		return time.Time{}, fmt.Errorf("%w (got %s)", ErrPauseUntilPast, t.Format(time.RFC3339))
+	}
+	return t.UTC(), nil
+}
+
+// watchPauses resumes paused tasks once their PauseUntil time has passed.
+func (s *Scheduler) watchPauses(ctx context.Context) {
+	s.resumeDueTasks(ctx)
+	ticker := s.clock.NewTicker(pauseCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			s.resumeDueTasks(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) resumeDueTasks(ctx context.Context) {
+	paused := TaskStatusPaused
+	tasks, err := s.store.ListTasks(ctx, &paused)
+	if err != nil {
+		s.logger.Warn("list paused tasks", "err", err)
+		return
+	}
+	now := s.clock.Now()
+	for _, task := range tasks {
+		if task.PauseUntil == nil || task.PauseUntil.After(now) {
+			continue
+		}
+		if err := s.store.UpdateTaskStatus(ctx, task.ID, TaskStatusActive); err != nil {
+			s.logger.Error("auto-resume task", "task_id", task.ID, "err", err)
+			continue
+		}
+		task.Status = TaskStatusActive
+		task.PauseUntil = nil
+		if err := s.AddOrUpdateTask(ctx, task); err != nil {
+			s.logger.Error("schedule auto-resumed task", "task_id", task.ID, "err", err)
+			continue
+		}
+		s.logger.Info("task resumed after pause_until", "task_id", task.ID)
+	}
+}
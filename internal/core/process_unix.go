@@ -0,0 +1,101 @@
+//go:build !windows
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// configureProcessGroup starts the command in its own process group so that the whole
+// process tree (e.g. claude and its helpers) can be signalled or reaped together.
+func configureProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return killProcessTree(cmd.Process)
+	}
+}
+
+// attachProcessTree is a no-op on Unix, where the process group set up by
+// configureProcessGroup already covers the process tree.
+func attachProcessTree(process *os.Process) (func(), error) {
+	return func() {}, nil
+}
+
+// terminationSignal describes what sendTermination sends, for run events.
+const terminationSignal = "SIGTERM to the process group"
+
+// sendTermination sends SIGTERM to the process group so children can clean up.
+func sendTermination(process *os.Process) {
+	if process == nil {
+		return
+	}
+	if err := syscall.Kill(-process.Pid, syscall.SIGTERM); err != nil {
+		_ = process.Signal(syscall.SIGTERM)
+	}
+}
+
+// killProcessTree sends SIGKILL to the process group, falling back to the process itself.
+func killProcessTree(process *os.Process) error {
+	if process == nil {
+		return nil
+	}
+	if err := syscall.Kill(-process.Pid, syscall.SIGKILL); err == nil {
+		return nil
+	}
+	return process.Kill()
+}
+
+// killProcess sends SIGKILL to one process.
+func killProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
+
+// processInfo describes a live OS process.
+type processInfo struct {
+	PID       int
+	PGID      int
+	StartedAt time.Time
+}
+
+// listProcesses returns all processes visible to the daemon with their group and approximate start time.
+func listProcesses(ctx context.Context) ([]processInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "ps", "-A", "-o", "pid=", "-o", "pgid=", "-o", "etime=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("ps: %w", err)
+	}
+	now := time.Now()
+	var procs []processInfo
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		pgid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		elapsed, err := parseCPUTime(fields[2])
+		if err != nil {
+			continue
+		}
+		procs = append(procs, processInfo{
+			PID:       pid,
+			PGID:      pgid,
+			StartedAt: now.Add(-time.Duration(elapsed * float64(time.Second))),
+		})
+	}
+	return procs, nil
+}
@@ -0,0 +1,107 @@
+//go:build windows
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// processJobs maps the PID of a task process to the job object holding its process tree.
+var processJobs sync.Map // pid -> windows.Handle
+
+// configureProcessGroup starts the command in a new process group so CTRL_BREAK_EVENT
+// can be sent to it alone, and makes cancellation kill the whole job.
+func configureProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP}
+	cmd.Cancel = func() error {
+		return killProcessTree(cmd.Process)
+	}
+}
+
+// attachProcessTree assigns the started process to a new job object, which the children
+// it spawns from then on join too, so killProcessTree can end the whole tree. The job is
+// created with KILL_ON_JOB_CLOSE, so release, called once the process has exited, also
+// ends any children left behind.
+func attachProcessTree(process *os.Process) (func(), error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return func() {}, fmt.Errorf("create job object: %w", err)
+	}
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{}
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		windows.CloseHandle(job)
+		return func() {}, fmt.Errorf("configure job object: %w", err)
+	}
+	handle, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(process.Pid))
+	if err != nil {
+		windows.CloseHandle(job)
+		return func() {}, fmt.Errorf("open process: %w", err)
+	}
+	defer windows.CloseHandle(handle)
+	if err := windows.AssignProcessToJobObject(job, handle); err != nil {
+		windows.CloseHandle(job)
+		return func() {}, fmt.Errorf("assign process to job object: %w", err)
+	}
+	processJobs.Store(process.Pid, job)
+	return func() {
+		processJobs.Delete(process.Pid)
+		windows.CloseHandle(job)
+	}, nil
+}
+
+// terminationSignal describes what sendTermination sends, for run events.
+const terminationSignal = "CTRL_BREAK_EVENT to the process group"
+
+// sendTermination sends CTRL_BREAK_EVENT to the process group, the closest Windows has
+// to SIGTERM. Console programs can handle it to clean up; when it cannot be delivered
+// (e.g. the daemon has no console) the tree is killed instead.
+func sendTermination(process *os.Process) {
+	if process == nil {
+		return
+	}
+	if err := windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(process.Pid)); err != nil {
+		_ = killProcessTree(process)
+	}
+}
+
+// killProcessTree terminates the process's job object, falling back to the process itself.
+func killProcessTree(process *os.Process) error {
+	if process == nil {
+		return nil
+	}
+	if value, ok := processJobs.Load(process.Pid); ok {
+		if err := windows.TerminateJobObject(value.(windows.Handle), 1); err == nil {
+			return nil
+		}
+	}
+	return process.Kill()
+}
+
+// killProcess is not supported on Windows, which does not list processes for the reaper.
+func killProcess(pid int) error {
+	return errors.New("killing orphaned processes is not supported on windows")
+}
+
+// processInfo describes a live OS process.
+type processInfo struct {
+	PID       int
+	PGID      int
+	StartedAt time.Time
+}
+
+// listProcesses is not supported on Windows.
+func listProcesses(ctx context.Context) ([]processInfo, error) {
+	return nil, errors.New("process listing is not supported on windows")
+}
@@ -0,0 +1,58 @@
+//go:build !windows
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sampleProcess reads the resident set size and accumulated CPU time of a process via ps(1),
+// which behaves the same on Linux and macOS.
+func sampleProcess(ctx context.Context, pid int) (ProcessUsage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "ps", "-o", "rss=", "-o", "time=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ProcessUsage{}, fmt.Errorf("ps: %w", err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 {
+		return ProcessUsage{}, fmt.Errorf("unexpected ps output %q", strings.TrimSpace(string(out)))
+	}
+	rss, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return ProcessUsage{}, fmt.Errorf("parse rss: %w", err)
+	}
+	cpu, err := parseCPUTime(fields[1])
+	if err != nil {
+		return ProcessUsage{}, err
+	}
+	return ProcessUsage{RSSKB: rss, CPUSeconds: cpu}, nil
+}
+
+// parseCPUTime parses ps TIME values such as "1-02:03:04", "02:03:04" or "3:04.56".
+func parseCPUTime(value string) (float64, error) {
+	var days float64
+	if idx := strings.Index(value, "-"); idx >= 0 {
+		d, err := strconv.ParseFloat(value[:idx], 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse cpu time %q: %w", value, err)
+		}
+		days = d
+		value = value[idx+1:]
+	}
+	var total float64
+	for _, part := range strings.Split(value, ":") {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse cpu time %q: %w", value, err)
+		}
+		total = total*60 + v
+	}
+	return days*86400 + total, nil
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package core
+
+import (
+	"context"
+	"errors"
+)
+
+// sampleProcess is not implemented on Windows; resource usage is left empty.
+func sampleProcess(ctx context.Context, pid int) (ProcessUsage, error) {
+	return ProcessUsage{}, errors.New("process sampling is not supported on windows")
+}
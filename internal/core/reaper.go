@@ -0,0 +1,200 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ReaperMode controls what the orphan reaper does with leftover processes.
+type ReaperMode string
+
+const (
+	ReaperModeOff  ReaperMode = "off"
+	ReaperModeLog  ReaperMode = "log"
+	ReaperModeKill ReaperMode = "kill"
+)
+
+const (
+	// reaperLookback bounds how long after a run ended its process group is still checked.
+	reaperLookback = 24 * time.Hour
+	// reaperStartSlack tolerates clock granularity when matching process start times to runs.
+	reaperStartSlack = 2 * time.Second
+)
+
+// Reaper detects processes started by earlier runs that are still alive after the run ended
+// or after a daemon restart, and optionally kills them.
+type Reaper struct {
+	store    Store
+	runs     *RunStateMachine
+	logger   *slog.Logger
+	mode     ReaperMode
+	interval time.Duration
+	kill     func(pid int) error
+	// previousShutdown is when the previous daemon stopped, or zero when unknown and
+	// ReapStale uses the time this one started; processes started later do not belong
+	// to its runs.
+	previousShutdown time.Time
+}
+
+// NewReaper constructs an orphan process reaper.
+func NewReaper(store Store, logger *slog.Logger, mode ReaperMode, interval time.Duration) *Reaper {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &Reaper{
+		store:    store,
+		runs:     NewRunStateMachine(store),
+		logger:   logger,
+		mode:     mode,
+		interval: interval,
+		kill:     killProcess,
+	}
+}
+
+// SetPreviousShutdown records when the previous daemon stopped, narrowing which
+// processes ReapStale attributes to the runs it left behind.
+func (r *Reaper) SetPreviousShutdown(at time.Time) {
+	r.previousShutdown = at
+}
+
+// ReapStale handles runs left in queued/running state by a previous daemon process:
+// runs still waiting in the queue are kept there to be dispatched again, and runs it
+// was executing are marked canceled. It must be called before the scheduler starts
+// dispatching runs.
+func (r *Reaper) ReapStale(ctx context.Context) error {
+	requeued, err := r.store.RecoverRunQueue(ctx)
+	if err != nil {
+		return err
+	}
+	runs, err := r.store.ListActiveRuns(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	waiting := make(map[string]bool, len(requeued))
+	for _, id := range requeued {
+		waiting[id] = true
+		if err := r.store.AddRunEvent(ctx, RunEvent{RunID: id, Type: RunEventQueued, Detail: "requeued after daemon restart", At: now}); err != nil {
+			r.logger.Warn("record requeued run", "run_id", id, "err", err)
+		}
+	}
+	if len(requeued) > 0 {
+		r.logger.Info("dispatching runs left queued by the previous daemon", "runs", len(requeued))
+	}
+	stoppedAt := r.previousShutdown
+	if stoppedAt.IsZero() {
+		stoppedAt = now
+	}
+	var procs []processInfo
+	if len(runs) > len(requeued) {
+		procs = r.processes(ctx)
+	}
+	for _, run := range runs {
+		if waiting[run.ID] {
+			continue
+		}
+		r.reapRun(run, procs, stoppedAt, runs)
+		errMsg := "daemon restarted while run was active"
+		if err := r.runs.Finish(ctx, run.ID, RunStatusCanceled, now, nil, &errMsg); err != nil {
+			r.logger.Error("mark stale run canceled", "run_id", run.ID, "err", err)
+		} else {
+			r.logger.Warn("marked stale run canceled", "run_id", run.ID, "task_id", run.TaskID)
+		}
+	}
+	return nil
+}
+
+// Run periodically checks recently ended runs for surviving processes until ctx is done.
+func (r *Reaper) Run(ctx context.Context) {
+	if r.mode == ReaperModeOff {
+		return
+	}
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.sweep(ctx)
+		}
+	}
+}
+
+func (r *Reaper) sweep(ctx context.Context) {
+	runs, err := r.store.ListEndedRunsWithPID(ctx, time.Now().UTC().Add(-reaperLookback))
+	if err != nil {
+		r.logger.Error("list ended runs for reaper", "err", err)
+		return
+	}
+	if len(runs) == 0 {
+		return
+	}
+	// Active runs may own a group whose PID an ended run used before
+	active, err := r.store.ListActiveRuns(ctx)
+	if err != nil {
+		r.logger.Error("list active runs for reaper", "err", err)
+		return
+	}
+	owners := append(runs, active...)
+	procs := r.processes(ctx)
+	for _, run := range runs {
+		r.reapRun(run, procs, *run.EndedAt, owners)
+	}
+}
+
+// processes lists live processes, returning nil when reaping is disabled or unsupported.
+func (r *Reaper) processes(ctx context.Context) []processInfo {
+	if r.mode == ReaperModeOff {
+		return nil
+	}
+	procs, err := listProcesses(ctx)
+	if err != nil {
+		r.logger.Debug("list processes for reaper", "err", err)
+		return nil
+	}
+	return procs
+}
+
+// reapRun finds processes in the run's process group that started while the run could
+// have started them, between its start and notAfter, and logs or kills them. The
+// group's leader has usually exited, so each process is matched on its own; as PIDs
+// are reused, a group that a later run among owners also used is left alone.
+func (r *Reaper) reapRun(run *Run, procs []processInfo, notAfter time.Time, owners []*Run) {
+	if run.PID == nil || run.StartedAt == nil {
+		return
+	}
+	for _, other := range owners {
+		if other != run && other.PID != nil && *other.PID == *run.PID && other.StartedAt != nil && other.StartedAt.After(*run.StartedAt) {
+			return
+		}
+	}
+	notBefore := run.StartedAt.Add(-reaperStartSlack)
+	notAfter = notAfter.Add(reaperStartSlack)
+	var orphans []int
+	for _, p := range procs {
+		if p.PGID != *run.PID || p.StartedAt.Before(notBefore) || p.StartedAt.After(notAfter) {
+			continue
+		}
+		orphans = append(orphans, p.PID)
+	}
+	if len(orphans) == 0 {
+		return
+	}
+	if r.mode != ReaperModeKill {
+		r.logger.Warn("orphaned run processes detected", "run_id", run.ID, "task_id", run.TaskID, "pgid", *run.PID, "pids", orphans)
+		return
+	}
+	var killed []int
+	for _, pid := range orphans {
+		if err := r.kill(pid); err != nil {
+			r.logger.Error("kill orphaned run process", "run_id", run.ID, "pgid", *run.PID, "pid", pid, "err", err)
+			continue
+		}
+		killed = append(killed, pid)
+	}
+	if len(killed) > 0 {
+		r.logger.Warn("killed orphaned run processes", "run_id", run.ID, "task_id", run.TaskID, "pgid", *run.PID, "pids", killed)
+	}
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReapRunKillsOrphansOfEndedRun(t *testing.T) {
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ended := started.Add(time.Minute)
+	pid := 4242
+	run := &Run{ID: "run", TaskID: "task", PID: &pid, StartedAt: &started, EndedAt: &ended}
+	laterStart := ended.Add(time.Hour)
+	later := &Run{ID: "later", TaskID: "task", PID: &pid, StartedAt: &laterStart}
+	tests := []struct {
+		name   string
+		procs  []processInfo
+		owners []*Run
+		want   []int
+	}{
+		{
+			name:  "child of an ended run whose leader exited",
+			procs: []processInfo{{PID: 5000, PGID: pid, StartedAt: started.Add(10 * time.Second)}, {PID: 5001, PGID: 77, StartedAt: started.Add(10 * time.Second)}},
+			want:  []int{5000},
+		},
+		{
+			name:  "group reused after the run ended",
+			procs: []processInfo{{PID: pid, PGID: pid, StartedAt: ended.Add(time.Hour)}, {PID: 5000, PGID: pid, StartedAt: ended.Add(time.Hour)}},
+		},
+		{
+			name:  "process older than the run",
+			procs: []processInfo{{PID: 5000, PGID: pid, StartedAt: started.Add(-time.Hour)}},
+		},
+		{
+			name:   "group owned by a later run",
+			procs:  []processInfo{{PID: 5000, PGID: pid, StartedAt: started.Add(10 * time.Second)}},
+			owners: []*Run{later},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			r := NewReaper(nil, slog.New(slog.NewTextHandler(&logs, nil)), ReaperModeKill, time.Minute)
+			var killed []int
+			r.kill = func(pid int) error {
+				killed = append(killed, pid)
+				return nil
+			}
+			r.reapRun(run, tt.procs, *run.EndedAt, append([]*Run{run}, tt.owners...))
+			if !reflect.DeepEqual(killed, tt.want) {
+				t.Errorf("reapRun killed %v, want %v (logs %q)", killed, tt.want, logs.String())
+			}
+		})
+	}
+}
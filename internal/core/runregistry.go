@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShutdown is the cancellation cause for runs still executing when the daemon's
+// shutdown grace period ends.
+var ErrShutdown = errors.New("system shutdown")
+
+// runRegistry tracks the runs executing in this process, each with its own context, so
+// a single run can be canceled and shutdown can wait for runs instead of killing them.
+type runRegistry struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelCauseFunc // runID -> cancel
+	wg      sync.WaitGroup
+}
+
+func newRunRegistry() *runRegistry {
+	return &runRegistry{cancels: make(map[string]context.CancelCauseFunc)}
+}
+
+// start registers the run and returns its context and a release func to call once the
+// run's final status is recorded. The context keeps parent's values but not its
+// cancellation: a run ends only through cancel, cancelAll or its own timeout.
+func (r *runRegistry) start(parent context.Context, runID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.WithoutCancel(parent))
+	r.mu.Lock()
+	r.cancels[runID] = cancel
+	r.mu.Unlock()
+	r.wg.Add(1)
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.cancels, runID)
+		r.mu.Unlock()
+		cancel(nil)
+		r.wg.Done()
+	}
+}
+
+// cancel cancels one run with cause; it returns false if the run is not executing here.
+func (r *runRegistry) cancel(runID string, cause error) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancels[runID]
+	r.mu.Unlock()
+	if ok {
+		cancel(cause)
+	}
+	return ok
+}
+
+// cancelAll cancels every executing run with cause and returns how many there were.
+func (r *runRegistry) cancelAll(cause error) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cancel := range r.cancels {
+		cancel(cause)
+	}
+	return len(r.cancels)
+}
+
+func (r *runRegistry) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.cancels)
+}
+
+// wait blocks until every registered run is released or ctx is done.
+func (r *runRegistry) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidRunTransition is returned for a run status change the state machine does not
+// allow, typically because the run already reached a terminal status.
+var ErrInvalidRunTransition = errors.New("invalid run status transition")
+
+// runTransitions lists the statuses a run may move to from each non-terminal status.
+// Runs are created queued (or recorded directly as skipped) and end in exactly one
+// terminal status; a new status must be added here before anything can set it.
+var runTransitions = map[RunStatus][]RunStatus{
+	RunStatusQueued:  {RunStatusRunning, RunStatusFailed, RunStatusCanceled},
+	RunStatusRunning: {RunStatusSucceeded, RunStatusFailed, RunStatusCanceled, RunStatusTimedOut},
+}
+
+// Terminal reports whether a run in this status can no longer change.
+func (s RunStatus) Terminal() bool {
+	switch s {
+	case RunStatusSucceeded, RunStatusFailed, RunStatusCanceled, RunStatusTimedOut, RunStatusSkipped:
+		return true
+	}
+	return false
+}
+
+// CanTransition reports whether a run may move from one status to another.
+func CanTransition(from, to RunStatus) bool {
+	for _, next := range runTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// runPredecessors returns the statuses from which a run may move to status.
+func runPredecessors(status RunStatus) []RunStatus {
+	var from []RunStatus
+	for prev := range runTransitions {
+		if CanTransition(prev, status) {
+			from = append(from, prev)
+		}
+	}
+	return from
+}
+
+// RunUpdate is the change written together with a status transition; nil fields keep
+// their stored value.
+type RunUpdate struct {
+	Status    RunStatus
+	StartedAt *time.Time
+	EndedAt   *time.Time
+	ExitCode  *int
+	Error     *string
+	Reason    *string
+	// Event is recorded together with the status change.
+	Event *RunEvent
+}
+
+// RunStateMachine is the only writer of run statuses. Each change is stored with
+// Store.TransitionRun, which applies it only while the stored status is one that
+// runTransitions allows to move to the new status, so a run that has already moved on
+// (for example finished while a cancel was in flight) is never overwritten.
+type RunStateMachine struct {
+	store Store
+}
+
+// NewRunStateMachine returns a state machine writing through store.
+func NewRunStateMachine(store Store) *RunStateMachine {
+	return &RunStateMachine{store: store}
+}
+
+// Create records a run that never executes, which must be skipped; runs that execute
+// are created by Enqueue.
+func (m *RunStateMachine) Create(ctx context.Context, run *Run) error {
+	if run.Status != RunStatusSkipped {
+		return fmt.Errorf("%w: only skipped runs are created without a queue entry, not %s", ErrInvalidRunTransition, run.Status)
+	}
+	return m.store.InsertRun(ctx, run)
+}
+
+// Enqueue inserts a queued run together with its queue entry and queued event.
+func (m *RunStateMachine) Enqueue(ctx context.Context, run *Run, entry QueueEntry) error {
+	if run.Status != RunStatusQueued {
+		return fmt.Errorf("%w: enqueued runs must be queued, not %s", ErrInvalidRunTransition, run.Status)
+	}
+	return m.store.EnqueueRun(ctx, run, entry)
+}
+
+// Start moves a queued run to running.
+func (m *RunStateMachine) Start(ctx context.Context, runID string, startedAt time.Time) error {
+	startedAt = startedAt.UTC()
+	return m.transition(ctx, runID, RunUpdate{
+		Status:    RunStatusRunning,
+		StartedAt: &startedAt,
+		Event:     &RunEvent{RunID: runID, Type: RunEventStarted, At: startedAt},
+	})
+}
+
+// Finish moves a queued or running run to a terminal status.
+func (m *RunStateMachine) Finish(ctx context.Context, runID string, status RunStatus, endedAt time.Time, exitCode *int, errMsg *string) error {
+	if !status.Terminal() {
+		return fmt.Errorf("%w: %s is not a terminal status", ErrInvalidRunTransition, status)
+	}
+	endedAt = endedAt.UTC()
+	detail := string(status)
+	if exitCode != nil {
+		detail += fmt.Sprintf(" (exit %d)", *exitCode)
+	}
+	if errMsg != nil {
+		detail += ": " + *errMsg
+	}
+	return m.transition(ctx, runID, RunUpdate{
+		Status:   status,
+		EndedAt:  &endedAt,
+		ExitCode: exitCode,
+		Error:    errMsg,
+		Event:    &RunEvent{RunID: runID, Type: RunEventFinished, Detail: detail, At: endedAt},
+	})
+}
+
+// Expire fails a queued run that could not start before its deadline.
+func (m *RunStateMachine) Expire(ctx context.Context, runID string, endedAt time.Time, errMsg string) error {
+	endedAt = endedAt.UTC()
+	reason := RunReasonExpired
+	return m.store.TransitionRun(ctx, runID, []RunStatus{RunStatusQueued},
+		RunUpdate{
+			Status:  RunStatusFailed,
+			EndedAt: &endedAt,
+			Error:   &errMsg,
+			Reason:  &reason,
+			Event:   &RunEvent{RunID: runID, Type: RunEventExpired, Detail: errMsg, At: endedAt},
+		})
+}
+
+// transition works from the stored status rather than a caller's copy of the run, which
+// may be stale or shared with other goroutines.
+func (m *RunStateMachine) transition(ctx context.Context, runID string, update RunUpdate) error {
+	return m.store.TransitionRun(ctx, runID, runPredecessors(update.Status), update)
+}
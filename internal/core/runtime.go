@@ -0,0 +1,254 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// ExecutorShell names the built-in executor running the task command with the shell. It
+// is what tasks without an executor use.
+const ExecutorShell = "shell"
+
+// errRuntimeTimeout is the cancel cause of a runtime's context when the task times out.
+var errRuntimeTimeout = errors.New("run timed out")
+
+var (
+	// ErrUnknownExecutor is returned for tasks naming an executor that is not registered.
+	ErrUnknownExecutor = errors.New("unknown executor")
+	// ErrNotShellTask is returned by CheckCommand for tasks run by another executor.
+	ErrNotShellTask = errors.New("task does not use the shell executor")
+)
+
+// executorNamePattern restricts executor names to what reads well in task fields and URLs.
+var executorNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+// Runtime performs a task's work for an executor other than the shell, e.g. in a
+// container, on a remote host or as a Go function when clicrontab is embedded. The
+// CommandExecutor records the run around it: status transitions, the run log, output
+// tail, timeout, notifications and incidents, so a runtime only does the work.
+type Runtime interface {
+	// Run performs the task, writing its output to out, until it is done or ctx ends; ctx
+	// carries the task's timeout. It returns the exit code when the runtime has one, and
+	// an error when the task failed.
+	Run(ctx context.Context, task *Task, run *Run, out io.Writer) (exitCode *int, err error)
+}
+
+// RuntimeFunc adapts a function to the Runtime interface.
+type RuntimeFunc func(ctx context.Context, task *Task, run *Run, out io.Writer) (*int, error)
+
+// Run calls f.
+func (f RuntimeFunc) Run(ctx context.Context, task *Task, run *Run, out io.Writer) (*int, error) {
+	return f(ctx, task, run, out)
+}
+
+// RuntimeRegistry is implemented by executors that run tasks with runtimes selected by
+// the task's executor field.
+type RuntimeRegistry interface {
+	// Runtimes returns the names tasks may select, the shell included, sorted.
+	Runtimes() []string
+}
+
+// ExecutorName returns the executor the task runs with, ExecutorShell when unset.
+func (t *Task) ExecutorName() string {
+	if t.Executor == nil || *t.Executor == "" {
+		return ExecutorShell
+	}
+	return *t.Executor
+}
+
+// RegisterRuntime makes tasks whose executor field is name run with rt. Names are 1-32
+// lowercase letters, digits, '-' or '_', starting with a letter; the built-in shell, http
+// and sql executors cannot be replaced and a name can be registered once.
+func (e *CommandExecutor) RegisterRuntime(name string, rt Runtime) error {
+	if !executorNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid executor name %q", name)
+	}
+	if name == ExecutorShell || ExecutorConfigField(name) != "" {
+		return fmt.Errorf("executor %q is built in", name)
+	}
+	e.runtimeMu.Lock()
+	defer e.runtimeMu.Unlock()
+	if _, ok := e.runtimes[name]; ok {
+		return fmt.Errorf("executor %q is already registered", name)
+	}
+	if e.runtimes == nil {
+		e.runtimes = make(map[string]Runtime)
+	}
+	e.runtimes[name] = rt
+	return nil
+}
+
+// Runtimes returns the registered executor names, the built-in ones included, sorted.
+func (e *CommandExecutor) Runtimes() []string {
+	e.runtimeMu.RLock()
+	defer e.runtimeMu.RUnlock()
+	names := []string{ExecutorShell}
+	for name := range e.runtimes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *CommandExecutor) runtime(name string) (Runtime, bool) {
+	e.runtimeMu.RLock()
+	defer e.runtimeMu.RUnlock()
+	rt, ok := e.runtimes[name]
+	return rt, ok
+}
+
+// executeRuntime runs a started run with the runtime registered as name, bounded by the
+// task's timeout, and records the outcome as Execute does for shell commands.
+func (e *CommandExecutor) executeRuntime(ctx context.Context, task *Task, run *Run, name string, logWriter io.Writer, startedAt time.Time) error {
+	outputTail := newTailBuffer(max(defaultOutputTailSize, e.tailSize))
+	rt, ok := e.runtime(name)
+	if !ok {
+		// The executor was registered when the task was saved but is not in this daemon
+		errMsg := fmt.Sprintf("%v %q", ErrUnknownExecutor, name)
+		e.logger.Warn("task names an unregistered executor", "task_id", task.ID, "run_id", run.ID, "executor", name)
+		return e.complete(ctx, task, run, RunStatusFailed, startedAt, e.clock.Now().UTC(), nil, &errMsg, outputTail)
+	}
+
+	runCtx, cancel := ctx, context.CancelFunc(func() {})
+	var timeout time.Duration
+	if task.TimeoutSeconds != nil && *task.TimeoutSeconds > 0 {
+		timeout = time.Duration(*task.TimeoutSeconds) * time.Second
+		runCtx, cancel = context.WithTimeoutCause(ctx, timeout, errRuntimeTimeout)
+	}
+	defer cancel()
+
+	e.logger.Info("task started", "task_id", task.ID, "run_id", run.ID, "executor", name)
+	e.recordEvent(ctx, run.ID, RunEventProcess, "executor="+name)
+	exitCode, runErr := runSafely(runCtx, rt, task, run, io.MultiWriter(logWriter, outputTail))
+	endedAt := e.clock.Now().UTC()
+
+	var status RunStatus
+	var errMsg *string
+	switch {
+	case ctx.Err() != nil:
+		status = RunStatusCanceled
+		errMsg = ptrString(cancelMessage(ctx))
+	case errors.Is(context.Cause(runCtx), errRuntimeTimeout):
+		status = RunStatusTimedOut
+		errMsg = ptrString(errRuntimeTimeout.Error())
+		e.recordEvent(ctx, run.ID, RunEventTimeout, fmt.Sprintf("exceeded %s; canceled the %s executor", timeout, name))
+	case runErr == nil:
+		status = RunStatusSucceeded
+		if exitCode == nil {
+			exitCode = new(int)
+		}
+	default:
+		status = RunStatusFailed
+		errMsg = ptrString(runErr.Error())
+	}
+	e.logger.Info("task finished", "task_id", task.ID, "run_id", run.ID, "executor", name, "status", status,
+		"output_tail", outputTail.String(), "log_path", e.store.RunLogPath(run.ID))
+	return e.complete(ctx, task, run, status, startedAt, endedAt, exitCode, errMsg, outputTail)
+}
+
+// runSafely calls the runtime, turning a panic into an error so a faulty plugin fails
+// the run instead of the daemon.
+func runSafely(ctx context.Context, rt Runtime, task *Task, run *Run, out io.Writer) (exitCode *int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			exitCode, err = nil, fmt.Errorf("executor panicked: %v", r)
+		}
+	}()
+	return rt.Run(ctx, task, run, out)
+}
+
+// ExecutorConfigError reports a task whose built-in executor is missing its
+// configuration or has an invalid one.
+type ExecutorConfigError struct {
+	// Field is the task field holding the configuration, "http" or "sql".
+	Field string
+	Err   error
+}
+
+func (e *ExecutorConfigError) Error() string { return e.Err.Error() }
+
+func (e *ExecutorConfigError) Unwrap() error { return e.Err }
+
+// ExecutorConfigField returns the task field configuring the named executor in place of
+// the command: "http" and "sql" for those built-in executors, "" for the others.
+func ExecutorConfigField(name string) string {
+	switch name {
+	case ExecutorHTTP, ExecutorSQL:
+		return name
+	}
+	return ""
+}
+
+// ValidateExecutorConfig checks that a task has what its built-in executor runs instead
+// of a command: the request of http tasks and the query of sql tasks. Errors are
+// *ExecutorConfigError.
+func ValidateExecutorConfig(t *Task) error {
+	var err error
+	switch t.ExecutorName() {
+	case ExecutorHTTP:
+		if t.HTTP == nil {
+			err = ErrHTTPRequestRequired
+		} else {
+			err = t.HTTP.Validate()
+		}
+	case ExecutorSQL:
+		if t.SQL == nil {
+			err = ErrSQLQueryRequired
+		} else {
+			err = t.SQL.Validate()
+		}
+	}
+	if err != nil {
+		return &ExecutorConfigError{Field: ExecutorConfigField(t.ExecutorName()), Err: err}
+	}
+	return nil
+}
+
+// PrepareExecutorConfig drops the configuration of executors the task does not use and
+// sets the command of http and sql tasks to a summary of theirs, so lists, searches and
+// notifications show what the task does.
+func PrepareExecutorConfig(t *Task) {
+	if t.ExecutorName() != ExecutorHTTP {
+		t.HTTP = nil
+	}
+	if t.ExecutorName() != ExecutorSQL {
+		t.SQL = nil
+	}
+	var summary string
+	switch {
+	case t.HTTP != nil:
+		summary = t.HTTP.Summary()
+	case t.SQL != nil:
+		summary = t.SQL.Summary()
+	default:
+		return
+	}
+	t.Command = summary
+	t.Prompt = summary
+}
+
+// Executors returns the executor names tasks may select.
+func (s *Scheduler) Executors() []string {
+	if registry, ok := s.executor.(RuntimeRegistry); ok {
+		return registry.Runtimes()
+	}
+	return []string{ExecutorShell}
+}
+
+// HasExecutor reports whether tasks may select the named executor; "" is the shell.
+func (s *Scheduler) HasExecutor(name string) bool {
+	if name == "" {
+		return true
+	}
+	for _, known := range s.Executors() {
+		if known == name {
+			return true
+		}
+	}
+	return false
+}
@@ -5,12 +5,28 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"clicrontab/internal/clock"
+	"clicrontab/internal/notify"
+
 	"github.com/robfig/cron/v3"
 )
 
+// ErrTaskNotFound is returned by Store.GetTask for unknown task IDs.
+var ErrTaskNotFound = errors.New("task not found")
+
+var (
+	// ErrTaskAlreadyRunning is returned by RunTaskNow while a run of the task is queued or executing.
+	ErrTaskAlreadyRunning = errors.New("task is already running")
+	// ErrTaskRateLimited is returned by RunTaskNow when min_interval_seconds has not elapsed
+	// since the task's last run; the attempt is recorded as a skipped run.
+	ErrTaskRateLimited = errors.New("task is rate limited by min_interval_seconds")
+)
+
 // Store abstracts the persistence layer used by the scheduler and executor.
 type Store interface {
 	// Task operations
@@ -18,17 +34,47 @@ type Store interface {
 	ListTasks(ctx context.Context, status *TaskStatus) ([]*Task, error)
 	UpdateTaskScheduleInfo(ctx context.Context, id string, lastRunAt, nextRunAt *time.Time) error
 	UpdateTaskNextRun(ctx context.Context, id string, nextRunAt *time.Time) error
+	UpdateTaskStatus(ctx context.Context, id string, status TaskStatus) error
+	SetTaskSkipNext(ctx context.Context, id string, at *time.Time) error
 
 	// Run operations
 	InsertRun(ctx context.Context, run *Run) error
-	MarkRunStarted(ctx context.Context, id string, startedAt time.Time) error
-	MarkRunCompleted(ctx context.Context, id string, status RunStatus, endedAt time.Time, exitCode *int, errMsg *string) error
-	UpdateRunStatus(ctx context.Context, id string, status RunStatus, errMsg *string) error
+	EnqueueRun(ctx context.Context, run *Run, entry QueueEntry) error
+	ClaimQueuedRun(ctx context.Context, now time.Time) (*Run, error)
+	RemoveQueuedRun(ctx context.Context, runID string, unclaimedOnly bool) (bool, error)
+	ExpiredQueuedRuns(ctx context.Context, now time.Time) ([]string, error)
+	RecoverRunQueue(ctx context.Context) ([]string, error)
+	// TransitionRun changes a run's status if it is currently one of from; use RunStateMachine.
+	TransitionRun(ctx context.Context, id string, from []RunStatus, update RunUpdate) error
+	SetRunPID(ctx context.Context, id string, pid int) error
+	UpdateRunUsage(ctx context.Context, id string, usage ProcessUsage) error
+	SetRunOutputTail(ctx context.Context, id string, tail string) error
+	SetRunWarning(ctx context.Context, id string, warning string) error
+	AddRunEvent(ctx context.Context, event RunEvent) error
+	ListActiveRuns(ctx context.Context) ([]*Run, error)
+	RecentRunStatuses(ctx context.Context, taskID string, limit int) ([]RunStatus, error)
+	RecentRunDurations(ctx context.Context, taskID, excludeRunID string, limit int) ([]time.Duration, error)
+	ListEndedRunsWithPID(ctx context.Context, since time.Time) ([]*Run, error)
+
+	// Scripts
+	GetScript(ctx context.Context, id string) (*Script, error)
+
+	// Maintenance windows
+	ActiveMaintenanceWindow(ctx context.Context, taskID string, at time.Time) (*MaintenanceWindow, error)
+
+	// Incident operations
+	// OpenIncident opens an incident for the task at runID, or counts one more
+	// failure on its unresolved one, and returns it.
+	OpenIncident(ctx context.Context, taskID, runID string, at time.Time) (*Incident, error)
+	// ResolveTaskIncident resolves the task's unresolved incident, if it has one.
+	ResolveTaskIncident(ctx context.Context, taskID, by string, note *string, at time.Time) error
+	MarkIncidentEscalated(ctx context.Context, id string, at time.Time) (bool, error)
 
 	// Log helpers
 	EnsureRunLogDir(runID string) error
 	RunLogPath(runID string) string
 	PruneOldRunLogs(ctx context.Context, taskID string) error
+	QueueRunLogIndex(runID, taskID string)
 }
 
 // Executor runs commands associated with a task.
@@ -36,45 +82,114 @@ type Executor interface {
 	Execute(ctx context.Context, task *Task, run *Run) error
 }
 
+// CheckCommand verifies that the task's command can be resolved, if the executor supports it.
+func (s *Scheduler) CheckCommand(ctx context.Context, task *Task) (*CommandCheck, error) {
+	checker, ok := s.executor.(CommandChecker)
+	if !ok {
+		return nil, errors.New("executor cannot check commands")
+	}
+	return checker.CheckCommand(ctx, task)
+}
+
+// SchedulerOptions tunes dispatch behaviour. The zero value means no limits.
+type SchedulerOptions struct {
+	// MaxConcurrent is the number of dispatcher workers, and so caps the runs executing at
+	// once; 0 means unlimited.
+	MaxConcurrent int
+	// QueueDeadline expires runs that could not start within this long of their scheduled
+	// time (or of being queued, when that was later); 0 disables.
+	QueueDeadline time.Duration
+	// LagWarnThreshold logs a warning when a run starts this long after its scheduled time; 0 disables.
+	LagWarnThreshold time.Duration
+	// Notifier receives scheduler-level alerts such as automatic pauses; nil disables them.
+	Notifier notify.Notifier
+	// MisfireGrace is how late a scheduled trigger may fire before MisfirePolicy applies; 0 disables.
+	MisfireGrace time.Duration
+	// MisfirePolicy handles triggers later than MisfireGrace, e.g. after system sleep.
+	MisfirePolicy MisfirePolicy
+	// SuspendOnClockAnomaly skips scheduled triggers after a backward clock jump until the
+	// clock catches up, so slots that already ran are not repeated.
+	SuspendOnClockAnomaly bool
+	// Clock drives triggers, timestamps and the scheduler's background checks; nil uses
+	// the system clock. A clock.Fake makes schedules deterministic.
+	Clock clock.Clock
+}
+
 // Scheduler manages cron-based scheduling and dispatching of tasks.
 type Scheduler struct {
 	store    Store
+	runs     *RunStateMachine
 	executor Executor
 	logger   *slog.Logger
 	location *time.Location
+	clock    clock.Clock
 
-	cron    *cron.Cron
-	entryMu sync.RWMutex
-	entries map[string]cron.EntryID
+	cron      *cronEngine
+	entryMu   sync.RWMutex
+	entries   map[string]cron.EntryID
+	refreshMu sync.Mutex // serializes RefreshTask so concurrent refreshes cannot leave two entries
 
 	running sync.Map // taskID -> struct{}{}
+	active  *runRegistry
+
+	workers       int           // dispatcher workers; 0 starts every claimed run at once
+	wake          chan struct{} // signals idle workers that a run was enqueued
+	pending       sync.Map      // runID -> *Task the run was enqueued with
+	queueDeadline time.Duration
+	lagWarn       time.Duration
+	notifier      notify.Notifier
+	misfireGrace  time.Duration
+	misfirePolicy MisfirePolicy
+
+	suspendOnClockAnomaly bool
+	clockHoldUntil        atomic.Int64 // wall-clock unix nanos; 0 when dispatch is not suspended
 
-	ctx context.Context
+	ctx          context.Context
+	stopDispatch context.CancelFunc
 }
 
 // NewScheduler constructs a scheduler with the given dependencies.
-func NewScheduler(store Store, executor Executor, logger *slog.Logger, location *time.Location) *Scheduler {
+func NewScheduler(store Store, executor Executor, logger *slog.Logger, location *time.Location, opts SchedulerOptions) *Scheduler {
 	if location == nil {
 		location = time.Local
 	}
-	c := cron.New(
-		cron.WithParser(cronParser),
-		cron.WithLocation(location),
-	)
+	clk := clock.OrSystem(opts.Clock)
 	return &Scheduler{
-		store:    store,
-		executor: executor,
-		logger:   logger,
-		location: location,
-		cron:     c,
-		entries:  make(map[string]cron.EntryID),
+		store:         store,
+		runs:          NewRunStateMachine(store),
+		executor:      executor,
+		logger:        logger,
+		location:      location,
+		clock:         clk,
+		cron:          newCronEngine(clk, location),
+		entries:       make(map[string]cron.EntryID),
+		workers:       opts.MaxConcurrent,
+		wake:          make(chan struct{}, 1),
+		active:        newRunRegistry(),
+		queueDeadline: opts.QueueDeadline,
+		lagWarn:       opts.LagWarnThreshold,
+		notifier:      opts.Notifier,
+		misfireGrace:  opts.MisfireGrace,
+		misfirePolicy: opts.MisfirePolicy,
+
+		suspendOnClockAnomaly: opts.SuspendOnClockAnomaly,
 	}
 }
 
+// Now returns the current time as read from the scheduler's clock, which is the system
+// clock unless SchedulerOptions.Clock replaced it.
+func (s *Scheduler) Now() time.Time {
+	return s.clock.Now()
+}
+
 // Start begins the scheduling loop. ctx is used for background operations (DB updates, executor runs).
 func (s *Scheduler) Start(ctx context.Context) {
 	s.ctx = ctx
 	s.cron.Start()
+	go s.watchClock(ctx)
+	go s.watchPauses(ctx)
+	go s.watchNextRuns(ctx)
+	s.startDispatcher(ctx)
 }
 
 // Stop stops the scheduler and waits for currently running cron jobs to finish dispatch.
@@ -82,31 +197,166 @@ func (s *Scheduler) Stop() context.Context {
 	return s.cron.Stop()
 }
 
+// shutdownRecordTimeout bounds how long Shutdown waits for canceled runs to record their status.
+const shutdownRecordTimeout = 5 * time.Second
+
+// Shutdown stops triggering and dispatching new runs, then gives executing runs until ctx
+// is done to finish. Runs still executing after that are canceled with ErrShutdown, and
+// Shutdown waits up to shutdownRecordTimeout more for them to record their final status.
+// Runs left in the queue stay queued and are dispatched again when the daemon next
+// starts, with the task's stored definition rather than one-off overrides.
+func (s *Scheduler) Shutdown(ctx context.Context) {
+	select {
+	case <-s.Stop().Done():
+	case <-ctx.Done():
+	}
+	if s.stopDispatch != nil {
+		s.stopDispatch()
+	}
+	if n := s.active.count(); n > 0 {
+		s.logger.Info("waiting for executing runs to finish", "runs", n)
+	}
+	if err := s.active.wait(ctx); err == nil {
+		return
+	}
+	n := s.active.cancelAll(ErrShutdown)
+	s.logger.Warn("canceling runs still executing after the shutdown grace period", "runs", n)
+	recordCtx, cancel := context.WithTimeout(context.Background(), shutdownRecordTimeout)
+	defer cancel()
+	if err := s.active.wait(recordCtx); err != nil {
+		s.logger.Warn("runs did not record their status before exit", "runs", s.active.count())
+	}
+}
+
+// SyncReport lists what Sync found out of step between the cron entries and the store,
+// by task ID. Sync has corrected all of it except Invalid.
+type SyncReport struct {
+	// Scheduled is the number of tasks with a cron entry after the sync.
+	Scheduled int
+	// Deleted tasks had a cron entry but are no longer in the store (or were archived).
+	Deleted []string
+	// Inactive tasks had a cron entry but are not active, e.g. paused.
+	Inactive []string
+	// Unscheduled tasks are active but had no cron entry; they have one now.
+	Unscheduled []string
+	// Invalid tasks were active but their cron expression does not parse; they map to the
+	// error and are now in the error status.
+	Invalid map[string]string
+	// Recovered tasks were in the error status and are active again, their cron
+	// expression having been fixed.
+	Recovered []string
+}
+
 // Sync loads all tasks from the store and ensures they are scheduled appropriately.
-func (s *Scheduler) Sync(ctx context.Context) error {
+// It is safe to call repeatedly; existing entries are replaced, and entries of tasks no
+// longer in the store (e.g. after a restore from backup) are removed. Executing runs are
+// not affected.
+func (s *Scheduler) Sync(ctx context.Context) (*SyncReport, error) {
+	// Held so a task created while the list is read is not dropped as stale
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
 	tasks, err := s.store.ListTasks(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("list tasks: %w", err)
+		return nil, fmt.Errorf("list tasks: %w", err)
 	}
+	report := &SyncReport{Invalid: map[string]string{}}
+	stored := make(map[string]bool, len(tasks))
 	for _, task := range tasks {
-		if task.Status == TaskStatusActive {
-			if err := s.scheduleTask(ctx, task); err != nil {
-				s.logger.Error("schedule task", "task_id", task.ID, "err", err)
+		stored[task.ID] = true
+	}
+	s.entryMu.RLock()
+	for taskID := range s.entries {
+		if !stored[taskID] {
+			report.Deleted = append(report.Deleted, taskID)
+		}
+	}
+	s.entryMu.RUnlock()
+	sort.Strings(report.Deleted)
+	for _, taskID := range report.Deleted {
+		s.logger.Info("unscheduling task no longer in store", "task_id", taskID)
+		s.unscheduleTask(taskID)
+	}
+
+	for _, task := range tasks {
+		_, hadEntry := s.getEntryID(task.ID)
+		s.unscheduleTask(task.ID)
+		if task.Status == TaskStatusError && s.reactivateIfCronValid(ctx, task) {
+			report.Recovered = append(report.Recovered, task.ID)
+		}
+		if task.Status != TaskStatusActive {
+			if hadEntry {
+				report.Inactive = append(report.Inactive, task.ID)
 			}
-		} else {
-			s.unscheduleTask(task.ID)
+			continue
+		}
+		if err := s.scheduleTask(ctx, task); err != nil {
+			report.Invalid[task.ID] = err.Error()
+			s.markCronInvalid(ctx, task, err)
+			continue
+		}
+		if !hadEntry {
+			report.Unscheduled = append(report.Unscheduled, task.ID)
 		}
+		report.Scheduled++
 	}
-	return nil
+	return report, nil
+}
+
+// ScheduleEntry is a cron entry as the cron engine holds it.
+type ScheduleEntry struct {
+	EntryID int
+	// TaskID is empty for an entry the scheduler no longer tracks, which would be a bug.
+	TaskID string
+	// Next is when the entry fires next; Prev when it last fired, zero if it has not.
+	Next time.Time
+	Prev time.Time
+}
+
+// Entries returns the cron engine's entries, soonest first.
+func (s *Scheduler) Entries() []ScheduleEntry {
+	s.entryMu.RLock()
+	tasks := make(map[cron.EntryID]string, len(s.entries))
+	for taskID, entryID := range s.entries {
+		tasks[entryID] = taskID
+	}
+	s.entryMu.RUnlock()
+	var entries []ScheduleEntry
+	for _, entry := range s.cron.Entries() {
+		entries = append(entries, ScheduleEntry{
+			EntryID: int(entry.ID),
+			TaskID:  tasks[entry.ID],
+			Next:    entry.Next,
+			Prev:    entry.Prev,
+		})
+	}
+	// Entries the engine has not placed yet have a zero Next; list them last
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Next.IsZero() || entries[j].Next.IsZero() {
+			return !entries[i].Next.IsZero()
+		}
+		return entries[i].Next.Before(entries[j].Next)
+	})
+	return entries
 }
 
 // AddOrUpdateTask updates the scheduler entry for a task that may have been created or modified.
 func (s *Scheduler) AddOrUpdateTask(ctx context.Context, task *Task) error {
 	s.unscheduleTask(task.ID)
+	if task.Status == TaskStatusError {
+		s.reactivateIfCronValid(ctx, task)
+	}
 	if task.Status == TaskStatusActive {
 		if err := s.scheduleTask(ctx, task); err != nil {
+			s.markCronInvalid(ctx, task, err)
 			return err
 		}
+		return nil
+	}
+	// next_run_at is the scheduler's to keep, and only active tasks have a next run
+	if task.NextRunAt != nil {
+		if err := s.store.UpdateTaskNextRun(ctx, task.ID, nil); err != nil {
+			s.logger.Warn("clear next_run_at failed", "task_id", task.ID, "err", err)
+		}
 	}
 	return nil
 }
@@ -116,21 +366,67 @@ func (s *Scheduler) RemoveTask(taskID string) {
 	s.unscheduleTask(taskID)
 }
 
-// RunTaskNow enqueues an immediate execution for the task if it is not already running.
+// RefreshTask schedules the task as it is currently stored: active tasks get a fresh entry,
+// while other and deleted tasks are unscheduled. It is meant to run after a task mutation
+// commits (see store.Tx.OnCommit); because it rereads the task, refreshes from concurrent
+// mutations converge on the last committed state whatever order they run in.
+func (s *Scheduler) RefreshTask(ctx context.Context, taskID string) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	task, err := s.store.GetTask(ctx, taskID)
+	if errors.Is(err, ErrTaskNotFound) {
+		s.unscheduleTask(taskID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reload task: %w", err)
+	}
+	return s.AddOrUpdateTask(ctx, task)
+}
+
+// CancelRun cancels an in-flight run, or removes a run still waiting in the queue. It
+// returns false if the run is not active in this scheduler.
+func (s *Scheduler) CancelRun(runID string) bool {
+	if s.active.cancel(runID, ErrRunCanceled) {
+		return true
+	}
+	return s.cancelQueued(runID)
+}
+
+// ActiveRuns returns how many runs are executing in this scheduler.
+func (s *Scheduler) ActiveRuns() int {
+	return s.active.count()
+}
+
+// RunTaskNow enqueues an immediate manual execution for the task if it is not already running.
+// It returns ErrTaskArchived, ErrTaskAlreadyRunning or ErrTaskRateLimited when the run is refused.
 func (s *Scheduler) RunTaskNow(ctx context.Context, task *Task) (*Run, error) {
+	return s.RunTaskNowAs(ctx, task, TriggerManual)
+}
+
+// RunTaskNowAs is RunTaskNow for runs started by something other than a user, recording
+// trigger as the run's trigger type.
+func (s *Scheduler) RunTaskNowAs(ctx context.Context, task *Task, trigger TriggerType) (*Run, error) {
+	if task.Status == TaskStatusArchived {
+		return nil, ErrTaskArchived
+	}
 	if s.isTaskRunning(task.ID) {
-		return nil, errors.New("task is already running")
+		return nil, ErrTaskAlreadyRunning
+	}
+	if s.isRateLimited(task, s.clock.Now()) {
+		s.recordSkippedRun(ctx, task, trigger, s.clock.Now().UTC(), SkipReasonRateLimited)
+		return nil, ErrTaskRateLimited
 	}
 	run := &Run{
 		ID:          NewID(),
 		TaskID:      task.ID,
 		Status:      RunStatusQueued,
-		ScheduledAt: time.Now().UTC(),
+		TriggerType: trigger,
+		ScheduledAt: s.clock.Now().UTC(),
 	}
-	if err := s.store.InsertRun(ctx, run); err != nil {
+	if err := s.enqueue(ctx, task, run); err != nil {
 		return nil, err
 	}
-	s.launchExecution(task, run)
 	return run, nil
 }
 
@@ -139,7 +435,7 @@ func (s *Scheduler) scheduleTask(ctx context.Context, task *Task) error {
 	if err != nil {
 		return err
 	}
-	now := time.Now().In(s.location)
+	now := s.clock.Now().In(s.location)
 	nextTimes := NextOccurrences(schedule, now, 1)
 	if len(nextTimes) == 1 {
 		nextUTC := nextTimes[0].UTC()
@@ -155,7 +451,7 @@ func (s *Scheduler) scheduleTask(ctx context.Context, task *Task) error {
 		entry := s.cron.Entry(entryID)
 		scheduledAt := entry.Prev
 		if scheduledAt.IsZero() {
-			scheduledAt = time.Now().In(s.location)
+			scheduledAt = s.clock.Now().In(s.location)
 		}
 		next := entry.Next
 		if !next.IsZero() {
@@ -181,62 +477,114 @@ func (s *Scheduler) handleScheduledTrigger(taskID string, scheduledAt time.Time)
 	if task.Status != TaskStatusActive {
 		return
 	}
+	if s.consumeSkipNext(ctx, task, scheduledAt) {
+		s.logger.Info("skipping run as requested by skip-next", "task_id", task.ID, "scheduled_at", scheduledAt)
+		s.recordSkippedRun(ctx, task, TriggerScheduled, scheduledAt, SkipReasonManual)
+		return
+	}
+	if s.isDispatchSuspended(s.clock.Now()) {
+		s.logger.Warn("skipping run while dispatch is suspended after a clock anomaly", "task_id", task.ID)
+		s.recordSkippedRun(ctx, task, TriggerScheduled, scheduledAt, SkipReasonClockAnomaly)
+		return
+	}
+	if now := s.clock.Now(); s.isMisfire(scheduledAt, now) {
+		s.logger.Warn("skipping misfired run", "task_id", task.ID, "scheduled_at", scheduledAt, "late_by", now.Sub(scheduledAt).Truncate(time.Second))
+		s.recordSkippedRun(ctx, task, TriggerScheduled, scheduledAt, SkipReasonMisfired)
+		return
+	}
 	if s.isTaskRunning(task.ID) {
 		s.logger.Info("skipping run because task is already running", "task_id", task.ID)
-		run := &Run{
-			ID:          NewID(),
-			TaskID:      task.ID,
-			Status:      RunStatusSkipped,
-			ScheduledAt: scheduledAt,
-		}
-		if err := s.store.InsertRun(ctx, run); err != nil {
-			s.logger.Error("record skipped run", "task_id", task.ID, "err", err)
-		}
+		s.recordSkippedRun(ctx, task, TriggerScheduled, scheduledAt, SkipReasonAlreadyRunning)
+		return
+	}
+	if s.isRateLimited(task, s.clock.Now()) {
+		s.logger.Info("skipping run because min interval has not elapsed", "task_id", task.ID, "min_interval_s", *task.MinIntervalSeconds)
+		s.recordSkippedRun(ctx, task, TriggerScheduled, scheduledAt, SkipReasonRateLimited)
 		return
 	}
 	run := &Run{
 		ID:          NewID(),
 		TaskID:      task.ID,
 		Status:      RunStatusQueued,
+		TriggerType: TriggerScheduled,
 		ScheduledAt: scheduledAt,
 	}
-	if err := s.store.InsertRun(ctx, run); err != nil {
-		s.logger.Error("insert run", "task_id", task.ID, "err", err)
-		return
+	if err := s.enqueue(ctx, task, run); err != nil {
+		s.logger.Error("enqueue run", "task_id", task.ID, "err", err)
+	}
+}
+
+// recordSkippedRun stores a skipped run with the given trigger and reason.
+func (s *Scheduler) recordSkippedRun(ctx context.Context, task *Task, trigger TriggerType, scheduledAt time.Time, reason string) {
+	run := &Run{
+		ID:          NewID(),
+		TaskID:      task.ID,
+		Status:      RunStatusSkipped,
+		TriggerType: trigger,
+		ScheduledAt: scheduledAt,
+		Reason:      &reason,
+	}
+	if err := s.runs.Create(ctx, run); err != nil {
+		s.logger.Error("record skipped run", "task_id", task.ID, "err", err)
 	}
-	s.launchExecution(task, run)
 }
 
-func (s *Scheduler) launchExecution(task *Task, run *Run) {
-	s.markTaskRunning(task.ID, true)
-	go func() {
-		defer s.markTaskRunning(task.ID, false)
-		ctx := s.ctxOrBackground()
+// isRateLimited reports whether starting the task at now would violate its minimum interval.
+func (s *Scheduler) isRateLimited(task *Task, now time.Time) bool {
+	if task.MinIntervalSeconds == nil || *task.MinIntervalSeconds <= 0 || task.LastRunAt == nil {
+		return false
+	}
+	gap := time.Duration(*task.MinIntervalSeconds) * time.Second
+	return now.Sub(*task.LastRunAt) < gap
+}
 
-		if err := s.executor.Execute(ctx, task, run); err != nil {
-			s.logger.Error("execute task", "task_id", task.ID, "run_id", run.ID, "err", err)
+// autoPauseHistoryMultiple bounds how many thresholds' worth of runs checkAutoPause inspects.
+const autoPauseHistoryMultiple = 10
 
-			// If execution failed due to context cancellation (e.g., system shutdown),
-			// try to save the run status using an independent context with a short timeout.
-			// This ensures the database state reflects that the run was canceled.
-			if errors.Is(err, context.Canceled) {
-				saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-				defer cancel()
+// checkAutoPause pauses the task once it accumulates PauseAfterFailures consecutive failures.
+// A resumed task gets another full set of attempts before it is paused again.
+func (s *Scheduler) checkAutoPause(task *Task) {
+	if task.PauseAfterFailures == nil || *task.PauseAfterFailures <= 0 {
+		return
+	}
+	threshold := *task.PauseAfterFailures
+	ctx := context.WithoutCancel(s.ctxOrBackground())
+	// Look further back than the threshold so a streak that continues after a resume is
+	// counted in whole multiples instead of re-pausing on the very next failure.
+	recent, err := s.store.RecentRunStatuses(ctx, task.ID, threshold*autoPauseHistoryMultiple)
+	if err != nil {
+		s.logger.Warn("load recent run statuses for auto-pause", "task_id", task.ID, "err", err)
+		return
+	}
+	failures := countConsecutiveFailures(recent)
+	if failures == 0 || failures%threshold != 0 {
+		return
+	}
 
-				errMsg := "system shutdown"
-				if updateErr := s.store.UpdateRunStatus(saveCtx, run.ID, RunStatusCanceled, &errMsg); updateErr != nil {
-					s.logger.Error("failed to mark run as canceled during shutdown", "run_id", run.ID, "err", updateErr)
-				} else {
-					s.logger.Info("marked run as canceled due to system shutdown", "run_id", run.ID)
-				}
-			}
-		}
+	if err := s.store.UpdateTaskStatus(ctx, task.ID, TaskStatusPaused); err != nil {
+		s.logger.Error("auto-pause task", "task_id", task.ID, "err", err)
+		return
+	}
+	if err := s.store.UpdateTaskNextRun(ctx, task.ID, nil); err != nil {
+		s.logger.Warn("clear next_run_at after auto-pause", "task_id", task.ID, "err", err)
+	}
+	s.unscheduleTask(task.ID)
+	s.logger.Warn("task auto-paused after consecutive failures", "task_id", task.ID, "failures", failures)
 
-		// Clean up old run logs (best effort, don't block on errors)
-		if err := s.store.PruneOldRunLogs(ctx, task.ID); err != nil {
-			s.logger.Warn("prune run logs", "task_id", task.ID, "err", err)
-		}
-	}()
+	if s.notifier == nil {
+		return
+	}
+	taskName := task.ID
+	if task.Name != nil {
+		taskName = *task.Name
+	}
+	title := fmt.Sprintf("[%s] Task Auto-Paused", taskName)
+	body := fmt.Sprintf("Task %s was paused after %d consecutive failures.\nResume it once the problem is fixed.", task.ID, failures)
+	notifyCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.notifier.Send(notifyCtx, title, body); err != nil {
+		s.logger.Error("failed to send auto-pause notification", "task_id", task.ID, "err", err)
+	}
 }
 
 func (s *Scheduler) setEntryID(taskID string, entryID cron.EntryID) {
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrScriptNotFound is returned for unknown script IDs.
+	ErrScriptNotFound = errors.New("script not found")
+	// ErrScriptNameTaken is returned when another script already has the name.
+	ErrScriptNameTaken = errors.New("script name is already used by another script")
+	// ErrScriptInUse is returned when deleting a script that tasks still run.
+	ErrScriptInUse = errors.New("script is used by tasks")
+)
+
+// Script is a named multi-line script stored in the daemon. Tasks referencing it run it
+// in place of an inline command: the executor writes it to a temporary file and runs
+// that with the interpreter of its type, in the task's shell, working directory and
+// environment.
+type Script struct {
+	ID   string
+	Name string
+	// Type selects the interpreter; see ScriptTypes.
+	Type        string
+	Content     string
+	Description *string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// scriptType is how scripts of one type are written out and run.
+type scriptType struct {
+	ext         string
+	interpreter string
+}
+
+var scriptTypes = map[string]scriptType{
+	"sh":         {ext: ".sh", interpreter: "sh"},
+	"bash":       {ext: ".sh", interpreter: "bash"},
+	"zsh":        {ext: ".zsh", interpreter: "zsh"},
+	"python":     {ext: ".py", interpreter: "python3"},
+	"node":       {ext: ".js", interpreter: "node"},
+	"ruby":       {ext: ".rb", interpreter: "ruby"},
+	"perl":       {ext: ".pl", interpreter: "perl"},
+	"powershell": {ext: ".ps1", interpreter: "pwsh -NoProfile -File"},
+}
+
+// ScriptTypes returns the script types, sorted.
+func ScriptTypes() []string {
+	types := make([]string, 0, len(scriptTypes))
+	for name := range scriptTypes {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// IsScriptType reports whether t is one of ScriptTypes.
+func IsScriptType(t string) bool {
+	_, ok := scriptTypes[t]
+	return ok
+}
+
+// ScriptCommand is the command shown by tasks running the named script.
+func ScriptCommand(name string) string {
+	return "script: " + name
+}
+
+// materializeScript writes the task's script to a temporary file and returns the shell
+// command running it, and a func removing the file once the run is over.
+func (e *CommandExecutor) materializeScript(ctx context.Context, scriptID string) (string, func(), error) {
+	script, err := e.store.GetScript(ctx, scriptID)
+	if err != nil {
+		return "", nil, fmt.Errorf("load script %s: %w", scriptID, err)
+	}
+	kind, ok := scriptTypes[script.Type]
+	if !ok {
+		return "", nil, fmt.Errorf("script %s has unknown type %q", script.Name, script.Type)
+	}
+	file, err := os.CreateTemp("", "clicron-script-*"+kind.ext)
+	if err != nil {
+		return "", nil, fmt.Errorf("create script file: %w", err)
+	}
+	cleanup := func() { os.Remove(file.Name()) }
+	content := script.Content
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("write script file: %w", err)
+	}
+	return kind.interpreter + " " + quotePath(file.Name()), cleanup, nil
+}
+
+// scriptInterpreter returns the command running a stored script's type, for checks.
+func (e *CommandExecutor) scriptInterpreter(ctx context.Context, scriptID string) (string, error) {
+	script, err := e.store.GetScript(ctx, scriptID)
+	if err != nil {
+		return "", fmt.Errorf("load script %s: %w", scriptID, err)
+	}
+	kind, ok := scriptTypes[script.Type]
+	if !ok {
+		return "", fmt.Errorf("script %s has unknown type %q", script.Name, script.Type)
+	}
+	return kind.interpreter, nil
+}
+
+// quotePath quotes a file path as one word for the task shell.
+func quotePath(path string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + path + `"`
+	}
+	return shellQuote(path)
+}
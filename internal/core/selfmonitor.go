@@ -0,0 +1,199 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"clicrontab/internal/notify"
+)
+
+// Self-monitoring limits. A reading beyond a limit is an anomaly; it is alerted once it
+// has lasted healthAlertAfter consecutive samples, so a single slow query or burst of
+// triggers stays quiet.
+const (
+	healthDBLatencyLimit  = time.Second
+	healthQueueDepthLimit = 100
+	healthGoroutineLimit  = 10000
+	healthHeapLimit       = 1 << 30
+	healthAlertAfter      = 3
+	// healthRetention is how long readings stay in the metrics table.
+	healthRetention = 7 * 24 * time.Hour
+	// healthProbeTimeout bounds the database probe and the notification of one sample.
+	healthProbeTimeout = 30 * time.Second
+)
+
+// HealthSample is one reading of the daemon's own health.
+type HealthSample struct {
+	RecordedAt time.Time
+	Goroutines int
+	HeapBytes  uint64
+	SysBytes   uint64
+	// DBLatency is how long a small query took, including waiting for the connection.
+	DBLatency time.Duration
+	// QueueDepth is the number of queued runs no worker has claimed yet.
+	QueueDepth int
+	ActiveRuns int
+}
+
+// HealthAnomaly is a reading beyond its limit.
+type HealthAnomaly struct {
+	// Metric is goroutines, heap_bytes, db_latency or queue_depth.
+	Metric string
+	Detail string
+}
+
+// Anomalies returns the sample's readings that are beyond their limits.
+func (s *HealthSample) Anomalies() []HealthAnomaly {
+	var anomalies []HealthAnomaly
+	if s.Goroutines > healthGoroutineLimit {
+		anomalies = append(anomalies, HealthAnomaly{"goroutines", fmt.Sprintf("%d goroutines (limit %d)", s.Goroutines, healthGoroutineLimit)})
+	}
+	if s.HeapBytes > healthHeapLimit {
+		anomalies = append(anomalies, HealthAnomaly{"heap_bytes", fmt.Sprintf("heap %d MiB (limit %d MiB)", s.HeapBytes>>20, healthHeapLimit>>20)})
+	}
+	if s.DBLatency > healthDBLatencyLimit {
+		anomalies = append(anomalies, HealthAnomaly{"db_latency", fmt.Sprintf("database query took %s (limit %s)", s.DBLatency.Truncate(time.Millisecond), healthDBLatencyLimit)})
+	}
+	if s.QueueDepth > healthQueueDepthLimit {
+		anomalies = append(anomalies, HealthAnomaly{"queue_depth", fmt.Sprintf("%d runs waiting for a worker (limit %d)", s.QueueDepth, healthQueueDepthLimit)})
+	}
+	return anomalies
+}
+
+// HealthStore persists self-monitoring readings.
+type HealthStore interface {
+	QueueDepth(ctx context.Context) (int, error)
+	InsertHealthSample(ctx context.Context, sample *HealthSample) error
+	PruneHealthSamples(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// SelfMonitor periodically records the daemon's health into the metrics table and alerts
+// when a reading stays beyond its limit, and again when it is back within the limit. It
+// is the monitoring for machines that have nothing else watching the daemon.
+type SelfMonitor struct {
+	store     HealthStore
+	scheduler *Scheduler
+	notifier  notify.Notifier
+	logger    *slog.Logger
+	interval  time.Duration
+
+	streaks  map[string]int  // metric -> consecutive anomalous samples
+	alerting map[string]bool // metrics an alert was sent for
+}
+
+// NewSelfMonitor constructs a self-monitor; notifier may be nil to only record and log.
+func NewSelfMonitor(store HealthStore, scheduler *Scheduler, notifier notify.Notifier, logger *slog.Logger, interval time.Duration) *SelfMonitor {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &SelfMonitor{
+		store:     store,
+		scheduler: scheduler,
+		notifier:  notifier,
+		logger:    logger,
+		interval:  interval,
+		streaks:   make(map[string]int),
+		alerting:  make(map[string]bool),
+	}
+}
+
+// Run records a sample every interval until ctx is done.
+func (m *SelfMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	var pruned time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		sample := m.Sample(ctx)
+		if err := m.store.InsertHealthSample(ctx, sample); err != nil {
+			m.logger.Warn("record daemon metrics", "err", err)
+		}
+		m.evaluate(ctx, sample)
+		if time.Since(pruned) >= time.Hour {
+			pruned = time.Now()
+			if _, err := m.store.PruneHealthSamples(ctx, pruned.Add(-healthRetention)); err != nil {
+				m.logger.Warn("prune daemon metrics", "err", err)
+			}
+		}
+	}
+}
+
+// Sample reads the daemon's current health. A failed database probe is logged and
+// reported as its latency.
+func (m *SelfMonitor) Sample(ctx context.Context) *HealthSample {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	sample := &HealthSample{
+		RecordedAt: time.Now().UTC(),
+		Goroutines: runtime.NumGoroutine(),
+		HeapBytes:  mem.HeapAlloc,
+		SysBytes:   mem.Sys,
+		ActiveRuns: m.scheduler.ActiveRuns(),
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+	started := time.Now()
+	depth, err := m.store.QueueDepth(probeCtx)
+	sample.DBLatency = time.Since(started)
+	if err != nil && ctx.Err() == nil {
+		m.logger.Warn("probe database for self-monitoring", "err", err, "took", sample.DBLatency.Truncate(time.Millisecond))
+	}
+	sample.QueueDepth = depth
+	return sample
+}
+
+// evaluate tracks how long each anomaly has lasted and notifies when one crosses
+// healthAlertAfter samples and when an alerted one clears.
+func (m *SelfMonitor) evaluate(ctx context.Context, sample *HealthSample) {
+	current := make(map[string]bool)
+	var fresh []string
+	for _, anomaly := range sample.Anomalies() {
+		current[anomaly.Metric] = true
+		m.streaks[anomaly.Metric]++
+		if m.streaks[anomaly.Metric] >= healthAlertAfter && !m.alerting[anomaly.Metric] {
+			m.alerting[anomaly.Metric] = true
+			fresh = append(fresh, anomaly.Detail)
+		}
+	}
+	var cleared []string
+	for metric := range m.streaks {
+		if current[metric] {
+			continue
+		}
+		delete(m.streaks, metric)
+		if m.alerting[metric] {
+			delete(m.alerting, metric)
+			cleared = append(cleared, metric)
+		}
+	}
+
+	if len(fresh) > 0 {
+		m.logger.Warn("daemon health anomaly", "anomalies", fresh)
+		m.notify(ctx, "[clicrontab] Daemon Health Warning", fmt.Sprintf("The daemon has been unhealthy for %d consecutive checks:\n%s", healthAlertAfter, strings.Join(fresh, "\n")))
+	}
+	if len(cleared) > 0 {
+		sort.Strings(cleared)
+		m.logger.Info("daemon health recovered", "metrics", cleared)
+		m.notify(ctx, "[clicrontab] Daemon Health Recovered", "Back within limits: "+strings.Join(cleared, ", "))
+	}
+}
+
+func (m *SelfMonitor) notify(ctx context.Context, title, body string) {
+	if m.notifier == nil {
+		return
+	}
+	notifyCtx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+	if err := m.notifier.Send(notifyCtx, title, body); err != nil {
+		m.logger.Error("failed to send daemon health notification", "err", err)
+	}
+}
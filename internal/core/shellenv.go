@@ -0,0 +1,88 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// shellEnvTimeout bounds how long sourcing the login shell's dotfiles may take.
+const shellEnvTimeout = 30 * time.Second
+
+// shellEnvMarker separates anything the dotfiles print from the environment dump.
+const shellEnvMarker = "__CLICRON_ENV__"
+
+// shellEnvSkip lists variables that describe the snapshot shell itself rather than the
+// environment it built, and would be wrong for the runs that reuse it.
+var shellEnvSkip = map[string]bool{"PWD": true, "OLDPWD": true, "SHLVL": true, "_": true}
+
+// shellEnvCache holds the environment of a login shell so runs can start a plain shell
+// with it instead of re-sourcing dotfiles (nvm, conda, ...) every time.
+type shellEnvCache struct {
+	shell  string
+	ttl    time.Duration // 0 keeps the first snapshot for the daemon's lifetime
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	env     []string
+	takenAt time.Time
+}
+
+func newShellEnvCache(shell string, ttl time.Duration, logger *slog.Logger) *shellEnvCache {
+	return &shellEnvCache{shell: shell, ttl: ttl, logger: logger}
+}
+
+// get returns the cached environment, taking a new snapshot when there is none yet or
+// the current one is older than ttl. A failed refresh keeps the previous snapshot.
+func (c *shellEnvCache) get(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.env != nil && (c.ttl <= 0 || time.Since(c.takenAt) < c.ttl) {
+		return c.env, nil
+	}
+	started := time.Now()
+	env, err := snapshotLoginEnv(ctx, c.shell)
+	if err != nil {
+		if c.env != nil {
+			c.logger.Warn("refresh login shell environment; keeping previous snapshot", "shell", c.shell, "err", err)
+			return c.env, nil
+		}
+		return nil, err
+	}
+	c.env, c.takenAt = env, time.Now()
+	c.logger.Info("snapshotted login shell environment", "shell", c.shell, "vars", len(env), "took", time.Since(started).Truncate(time.Millisecond))
+	return c.env, nil
+}
+
+// snapshotLoginEnv runs shell as a login shell once and returns the environment it ends
+// up with, as KEY=VALUE entries.
+func snapshotLoginEnv(ctx context.Context, shell string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, shellEnvTimeout)
+	defer cancel()
+	script := `printf '%s\0' ` + shellEnvMarker + `; env -0`
+	out, err := exec.CommandContext(ctx, shell, "-l", "-c", script).Output() // #nosec G204
+	if err != nil {
+		return nil, fmt.Errorf("run login shell %s: %w", shell, err)
+	}
+	_, dump, ok := bytes.Cut(out, []byte(shellEnvMarker+"\x00"))
+	if !ok {
+		return nil, fmt.Errorf("login shell %s printed no environment", shell)
+	}
+	var env []string
+	for _, entry := range strings.Split(string(dump), "\x00") {
+		name, _, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || shellEnvSkip[name] {
+			continue
+		}
+		env = append(env, entry)
+	}
+	if len(env) == 0 {
+		return nil, fmt.Errorf("login shell %s printed an empty environment", shell)
+	}
+	return env, nil
+}
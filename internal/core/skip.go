@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SkipReasonManual marks a scheduled run suppressed by the skip-next action.
+const SkipReasonManual = "manual"
+
+// ErrTaskNotActive is returned for actions that only apply to active tasks.
+var ErrTaskNotActive = errors.New("task is not active")
+
+// SkipNext marks the task's next scheduled occurrence to be recorded as skipped instead
+// of run, and returns that occurrence. Calling it again before the occurrence is a no-op.
+func (s *Scheduler) SkipNext(ctx context.Context, task *Task) (time.Time, error) {
+	if task.Status != TaskStatusActive {
+		return time.Time{}, ErrTaskNotActive
+	}
+	var next time.Time
+	if entryID, ok := s.getEntryID(task.ID); ok {
+		next = s.cron.Entry(entryID).Next
+	}
+	if next.IsZero() {
+		schedule, err := ParseCron(task.Cron)
+		if err != nil {
+			return time.Time{}, err
+		}
+		next = NextOccurrences(schedule, s.clock.Now().In(s.location), 1)[0]
+	}
+	next = next.UTC()
+	if err := s.store.SetTaskSkipNext(ctx, task.ID, &next); err != nil {
+		return time.Time{}, err
+	}
+	task.SkipNextAt = &next
+	return next, nil
+}
+
+// CancelSkipNext clears a pending skip-next.
+func (s *Scheduler) CancelSkipNext(ctx context.Context, task *Task) error {
+	if err := s.store.SetTaskSkipNext(ctx, task.ID, nil); err != nil {
+		return err
+	}
+	task.SkipNextAt = nil
+	return nil
+}
+
+// consumeSkipNext reports whether the trigger at scheduledAt was marked to be skipped.
+// The mark is cleared when it matches, or when its occurrence has already passed (e.g.
+// the cron expression changed so it never fired).
+func (s *Scheduler) consumeSkipNext(ctx context.Context, task *Task, scheduledAt time.Time) bool {
+	if task.SkipNextAt == nil {
+		return false
+	}
+	skipAt := task.SkipNextAt.Truncate(time.Second)
+	at := scheduledAt.Truncate(time.Second)
+	if at.Before(skipAt) {
+		return false
+	}
+	if err := s.store.SetTaskSkipNext(ctx, task.ID, nil); err != nil {
+		s.logger.Warn("clear skip_next_at", "task_id", task.ID, "err", err)
+	}
+	return at.Equal(skipAt)
+}
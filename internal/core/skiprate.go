@@ -0,0 +1,190 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"clicrontab/internal/clock"
+	"clicrontab/internal/notify"
+)
+
+const (
+	// skipRateCheckInterval is how often skip rates are evaluated.
+	skipRateCheckInterval = 10 * time.Minute
+	// skipRateMinTriggers keeps tasks that rarely fire from alerting on one or two skips.
+	skipRateMinTriggers = 4
+)
+
+// TaskSkipCounts counts a task's scheduled triggers in a window and how many of them
+// were skipped.
+type TaskSkipCounts struct {
+	TaskID   string
+	Triggers int
+	// Skipped counts skipped triggers by skip reason.
+	Skipped map[string]int
+}
+
+// SkipRate returns the share of triggers that were skipped.
+func (c *TaskSkipCounts) SkipRate() float64 {
+	if c.Triggers == 0 {
+		return 0
+	}
+	skipped := 0
+	for _, n := range c.Skipped {
+		skipped += n
+	}
+	return float64(skipped) / float64(c.Triggers)
+}
+
+// SkipRateStore reads what the skip-rate monitor needs.
+type SkipRateStore interface {
+	SkipCountsSince(ctx context.Context, since time.Time) ([]*TaskSkipCounts, error)
+	GetTask(ctx context.Context, id string) (*Task, error)
+	RecentRunDurations(ctx context.Context, taskID, excludeRunID string, limit int) ([]time.Duration, error)
+}
+
+// SkipRateMonitor alerts when too many of a task's scheduled triggers are skipped, which
+// usually means the schedule does not fit how long the task takes. Each task is alerted
+// once until its skip rate falls below the threshold again.
+type SkipRateMonitor struct {
+	store     SkipRateStore
+	notifier  notify.Notifier
+	logger    *slog.Logger
+	threshold float64
+	window    time.Duration
+	location  *time.Location
+	clock     clock.Clock
+
+	alerting map[string]bool // task IDs an alert was sent for
+}
+
+// NewSkipRateMonitor constructs a monitor alerting when more than threshold (0-1) of a
+// task's triggers within window were skipped.
+func NewSkipRateMonitor(store SkipRateStore, notifier notify.Notifier, logger *slog.Logger, threshold float64, window time.Duration, location *time.Location) *SkipRateMonitor {
+	return &SkipRateMonitor{
+		store:     store,
+		notifier:  notifier,
+		logger:    logger,
+		threshold: threshold,
+		window:    window,
+		location:  location,
+		clock:     clock.System,
+		alerting:  make(map[string]bool),
+	}
+}
+
+// SetClock makes the window follow c instead of the system clock.
+func (m *SkipRateMonitor) SetClock(c clock.Clock) {
+	m.clock = clock.OrSystem(c)
+}
+
+// Run checks skip rates every skipRateCheckInterval until ctx is done.
+func (m *SkipRateMonitor) Run(ctx context.Context) {
+	ticker := m.clock.NewTicker(skipRateCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			m.check(ctx)
+		}
+	}
+}
+
+func (m *SkipRateMonitor) check(ctx context.Context) {
+	counts, err := m.store.SkipCountsSince(ctx, m.clock.Now().Add(-m.window))
+	if err != nil {
+		m.logger.Warn("count skipped runs", "err", err)
+		return
+	}
+	high := make(map[string]bool)
+	for _, c := range counts {
+		rate := c.SkipRate()
+		if c.Triggers < skipRateMinTriggers || rate <= m.threshold {
+			continue
+		}
+		high[c.TaskID] = true
+		if m.alerting[c.TaskID] {
+			continue
+		}
+		m.alerting[c.TaskID] = true
+		m.alert(ctx, c, rate)
+	}
+	for taskID := range m.alerting {
+		if !high[taskID] {
+			delete(m.alerting, taskID)
+			m.logger.Info("skip rate back below threshold", "task_id", taskID)
+		}
+	}
+}
+
+func (m *SkipRateMonitor) alert(ctx context.Context, c *TaskSkipCounts, rate float64) {
+	task, err := m.store.GetTask(ctx, c.TaskID)
+	if err != nil {
+		m.logger.Warn("load task for skip rate alert", "task_id", c.TaskID, "err", err)
+		return
+	}
+	taskName := task.ID
+	if task.Name != nil {
+		taskName = *task.Name
+	}
+
+	reasons := make([]string, 0, len(c.Skipped))
+	for reason := range c.Skipped {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool { return c.Skipped[reasons[i]] > c.Skipped[reasons[j]] })
+	var breakdown []string
+	for _, reason := range reasons {
+		breakdown = append(breakdown, fmt.Sprintf("%s: %d", reason, c.Skipped[reason]))
+	}
+	body := fmt.Sprintf("%.0f%% of %d scheduled triggers in the last %s were skipped (%s).",
+		rate*100, c.Triggers, strings.TrimSuffix(strings.TrimSuffix(m.window.String(), "0s"), "0m"), strings.Join(breakdown, ", "))
+	if advice := m.skipAdvice(ctx, task, reasons[0]); advice != "" {
+		body += "\n" + advice
+	}
+
+	m.logger.Warn("task skips most of its triggers", "task_id", task.ID, "skip_rate", rate, "triggers", c.Triggers, "skipped", c.Skipped)
+	if m.notifier == nil {
+		return
+	}
+	notifyCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := notify.SendMessage(notifyCtx, m.notifier, notify.Message{
+		Title:  fmt.Sprintf("[%s] Frequent Skips", taskName),
+		Body:   body,
+		TaskID: task.ID,
+	}); err != nil {
+		m.logger.Error("failed to send skip rate notification", "err", err)
+	}
+}
+
+// skipAdvice suggests a fix for the most common skip reason.
+func (m *SkipRateMonitor) skipAdvice(ctx context.Context, task *Task, reason string) string {
+	var interval time.Duration
+	if schedule, err := ParseCron(task.Cron); err == nil {
+		interval = ShortestInterval(schedule, m.clock.Now().In(m.location))
+	}
+	switch reason {
+	case SkipReasonAlreadyRunning:
+		advice := "Runs overlap: the previous run is still going when the next trigger fires."
+		if durations, err := m.store.RecentRunDurations(ctx, task.ID, "", durationBaselineRuns); err == nil && len(durations) > 0 && interval > 0 {
+			advice += fmt.Sprintf(" Runs take about %s but the schedule fires every %s.", medianDuration(durations).Round(time.Second), interval)
+		}
+		advice += " Lengthen the cron interval, or lower timeout_s so a stuck run ends sooner."
+		return advice
+	case SkipReasonRateLimited:
+		if task.MinIntervalSeconds != nil {
+			return fmt.Sprintf("min_interval_s (%ds) is longer than the cron interval (%s); lower it or lengthen the interval.", *task.MinIntervalSeconds, interval)
+		}
+		return "min_interval_s is longer than the cron interval; lower it or lengthen the interval."
+	case SkipReasonMisfired:
+		return "Triggers fire late, usually because the machine was asleep; raise CLICRON_MISFIRE_GRACE or set CLICRON_MISFIRE_POLICY=run_once."
+	}
+	return ""
+}
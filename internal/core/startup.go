@@ -0,0 +1,26 @@
+package core
+
+import "context"
+
+// RunStartupTasks starts one run of every active task that has RunOnStart set. It is
+// meant to be called once after the initial Sync, standing in for cron's @reboot.
+// Tasks that are already running or rate limited are handled as for a manual run.
+func (s *Scheduler) RunStartupTasks(ctx context.Context) {
+	status := TaskStatusActive
+	tasks, err := s.store.ListTasks(ctx, &status)
+	if err != nil {
+		s.logger.Error("list tasks for startup runs", "err", err)
+		return
+	}
+	for _, task := range tasks {
+		if !task.RunOnStart {
+			continue
+		}
+		run, err := s.RunTaskNowAs(ctx, task, TriggerStartup)
+		if err != nil {
+			s.logger.Warn("startup run not started", "task_id", task.ID, "err", err)
+			continue
+		}
+		s.logger.Info("started run on daemon start", "task_id", task.ID, "run_id", run.ID)
+	}
+}
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"sort"
+	"strings"
+)
+
+// TaskMatch is a task found by FindTasks.
+type TaskMatch struct {
+	Task *Task
+	// Score ranks the match from 0 (exclusive) to 1 (exact name or ID).
+	Score float64
+	// Field is where the best match was found: id, name, prompt or command.
+	Field string
+}
+
+// taskMatchFields weights where a query matches: a hit in the name means more than one
+// somewhere in a long prompt or generated command.
+var taskMatchFields = []struct {
+	name   string
+	weight float64
+	value  func(*Task) string
+}{
+	{"name", 1, func(t *Task) string {
+		if t.Name == nil {
+			return ""
+		}
+		return *t.Name
+	}},
+	{"prompt", 0.85, func(t *Task) string { return t.Prompt }},
+	{"command", 0.7, func(t *Task) string { return t.Command }},
+}
+
+// minIDPrefix is the shortest query treated as a possible task ID prefix.
+const minIDPrefix = 4
+
+// maxSubsequenceText keeps in-order character matching to short texts such as names,
+// where it catches abbreviations; in long prompts almost any query would match.
+const maxSubsequenceText = 64
+
+// FindTasks fuzzy-matches query against the tasks' IDs, names, prompts and commands and
+// returns at most limit matches, best first.
+func FindTasks(tasks []*Task, query string, limit int) []TaskMatch {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+	var matches []TaskMatch
+	for _, task := range tasks {
+		best := TaskMatch{Task: task}
+		if len(query) >= minIDPrefix && strings.HasPrefix(task.ID, query) {
+			best.Score, best.Field = 1, "id"
+		}
+		for _, field := range taskMatchFields {
+			if score := field.weight * matchScore(strings.ToLower(field.value(task)), query); score > best.Score {
+				best.Score, best.Field = score, field.name
+			}
+		}
+		if best.Score > 0 {
+			matches = append(matches, best)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// matchScore rates how well query (lower-cased) matches text (lower-cased): whole text,
+// prefix, substring, then the share of query words found, then the query's characters
+// appearing in order. It returns 0 for no match.
+func matchScore(text, query string) float64 {
+	switch {
+	case text == "":
+		return 0
+	case text == query:
+		return 1
+	case strings.HasPrefix(text, query):
+		return 0.9
+	case strings.Contains(text, query):
+		return 0.8
+	}
+	if words := strings.Fields(query); len(words) > 1 {
+		found := 0
+		for _, word := range words {
+			if strings.Contains(text, word) {
+				found++
+			}
+		}
+		if found > 0 {
+			return 0.7 * float64(found) / float64(len(words))
+		}
+	}
+	if len(text) <= maxSubsequenceText && isSubsequence(text, query) {
+		return 0.3
+	}
+	return 0
+}
+
+// isSubsequence reports whether the characters of query appear in text in order.
+func isSubsequence(text, query string) bool {
+	rest := []rune(query)
+	for _, r := range text {
+		if len(rest) == 0 {
+			break
+		}
+		if r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
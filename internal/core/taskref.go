@@ -0,0 +1,28 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAmbiguousTaskRef is matched by errors for task references that fit several tasks.
+var ErrAmbiguousTaskRef = errors.New("task reference is ambiguous")
+
+// ErrTaskNameTaken is returned when unique task names are enforced and another task
+// already uses the name.
+var ErrTaskNameTaken = errors.New("task name is already used by another task")
+
+// AmbiguousTaskRefError lists the tasks an ambiguous name or ID prefix matches.
+type AmbiguousTaskRefError struct {
+	Ref     string
+	Matches []string // task IDs
+}
+
+func (e *AmbiguousTaskRefError) Error() string {
+	return fmt.Sprintf("task reference %q matches several tasks: %s", e.Ref, strings.Join(e.Matches, ", "))
+}
+
+func (e *AmbiguousTaskRefError) Unwrap() error {
+	return ErrAmbiguousTaskRef
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// placeholderPattern matches the placeholders expanded in a task's command and working
+// directory at execution time:
+//
+//	{{date "2006-01-02"}}           the run's scheduled time in a Go layout
+//	{{date "2006-01-02" "-24h"}}    the same, shifted by a duration; "d" counts days
+//	{{run_id}} {{task_id}} {{task_name}}
+//
+// Anything else between double braces, e.g. docker's --format '{{.Names}}', is left as is.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*(?:date\s+"([^"]*)"(?:\s+"([^"]*)")?|(run_id|task_id|task_name))\s*\}\}`)
+
+// plainValue matches values that are one word to any shell, inserted into commands
+// without quotes.
+var plainValue = regexp.MustCompile(`^[A-Za-z0-9_./:@+=,-]+$`)
+
+// TemplateData is what placeholders expand to.
+type TemplateData struct {
+	RunID    string
+	TaskID   string
+	TaskName string
+	// Time is the run's scheduled time, in the daemon's time zone.
+	Time time.Time
+}
+
+// HasPlaceholders reports whether text contains placeholders.
+func HasPlaceholders(text string) bool {
+	return placeholderPattern.MatchString(text)
+}
+
+// ValidateTemplate checks that the date offsets of text's placeholders parse, so
+// mistakes surface when the task is saved rather than at its next run.
+func ValidateTemplate(text string) error {
+	for _, m := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+		if m[1] == "" && m[3] == "" {
+			return fmt.Errorf("%s: date layout is empty", m[0])
+		}
+		if m[2] != "" {
+			if _, err := parseDateOffset(m[2]); err != nil {
+				return fmt.Errorf("%s: %w", m[0], err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateWorkingDir checks a working directory's placeholders and, when it has any,
+// that it is an absolute path, so its expansion cannot land relative to the daemon.
+func ValidateWorkingDir(text string) error {
+	if err := ValidateTemplate(text); err != nil {
+		return err
+	}
+	if HasPlaceholders(text) && !filepath.IsAbs(text) {
+		return errors.New("a working directory with placeholders must be an absolute path")
+	}
+	return nil
+}
+
+// ExpandTemplate replaces the placeholders of text with data. Values are inserted as
+// they are, without shell quoting; commands use ExpandCommand.
+func ExpandTemplate(text string, data TemplateData) (string, error) {
+	return expandTemplate(text, data, func(value string) string { return value })
+}
+
+// ExpandCommand replaces the placeholders of a command with data, quoting each value
+// for the task shell unless it is a plain word, so a task name holding spaces, ";" or
+// "$()" stays one argument. Placeholders therefore must not be wrapped in quotes.
+func ExpandCommand(text string, data TemplateData) (string, error) {
+	return expandTemplate(text, data, quoteValue)
+}
+
+// quoteValue quotes a placeholder value as one word for the task shell. cmd.exe has
+// no escape for a double quote inside a quoted word, so those are dropped.
+func quoteValue(value string) string {
+	if plainValue.MatchString(value) {
+		return value
+	}
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(value, `"`, "") + `"`
+	}
+	return shellQuote(value)
+}
+
+func expandTemplate(text string, data TemplateData, quote func(string) string) (string, error) {
+	var expandErr error
+	out := placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := placeholderPattern.FindStringSubmatch(match)
+		switch m[3] {
+		case "run_id":
+			return quote(data.RunID)
+		case "task_id":
+			return quote(data.TaskID)
+		case "task_name":
+			return quote(data.TaskName)
+		}
+		if m[1] == "" {
+			expandErr = fmt.Errorf("%s: date layout is empty", match)
+			return match
+		}
+		t := data.Time
+		if m[2] != "" {
+			offset, err := parseDateOffset(m[2])
+			if err != nil {
+				expandErr = fmt.Errorf("%s: %w", match, err)
+				return match
+			}
+			t = t.Add(offset)
+		}
+		return quote(t.Format(m[1]))
+	})
+	return out, expandErr
+}
+
+// prepareWorkingDir checks that an expanded working directory is an absolute, clean
+// path and creates its last element when missing. Its parent must already exist, so a
+// placeholder value cannot make the daemon create directory trees.
+func prepareWorkingDir(dir string) error {
+	if !filepath.IsAbs(dir) || filepath.Clean(dir) != dir {
+		return fmt.Errorf("expanded working_dir %q is not an absolute, clean path", dir)
+	}
+	if err := os.Mkdir(dir, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("create working directory: %w", err)
+	}
+	return nil
+}
+
+// parseDateOffset parses a Go duration, also accepting whole days such as "-1d".
+func parseDateOffset(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid date offset %q; use a duration such as -24h or a day count such as -1d", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date offset %q; use a duration such as -24h or a day count such as -1d", value)
+	}
+	return d, nil
+}
+
+// templateData returns what the placeholders of a run of task expand to.
+func (e *CommandExecutor) templateData(task *Task, run *Run, startedAt time.Time) TemplateData {
+	at := run.ScheduledAt
+	if at.IsZero() {
+		at = startedAt
+	}
+	name := task.ID
+	if task.Name != nil && *task.Name != "" {
+		name = *task.Name
+	}
+	return TemplateData{RunID: run.ID, TaskID: task.ID, TaskName: name, Time: at.In(e.location)}
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandCommandQuotesValues(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell quoting")
+	}
+	data := TemplateData{
+		RunID:    "01hzy3k8m4q2w6e9r7t5y1v3x0",
+		TaskName: `it's; echo injected $(echo sub) > x`,
+		Time:     time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC),
+	}
+	command, err := ExpandCommand(`printf '%s\n' {{task_name}} {{date "2006-01-02 15:04"}} {{run_id}}`, data)
+	if err != nil {
+		t.Fatalf("ExpandCommand() error = %v", err)
+	}
+	out, err := exec.Command("sh", "-c", command).Output()
+	if err != nil {
+		t.Fatalf("run %q: %v", command, err)
+	}
+	want := data.TaskName + "\n2026-03-01 01:00\n" + data.RunID + "\n"
+	if string(out) != want {
+		t.Fatalf("output = %q, want %q", out, want)
+	}
+	if !strings.Contains(command, " 01hzy3k8m4q2w6e9r7t5y1v3x0") {
+		t.Errorf("plain value was quoted: %q", command)
+	}
+}
+
+func TestExpandTemplateLeavesValues(t *testing.T) {
+	got, err := ExpandTemplate("/data/{{task_name}}", TemplateData{TaskName: "my task"})
+	if err != nil || got != "/data/my task" {
+		t.Fatalf("ExpandTemplate() = %q, %v", got, err)
+	}
+}
+
+func TestValidateWorkingDir(t *testing.T) {
+	abs := filepath.Join(t.TempDir(), `{{date "2006-01-02"}}`)
+	for _, tt := range []struct {
+		dir string
+		ok  bool
+	}{
+		{dir: abs, ok: true},
+		{dir: "relative/dir", ok: true},
+		{dir: `exports/{{date "2006-01-02"}}`},
+		{dir: `{{task_name}}`},
+	} {
+		if err := ValidateWorkingDir(tt.dir); (err == nil) != tt.ok {
+			t.Errorf("ValidateWorkingDir(%q) error = %v, want ok %v", tt.dir, err, tt.ok)
+		}
+	}
+}
+
+func TestPrepareWorkingDir(t *testing.T) {
+	root := t.TempDir()
+	dated := filepath.Join(root, "2026-03-01")
+	if err := prepareWorkingDir(dated); err != nil {
+		t.Fatalf("prepareWorkingDir(%q) error = %v", dated, err)
+	}
+	if info, err := os.Stat(dated); err != nil || !info.IsDir() {
+		t.Fatalf("directory not created: %v", err)
+	}
+	if err := prepareWorkingDir(dated); err != nil {
+		t.Fatalf("prepareWorkingDir on an existing directory: %v", err)
+	}
+	for _, dir := range []string{
+		filepath.Join(root, "a", "b", "c"),
+		root + string(filepath.Separator) + ".." + string(filepath.Separator) + "escape",
+		"relative",
+	} {
+		if err := prepareWorkingDir(dir); err == nil {
+			t.Errorf("prepareWorkingDir(%q) succeeded", dir)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "a")); !os.IsNotExist(err) {
+		t.Errorf("intermediate directory created: %v", err)
+	}
+}
@@ -1,15 +1,23 @@
 package core
 
 import (
+	"errors"
 	"time"
 )
 
+// ErrRunCanceled is the cancellation cause used when a run is canceled on request.
+var ErrRunCanceled = errors.New("canceled by request")
+
 // TaskStatus describes the lifecycle state of a task.
 type TaskStatus string
 
 const (
-	TaskStatusActive TaskStatus = "active"
-	TaskStatusPaused TaskStatus = "paused"
+	TaskStatusActive   TaskStatus = "active"
+	TaskStatusPaused   TaskStatus = "paused"
+	TaskStatusArchived TaskStatus = "archived"
+	// TaskStatusError marks a task whose stored cron expression cannot be parsed; it is
+	// not scheduled until the expression is fixed.
+	TaskStatusError TaskStatus = "error"
 )
 
 // RunStatus describes the state of an individual execution.
@@ -25,31 +33,147 @@ const (
 	RunStatusSkipped   RunStatus = "skipped"
 )
 
+// Valid reports whether s is a known run status.
+func (s RunStatus) Valid() bool {
+	switch s {
+	case RunStatusQueued, RunStatusRunning, RunStatusSucceeded, RunStatusFailed, RunStatusCanceled, RunStatusTimedOut, RunStatusSkipped:
+		return true
+	}
+	return false
+}
+
+// TriggerType records what started a run.
+type TriggerType string
+
+const (
+	TriggerScheduled  TriggerType = "scheduled"
+	TriggerManual     TriggerType = "manual"
+	TriggerRetry      TriggerType = "retry"
+	TriggerDependency TriggerType = "dependency"
+	TriggerWebhook    TriggerType = "webhook"
+	TriggerStartup    TriggerType = "startup"
+)
+
+// Valid reports whether t is a known trigger type.
+func (t TriggerType) Valid() bool {
+	switch t {
+	case TriggerScheduled, TriggerManual, TriggerRetry, TriggerDependency, TriggerWebhook, TriggerStartup:
+		return true
+	}
+	return false
+}
+
+// Reasons recorded on skipped or expired runs.
+const (
+	SkipReasonAlreadyRunning = "already_running"
+	SkipReasonRateLimited    = "rate_limited"
+	RunReasonExpired         = "expired"
+)
+
 // Task represents a scheduled automation command.
 type Task struct {
 	ID             string
 	Name           *string
-	Prompt         string  // User-provided prompt for AI CLI tools (e.g., Claude)
-	Command        string  // Full command to execute (built from prompt or directly specified)
+	Prompt         string // User-provided prompt for AI CLI tools (e.g., Claude)
+	Command        string // Full command to execute (built from prompt or directly specified)
 	Cron           string
 	TimeoutSeconds *int
 	WorkingDir     *string
-	Status         TaskStatus
-	LastRunAt      *time.Time
-	NextRunAt      *time.Time
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	// MinIntervalSeconds enforces a minimum gap between run starts, regardless of trigger source.
+	MinIntervalSeconds *int
+	// PauseAfterFailures pauses the task automatically after this many consecutive failures.
+	PauseAfterFailures *int
+	// LogRetention overrides how many of the task's latest runs keep their logs; nil uses
+	// the global setting.
+	LogRetention *int
+	// LogMaxAgeDays overrides after how many days the task's run logs are removed; nil
+	// uses the global setting.
+	LogMaxAgeDays *int
+	// Executor names the registered runtime that runs the task; nil runs Command with the
+	// shell (ExecutorShell).
+	Executor *string
+	// HTTP is the request sent by tasks using the http executor (ExecutorHTTP); their
+	// Command is its summary.
+	HTTP *HTTPRequest
+	// SQL is the query run by tasks using the sql executor (ExecutorSQL); their Command
+	// is its summary.
+	SQL *SQLQuery
+	// ScriptID references the stored script the task runs in place of an inline
+	// command; Command then shows ScriptCommand of its name.
+	ScriptID *string
+	// RunOnStart also runs the task once when the daemon starts (the equivalent of @reboot).
+	RunOnStart bool
+	Status     TaskStatus
+	// PauseUntil, set only while paused, is when the scheduler resumes the task automatically.
+	PauseUntil *time.Time
+	// SkipNextAt is a scheduled occurrence that will be recorded as skipped instead of run.
+	SkipNextAt *time.Time
+	// Source is TaskSourceFile for tasks managed by the declarative tasks file, empty otherwise.
+	Source    string
+	LastRunAt *time.Time
+	NextRunAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
+// TaskSourceFile marks tasks created and kept in sync from the declarative tasks file.
+const TaskSourceFile = "file"
+
 // Run captures a single execution attempt of a task.
 type Run struct {
 	ID          string
 	TaskID      string
 	Status      RunStatus
+	TriggerType TriggerType
 	ScheduledAt time.Time
 	StartedAt   *time.Time
 	EndedAt     *time.Time
 	ExitCode    *int
 	Error       *string
+	Reason      *string // Why the run was skipped or expired, if applicable
+	PID         *int    // OS process ID of the command once started
+	MaxRSSKB    *int64  // Peak sampled resident memory of the process, in KiB
+	CPUSeconds  *float64
+	Note        *string // Free-form annotation, e.g. the outcome of an investigation
+	OutputTail  *string // Last bytes of the combined stdout/stderr, kept after the log is pruned
+	Warning     *string // Why the finished run looks suspicious, e.g. it was unusually slow
+	Pinned      bool    // Kept by log pruning and purges
 	CreatedAt   time.Time
 }
+
+// RunEventType names a step in a run's lifecycle.
+type RunEventType string
+
+const (
+	RunEventQueued    RunEventType = "queued"
+	RunEventStarted   RunEventType = "started"   // the run left the queue
+	RunEventProcess   RunEventType = "process"   // the command's process started; detail has its PID, or the executor running the task
+	RunEventTimeout   RunEventType = "timeout"   // the timeout passed and termination was requested
+	RunEventKilled    RunEventType = "killed"    // the process tree was force-killed
+	RunEventCanceling RunEventType = "canceling" // the run was canceled while executing
+	RunEventFinished  RunEventType = "finished"  // the run reached its final status
+	RunEventExpired   RunEventType = "expired"   // the run left the queue without starting
+)
+
+// RunEvent is a timestamped lifecycle event of a run.
+type RunEvent struct {
+	RunID  string
+	Type   RunEventType
+	Detail string
+	At     time.Time
+}
+
+// TaskComment is a free-form note explaining a change to a task.
+type TaskComment struct {
+	ID        string
+	TaskID    string
+	Author    string
+	Body      string
+	CreatedAt time.Time
+}
+
+// ProcessUsage is a point-in-time resource sample of a run's process.
+type ProcessUsage struct {
+	RSSKB      int64
+	CPUSeconds float64
+}
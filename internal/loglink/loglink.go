@@ -0,0 +1,75 @@
+// Package loglink creates and verifies expiring signed links to run logs, so a
+// notification can link to a log without carrying the API token.
+package loglink
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidLink is returned for links with a missing or wrong signature.
+	ErrInvalidLink = errors.New("invalid log link")
+	// ErrLinkExpired is returned for correctly signed links past their expiry.
+	ErrLinkExpired = errors.New("log link expired")
+)
+
+// PathPrefix is where signed run logs are served; the run ID follows it.
+const PathPrefix = "/share/runs/"
+
+// Signer signs links to run logs served under baseURL.
+type Signer struct {
+	baseURL string
+	key     []byte
+	ttl     time.Duration
+}
+
+// New returns a signer for links under baseURL (e.g. "http://mac.local:7070") that stay
+// valid for ttl.
+func New(baseURL string, key []byte, ttl time.Duration) *Signer {
+	return &Signer{baseURL: strings.TrimRight(baseURL, "/"), key: key, ttl: ttl}
+}
+
+// NewKey returns a random signing key, hex encoded.
+func NewKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// URL returns a link to the run's log that expires ttl after now.
+func (s *Signer) URL(runID string, now time.Time) string {
+	expires := strconv.FormatInt(now.Add(s.ttl).Unix(), 10)
+	query := url.Values{"expires": {expires}, "sig": {s.sign(runID, expires)}}
+	return s.baseURL + PathPrefix + url.PathEscape(runID) + "/log?" + query.Encode()
+}
+
+// Verify checks the expires and sig query values of a link to the run's log.
+func (s *Signer) Verify(runID, expires, sig string, now time.Time) error {
+	if !hmac.Equal([]byte(sig), []byte(s.sign(runID, expires))) {
+		return ErrInvalidLink
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidLink
+	}
+	if now.Unix() > unix {
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+func (s *Signer) sign(runID, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(runID + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
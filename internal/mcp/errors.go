@@ -0,0 +1,49 @@
+package mcp
+
+import (
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Error codes returned by tools. They mirror the codes used by the HTTP API's error
+// responses so agents can branch on the same values regardless of transport.
+const (
+	errCodeInvalidInput   = "invalid_input"
+	errCodeInvalidCron    = "invalid_cron"
+	errCodeNotFound       = "not_found"
+	errCodeAlreadyRunning = "already_running"
+	errCodeRateLimited    = "rate_limited"
+	errCodeConflict       = "conflict"
+	errCodeAmbiguous      = "ambiguous"
+	errCodeNameTaken      = "name_taken"
+	errCodeInternal       = "internal_error"
+)
+
+// toolErrorBody is the machine-readable part of a failed tool call.
+type toolErrorBody struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// result renders the error as a failed tool result.
+func (b *toolErrorBody) result() *mcp.CallToolResult {
+	return toolError(b.Code, b.Message, b.Details)
+}
+
+// toolError builds a failed tool result. The envelope {"error": {code, message, details}}
+// is returned as structured content and, for clients that only read text, appended to
+// the human-readable message as JSON.
+func toolError(code, message string, details map[string]any) *mcp.CallToolResult {
+	envelope := map[string]toolErrorBody{
+		"error": {Code: code, Message: message, Details: details},
+	}
+	text := message
+	if data, err := json.Marshal(envelope); err == nil {
+		text += "\n\n" + string(data)
+	}
+	result := mcp.NewToolResultError(text)
+	result.StructuredContent = envelope
+	return result
+}
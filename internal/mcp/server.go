@@ -1,15 +1,24 @@
 package mcp
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"clicrontab/internal/core"
 	"clicrontab/internal/store"
+	"clicrontab/internal/version"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -26,10 +35,28 @@ type MCPServer struct {
 	location  *time.Location
 	tools     map[string]mcp.Tool
 	handlers  map[string]ToolHandler
+	filter    ToolFilter
+	known     []string  // every tool name offered, including filtered ones
+	startedAt time.Time // when the daemon built the server, for cron_status uptime
+}
+
+// ToolFilter selects which tools are exposed. An empty Allow list exposes every tool;
+// Deny always wins over Allow.
+type ToolFilter struct {
+	Allow []string
+	Deny  []string
+}
+
+// permits reports whether the named tool passes the filter.
+func (f ToolFilter) permits(name string) bool {
+	if slices.Contains(f.Deny, name) {
+		return false
+	}
+	return len(f.Allow) == 0 || slices.Contains(f.Allow, name)
 }
 
 // NewMCPServer creates a new MCP server instance.
-func NewMCPServer(store *store.Store, scheduler *core.Scheduler, logger *slog.Logger, location *time.Location, addr string) *MCPServer {
+func NewMCPServer(store *store.Store, scheduler *core.Scheduler, logger *slog.Logger, location *time.Location, addr string, filter ToolFilter) *MCPServer {
 	s := &MCPServer{
 		store:     store,
 		scheduler: scheduler,
@@ -37,6 +64,8 @@ func NewMCPServer(store *store.Store, scheduler *core.Scheduler, logger *slog.Lo
 		location:  location,
 		tools:     make(map[string]mcp.Tool),
 		handlers:  make(map[string]ToolHandler),
+		filter:    filter,
+		startedAt: time.Now(),
 	}
 
 	// Register tools
@@ -63,7 +92,7 @@ func (s *MCPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(map[string]string{
 			"status":  "ok",
 			"service": "clicrontab-mcp",
-			"version": "1.0.0",
+			"version": version.Version,
 		})
 		return
 	}
@@ -90,7 +119,7 @@ func (s *MCPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
 			ServerInfo: mcp.Implementation{
 				Name:    "clicrontab",
-				Version: "1.0.0",
+				Version: version.Version,
 			},
 			Capabilities: mcp.ServerCapabilities{
 				Tools: &struct {
@@ -169,8 +198,12 @@ func (s *MCPServer) writeJSONRPCError(w http.ResponseWriter, id mcp.RequestId, c
 	json.NewEncoder(w).Encode(response)
 }
 
-// AddTool registers a tool with the server
+// AddTool registers a tool with the server unless the tool filter excludes it.
 func (s *MCPServer) AddTool(tool mcp.Tool, handler ToolHandler) {
+	s.known = append(s.known, tool.Name)
+	if !s.filter.permits(tool.Name) {
+		return
+	}
 	s.tools[tool.Name] = tool
 	s.handlers[tool.Name] = handler
 }
@@ -179,7 +212,7 @@ func (s *MCPServer) AddTool(tool mcp.Tool, handler ToolHandler) {
 func (s *MCPServer) registerTools() {
 	// cron_create_task
 	s.AddTool(mcp.NewTool("cron_create_task",
-		mcp.WithDescription("创建一个定时执行 Claude 命令的任务。使用标准 5 字段 cron 表达式（分 时 日 月 周）"),
+		mcp.WithDescription("创建一个定时执行 Claude 命令的任务。使用标准 5 字段 cron 表达式（分 时 日 月 周），或用 schedule 结构化给出触发时间"),
 		mcp.WithString("name",
 			mcp.Description("任务名称（可选）"),
 		),
@@ -188,34 +221,91 @@ func (s *MCPServer) registerTools() {
 			mcp.Description("要执行的 Claude prompt"),
 		),
 		mcp.WithString("cron",
-			mcp.Required(),
-			mcp.Description("Cron 表达式，例如: '0 9 * * 1-5' 表示工作日早上 9 点"),
+			mcp.Description("Cron 表达式，例如: '0 9 * * 1-5' 表示工作日早上 9 点；与 schedule 二选一"),
+		),
+		mcp.WithObject("schedule",
+			mcp.Description(scheduleDescription),
+			mcp.Properties(scheduleProperties),
 		),
 		mcp.WithString("working_dir",
 			mcp.Required(),
-			mcp.Description("命令执行的工作目录"),
+			mcp.Description("命令执行的工作目录，可使用 {{date \"2006-01-02\"}}、{{run_id}}、{{task_name}} 等占位符，须为绝对路径，运行时展开，目录不存在时只创建最后一级"),
 		),
 		mcp.WithNumber("timeout_minutes",
 			mcp.Description("超时时间（分钟），默认 30"),
 			mcp.Min(0),
 		),
+		mcp.WithNumber("min_interval_seconds",
+			mcp.Description("两次运行之间的最小间隔（秒），间隔不足的触发会记录为 skipped（rate_limited）"),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("pause_after_failures",
+			mcp.Description("连续失败达到该次数后自动暂停任务并发送通知（可选）"),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("log_retention",
+			mcp.Description("保留最近多少次运行的日志，覆盖全局 CLICRON_LOG_RETENTION（可选）"),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("log_max_age_days",
+			mcp.Description("删除早于该天数的运行日志，覆盖全局 CLICRON_LOG_MAX_AGE_DAYS（可选）"),
+			mcp.Min(0),
+		),
+		mcp.WithString("executor",
+			mcp.Description(executorDescription),
+		),
+		mcp.WithBoolean("run_on_start",
+			mcp.Description("守护进程启动时额外执行一次（相当于 cron 的 @reboot），默认 false"),
+		),
+		mcp.WithString("pause_until",
+			mcp.Description(pauseUntilDescription),
+		),
 	), s.handleCreateTask)
 
+	// cron_create_tasks
+	s.AddTool(mcp.NewTool("cron_create_tasks",
+		mcp.WithDescription(fmt.Sprintf("一次创建多个定时任务（最多 %d 个）。先校验全部任务定义，任一无效或写入失败则一个都不创建；每项参数与 cron_create_task 相同", maxBatchTasks)),
+		mcp.WithArray("tasks",
+			mcp.Required(),
+			mcp.Description("任务定义数组"),
+			mcp.MinItems(1),
+			mcp.MaxItems(maxBatchTasks),
+			mcp.Items(taskSpecSchema),
+		),
+	), s.handleCreateTasks)
+
 	// cron_list_tasks
 	s.AddTool(mcp.NewTool("cron_list_tasks",
-		mcp.WithDescription("列出所有定时任务"),
+		mcp.WithDescription("列出所有定时任务；有问题的任务会标出健康状态：failing（最近一次运行失败）、stalled（调度未按时触发）、error（cron 表达式无效）"),
 		mcp.WithString("status",
-			mcp.Description("过滤状态: active 或 paused"),
-			mcp.Enum("active", "paused"),
+			mcp.Description("过滤状态: active、paused、archived 或 error（cron 表达式无效、无法调度的任务；默认列出未归档的任务）"),
+			mcp.Enum("active", "paused", "archived", "error"),
 		),
 	), s.handleListTasks)
 
+	// cron_find_task
+	s.AddTool(mcp.NewTool("cron_find_task",
+		mcp.WithDescription("按名称、prompt、命令或 ID 前缀模糊查找任务，返回候选任务 ID 及匹配分数（0-1），适合不记得任务 ID 时使用，比列出全部任务更省 token"),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("查询内容，例如任务名称的一部分或 prompt 中的关键词"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("最多返回的候选数，默认 5"),
+			mcp.Min(1),
+			mcp.Max(20),
+		),
+		mcp.WithBoolean("include_archived",
+			mcp.Description("是否包含已归档任务，默认 false"),
+		),
+	), s.handleFindTask)
+
 	// cron_get_task
 	s.AddTool(mcp.NewTool("cron_get_task",
 		mcp.WithDescription("获取任务详情"),
 		mcp.WithString("task_id",
 			mcp.Required(),
-			mcp.Description("任务 ID"),
+			mcp.Description("任务 ID、至少 4 位的 ID 前缀或唯一的任务名称"),
 		),
 	), s.handleGetTask)
 
@@ -224,7 +314,7 @@ func (s *MCPServer) registerTools() {
 		mcp.WithDescription("更新任务配置"),
 		mcp.WithString("task_id",
 			mcp.Required(),
-			mcp.Description("任务 ID"),
+			mcp.Description("任务 ID、至少 4 位的 ID 前缀或唯一的任务名称"),
 		),
 		mcp.WithString("prompt",
 			mcp.Description("新的 prompt"),
@@ -232,12 +322,41 @@ func (s *MCPServer) registerTools() {
 		mcp.WithString("cron",
 			mcp.Description("新的 cron 表达式"),
 		),
+		mcp.WithObject("schedule",
+			mcp.Description("以结构化方式给出新的调度，可代替 cron。"+scheduleDescription),
+			mcp.Properties(scheduleProperties),
+		),
 		mcp.WithString("working_dir",
-			mcp.Description("新的工作目录"),
+			mcp.Description("新的工作目录，占位符同 cron_create_task"),
+		),
+		mcp.WithNumber("min_interval_seconds",
+			mcp.Description("两次运行之间的最小间隔（秒），0 表示不限制"),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("pause_after_failures",
+			mcp.Description("连续失败自动暂停阈值，0 表示关闭"),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("log_retention",
+			mcp.Description("保留最近多少次运行的日志，0 表示使用全局设置"),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("log_max_age_days",
+			mcp.Description("删除早于该天数的运行日志，0 表示使用全局设置"),
+			mcp.Min(0),
+		),
+		mcp.WithString("executor",
+			mcp.Description(executorDescription+"；空字符串恢复为 shell"),
+		),
+		mcp.WithBoolean("run_on_start",
+			mcp.Description("守护进程启动时是否额外执行一次"),
 		),
 		mcp.WithBoolean("paused",
 			mcp.Description("是否暂停任务"),
 		),
+		mcp.WithString("pause_until",
+			mcp.Description(pauseUntilDescription),
+		),
 	), s.handleUpdateTask)
 
 	// cron_delete_task
@@ -245,7 +364,7 @@ func (s *MCPServer) registerTools() {
 		mcp.WithDescription("删除任务"),
 		mcp.WithString("task_id",
 			mcp.Required(),
-			mcp.Description("任务 ID"),
+			mcp.Description("任务 ID、至少 4 位的 ID 前缀或唯一的任务名称"),
 		),
 	), s.handleDeleteTask)
 
@@ -254,40 +373,284 @@ func (s *MCPServer) registerTools() {
 		mcp.WithDescription("立即执行指定任务"),
 		mcp.WithString("task_id",
 			mcp.Required(),
-			mcp.Description("任务 ID"),
+			mcp.Description("任务 ID、至少 4 位的 ID 前缀或唯一的任务名称"),
 		),
 		mcp.WithString("working_dir",
 			mcp.Description("临时覆盖工作目录（可选）"),
 		),
+		mcp.WithNumber("timeout_minutes",
+			mcp.Description("仅本次执行的超时时间（分钟），覆盖任务设置，0 表示不限时（可选）"),
+			mcp.Min(0),
+		),
 	), s.handleRunTask)
 
+	// cron_skip_next
+	s.AddTool(mcp.NewTool("cron_skip_next",
+		mcp.WithDescription("跳过任务的下一次计划执行（记录为 skipped，原因 manual），不暂停任务"),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("任务 ID、至少 4 位的 ID 前缀或唯一的任务名称"),
+		),
+		mcp.WithBoolean("cancel",
+			mcp.Description("为 true 时撤销尚未生效的跳过"),
+		),
+	), s.handleSkipNext)
+
+	// cron_archive_task
+	s.AddTool(mcp.NewTool("cron_archive_task",
+		mcp.WithDescription("归档任务：停止调度并从默认列表和统计中隐藏，保留运行历史；取消归档后任务为暂停状态"),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("任务 ID、至少 4 位的 ID 前缀或唯一的任务名称"),
+		),
+		mcp.WithBoolean("unarchive",
+			mcp.Description("为 true 时取消归档"),
+		),
+	), s.handleArchiveTask)
+
 	// cron_list_runs
 	s.AddTool(mcp.NewTool("cron_list_runs",
 		mcp.WithDescription("查看任务的运行历史"),
 		mcp.WithString("task_id",
 			mcp.Required(),
-			mcp.Description("任务 ID"),
+			mcp.Description("任务 ID、至少 4 位的 ID 前缀或唯一的任务名称"),
 		),
 		mcp.WithNumber("limit",
 			mcp.Description("返回的运行记录数量，默认 20"),
 			mcp.Min(1),
 			mcp.Max(100),
 		),
+		mcp.WithString("trigger_type",
+			mcp.Description("按触发方式过滤: scheduled（定时）、manual（手动）、retry、dependency、webhook、startup（启动时执行）"),
+			mcp.Enum("scheduled", "manual", "retry", "dependency", "webhook", "startup"),
+		),
+		mcp.WithString("status",
+			mcp.Description("按状态过滤，多个状态用逗号分隔，例如 failed,timed_out。可选: queued、running、succeeded、failed、canceled、timed_out、skipped"),
+		),
+		mcp.WithString("since",
+			mcp.Description("只返回此时间及之后创建的运行，支持 RFC 3339、YYYY-MM-DD HH:MM 或 YYYY-MM-DD（按服务器时区）"),
+		),
+		mcp.WithString("until",
+			mcp.Description("只返回此时间之前创建的运行，格式同 since"),
+		),
+		mcp.WithBoolean("pinned",
+			mcp.Description("true 只返回已固定的运行，false 只返回未固定的运行"),
+		),
 	), s.handleListRuns)
 
+	// cron_schedule_maintenance
+	s.AddTool(mcp.NewTool("cron_schedule_maintenance",
+		mcp.WithDescription("安排维护窗口：窗口内任务照常运行并记录，但不发送失败、恢复、慢运行等通知，也不升级，适用于上游计划维护导致的预期失败"),
+		mcp.WithString("task_id",
+			mcp.Description("只对该任务生效：任务 ID、至少 4 位的 ID 前缀或唯一的任务名称；省略则对所有任务生效"),
+		),
+		mcp.WithString("starts_at",
+			mcp.Description("开始时间，支持 RFC 3339、YYYY-MM-DD HH:MM 或 YYYY-MM-DD（按服务器时区），默认立即开始"),
+		),
+		mcp.WithString("ends_at",
+			mcp.Description("结束时间，格式同 starts_at；与 duration 二选一"),
+		),
+		mcp.WithString("duration",
+			mcp.Description("持续时长，如 90m、2h；与 ends_at 二选一"),
+		),
+		mcp.WithString("reason",
+			mcp.Description("维护原因，如上游数据库升级"),
+		),
+	), s.handleScheduleMaintenance)
+
+	// cron_list_maintenance
+	s.AddTool(mcp.NewTool("cron_list_maintenance",
+		mcp.WithDescription("查看维护窗口，默认只列出进行中和尚未开始的窗口"),
+		mcp.WithBoolean("all",
+			mcp.Description("true 时包括已结束的窗口"),
+		),
+	), s.handleListMaintenance)
+
+	// cron_list_scripts
+	s.AddTool(mcp.NewTool("cron_list_scripts",
+		mcp.WithDescription("查看脚本库中的脚本；任务可通过 HTTP API 或 Web UI 的 script_id 运行脚本"),
+		mcp.WithBoolean("content",
+			mcp.Description("true 时同时输出脚本内容"),
+		),
+	), s.handleListScripts)
+
+	// cron_upload_file
+	s.AddTool(mcp.NewTool("cron_upload_file",
+		mcp.WithDescription("上传文件（输入数据、脚本等）到守护进程的文件目录，返回任务可引用的绝对路径；同名文件会被替换"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("文件名，1-128 个字母、数字、.、- 或 _，不能以 . 开头，如 input.csv"),
+		),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("文件内容"),
+		),
+		mcp.WithString("encoding",
+			mcp.Description("content 的编码：text（默认）或 base64（二进制文件）"),
+			mcp.Enum("text", "base64"),
+		),
+	), s.handleUploadFile)
+
+	// cron_list_files
+	s.AddTool(mcp.NewTool("cron_list_files",
+		mcp.WithDescription("查看已上传的文件及其路径"),
+	), s.handleListFiles)
+
+	// cron_cancel_maintenance
+	s.AddTool(mcp.NewTool("cron_cancel_maintenance",
+		mcp.WithDescription("取消维护窗口；进行中的窗口立即结束，通知恢复正常"),
+		mcp.WithString("window_id",
+			mcp.Required(),
+			mcp.Description("维护窗口 ID"),
+		),
+	), s.handleCancelMaintenance)
+
+	// cron_list_incidents
+	s.AddTool(mcp.NewTool("cron_list_incidents",
+		mcp.WithDescription("查看故障事件：任务失败时开启事件，连续失败计入同一事件，任务再次成功或被手动解决时关闭。可用于查看当前故障或某任务的历史故障"),
+		mcp.WithString("task_id",
+			mcp.Description("只看该任务的事件：任务 ID、至少 4 位的 ID 前缀或唯一的任务名称；省略则列出所有任务"),
+		),
+		mcp.WithString("status",
+			mcp.Description("按状态过滤，多个状态用逗号分隔。可选: open（未确认）、acknowledged（已确认）、resolved（已解决）"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("返回的事件数量，默认 20"),
+			mcp.Min(1),
+			mcp.Max(100),
+		),
+	), s.handleListIncidents)
+
+	// cron_acknowledge_failure
+	s.AddTool(mcp.NewTool("cron_acknowledge_failure",
+		mcp.WithDescription("确认任务当前的故障事件（表示已有人在处理）：之后不再升级通知，重复的失败通知也暂停，直到事件解决"),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("任务 ID、至少 4 位的 ID 前缀或唯一的任务名称"),
+		),
+		mcp.WithString("by",
+			mcp.Description("确认人，默认 mcp"),
+		),
+	), s.handleAcknowledgeFailure)
+
+	// cron_resolve_incident
+	s.AddTool(mcp.NewTool("cron_resolve_incident",
+		mcp.WithDescription("手动解决任务当前的故障事件（如已部署修复）；任务之后再失败会开启新的事件"),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("任务 ID、至少 4 位的 ID 前缀或唯一的任务名称"),
+		),
+		mcp.WithString("by",
+			mcp.Description("解决人，默认 mcp"),
+		),
+		mcp.WithString("note",
+			mcp.Description("解决说明，如原因与修复方式"),
+		),
+	), s.handleResolveIncident)
+
+	// cron_add_comment
+	s.AddTool(mcp.NewTool("cron_add_comment",
+		mcp.WithDescription("为任务添加评论，记录修改调度或暂停任务的原因"),
+		mcp.WithString("task_id",
+			mcp.Required(),
+			mcp.Description("任务 ID、至少 4 位的 ID 前缀或唯一的任务名称"),
+		),
+		mcp.WithString("body",
+			mcp.Required(),
+			mcp.Description("评论内容"),
+		),
+		mcp.WithString("author",
+			mcp.Description("评论者，默认 mcp"),
+		),
+	), s.handleAddComment)
+
+	// cron_annotate_run
+	s.AddTool(mcp.NewTool("cron_annotate_run",
+		mcp.WithDescription("为运行记录添加备注（例如排查结论），备注会显示在运行历史中"),
+		mcp.WithString("run_id",
+			mcp.Required(),
+			mcp.Description("运行记录 ID"),
+		),
+		mcp.WithString("note",
+			mcp.Required(),
+			mcp.Description("备注内容，传空字符串清除备注"),
+		),
+	), s.handleAnnotateRun)
+
+	// cron_pin_run
+	s.AddTool(mcp.NewTool("cron_pin_run",
+		mcp.WithDescription("固定或取消固定运行记录。已固定的运行及其日志不会被日志保留策略清理，也不会被清除运行历史删除，适合保存重要输出（例如月度报表）"),
+		mcp.WithString("run_id",
+			mcp.Required(),
+			mcp.Description("运行记录 ID"),
+		),
+		mcp.WithBoolean("pinned",
+			mcp.Description("true 固定，false 取消固定，默认 true"),
+		),
+	), s.handlePinRun)
+
+	// cron_list_active
+	s.AddTool(mcp.NewTool("cron_list_active",
+		mcp.WithDescription("列出当前正在运行或排队中的运行记录（含 PID 与已运行时长）"),
+	), s.handleListActive)
+
+	// cron_status
+	s.AddTool(mcp.NewTool("cron_status",
+		mcp.WithDescription("获取守护进程概况：版本、运行时长、各状态任务数、正在运行的运行记录与近期失败次数（结构化结果），适合在会话开始时了解系统状态"),
+		mcp.WithNumber("hours",
+			mcp.Description("统计失败次数的时间窗口（小时），默认 24"),
+			mcp.Min(1),
+			mcp.Max(720),
+		),
+	), s.handleStatus)
+
 	// cron_get_run_log
 	s.AddTool(mcp.NewTool("cron_get_run_log",
-		mcp.WithDescription("获取运行的日志输出"),
+		mcp.WithDescription("获取运行的日志输出。日志较大时先用 grep/head/tail 在服务端筛选，超出 max_chars 的部分会被截断并在结果开头注明"),
 		mcp.WithString("run_id",
 			mcp.Required(),
 			mcp.Description("运行记录 ID"),
 		),
+		mcp.WithString("grep",
+			mcp.Description("只返回匹配该正则表达式（RE2 语法，(?i) 忽略大小写）的行，并带上行号"),
+		),
+		mcp.WithNumber("head",
+			mcp.Description("返回前 N 行日志，不能与 tail 同时使用"),
+			mcp.Min(0),
+		),
 		mcp.WithNumber("tail",
 			mcp.Description("返回最后 N 行日志，默认全部"),
 			mcp.Min(0),
 		),
+		mcp.WithNumber("max_chars",
+			mcp.Description(fmt.Sprintf("最多返回的字符数，默认 %d，0 表示不限制；使用 head 时保留开头，否则保留结尾", defaultLogMaxChars)),
+			mcp.Min(0),
+		),
 	), s.handleGetRunLog)
 
+	// cron_grep_run_log
+	s.AddTool(mcp.NewTool("cron_grep_run_log",
+		mcp.WithDescription("在运行日志中按正则表达式搜索，返回匹配行及其上下文与行号，无需读取整个日志"),
+		mcp.WithString("run_id",
+			mcp.Required(),
+			mcp.Description("运行记录 ID"),
+		),
+		mcp.WithString("pattern",
+			mcp.Required(),
+			mcp.Description("正则表达式（RE2 语法，(?i) 忽略大小写）"),
+		),
+		mcp.WithNumber("context",
+			mcp.Description("每处匹配前后附带的行数，默认 2"),
+			mcp.Min(0),
+			mcp.Max(20),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("最多返回的匹配数，默认 20"),
+			mcp.Min(1),
+			mcp.Max(200),
+		),
+	), s.handleGrepRunLog)
+
 	// cron_preview
 	s.AddTool(mcp.NewTool("cron_preview",
 		mcp.WithDescription("预览 cron 表达式的未来触发时间"),
@@ -302,20 +665,157 @@ func (s *MCPServer) registerTools() {
 		),
 	), s.handleCronPreview)
 
-	s.logger.Info("MCP tools registered", "count", 9)
+	for _, name := range append(slices.Clone(s.filter.Allow), s.filter.Deny...) {
+		if !slices.Contains(s.known, name) {
+			s.logger.Warn("unknown MCP tool in filter config", "tool", name)
+		}
+	}
+	s.logger.Info("MCP tools registered", "count", len(s.tools), "disabled", len(s.known)-len(s.tools))
 }
 
 // handleCreateTask handles the cron_create_task tool call.
 func (s *MCPServer) handleCreateTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Parse required parameters
+	task, failure := s.taskFromRequest(request)
+	if failure != nil {
+		return failure.result(), nil
+	}
+
+	// Save to database; the task is scheduled once the insert commits
+	err := s.store.InTx(ctx, func(tx *store.Tx) error {
+		if err := tx.InsertTask(ctx, task); err != nil {
+			return err
+		}
+		tx.OnCommit(func() { s.refreshSchedule(ctx, task.ID) })
+		return nil
+	})
+	if errors.Is(err, core.ErrTaskNameTaken) {
+		return toolError(errCodeNameTaken, fmt.Sprintf("任务名称已被其他任务使用: %s", *task.Name), map[string]any{"name": *task.Name}), nil
+	}
+	if err != nil {
+		s.logger.Error("insert task", "err", err)
+		return toolError(errCodeInternal, fmt.Sprintf("创建任务失败: %v", err), nil), nil
+	}
+
+	s.logger.Info("task created", "task_id", task.ID, "cron", task.Cron, "working_dir", *task.WorkingDir)
+
+	result := fmt.Sprintf("任务已创建\nID: %s\n下次执行: %s\n工作目录: %s",
+		task.ID,
+		formatTime(task.NextRunAt),
+		*task.WorkingDir,
+	)
+	if task.PauseUntil != nil {
+		result += fmt.Sprintf("\n已暂停，将于 %s 自动恢复", formatTime(task.PauseUntil))
+	}
+	return mcp.NewToolResultText(result + s.taskWarnings(task)), nil
+}
+
+// maxBatchTasks caps how many tasks one cron_create_tasks call may create.
+const maxBatchTasks = 50
+
+// handleCreateTasks handles the cron_create_tasks tool call. Every spec is validated
+// before anything is written, and the tasks are inserted in one transaction, so the
+// call creates either all tasks or none.
+func (s *MCPServer) handleCreateTasks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	specs, ok := request.GetArguments()["tasks"].([]any)
+	if !ok || len(specs) == 0 {
+		return toolError(errCodeInvalidInput, "tasks 必须是非空数组", nil), nil
+	}
+	if len(specs) > maxBatchTasks {
+		return toolError(errCodeInvalidInput, fmt.Sprintf("一次最多创建 %d 个任务", maxBatchTasks), map[string]any{"count": len(specs)}), nil
+	}
+
+	tasks := make([]*core.Task, 0, len(specs))
+	var failures []map[string]any
+	for i, spec := range specs {
+		args, ok := spec.(map[string]any)
+		if !ok {
+			failures = append(failures, map[string]any{"index": i, "code": errCodeInvalidInput, "message": "任务定义必须是对象"})
+			continue
+		}
+		var item mcp.CallToolRequest
+		item.Params.Arguments = args
+		task, failure := s.taskFromRequest(item)
+		if failure != nil {
+			failures = append(failures, map[string]any{"index": i, "code": failure.Code, "message": failure.Message})
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	if len(failures) > 0 {
+		lines := make([]string, 0, len(failures))
+		for _, f := range failures {
+			lines = append(lines, fmt.Sprintf("第 %d 个任务: %s", f["index"].(int)+1, f["message"]))
+		}
+		return toolError(errCodeInvalidInput, "任务定义无效，未创建任何任务:\n"+strings.Join(lines, "\n"),
+			map[string]any{"failures": failures}), nil
+	}
+
+	failed := -1
+	err := s.store.InTx(ctx, func(tx *store.Tx) error {
+		for i, task := range tasks {
+			if err := tx.InsertTask(ctx, task); err != nil {
+				failed = i
+				return fmt.Errorf("task %d: %w", i+1, err)
+			}
+			taskID := task.ID
+			tx.OnCommit(func() { s.refreshSchedule(ctx, taskID) })
+		}
+		return nil
+	})
+	if errors.Is(err, core.ErrTaskNameTaken) {
+		name := *tasks[failed].Name
+		return toolError(errCodeNameTaken, fmt.Sprintf("第 %d 个任务的名称已被其他任务使用，未创建任何任务: %s", failed+1, name),
+			map[string]any{"index": failed, "name": name}), nil
+	}
+	if err != nil {
+		s.logger.Error("insert tasks", "count", len(tasks), "err", err)
+		return toolError(errCodeInternal, fmt.Sprintf("创建任务失败，未创建任何任务: %v", err), nil), nil
+	}
+
+	s.logger.Info("tasks created", "count", len(tasks))
+	result := fmt.Sprintf("已创建 %d 个任务:\n", len(tasks))
+	for _, task := range tasks {
+		name := "-"
+		if task.Name != nil {
+			name = *task.Name
+		}
+		result += fmt.Sprintf("\n- ID: %s\n  名称: %s\n  下次执行: %s\n  工作目录: %s", task.ID, name, formatTime(task.NextRunAt), *task.WorkingDir)
+		if task.PauseUntil != nil {
+			result += fmt.Sprintf("\n  已暂停，将于 %s 自动恢复", formatTime(task.PauseUntil))
+		}
+		if warning := s.taskWarnings(task); warning != "" {
+			result += "\n  " + strings.TrimSpace(warning)
+		}
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// taskFromRequest validates the arguments of cron_create_task (or one cron_create_tasks
+// item) and builds the task they describe, without storing it.
+func (s *MCPServer) taskFromRequest(request mcp.CallToolRequest) (*core.Task, *toolErrorBody) {
 	prompt := mcp.ParseString(request, "prompt", "")
-	cronExpr := mcp.ParseString(request, "cron", "")
 	workingDir := mcp.ParseString(request, "working_dir", "")
+	if strings.TrimSpace(prompt) == "" {
+		return nil, &toolErrorBody{Code: errCodeInvalidInput, Message: "prompt 不能为空"}
+	}
+	if workingDir == "" {
+		return nil, &toolErrorBody{Code: errCodeInvalidInput, Message: "working_dir 不能为空"}
+	}
+	if err := core.ValidateWorkingDir(workingDir); err != nil {
+		return nil, &toolErrorBody{Code: errCodeInvalidInput, Message: fmt.Sprintf("working_dir 无效: %v", err)}
+	}
+	cronExpr, failure := cronFromRequest(request)
+	if failure != nil {
+		return nil, failure
+	}
+	if cronExpr == "" {
+		return nil, &toolErrorBody{Code: errCodeInvalidInput, Message: "必须提供 cron 或 schedule"}
+	}
 
 	// Validate cron expression
 	schedule, err := core.ParseCron(cronExpr)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("无效的 cron 表达式: %v", err)), nil
+		return nil, &toolErrorBody{Code: errCodeInvalidCron, Message: fmt.Sprintf("无效的 cron 表达式: %v", err)}
 	}
 
 	// Build command from prompt
@@ -335,44 +835,206 @@ func (s *MCPServer) handleCreateTask(ctx context.Context, request mcp.CallToolRe
 		timeoutPtr = &timeout
 	}
 
-	// Create task
+	var minIntervalPtr *int
+	if minInterval := int(mcp.ParseFloat64(request, "min_interval_seconds", 0)); minInterval > 0 {
+		minIntervalPtr = &minInterval
+	}
+
+	var pauseAfterPtr *int
+	if pauseAfter := int(mcp.ParseFloat64(request, "pause_after_failures", 0)); pauseAfter > 0 {
+		pauseAfterPtr = &pauseAfter
+	}
+
+	var logRetentionPtr *int
+	if logRetention := int(mcp.ParseFloat64(request, "log_retention", 0)); logRetention > 0 {
+		logRetentionPtr = &logRetention
+	}
+
+	var logMaxAgePtr *int
+	if logMaxAge := int(mcp.ParseFloat64(request, "log_max_age_days", 0)); logMaxAge > 0 {
+		logMaxAgePtr = &logMaxAge
+	}
+
+	executor, failure := s.parseExecutor(request)
+	if failure != nil {
+		return nil, failure
+	}
+
+	pauseUntil, failure := s.parsePauseUntil(request)
+	if failure != nil {
+		return nil, failure
+	}
+	status := core.TaskStatusActive
+	if pauseUntil != nil {
+		status = core.TaskStatusPaused
+	}
+
 	task := &core.Task{
-		ID:             core.NewID(),
-		Name:           namePtr,
-		Prompt:         prompt,
-		Command:        command,
-		Cron:           cronExpr,
-		WorkingDir:     &workingDir,
-		TimeoutSeconds: timeoutPtr,
-		Status:         core.TaskStatusActive,
+		ID:                 core.NewID(),
+		Name:               namePtr,
+		Prompt:             prompt,
+		Command:            command,
+		Cron:               cronExpr,
+		WorkingDir:         &workingDir,
+		TimeoutSeconds:     timeoutPtr,
+		MinIntervalSeconds: minIntervalPtr,
+		PauseAfterFailures: pauseAfterPtr,
+		LogRetention:       logRetentionPtr,
+		LogMaxAgeDays:      logMaxAgePtr,
+		Executor:           executor,
+		RunOnStart:         mcp.ParseBoolean(request, "run_on_start", false),
+		Status:             status,
+		PauseUntil:         pauseUntil,
+	}
+	if core.ValidateExecutorConfig(task) != nil {
+		return nil, &toolErrorBody{Code: errCodeInvalidInput, Message: configuredExecutorMessage}
 	}
 
 	// Calculate next run time
-	now := time.Now().In(s.location)
+	now := s.scheduler.Now().In(s.location)
 	nextTimes := core.NextOccurrences(schedule, now, 1)
-	if len(nextTimes) > 0 {
+	if len(nextTimes) > 0 && status == core.TaskStatusActive {
 		nextUTC := nextTimes[0].UTC()
 		task.NextRunAt = &nextUTC
 	}
+	return task, nil
+}
 
-	// Save to database
-	if err := s.store.InsertTask(ctx, task); err != nil {
-		s.logger.Error("insert task", "err", err)
-		return mcp.NewToolResultError(fmt.Sprintf("创建任务失败: %v", err)), nil
+// taskSpecSchema is the JSON schema of one cron_create_tasks item, mirroring the
+// cron_create_task parameters.
+var taskSpecSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"name":                 map[string]any{"type": "string", "description": "任务名称（可选）"},
+		"prompt":               map[string]any{"type": "string", "description": "要执行的 Claude prompt"},
+		"cron":                 map[string]any{"type": "string", "description": "Cron 表达式（分 时 日 月 周），与 schedule 二选一"},
+		"schedule":             map[string]any{"type": "object", "description": scheduleDescription, "properties": scheduleProperties},
+		"working_dir":          map[string]any{"type": "string", "description": "命令执行的工作目录，占位符同 cron_create_task"},
+		"timeout_minutes":      map[string]any{"type": "number", "minimum": 0, "description": "超时时间（分钟）"},
+		"min_interval_seconds": map[string]any{"type": "number", "minimum": 0, "description": "两次运行之间的最小间隔（秒）"},
+		"pause_after_failures": map[string]any{"type": "number", "minimum": 0, "description": "连续失败达到该次数后自动暂停任务"},
+		"log_retention":        map[string]any{"type": "number", "minimum": 0, "description": "保留最近多少次运行的日志"},
+		"log_max_age_days":     map[string]any{"type": "number", "minimum": 0, "description": "删除早于该天数的运行日志"},
+		"executor":             map[string]any{"type": "string", "description": executorDescription},
+		"run_on_start":         map[string]any{"type": "boolean", "description": "守护进程启动时额外执行一次"},
+		"pause_until":          map[string]any{"type": "string", "description": pauseUntilDescription},
+	},
+	"required": []string{"prompt", "working_dir"},
+}
+
+// executorDescription documents the executor tool parameter.
+const executorDescription = "运行任务的执行器，默认 shell（用 shell 执行命令）；其他执行器需由守护进程注册"
+
+// configuredExecutorMessage explains that http and sql tasks cannot be defined through
+// MCP tools, which take a prompt rather than a request or query.
+const configuredExecutorMessage = "http 与 sql 执行器需要请求或查询定义（http / sql 字段），请通过 HTTP API 或任务文件创建或修改"
+
+// parseExecutor reads the optional executor parameter; absent, empty and shell yield nil.
+func (s *MCPServer) parseExecutor(request mcp.CallToolRequest) (*string, *toolErrorBody) {
+	name := strings.TrimSpace(mcp.ParseString(request, "executor", ""))
+	if name == "" || name == core.ExecutorShell {
+		return nil, nil
 	}
+	if !s.scheduler.HasExecutor(name) {
+		executors := s.scheduler.Executors()
+		return nil, &toolErrorBody{Code: errCodeInvalidInput, Message: fmt.Sprintf("未知的执行器 %q，可用: %s", name, strings.Join(executors, ", ")), Details: map[string]any{"executors": executors}}
+	}
+	return &name, nil
+}
 
-	// Schedule the task
-	if err := s.scheduler.AddOrUpdateTask(ctx, task); err != nil {
-		s.logger.Error("schedule task", "task_id", task.ID, "err", err)
+// scheduleDescription documents the schedule tool parameter.
+const scheduleDescription = "结构化调度（可代替 cron）：minutes 必填，其余字段为空表示不限。例如 {\"minutes\":[0],\"hours\":[9],\"weekdays\":[1,2,3,4,5]} 等同于 '0 9 * * 1-5'"
+
+// scheduleProperties is the JSON schema of the schedule parameter's fields.
+var scheduleProperties = map[string]any{
+	"minutes":  map[string]any{"type": "array", "items": map[string]any{"type": "integer", "minimum": 0, "maximum": 59}, "description": "分钟（0-59）"},
+	"hours":    map[string]any{"type": "array", "items": map[string]any{"type": "integer", "minimum": 0, "maximum": 23}, "description": "小时（0-23）"},
+	"days":     map[string]any{"type": "array", "items": map[string]any{"type": "integer", "minimum": 1, "maximum": 31}, "description": "日期（1-31）"},
+	"months":   map[string]any{"type": "array", "items": map[string]any{"type": "integer", "minimum": 1, "maximum": 12}, "description": "月份（1-12）"},
+	"weekdays": map[string]any{"type": "array", "items": map[string]any{"type": "integer", "minimum": 0, "maximum": 6}, "description": "星期（0=周日 … 6=周六）"},
+}
+
+// cronFromRequest returns the cron expression given by the cron or schedule argument, or
+// "" when neither is set.
+func cronFromRequest(request mcp.CallToolRequest) (string, *toolErrorBody) {
+	cronExpr := mcp.ParseString(request, "cron", "")
+	raw, ok := request.GetArguments()["schedule"]
+	if !ok || raw == nil {
+		return cronExpr, nil
+	}
+	if cronExpr != "" {
+		return "", &toolErrorBody{Code: errCodeInvalidInput, Message: "cron 与 schedule 只能提供一个"}
+	}
+	fields, ok := raw.(map[string]any)
+	if !ok {
+		return "", &toolErrorBody{Code: errCodeInvalidInput, Message: "schedule 必须是对象"}
 	}
+	var spec core.CronSpec
+	for name, target := range map[string]*[]int{
+		"minutes":  &spec.Minutes,
+		"hours":    &spec.Hours,
+		"days":     &spec.Days,
+		"months":   &spec.Months,
+		"weekdays": &spec.Weekdays,
+	} {
+		values, ok := fields[name]
+		if !ok || values == nil {
+			continue
+		}
+		list, ok := values.([]any)
+		if !ok {
+			return "", &toolErrorBody{Code: errCodeInvalidInput, Message: fmt.Sprintf("schedule.%s 必须是整数数组", name)}
+		}
+		for _, value := range list {
+			n, ok := value.(float64)
+			if !ok || n != float64(int(n)) {
+				return "", &toolErrorBody{Code: errCodeInvalidInput, Message: fmt.Sprintf("schedule.%s 必须是整数数组", name)}
+			}
+			*target = append(*target, int(n))
+		}
+	}
+	cronExpr, err := core.BuildCron(spec)
+	if err != nil {
+		return "", &toolErrorBody{Code: errCodeInvalidCron, Message: fmt.Sprintf("无效的 schedule: %v", err)}
+	}
+	return cronExpr, nil
+}
 
-	s.logger.Info("task created", "task_id", task.ID, "cron", cronExpr, "working_dir", workingDir)
+// pauseUntilDescription documents the pause_until tool parameter.
+const pauseUntilDescription = "暂停到指定时间后自动恢复（可选），支持 RFC 3339、'YYYY-MM-DD HH:MM' 或 'YYYY-MM-DD'（服务器时区），设置后任务立即暂停"
 
-	return mcp.NewToolResultText(fmt.Sprintf("任务已创建\nID: %s\n下次执行: %s\n工作目录: %s",
-		task.ID,
-		formatTime(task.NextRunAt),
-		workingDir,
-	)), nil
+// parsePauseUntil reads the optional pause_until argument; an invalid value yields a tool error.
+func (s *MCPServer) parsePauseUntil(request mcp.CallToolRequest) (*time.Time, *toolErrorBody) {
+	value := strings.TrimSpace(mcp.ParseString(request, "pause_until", ""))
+	if value == "" {
+		return nil, nil
+	}
+	until, err := core.ParsePauseUntil(value, s.location, s.scheduler.Now())
+	if err != nil {
+		return nil, &toolErrorBody{Code: errCodeInvalidInput, Message: fmt.Sprintf("无效的 pause_until: %v", err), Details: map[string]any{"pause_until": value}}
+	}
+	return &until, nil
+}
+
+// refreshSchedule brings the scheduler in line with the task's committed state; it runs
+// from store.Tx.OnCommit after create and update.
+func (s *MCPServer) refreshSchedule(ctx context.Context, taskID string) {
+	if err := s.scheduler.RefreshTask(ctx, taskID); err != nil {
+		s.logger.Error("reschedule task", "task_id", taskID, "err", err)
+	}
+}
+
+// taskWarnings returns warning lines for settings that are allowed but likely unintended.
+func (s *MCPServer) taskWarnings(task *core.Task) string {
+	schedule, err := core.ParseCron(task.Cron)
+	if err != nil {
+		return ""
+	}
+	if interval, exceeds := core.TimeoutExceedsInterval(schedule, task.TimeoutSeconds, s.scheduler.Now().In(s.location)); exceeds {
+		return fmt.Sprintf("\n\n⚠️ 警告: 超时时间（%d 秒）大于两次调度之间的最短间隔（%s），运行接近超时时后续触发会被跳过",
+			*task.TimeoutSeconds, interval)
+	}
+	return ""
 }
 
 // handleListTasks handles the cron_list_tasks tool call.
@@ -385,28 +1047,50 @@ func (s *MCPServer) handleListTasks(ctx context.Context, request mcp.CallToolReq
 	} else if statusStr == "paused" {
 		status := core.TaskStatusPaused
 		statusFilter = &status
+	} else if statusStr == "archived" {
+		status := core.TaskStatusArchived
+		statusFilter = &status
+	} else if statusStr == "error" {
+		status := core.TaskStatusError
+		statusFilter = &status
 	}
 
 	tasks, err := s.store.ListTasks(ctx, statusFilter)
 	if err != nil {
 		s.logger.Error("list tasks", "err", err)
-		return mcp.NewToolResultError(fmt.Sprintf("获取任务列表失败: %v", err)), nil
+		return toolError(errCodeInternal, fmt.Sprintf("获取任务列表失败: %v", err), nil), nil
 	}
 
 	if len(tasks) == 0 {
 		return mcp.NewToolResultText("没有找到任务"), nil
 	}
+	lastStatuses, err := s.store.LastRunStatuses(ctx)
+	if err != nil {
+		s.logger.Error("load last run statuses", "err", err)
+		return toolError(errCodeInternal, fmt.Sprintf("获取任务列表失败: %v", err), nil), nil
+	}
 
+	now := s.scheduler.Now()
 	result := fmt.Sprintf("找到 %d 个任务:\n\n", len(tasks))
 	for _, t := range tasks {
 		statusIcon := "▶️"
 		if t.Status == core.TaskStatusPaused {
 			statusIcon = "⏸️"
+		} else if t.Status == core.TaskStatusArchived {
+			statusIcon = "🗄️"
+		} else if t.Status == core.TaskStatusError {
+			statusIcon = "⚠️"
 		}
 		result += fmt.Sprintf("%s %s\n", statusIcon, t.ID)
 		if t.Name != nil {
 			result += fmt.Sprintf("  名称: %s\n", *t.Name)
 		}
+		if health := core.DeriveTaskHealth(t, lastStatuses[t.ID], now); health != core.TaskHealthy {
+			result += fmt.Sprintf("  健康: %s\n", health)
+		}
+		if t.PauseUntil != nil {
+			result += fmt.Sprintf("  暂停至: %s\n", formatTime(t.PauseUntil))
+		}
 		result += fmt.Sprintf("  Cron: %s\n", t.Cron)
 		result += fmt.Sprintf("  Prompt: %s\n", truncateString(t.Prompt, 60))
 		result += fmt.Sprintf("  工作目录: %s\n", *t.WorkingDir)
@@ -419,29 +1103,134 @@ func (s *MCPServer) handleListTasks(ctx context.Context, request mcp.CallToolReq
 	return mcp.NewToolResultText(result), nil
 }
 
-// handleGetTask handles the cron_get_task tool call.
-func (s *MCPServer) handleGetTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	taskID := mcp.ParseString(request, "task_id", "")
+// handleFindTask handles the cron_find_task tool call.
+func (s *MCPServer) handleFindTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := strings.TrimSpace(mcp.ParseString(request, "query", ""))
+	if query == "" {
+		return toolError(errCodeInvalidInput, "query 不能为空", nil), nil
+	}
+	limit := int(mcp.ParseFloat64(request, "limit", 5))
+	if limit < 1 || limit > 20 {
+		limit = 5
+	}
 
-	task, err := s.store.GetTask(ctx, taskID)
+	tasks, err := s.store.ListTasks(ctx, nil)
 	if err != nil {
-		if err == store.ErrTaskNotFound {
-			return mcp.NewToolResultError(fmt.Sprintf("任务不存在: %s", taskID)), nil
+		s.logger.Error("list tasks for find", "err", err)
+		return toolError(errCodeInternal, fmt.Sprintf("获取任务列表失败: %v", err), nil), nil
+	}
+	if mcp.ParseBoolean(request, "include_archived", false) {
+		archived := core.TaskStatusArchived
+		more, err := s.store.ListTasks(ctx, &archived)
+		if err != nil {
+			s.logger.Error("list archived tasks for find", "err", err)
+			return toolError(errCodeInternal, fmt.Sprintf("获取任务列表失败: %v", err), nil), nil
 		}
-		return mcp.NewToolResultError(fmt.Sprintf("获取任务失败: %v", err)), nil
+		tasks = append(tasks, more...)
 	}
 
-	result := fmt.Sprintf("任务 ID: %s\n", task.ID)
-	if task.Name != nil {
-		result += fmt.Sprintf("名称: %s\n", *task.Name)
+	matches := core.FindTasks(tasks, query, limit)
+	if len(matches) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("没有与 %q 匹配的任务", query)), nil
 	}
-	result += fmt.Sprintf("状态: %s\n", task.Status)
-	result += fmt.Sprintf("Prompt: %s\n", task.Prompt)
-	result += fmt.Sprintf("Cron: %s\n", task.Cron)
-	result += fmt.Sprintf("工作目录: %s\n", *task.WorkingDir)
+	result := fmt.Sprintf("找到 %d 个候选任务:\n\n", len(matches))
+	for _, m := range matches {
+		result += fmt.Sprintf("%s  分数: %.2f（匹配 %s）\n", m.Task.ID, m.Score, m.Field)
+		if m.Task.Name != nil {
+			result += fmt.Sprintf("  名称: %s\n", *m.Task.Name)
+		}
+		result += fmt.Sprintf("  状态: %s\n", m.Task.Status)
+		result += fmt.Sprintf("  Prompt: %s\n\n", truncateString(m.Task.Prompt, 60))
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// resolveTaskID resolves the task_id argument, which may also be a task name or an ID
+// prefix, to a full task ID. A reference that matches no task is returned unchanged so
+// the handler reports it as not found.
+func (s *MCPServer) resolveTaskID(ctx context.Context, request mcp.CallToolRequest) (string, *toolErrorBody) {
+	ref := mcp.ParseString(request, "task_id", "")
+	id, err := s.store.ResolveTaskRef(ctx, ref)
+	var ambiguous *core.AmbiguousTaskRefError
+	switch {
+	case err == nil:
+		return id, nil
+	case errors.Is(err, store.ErrTaskNotFound):
+		return ref, nil
+	case errors.As(err, &ambiguous):
+		return "", &toolErrorBody{
+			Code:    errCodeAmbiguous,
+			Message: fmt.Sprintf("任务引用 %s 匹配到多个任务，请使用完整 ID 或更长的前缀: %s", ref, strings.Join(ambiguous.Matches, ", ")),
+			Details: map[string]any{"task_id": ref, "matches": ambiguous.Matches},
+		}
+	default:
+		return "", &toolErrorBody{Code: errCodeInternal, Message: fmt.Sprintf("解析任务引用失败: %v", err)}
+	}
+}
+
+// handleGetTask handles the cron_get_task tool call.
+func (s *MCPServer) handleGetTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	taskID, failure := s.resolveTaskID(ctx, request)
+	if failure != nil {
+		return failure.result(), nil
+	}
+
+	task, err := s.store.GetTask(ctx, taskID)
+	if err != nil {
+		if err == store.ErrTaskNotFound {
+			return toolError(errCodeNotFound, fmt.Sprintf("任务不存在: %s", taskID), map[string]any{"task_id": taskID}), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("获取任务失败: %v", err), nil), nil
+	}
+
+	result := fmt.Sprintf("任务 ID: %s\n", task.ID)
+	if task.Name != nil {
+		result += fmt.Sprintf("名称: %s\n", *task.Name)
+	}
+	result += fmt.Sprintf("状态: %s\n", task.Status)
+	if task.PauseUntil != nil {
+		result += fmt.Sprintf("暂停至: %s（到期自动恢复）\n", formatTime(task.PauseUntil))
+	}
+	if task.SkipNextAt != nil {
+		result += fmt.Sprintf("将跳过: %s 的执行\n", formatTime(task.SkipNextAt))
+	}
+	result += fmt.Sprintf("Prompt: %s\n", task.Prompt)
+	result += fmt.Sprintf("Cron: %s\n", task.Cron)
+	result += fmt.Sprintf("工作目录: %s\n", *task.WorkingDir)
 	if task.TimeoutSeconds != nil {
 		result += fmt.Sprintf("超时: %d 秒\n", *task.TimeoutSeconds)
 	}
+	if task.MinIntervalSeconds != nil {
+		result += fmt.Sprintf("最小间隔: %d 秒\n", *task.MinIntervalSeconds)
+	}
+	if task.PauseAfterFailures != nil {
+		result += fmt.Sprintf("连续失败 %d 次后自动暂停\n", *task.PauseAfterFailures)
+	}
+	if task.LogRetention != nil {
+		result += fmt.Sprintf("日志保留: 最近 %d 次运行\n", *task.LogRetention)
+	}
+	if task.LogMaxAgeDays != nil {
+		result += fmt.Sprintf("日志最长保留: %d 天\n", *task.LogMaxAgeDays)
+	}
+	if task.Executor != nil {
+		result += fmt.Sprintf("执行器: %s\n", *task.Executor)
+	}
+	if task.HTTP != nil {
+		result += fmt.Sprintf("HTTP 请求: %s\n", task.HTTP.Summary())
+	}
+	if task.SQL != nil {
+		result += fmt.Sprintf("SQL 查询: %s\n", task.SQL.Summary())
+	}
+	if task.ScriptID != nil {
+		if script, err := s.store.GetScript(ctx, *task.ScriptID); err == nil {
+			result += fmt.Sprintf("脚本: %s [%s] (ID: %s)\n", script.Name, script.Type, script.ID)
+		} else {
+			result += fmt.Sprintf("脚本: %s（无法读取: %v）\n", *task.ScriptID, err)
+		}
+	}
+	if task.RunOnStart {
+		result += "守护进程启动时执行一次\n"
+	}
 	if task.LastRunAt != nil {
 		result += fmt.Sprintf("上次运行: %s\n", formatTime(task.LastRunAt))
 	}
@@ -450,33 +1239,415 @@ func (s *MCPServer) handleGetTask(ctx context.Context, request mcp.CallToolReque
 	}
 	result += fmt.Sprintf("创建时间: %s\n", formatTime(&task.CreatedAt))
 
+	comments, err := s.store.ListTaskComments(ctx, task.ID, taskCommentPreviewCount)
+	if err != nil {
+		s.logger.Warn("list task comments", "task_id", task.ID, "err", err)
+	} else if len(comments) > 0 {
+		result += "\n最近评论:\n"
+		for _, c := range comments {
+			result += fmt.Sprintf("  [%s] %s: %s\n", formatTime(&c.CreatedAt), c.Author, c.Body)
+		}
+	}
+
+	return mcp.NewToolResultText(result), nil
+}
+
+// taskCommentPreviewCount is the number of recent comments shown by cron_get_task.
+const taskCommentPreviewCount = 5
+
+// handleAddComment handles the cron_add_comment tool call.
+func (s *MCPServer) handleAddComment(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	taskID, failure := s.resolveTaskID(ctx, request)
+	if failure != nil {
+		return failure.result(), nil
+	}
+	body := strings.TrimSpace(mcp.ParseString(request, "body", ""))
+	if body == "" {
+		return toolError(errCodeInvalidInput, "评论内容不能为空", nil), nil
+	}
+	author := strings.TrimSpace(mcp.ParseString(request, "author", ""))
+	if author == "" {
+		author = "mcp"
+	}
+
+	if _, err := s.store.GetTask(ctx, taskID); err != nil {
+		if err == store.ErrTaskNotFound {
+			return toolError(errCodeNotFound, fmt.Sprintf("任务不存在: %s", taskID), map[string]any{"task_id": taskID}), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("获取任务失败: %v", err), nil), nil
+	}
+
+	comment := &core.TaskComment{
+		ID:     core.NewID(),
+		TaskID: taskID,
+		Author: author,
+		Body:   body,
+	}
+	if err := s.store.InsertTaskComment(ctx, comment); err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("添加评论失败: %v", err), nil), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("评论已添加到任务 %s", taskID)), nil
+}
+
+// handleAcknowledgeFailure handles the cron_acknowledge_failure tool call.
+func (s *MCPServer) handleAcknowledgeFailure(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	taskID, failure := s.resolveTaskID(ctx, request)
+	if failure != nil {
+		return failure.result(), nil
+	}
+	by := strings.TrimSpace(mcp.ParseString(request, "by", ""))
+	if by == "" {
+		by = "mcp"
+	}
+
+	incident, err := s.store.GetTaskIncident(ctx, taskID)
+	if err == nil {
+		incident, err = s.store.AcknowledgeIncident(ctx, incident.ID, by, s.scheduler.Now())
+	}
+	if err != nil {
+		if err == store.ErrIncidentNotFound {
+			return toolError(errCodeConflict, fmt.Sprintf("任务 %s 当前没有失败需要确认", taskID), map[string]any{"task_id": taskID}), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("确认失败: %v", err), nil), nil
+	}
+
+	result := fmt.Sprintf("已确认任务 %s 的故障事件（确认人: %s），事件解决前不再发送失败通知\n", incident.TaskName, *incident.AcknowledgedBy)
+	result += formatIncident(incident)
 	return mcp.NewToolResultText(result), nil
 }
 
+// handleResolveIncident handles the cron_resolve_incident tool call.
+func (s *MCPServer) handleResolveIncident(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	taskID, failure := s.resolveTaskID(ctx, request)
+	if failure != nil {
+		return failure.result(), nil
+	}
+	by := strings.TrimSpace(mcp.ParseString(request, "by", ""))
+	if by == "" {
+		by = "mcp"
+	}
+	var note *string
+	if trimmed := strings.TrimSpace(mcp.ParseString(request, "note", "")); trimmed != "" {
+		note = &trimmed
+	}
+
+	incident, err := s.store.GetTaskIncident(ctx, taskID)
+	if err == nil {
+		incident, err = s.store.ResolveIncident(ctx, incident.ID, by, note, s.scheduler.Now())
+	}
+	if err != nil {
+		if err == store.ErrIncidentNotFound || err == store.ErrIncidentResolved {
+			return toolError(errCodeConflict, fmt.Sprintf("任务 %s 当前没有未解决的故障事件", taskID), map[string]any{"task_id": taskID}), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("解决事件失败: %v", err), nil), nil
+	}
+
+	result := fmt.Sprintf("已解决任务 %s 的故障事件（解决人: %s）\n", incident.TaskName, by)
+	result += formatIncident(incident)
+	return mcp.NewToolResultText(result), nil
+}
+
+// handleScheduleMaintenance handles the cron_schedule_maintenance tool call.
+func (s *MCPServer) handleScheduleMaintenance(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	now := s.scheduler.Now()
+	window := &core.MaintenanceWindow{ID: core.NewID(), StartsAt: now.UTC()}
+	if value := strings.TrimSpace(mcp.ParseString(request, "starts_at", "")); value != "" {
+		startsAt, err := core.ParseTime(value, s.location)
+		if err != nil {
+			return toolError(errCodeInvalidInput, fmt.Sprintf("无效的 starts_at: %v", err), nil), nil
+		}
+		window.StartsAt = startsAt
+	}
+	endsAt := strings.TrimSpace(mcp.ParseString(request, "ends_at", ""))
+	duration := strings.TrimSpace(mcp.ParseString(request, "duration", ""))
+	switch {
+	case endsAt != "" && duration != "":
+		return toolError(errCodeInvalidInput, "ends_at 与 duration 只能指定一个", nil), nil
+	case endsAt != "":
+		t, err := core.ParseTime(endsAt, s.location)
+		if err != nil {
+			return toolError(errCodeInvalidInput, fmt.Sprintf("无效的 ends_at: %v", err), nil), nil
+		}
+		window.EndsAt = t
+	case duration != "":
+		d, err := time.ParseDuration(duration)
+		if err != nil || d <= 0 {
+			return toolError(errCodeInvalidInput, fmt.Sprintf("无效的 duration: %s（应为 90m、2h 等正时长）", duration), nil), nil
+		}
+		window.EndsAt = window.StartsAt.Add(d)
+	default:
+		return toolError(errCodeInvalidInput, "需要指定 ends_at 或 duration", nil), nil
+	}
+	if !window.EndsAt.After(window.StartsAt) {
+		return toolError(errCodeInvalidInput, "ends_at 必须晚于 starts_at", nil), nil
+	}
+	if !window.EndsAt.After(now) {
+		return toolError(errCodeInvalidInput, "ends_at 必须晚于当前时间", nil), nil
+	}
+	if reason := strings.TrimSpace(mcp.ParseString(request, "reason", "")); reason != "" {
+		window.Reason = &reason
+	}
+	scope := "所有任务"
+	if mcp.ParseString(request, "task_id", "") != "" {
+		taskID, failure := s.resolveTaskID(ctx, request)
+		if failure != nil {
+			return failure.result(), nil
+		}
+		task, err := s.store.GetTask(ctx, taskID)
+		if err != nil {
+			if err == store.ErrTaskNotFound {
+				return toolError(errCodeNotFound, fmt.Sprintf("任务不存在: %s", taskID), map[string]any{"task_id": taskID}), nil
+			}
+			return toolError(errCodeInternal, fmt.Sprintf("获取任务失败: %v", err), nil), nil
+		}
+		window.TaskID = &task.ID
+		scope = "任务 " + task.ID
+		if task.Name != nil {
+			scope = "任务 " + *task.Name
+		}
+	}
+
+	if err := s.store.CreateMaintenanceWindow(ctx, window); err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("创建维护窗口失败: %v", err), nil), nil
+	}
+	result := fmt.Sprintf("已安排维护窗口（ID: %s）\n", window.ID)
+	result += fmt.Sprintf("  范围: %s\n", scope)
+	result += fmt.Sprintf("  时间: %s ~ %s\n", formatTime(&window.StartsAt), formatTime(&window.EndsAt))
+	result += "  窗口内任务照常运行，但不发送通知\n"
+	return mcp.NewToolResultText(result), nil
+}
+
+// handleListMaintenance handles the cron_list_maintenance tool call.
+func (s *MCPServer) handleListMaintenance(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	now := s.scheduler.Now()
+	endsAfter := &now
+	if mcp.ParseBoolean(request, "all", false) {
+		endsAfter = nil
+	}
+	windows, err := s.store.ListMaintenanceWindows(ctx, endsAfter)
+	if err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("获取维护窗口失败: %v", err), nil), nil
+	}
+	if len(windows) == 0 {
+		return mcp.NewToolResultText("没有维护窗口"), nil
+	}
+
+	result := fmt.Sprintf("找到 %d 个维护窗口:\n\n", len(windows))
+	for _, window := range windows {
+		state := "⏳ 未开始"
+		if window.Active(now) {
+			state = "🔧 进行中"
+		} else if !window.EndsAt.After(now) {
+			state = "✅ 已结束"
+		}
+		scope := "所有任务"
+		if window.TaskID != nil {
+			scope = "任务 " + *window.TaskID
+		}
+		result += fmt.Sprintf("[%s] ID: %s\n", state, window.ID)
+		result += fmt.Sprintf("    范围: %s\n", scope)
+		result += fmt.Sprintf("    时间: %s ~ %s\n", formatTime(&window.StartsAt), formatTime(&window.EndsAt))
+		if window.Reason != nil {
+			result += fmt.Sprintf("    原因: %s\n", *window.Reason)
+		}
+		result += "\n"
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// handleUploadFile handles the cron_upload_file tool call.
+func (s *MCPServer) handleUploadFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := strings.TrimSpace(mcp.ParseString(request, "name", ""))
+	content := mcp.ParseString(request, "content", "")
+	data := []byte(content)
+	switch encoding := mcp.ParseString(request, "encoding", "text"); encoding {
+	case "", "text":
+	case "base64":
+		decoded, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return toolError(errCodeInvalidInput, fmt.Sprintf("content 不是有效的 base64: %v", err), nil), nil
+		}
+		data = decoded
+	default:
+		return toolError(errCodeInvalidInput, "encoding 必须是 text 或 base64", nil), nil
+	}
+
+	file, replaced, err := s.store.SaveFile(name, bytes.NewReader(data))
+	switch {
+	case errors.Is(err, store.ErrInvalidFileName), errors.Is(err, store.ErrFileTooLarge), errors.Is(err, store.ErrFilesQuotaExceeded):
+		return toolError(errCodeInvalidInput, err.Error(), map[string]any{"name": name}), nil
+	case err != nil:
+		return toolError(errCodeInternal, fmt.Sprintf("保存文件失败: %v", err), nil), nil
+	}
+	verb := "已上传"
+	if replaced {
+		verb = "已替换"
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("✅ %s文件 %s（%d 字节）\n路径: %s", verb, file.Name, file.Size, file.Path)), nil
+}
+
+// handleListFiles handles the cron_list_files tool call.
+func (s *MCPServer) handleListFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	files, err := s.store.ListFiles()
+	if err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("获取文件失败: %v", err), nil), nil
+	}
+	if len(files) == 0 {
+		return mcp.NewToolResultText("没有已上传的文件"), nil
+	}
+	result := fmt.Sprintf("找到 %d 个文件:\n\n", len(files))
+	for _, f := range files {
+		result += fmt.Sprintf("%s（%d 字节，更新于 %s）\n    路径: %s\n", f.Name, f.Size, formatTime(&f.ModifiedAt), f.Path)
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// handleListScripts handles the cron_list_scripts tool call.
+func (s *MCPServer) handleListScripts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	scripts, err := s.store.ListScripts(ctx)
+	if err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("获取脚本失败: %v", err), nil), nil
+	}
+	if len(scripts) == 0 {
+		return mcp.NewToolResultText("脚本库为空"), nil
+	}
+	usage, err := s.store.ScriptUsage(ctx)
+	if err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("获取脚本失败: %v", err), nil), nil
+	}
+	withContent := mcp.ParseBoolean(request, "content", false)
+
+	result := fmt.Sprintf("找到 %d 个脚本:\n\n", len(scripts))
+	for _, script := range scripts {
+		result += fmt.Sprintf("[%s] %s (ID: %s)\n", script.Type, script.Name, script.ID)
+		if script.Description != nil {
+			result += fmt.Sprintf("    说明: %s\n", *script.Description)
+		}
+		result += fmt.Sprintf("    使用中的任务: %d\n", usage[script.ID])
+		result += fmt.Sprintf("    更新于: %s\n", formatTime(&script.UpdatedAt))
+		if withContent {
+			result += "    内容:\n" + indentLines(script.Content, "      ") + "\n"
+		}
+		result += "\n"
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// handleCancelMaintenance handles the cron_cancel_maintenance tool call.
+func (s *MCPServer) handleCancelMaintenance(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	windowID := strings.TrimSpace(mcp.ParseString(request, "window_id", ""))
+	if windowID == "" {
+		return toolError(errCodeInvalidInput, "window_id 不能为空", nil), nil
+	}
+	if err := s.store.DeleteMaintenanceWindow(ctx, windowID); err != nil {
+		if err == store.ErrMaintenanceWindowNotFound {
+			return toolError(errCodeNotFound, fmt.Sprintf("维护窗口不存在: %s", windowID), map[string]any{"window_id": windowID}), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("取消维护窗口失败: %v", err), nil), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("维护窗口 %s 已取消", windowID)), nil
+}
+
+// handleListIncidents handles the cron_list_incidents tool call.
+func (s *MCPServer) handleListIncidents(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var filter store.IncidentFilter
+	if mcp.ParseString(request, "task_id", "") != "" {
+		taskID, failure := s.resolveTaskID(ctx, request)
+		if failure != nil {
+			return failure.result(), nil
+		}
+		filter.TaskID = taskID
+	}
+	for _, value := range strings.Split(mcp.ParseString(request, "status", ""), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		status := core.IncidentStatus(value)
+		if !status.Valid() {
+			return toolError(errCodeInvalidInput, fmt.Sprintf("无效的 status: %s", value), nil), nil
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	limit := int(mcp.ParseFloat64(request, "limit", 20))
+
+	incidents, err := s.store.ListIncidents(ctx, filter, limit, 0)
+	if err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("获取故障事件失败: %v", err), nil), nil
+	}
+	if len(incidents) == 0 {
+		return mcp.NewToolResultText("没有符合条件的故障事件"), nil
+	}
+
+	result := fmt.Sprintf("找到 %d 个故障事件:\n\n", len(incidents))
+	for _, incident := range incidents {
+		result += fmt.Sprintf("[%s] %s  事件 ID: %s\n", incidentStatusLabel(incident.Status), incident.TaskName, incident.ID)
+		result += formatIncident(incident) + "\n"
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// formatIncident describes an incident's progress, one indented line per step.
+func formatIncident(incident *core.Incident) string {
+	result := fmt.Sprintf("    自 %s 起连续失败 %d 次，首次失败的运行: %s\n", formatTime(&incident.OpenedAt), incident.Failures, incident.FirstRunID)
+	if incident.AcknowledgedAt != nil {
+		result += fmt.Sprintf("    %s 由 %s 确认\n", formatTime(incident.AcknowledgedAt), *incident.AcknowledgedBy)
+	}
+	if incident.EscalatedAt != nil {
+		result += fmt.Sprintf("    已于 %s 升级通知\n", formatTime(incident.EscalatedAt))
+	}
+	if incident.ResolvedAt != nil {
+		result += fmt.Sprintf("    %s 由 %s 解决，持续 %s\n", formatTime(incident.ResolvedAt), *incident.ResolvedBy, incident.ResolvedAt.Sub(incident.OpenedAt).Round(time.Second))
+	}
+	if incident.Note != nil {
+		result += fmt.Sprintf("    说明: %s\n", *incident.Note)
+	}
+	return result
+}
+
+func incidentStatusLabel(status core.IncidentStatus) string {
+	switch status {
+	case core.IncidentStatusOpen:
+		return "🔴 未确认"
+	case core.IncidentStatusAcknowledged:
+		return "🟡 已确认"
+	default:
+		return "✅ 已解决"
+	}
+}
+
 // handleUpdateTask handles the cron_update_task tool call.
 func (s *MCPServer) handleUpdateTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	taskID := mcp.ParseString(request, "task_id", "")
+	taskID, failure := s.resolveTaskID(ctx, request)
+	if failure != nil {
+		return failure.result(), nil
+	}
 
 	task, err := s.store.GetTask(ctx, taskID)
 	if err != nil {
 		if err == store.ErrTaskNotFound {
-			return mcp.NewToolResultError(fmt.Sprintf("任务不存在: %s", taskID)), nil
+			return toolError(errCodeNotFound, fmt.Sprintf("任务不存在: %s", taskID), map[string]any{"task_id": taskID}), nil
 		}
-		return mcp.NewToolResultError(fmt.Sprintf("获取任务失败: %v", err)), nil
+		return toolError(errCodeInternal, fmt.Sprintf("获取任务失败: %v", err), nil), nil
 	}
 
 	// Update prompt if provided
+	// A prompt replaces the script the task runs, if any
 	prompt := mcp.ParseString(request, "prompt", "")
 	if prompt != "" {
 		task.Prompt = prompt
 		task.Command = BuildClaudeCommand(prompt)
+		task.ScriptID = nil
 	}
 
-	// Update cron if provided
-	cronExpr := mcp.ParseString(request, "cron", "")
+	// Update cron if provided, directly or as a schedule
+	cronExpr, failure := cronFromRequest(request)
+	if failure != nil {
+		return failure.result(), nil
+	}
 	if cronExpr != "" {
 		if _, err := core.ParseCron(cronExpr); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("无效的 cron 表达式: %v", err)), nil
+			return toolError(errCodeInvalidCron, fmt.Sprintf("无效的 cron 表达式: %v", err), nil), nil
 		}
 		task.Cron = cronExpr
 	}
@@ -484,24 +1655,101 @@ func (s *MCPServer) handleUpdateTask(ctx context.Context, request mcp.CallToolRe
 	// Update working_dir if provided
 	workingDir := mcp.ParseString(request, "working_dir", "")
 	if workingDir != "" {
+		if err := core.ValidateWorkingDir(workingDir); err != nil {
+			return toolError(errCodeInvalidInput, fmt.Sprintf("working_dir 无效: %v", err), map[string]any{"task_id": task.ID}), nil
+		}
 		task.WorkingDir = &workingDir
 	}
 
-	// Update paused status
+	// Update min interval if provided (0 clears it)
+	if _, ok := request.GetArguments()["min_interval_seconds"]; ok {
+		if minInterval := int(mcp.ParseFloat64(request, "min_interval_seconds", 0)); minInterval > 0 {
+			task.MinIntervalSeconds = &minInterval
+		} else {
+			task.MinIntervalSeconds = nil
+		}
+	}
+
+	// Update auto-pause threshold if provided (0 clears it)
+	if _, ok := request.GetArguments()["pause_after_failures"]; ok {
+		if pauseAfter := int(mcp.ParseFloat64(request, "pause_after_failures", 0)); pauseAfter > 0 {
+			task.PauseAfterFailures = &pauseAfter
+		} else {
+			task.PauseAfterFailures = nil
+		}
+	}
+
+	// Update log retention if provided (0 falls back to the global setting)
+	if _, ok := request.GetArguments()["log_retention"]; ok {
+		if logRetention := int(mcp.ParseFloat64(request, "log_retention", 0)); logRetention > 0 {
+			task.LogRetention = &logRetention
+		} else {
+			task.LogRetention = nil
+		}
+	}
+
+	// Update log age limit if provided (0 falls back to the global setting)
+	if _, ok := request.GetArguments()["log_max_age_days"]; ok {
+		if logMaxAge := int(mcp.ParseFloat64(request, "log_max_age_days", 0)); logMaxAge > 0 {
+			task.LogMaxAgeDays = &logMaxAge
+		} else {
+			task.LogMaxAgeDays = nil
+		}
+	}
+
+	// Update executor if provided ("" goes back to the shell)
+	if _, ok := request.GetArguments()["executor"]; ok {
+		executor, failure := s.parseExecutor(request)
+		if failure != nil {
+			return failure.result(), nil
+		}
+		previous := task.ExecutorName()
+		task.Executor = executor
+		if core.ValidateExecutorConfig(task) != nil {
+			return toolError(errCodeInvalidInput, configuredExecutorMessage, map[string]any{"task_id": task.ID}), nil
+		}
+		if task.ScriptID != nil && task.ExecutorName() != core.ExecutorShell {
+			return toolError(errCodeInvalidInput, "任务运行脚本库中的脚本，只能使用 shell 执行器；请同时提供 prompt 替换脚本", map[string]any{"task_id": task.ID}), nil
+		}
+		if core.ExecutorConfigField(previous) != "" && core.ExecutorConfigField(task.ExecutorName()) == "" && prompt == "" {
+			return toolError(errCodeInvalidInput, fmt.Sprintf("离开 %s 执行器时必须提供 prompt", previous), map[string]any{"task_id": task.ID}), nil
+		}
+	}
+	// http and sql tasks keep showing their configuration as the command
+	core.PrepareExecutorConfig(task)
+
+	// Update run-on-start if provided
+	if _, ok := request.GetArguments()["run_on_start"]; ok {
+		task.RunOnStart = mcp.ParseBoolean(request, "run_on_start", false)
+	}
+
+	// Update paused status; pause_until implies paused
+	pauseUntil, failure := s.parsePauseUntil(request)
+	if failure != nil {
+		return failure.result(), nil
+	}
 	cronChanged := false
-	paused := mcp.ParseBoolean(request, "paused", false)
-	if paused {
+	_, pausedGiven := request.GetArguments()["paused"]
+	if task.Status == core.TaskStatusArchived {
+		// Archived tasks keep their status until unarchived
+		if pausedGiven || pauseUntil != nil {
+			return toolError(errCodeConflict, "任务已归档，请先用 cron_archive_task（unarchive=true）取消归档", map[string]any{"task_id": task.ID}), nil
+		}
+	} else if mcp.ParseBoolean(request, "paused", false) || pauseUntil != nil {
 		task.Status = core.TaskStatusPaused
+		task.PauseUntil = pauseUntil
 		cronChanged = true
-	} else {
+	} else if task.Status != core.TaskStatusError || cronExpr != "" {
+		// A task in the error status stays there until it gets a valid cron expression
 		task.Status = core.TaskStatusActive
+		task.PauseUntil = nil
 		cronChanged = true
 	}
 
 	// Recalculate next run time if active and cron changed
 	if task.Status == core.TaskStatusActive && cronChanged {
 		schedule, _ := core.ParseCron(task.Cron)
-		nextTimes := core.NextOccurrences(schedule, time.Now().In(s.location), 1)
+		nextTimes := core.NextOccurrences(schedule, s.scheduler.Now().In(s.location), 1)
 		if len(nextTimes) > 0 {
 			nextUTC := nextTimes[0].UTC()
 			task.NextRunAt = &nextUTC
@@ -510,43 +1758,64 @@ func (s *MCPServer) handleUpdateTask(ctx context.Context, request mcp.CallToolRe
 		task.NextRunAt = nil
 	}
 
-	if err := s.store.UpdateTask(ctx, task); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("更新任务失败: %v", err)), nil
+	err = s.store.InTx(ctx, func(tx *store.Tx) error {
+		if err := tx.UpdateTask(ctx, task); err != nil {
+			return err
+		}
+		tx.OnCommit(func() { s.refreshSchedule(ctx, task.ID) })
+		return nil
+	})
+	if errors.Is(err, core.ErrTaskNameTaken) {
+		return toolError(errCodeNameTaken, fmt.Sprintf("任务名称已被其他任务使用: %s", *task.Name), map[string]any{"name": *task.Name}), nil
 	}
-
-	if err := s.scheduler.AddOrUpdateTask(ctx, task); err != nil {
-		s.logger.Error("reschedule task", "task_id", task.ID, "err", err)
+	if err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("更新任务失败: %v", err), nil), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("任务已更新: %s\n状态: %s", task.ID, task.Status)), nil
+	result := fmt.Sprintf("任务已更新: %s\n状态: %s", task.ID, task.Status)
+	if task.PauseUntil != nil {
+		result += fmt.Sprintf("\n将于 %s 自动恢复", formatTime(task.PauseUntil))
+	}
+	return mcp.NewToolResultText(result + s.taskWarnings(task)), nil
 }
 
 // handleDeleteTask handles the cron_delete_task tool call.
 func (s *MCPServer) handleDeleteTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	taskID := mcp.ParseString(request, "task_id", "")
+	taskID, failure := s.resolveTaskID(ctx, request)
+	if failure != nil {
+		return failure.result(), nil
+	}
 
-	if err := s.store.DeleteTask(ctx, taskID); err != nil {
+	err := s.store.InTx(ctx, func(tx *store.Tx) error {
+		if err := tx.DeleteTask(ctx, taskID); err != nil {
+			return err
+		}
+		tx.OnCommit(func() { s.scheduler.RemoveTask(taskID) })
+		return nil
+	})
+	if err != nil {
 		if err == store.ErrTaskNotFound {
-			return mcp.NewToolResultError(fmt.Sprintf("任务不存在: %s", taskID)), nil
+			return toolError(errCodeNotFound, fmt.Sprintf("任务不存在: %s", taskID), map[string]any{"task_id": taskID}), nil
 		}
-		return mcp.NewToolResultError(fmt.Sprintf("删除任务失败: %v", err)), nil
+		return toolError(errCodeInternal, fmt.Sprintf("删除任务失败: %v", err), nil), nil
 	}
 
-	s.scheduler.RemoveTask(taskID)
-
 	return mcp.NewToolResultText(fmt.Sprintf("任务已删除: %s", taskID)), nil
 }
 
 // handleRunTask handles the cron_run_task tool call.
 func (s *MCPServer) handleRunTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	taskID := mcp.ParseString(request, "task_id", "")
+	taskID, failure := s.resolveTaskID(ctx, request)
+	if failure != nil {
+		return failure.result(), nil
+	}
 
 	task, err := s.store.GetTask(ctx, taskID)
 	if err != nil {
 		if err == store.ErrTaskNotFound {
-			return mcp.NewToolResultError(fmt.Sprintf("任务不存在: %s", taskID)), nil
+			return toolError(errCodeNotFound, fmt.Sprintf("任务不存在: %s", taskID), map[string]any{"task_id": taskID}), nil
 		}
-		return mcp.NewToolResultError(fmt.Sprintf("获取任务失败: %v", err)), nil
+		return toolError(errCodeInternal, fmt.Sprintf("获取任务失败: %v", err), nil), nil
 	}
 
 	// Check if working_dir override is provided
@@ -561,26 +1830,156 @@ func (s *MCPServer) handleRunTask(ctx context.Context, request mcp.CallToolReque
 		s.logger.Debug("overriding working_dir", "task_id", taskID, "working_dir", workingDir)
 	}
 
+	// Check if timeout override is provided (0 runs without a timeout)
+	if _, ok := request.GetArguments()["timeout_minutes"]; ok {
+		taskCopy := *runTask
+		taskCopy.TimeoutSeconds = nil
+		if timeoutMinutes := mcp.ParseFloat64(request, "timeout_minutes", 0); timeoutMinutes > 0 {
+			timeout := int(timeoutMinutes * 60)
+			taskCopy.TimeoutSeconds = &timeout
+		}
+		runTask = &taskCopy
+		s.logger.Debug("overriding timeout", "task_id", taskID, "timeout_minutes", mcp.ParseFloat64(request, "timeout_minutes", 0))
+	}
+
 	run, err := s.scheduler.RunTaskNow(ctx, runTask)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("执行任务失败: %v", err)), nil
+		details := map[string]any{"task_id": task.ID}
+		switch {
+		case errors.Is(err, core.ErrTaskArchived):
+			return toolError(errCodeConflict, "任务已归档，无法执行", details), nil
+		case errors.Is(err, core.ErrTaskAlreadyRunning):
+			return toolError(errCodeAlreadyRunning, "任务正在运行中，本次执行已跳过", details), nil
+		case errors.Is(err, core.ErrTaskRateLimited):
+			return toolError(errCodeRateLimited, "距上次运行未满最小间隔，本次执行已跳过", details), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("执行任务失败: %v", err), details), nil
+	}
+
+	result := fmt.Sprintf("任务已开始执行\n任务 ID: %s\n运行 ID: %s", task.ID, run.ID)
+	if runTask.TimeoutSeconds != nil {
+		result += fmt.Sprintf("\n本次超时: %d 秒", *runTask.TimeoutSeconds)
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// handleArchiveTask handles the cron_archive_task tool call.
+func (s *MCPServer) handleArchiveTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	taskID, failure := s.resolveTaskID(ctx, request)
+	if failure != nil {
+		return failure.result(), nil
+	}
+
+	task, err := s.store.GetTask(ctx, taskID)
+	if err != nil {
+		if err == store.ErrTaskNotFound {
+			return toolError(errCodeNotFound, fmt.Sprintf("任务不存在: %s", taskID), map[string]any{"task_id": taskID}), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("获取任务失败: %v", err), nil), nil
+	}
+
+	if mcp.ParseBoolean(request, "unarchive", false) {
+		if err := s.scheduler.UnarchiveTask(ctx, task); err != nil {
+			return toolError(errCodeInternal, fmt.Sprintf("取消归档失败: %v", err), nil), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("任务已取消归档: %s\n状态: %s（恢复调度需将 paused 设为 false）", task.ID, task.Status)), nil
+	}
+
+	if err := s.scheduler.ArchiveTask(ctx, task); err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("归档任务失败: %v", err), nil), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("任务已归档: %s\n运行历史已保留", task.ID)), nil
+}
+
+// handleSkipNext handles the cron_skip_next tool call.
+func (s *MCPServer) handleSkipNext(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	taskID, failure := s.resolveTaskID(ctx, request)
+	if failure != nil {
+		return failure.result(), nil
+	}
+
+	task, err := s.store.GetTask(ctx, taskID)
+	if err != nil {
+		if err == store.ErrTaskNotFound {
+			return toolError(errCodeNotFound, fmt.Sprintf("任务不存在: %s", taskID), map[string]any{"task_id": taskID}), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("获取任务失败: %v", err), nil), nil
+	}
+
+	if mcp.ParseBoolean(request, "cancel", false) {
+		if err := s.scheduler.CancelSkipNext(ctx, task); err != nil {
+			return toolError(errCodeInternal, fmt.Sprintf("撤销跳过失败: %v", err), nil), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("已撤销任务 %s 的跳过，下次执行: %s", task.ID, formatTime(task.NextRunAt))), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("任务已开始执行\n任务 ID: %s\n运行 ID: %s", task.ID, run.ID)), nil
+	skipped, err := s.scheduler.SkipNext(ctx, task)
+	if err != nil {
+		if errors.Is(err, core.ErrTaskNotActive) {
+			return toolError(errCodeConflict, "任务未处于 active 状态，没有可跳过的下一次执行", map[string]any{"task_id": task.ID}), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("跳过下一次执行失败: %v", err), nil), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("将跳过任务 %s 在 %s 的执行，之后按计划继续", task.ID, formatTime(&skipped))), nil
 }
 
 // handleListRuns handles the cron_list_runs tool call.
 func (s *MCPServer) handleListRuns(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	taskID := mcp.ParseString(request, "task_id", "")
+	taskID, failure := s.resolveTaskID(ctx, request)
+	if failure != nil {
+		return failure.result(), nil
+	}
 
 	limit := int(mcp.ParseFloat64(request, "limit", 20))
 
-	runs, err := s.store.ListRuns(ctx, taskID, limit, 0)
+	var filter store.RunFilter
+	if trigger := mcp.ParseString(request, "trigger_type", ""); trigger != "" {
+		filter.TriggerType = core.TriggerType(trigger)
+		if !filter.TriggerType.Valid() {
+			return toolError(errCodeInvalidInput, fmt.Sprintf("无效的 trigger_type: %s", trigger), nil), nil
+		}
+	}
+	for _, value := range strings.Split(mcp.ParseString(request, "status", ""), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		status := core.RunStatus(value)
+		if !status.Valid() {
+			return toolError(errCodeInvalidInput, fmt.Sprintf("无效的 status: %s", value), nil), nil
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	if value := strings.TrimSpace(mcp.ParseString(request, "since", "")); value != "" {
+		since, err := core.ParseTime(value, s.location)
+		if err != nil {
+			return toolError(errCodeInvalidInput, fmt.Sprintf("无效的 since: %v", err), nil), nil
+		}
+		filter.Since = &since
+	}
+	if value := strings.TrimSpace(mcp.ParseString(request, "until", "")); value != "" {
+		until, err := core.ParseTime(value, s.location)
+		if err != nil {
+			return toolError(errCodeInvalidInput, fmt.Sprintf("无效的 until: %v", err), nil), nil
+		}
+		filter.Until = &until
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Until.After(*filter.Since) {
+		return toolError(errCodeInvalidInput, "until 必须晚于 since", nil), nil
+	}
+	if _, ok := request.GetArguments()["pinned"]; ok {
+		pinned := mcp.ParseBoolean(request, "pinned", false)
+		filter.Pinned = &pinned
+	}
+
+	runs, err := s.store.ListRuns(ctx, taskID, filter, limit, 0)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("获取运行历史失败: %v", err)), nil
+		return toolError(errCodeInternal, fmt.Sprintf("获取运行历史失败: %v", err), nil), nil
 	}
 
 	if len(runs) == 0 {
+		if filter.TriggerType != "" || len(filter.Statuses) > 0 || filter.Since != nil || filter.Until != nil || filter.Pinned != nil {
+			return mcp.NewToolResultText("没有符合条件的运行记录"), nil
+		}
 		return mcp.NewToolResultText("该任务暂无运行记录"), nil
 	}
 
@@ -589,6 +1988,7 @@ func (s *MCPServer) handleListRuns(ctx context.Context, request mcp.CallToolRequ
 		statusIcon := statusToIcon(r.Status)
 		result += fmt.Sprintf("[%s] 运行 ID: %s\n", statusIcon, r.ID)
 		result += fmt.Sprintf("    状态: %s\n", r.Status)
+		result += fmt.Sprintf("    触发: %s\n", r.TriggerType)
 		if r.StartedAt != nil {
 			result += fmt.Sprintf("    开始: %s\n", formatTime(r.StartedAt))
 		}
@@ -598,25 +1998,246 @@ func (s *MCPServer) handleListRuns(ctx context.Context, request mcp.CallToolRequ
 		if r.ExitCode != nil {
 			result += fmt.Sprintf("    退出码: %d\n", *r.ExitCode)
 		}
+		if r.Reason != nil {
+			result += fmt.Sprintf("    原因: %s\n", *r.Reason)
+		}
+		if r.Warning != nil {
+			result += fmt.Sprintf("    ⚠️ 警告: %s\n", *r.Warning)
+		}
+		if r.Note != nil {
+			result += fmt.Sprintf("    备注: %s\n", *r.Note)
+		}
+		if r.Pinned {
+			result += "    📌 已固定\n"
+		}
 		result += "\n"
 	}
 
 	return mcp.NewToolResultText(result), nil
 }
 
+// handleAnnotateRun handles the cron_annotate_run tool call.
+func (s *MCPServer) handleAnnotateRun(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	runID := mcp.ParseString(request, "run_id", "")
+	if runID == "" {
+		return toolError(errCodeInvalidInput, "run_id 不能为空", nil), nil
+	}
+
+	var note *string
+	if trimmed := strings.TrimSpace(mcp.ParseString(request, "note", "")); trimmed != "" {
+		note = &trimmed
+	}
+
+	if err := s.store.SetRunNote(ctx, runID, note); err != nil {
+		if err == store.ErrRunNotFound {
+			return toolError(errCodeNotFound, fmt.Sprintf("运行记录不存在: %s", runID), map[string]any{"run_id": runID}), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("更新备注失败: %v", err), nil), nil
+	}
+
+	if note == nil {
+		return mcp.NewToolResultText(fmt.Sprintf("已清除运行 %s 的备注", runID)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("已为运行 %s 添加备注: %s", runID, *note)), nil
+}
+
+// handlePinRun handles the cron_pin_run tool call.
+func (s *MCPServer) handlePinRun(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	runID := mcp.ParseString(request, "run_id", "")
+	if runID == "" {
+		return toolError(errCodeInvalidInput, "run_id 不能为空", nil), nil
+	}
+	pinned := mcp.ParseBoolean(request, "pinned", true)
+
+	if err := s.store.SetRunPinned(ctx, runID, pinned); err != nil {
+		if err == store.ErrRunNotFound {
+			return toolError(errCodeNotFound, fmt.Sprintf("运行记录不存在: %s", runID), map[string]any{"run_id": runID}), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("更新固定状态失败: %v", err), nil), nil
+	}
+
+	if !pinned {
+		return mcp.NewToolResultText(fmt.Sprintf("已取消固定运行 %s，其日志将按保留策略清理", runID)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("已固定运行 %s，其日志不会被清理", runID)), nil
+}
+
+// handleListActive handles the cron_list_active tool call.
+func (s *MCPServer) handleListActive(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	runs, err := s.store.ListActiveRuns(ctx)
+	if err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("获取运行中任务失败: %v", err), nil), nil
+	}
+
+	if len(runs) == 0 {
+		return mcp.NewToolResultText("当前没有正在运行的任务"), nil
+	}
+
+	now := s.scheduler.Now()
+	result := fmt.Sprintf("找到 %d 个活动运行:\n\n", len(runs))
+	for _, r := range runs {
+		since := r.CreatedAt
+		if r.StartedAt != nil {
+			since = *r.StartedAt
+		}
+		result += fmt.Sprintf("[%s] 运行 ID: %s\n", statusToIcon(r.Status), r.ID)
+		result += fmt.Sprintf("    任务 ID: %s\n", r.TaskID)
+		result += fmt.Sprintf("    状态: %s\n", r.Status)
+		if r.PID != nil {
+			result += fmt.Sprintf("    PID: %d\n", *r.PID)
+		}
+		if r.MaxRSSKB != nil {
+			result += fmt.Sprintf("    内存峰值: %d KB\n", *r.MaxRSSKB)
+		}
+		if r.CPUSeconds != nil {
+			result += fmt.Sprintf("    CPU 时间: %.1f 秒\n", *r.CPUSeconds)
+		}
+		result += fmt.Sprintf("    已运行: %s\n", now.Sub(since).Truncate(time.Second))
+		result += fmt.Sprintf("    取消: POST /v1/runs/%s/cancel\n", r.ID)
+		result += "\n"
+	}
+
+	return mcp.NewToolResultText(result), nil
+}
+
+// statusResult is the structured content of cron_status.
+type statusResult struct {
+	Version        string         `json:"version"`
+	StartedAt      string         `json:"started_at"`
+	UptimeSeconds  int64          `json:"uptime_s"`
+	Tasks          map[string]int `json:"tasks"`
+	Running        []statusRun    `json:"running"`
+	RecentFailures statusFailures `json:"recent_failures"`
+}
+
+type statusRun struct {
+	RunID          string `json:"run_id"`
+	TaskID         string `json:"task_id"`
+	TaskName       string `json:"task_name,omitempty"`
+	Status         string `json:"status"`
+	TriggerType    string `json:"trigger_type"`
+	ElapsedSeconds int64  `json:"elapsed_s"`
+	PID            *int   `json:"pid,omitempty"`
+}
+
+type statusFailures struct {
+	Hours int `json:"hours"`
+	Count int `json:"count"`
+}
+
+// handleStatus handles the cron_status tool call.
+func (s *MCPServer) handleStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	hours := int(mcp.ParseFloat64(request, "hours", 24))
+	if hours < 1 || hours > 720 {
+		return toolError(errCodeInvalidInput, "hours 必须在 1 到 720 之间", map[string]any{"hours": hours}), nil
+	}
+
+	counts, err := s.store.TaskCounts(ctx)
+	if err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("统计任务失败: %v", err), nil), nil
+	}
+	runs, err := s.store.ListActiveRuns(ctx)
+	if err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("获取运行中任务失败: %v", err), nil), nil
+	}
+	now := s.scheduler.Now()
+	failed, err := s.store.CountFailedRunsSince(ctx, now.Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		return toolError(errCodeInternal, fmt.Sprintf("统计失败运行失败: %v", err), nil), nil
+	}
+
+	status := statusResult{
+		Version:        version.Version,
+		StartedAt:      s.startedAt.UTC().Format(time.RFC3339),
+		UptimeSeconds:  int64(now.Sub(s.startedAt).Seconds()),
+		Tasks:          make(map[string]int, len(counts)),
+		Running:        []statusRun{},
+		RecentFailures: statusFailures{Hours: hours, Count: failed},
+	}
+	for taskStatus, n := range counts {
+		status.Tasks[string(taskStatus)] = n
+	}
+	names := make(map[string]string)
+	for _, r := range runs {
+		if _, ok := names[r.TaskID]; !ok {
+			if task, err := s.store.GetTask(ctx, r.TaskID); err == nil && task.Name != nil {
+				names[r.TaskID] = *task.Name
+			}
+		}
+		since := r.CreatedAt
+		if r.StartedAt != nil {
+			since = *r.StartedAt
+		}
+		status.Running = append(status.Running, statusRun{
+			RunID:          r.ID,
+			TaskID:         r.TaskID,
+			TaskName:       names[r.TaskID],
+			Status:         string(r.Status),
+			TriggerType:    string(r.TriggerType),
+			ElapsedSeconds: int64(now.Sub(since).Seconds()),
+			PID:            r.PID,
+		})
+	}
+
+	result := fmt.Sprintf("clicrontab %s，已运行 %s（启动于 %s）\n", status.Version,
+		now.Sub(s.startedAt).Truncate(time.Second), s.startedAt.In(s.location).Format("2006-01-02 15:04:05"))
+	result += fmt.Sprintf("任务: %d 个启用，%d 个暂停，%d 个出错，%d 个已归档\n",
+		counts[core.TaskStatusActive], counts[core.TaskStatusPaused], counts[core.TaskStatusError], counts[core.TaskStatusArchived])
+	result += fmt.Sprintf("正在运行: %d 个\n", len(status.Running))
+	for _, r := range status.Running {
+		name := r.TaskName
+		if name == "" {
+			name = r.TaskID
+		}
+		result += fmt.Sprintf("    [%s] %s（运行 %s，已运行 %s）\n", statusToIcon(core.RunStatus(r.Status)), name, r.RunID, time.Duration(r.ElapsedSeconds)*time.Second)
+	}
+	result += fmt.Sprintf("最近 %d 小时失败或超时: %d 次\n", hours, failed)
+	if data, err := json.Marshal(status); err == nil {
+		result += "\n" + string(data)
+	}
+	return mcp.NewToolResultStructured(status, result), nil
+}
+
 // handleGetRunLog handles the cron_get_run_log tool call.
 func (s *MCPServer) handleGetRunLog(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	runID := mcp.ParseString(request, "run_id", "")
+	headLines := int(mcp.ParseFloat64(request, "head", 0))
+	tailLines := int(mcp.ParseFloat64(request, "tail", 0))
+	maxChars := int(mcp.ParseFloat64(request, "max_chars", defaultLogMaxChars))
+	if headLines > 0 && tailLines > 0 {
+		return toolError(errCodeInvalidInput, "head 与 tail 不能同时使用", nil), nil
+	}
+	if headLines < 0 || tailLines < 0 || maxChars < 0 {
+		return toolError(errCodeInvalidInput, "head、tail 与 max_chars 不能为负数", nil), nil
+	}
+	var pattern *regexp.Regexp
+	if expr := mcp.ParseString(request, "grep", ""); expr != "" {
+		var err error
+		if pattern, err = regexp.Compile(expr); err != nil {
+			return toolError(errCodeInvalidInput, fmt.Sprintf("grep 不是有效的正则表达式: %v", err), map[string]any{"grep": expr}), nil
+		}
+	}
 
 	logPath := s.store.RunLogPath(runID)
 
 	content, err := s.store.ReadRunLog(logPath)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("读取日志失败: %v", err)), nil
+		if errors.Is(err, os.ErrNotExist) {
+			return toolError(errCodeNotFound, fmt.Sprintf("日志不存在: %s", runID), map[string]any{"run_id": runID}), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("读取日志失败: %v", err), nil), nil
 	}
 
-	// Apply tail if specified
-	tailLines := int(mcp.ParseFloat64(request, "tail", 0))
+	// Filter by pattern first, then take lines from either end, then enforce the budget
+	if pattern != nil {
+		content = grepLines(content, pattern)
+		if content == "" {
+			return mcp.NewToolResultText(fmt.Sprintf("日志中没有匹配 %q 的行", pattern.String())), nil
+		}
+	}
+	if headLines > 0 {
+		content = headOf(content, headLines)
+	}
 	if tailLines > 0 {
 		lines, err := s.store.TailRunLog(content, tailLines)
 		if err == nil {
@@ -624,21 +2245,106 @@ func (s *MCPServer) handleGetRunLog(ctx context.Context, request mcp.CallToolReq
 		}
 	}
 
+	total := utf8.RuneCountInString(content)
+	if maxChars == 0 || total <= maxChars {
+		return mcp.NewToolResultText(content), nil
+	}
+	runes := []rune(content)
+	if headLines > 0 {
+		content = fmt.Sprintf("[已截断：共 %d 个字符，仅返回前 %d 个]\n", total, maxChars) + string(runes[:maxChars])
+	} else {
+		content = fmt.Sprintf("[已截断：共 %d 个字符，仅返回最后 %d 个]\n", total, maxChars) + string(runes[total-maxChars:])
+	}
 	return mcp.NewToolResultText(content), nil
 }
 
+// handleGrepRunLog handles the cron_grep_run_log tool call.
+func (s *MCPServer) handleGrepRunLog(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	runID := mcp.ParseString(request, "run_id", "")
+	expr := mcp.ParseString(request, "pattern", "")
+	context := int(mcp.ParseFloat64(request, "context", 2))
+	limit := int(mcp.ParseFloat64(request, "limit", 20))
+	if expr == "" {
+		return toolError(errCodeInvalidInput, "pattern 不能为空", nil), nil
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return toolError(errCodeInvalidInput, fmt.Sprintf("pattern 不是有效的正则表达式: %v", err), map[string]any{"pattern": expr}), nil
+	}
+	if context < 0 || context > 20 {
+		return toolError(errCodeInvalidInput, "context 必须在 0 到 20 之间", nil), nil
+	}
+	if limit < 1 || limit > 200 {
+		return toolError(errCodeInvalidInput, "limit 必须在 1 到 200 之间", nil), nil
+	}
+
+	result, err := s.store.GrepRunLog(ctx, runID, pattern, context, limit)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return toolError(errCodeNotFound, fmt.Sprintf("日志不存在: %s", runID), map[string]any{"run_id": runID}), nil
+		}
+		return toolError(errCodeInternal, fmt.Sprintf("搜索日志失败: %v", err), nil), nil
+	}
+	if result.Total == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("日志共 %d 行，没有匹配 %q 的行", result.Lines, expr)), nil
+	}
+
+	text := fmt.Sprintf("日志共 %d 行，%d 行匹配 %q", result.Lines, result.Total, expr)
+	if result.Total > len(result.Matches) {
+		text += fmt.Sprintf("，仅显示前 %d 处", len(result.Matches))
+	}
+	text += ":\n"
+	for i, m := range result.Matches {
+		if i > 0 && context > 0 {
+			text += "--\n"
+		}
+		for j, line := range m.Before {
+			text += fmt.Sprintf("%d- %s\n", m.Line-len(m.Before)+j, line)
+		}
+		text += fmt.Sprintf("%d: %s\n", m.Line, m.Text)
+		for j, line := range m.After {
+			text += fmt.Sprintf("%d- %s\n", m.Line+1+j, line)
+		}
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+// defaultLogMaxChars bounds cron_get_run_log output so a large log does not fill the
+// caller's context.
+const defaultLogMaxChars = 20000
+
+// grepLines keeps the lines matching pattern, prefixed with their 1-based line numbers.
+func grepLines(content string, pattern *regexp.Regexp) string {
+	var b strings.Builder
+	for i, line := range strings.Split(content, "\n") {
+		if pattern.MatchString(line) {
+			fmt.Fprintf(&b, "%d: %s\n", i+1, line)
+		}
+	}
+	return b.String()
+}
+
+// headOf returns the first n lines of content.
+func headOf(content string, n int) string {
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) <= n {
+		return content
+	}
+	return strings.Join(lines[:n], "")
+}
+
 // handleCronPreview handles the cron_preview tool call.
 func (s *MCPServer) handleCronPreview(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	cronExpr := mcp.ParseString(request, "cron", "")
 
 	schedule, err := core.ParseCron(cronExpr)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("无效的 cron 表达式: %v", err)), nil
+		return toolError(errCodeInvalidCron, fmt.Sprintf("无效的 cron 表达式: %v", err), nil), nil
 	}
 
 	count := int(mcp.ParseFloat64(request, "count", 5))
 
-	now := time.Now().In(s.location)
+	now := s.scheduler.Now().In(s.location)
 	nextTimes := core.NextOccurrences(schedule, now, count)
 
 	result := fmt.Sprintf("Cron 表达式: %s\n", cronExpr)
@@ -653,6 +2359,15 @@ func (s *MCPServer) handleCronPreview(ctx context.Context, request mcp.CallToolR
 
 // Helper functions
 
+// indentLines prefixes every line of text with indent.
+func indentLines(text, indent string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = indent + line
+	}
+	return strings.Join(lines, "\n")
+}
+
 func formatTime(t *time.Time) string {
 	if t == nil {
 		return "-"
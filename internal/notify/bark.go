@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"time"
 )
 
 // BarkNotifier sends notifications via Bark app.
@@ -14,20 +13,39 @@ type BarkNotifier struct {
 	client  *http.Client
 }
 
-// NewBarkNotifier creates a new Bark notifier.
-func NewBarkNotifier(baseURL string) (*BarkNotifier, error) {
+// NewBarkNotifier creates a new Bark notifier sending through proxy (see newHTTPClient).
+func NewBarkNotifier(baseURL, proxy string) (*BarkNotifier, error) {
 	if baseURL == "" {
 		return nil, fmt.Errorf("bark url is empty")
 	}
-	return &BarkNotifier{
-		baseURL: baseURL,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-	}, nil
+	client, err := newHTTPClient(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("bark: %w", err)
+	}
+	return &BarkNotifier{baseURL: baseURL, client: client}, nil
 }
 
+// barkExcerptLimit keeps pushes well inside the 4KB APNs payload limit.
+const barkExcerptLimit = 1000
+
 func (b *BarkNotifier) Send(ctx context.Context, title, body string) error {
+	return b.send(ctx, title, body, "")
+}
+
+// SendMessage pushes the summary and a shortened excerpt; tapping the notification
+// opens the full log when the message has a link.
+func (b *BarkNotifier) SendMessage(ctx context.Context, msg Message) error {
+	body := msg.Body
+	if excerpt := msg.Excerpt; excerpt != "" {
+		if len(excerpt) > barkExcerptLimit {
+			excerpt = "..." + excerpt[len(excerpt)-barkExcerptLimit:]
+		}
+		body += "\n\n" + excerpt
+	}
+	return b.send(ctx, msg.Title, body, msg.LogURL)
+}
+
+func (b *BarkNotifier) send(ctx context.Context, title, body, link string) error {
 	// Bark format: /{key}/{title}/{body}
 	// We need to properly escape title and body
 	// Alternatively, Bark supports POST requests which are safer for long content
@@ -44,6 +62,9 @@ func (b *BarkNotifier) Send(ctx context.Context, title, body string) error {
 	form.Set("body", body)
 	form.Set("group", "clicrontab")
 	form.Set("icon", "https://github.com/clicrontab.png") // Optional icon
+	if link != "" {
+		form.Set("url", link)
+	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, nil)
 	if err != nil {
@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Channel names understood by the Dispatcher.
+const (
+	ChannelBark    = "bark"
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+)
+
+// ErrChannelDisabled is returned when sending to a channel that is not enabled.
+var ErrChannelDisabled = errors.New("channel is not enabled")
+
+// ChannelSettings configures a single notification channel.
+type ChannelSettings struct {
+	Enabled bool   `json:"enabled"`
+	URL     string `json:"url"`
+	// Proxy is a proxy URL or ProxyDirect; empty uses the proxy environment variables.
+	Proxy string `json:"proxy,omitempty"`
+}
+
+// Settings is the runtime-adjustable notification configuration.
+type Settings struct {
+	Bark    ChannelSettings `json:"bark"`
+	Webhook ChannelSettings `json:"webhook"`
+	// Email's URL is an SMTP URL; see NewEmailNotifier.
+	Email ChannelSettings `json:"email"`
+}
+
+// Dispatcher fans notifications out to the enabled channels and can be
+// reconfigured at runtime without restarting the daemon.
+type Dispatcher struct {
+	mu       sync.RWMutex
+	settings Settings
+	channels map[string]Notifier
+	// escalation names channels that only receive escalations.
+	escalation map[string]bool
+}
+
+// NewDispatcher creates a dispatcher with no channels enabled.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{channels: make(map[string]Notifier), escalation: make(map[string]bool)}
+}
+
+// ReserveForEscalation makes the named channels receive only escalations, so a routine
+// failure alert goes to the other channels first and a phone-call webhook, say, is
+// only used once a failure escalates. The reservation outlasts Apply.
+func (d *Dispatcher) ReserveForEscalation(channels []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.escalation = make(map[string]bool, len(channels))
+	for _, name := range channels {
+		d.escalation[name] = true
+	}
+}
+
+// Apply validates the settings and swaps in the resulting channels.
+func (d *Dispatcher) Apply(settings Settings) error {
+	channels := make(map[string]Notifier)
+	if settings.Bark.Enabled {
+		bark, err := NewBarkNotifier(settings.Bark.URL, settings.Bark.Proxy)
+		if err != nil {
+			return err
+		}
+		channels[ChannelBark] = bark
+	}
+	if settings.Webhook.Enabled {
+		webhook, err := NewWebhookNotifier(settings.Webhook.URL, settings.Webhook.Proxy)
+		if err != nil {
+			return err
+		}
+		channels[ChannelWebhook] = webhook
+	}
+	if settings.Email.Enabled {
+		email, err := NewEmailNotifier(settings.Email.URL, settings.Email.Proxy)
+		if err != nil {
+			return err
+		}
+		channels[ChannelEmail] = email
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.settings = settings
+	d.channels = channels
+	return nil
+}
+
+// Settings returns the currently applied settings.
+func (d *Dispatcher) Settings() Settings {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.settings
+}
+
+// enabled copies the enabled channels, leaving out those reserved for escalations
+// unless escalation is set.
+func (d *Dispatcher) enabled(escalation bool) map[string]Notifier {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	channels := make(map[string]Notifier, len(d.channels))
+	for name, n := range d.channels {
+		if escalation || !d.escalation[name] {
+			channels[name] = n
+		}
+	}
+	return channels
+}
+
+// Send delivers the notification to every enabled channel not reserved for escalations
+// and joins their errors.
+func (d *Dispatcher) Send(ctx context.Context, title, body string) error {
+	channels := d.enabled(false)
+	var errs []error
+	for name, n := range channels {
+		if err := n.Send(ctx, title, body); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendMessage delivers msg to every enabled channel not reserved for escalations, in
+// that channel's format, and joins their errors.
+func (d *Dispatcher) SendMessage(ctx context.Context, msg Message) error {
+	return d.sendAll(ctx, d.enabled(false), msg)
+}
+
+// Escalate delivers msg to every enabled channel, including those reserved for
+// escalations.
+func (d *Dispatcher) Escalate(ctx context.Context, msg Message) error {
+	return d.sendAll(ctx, d.enabled(true), msg)
+}
+
+func (d *Dispatcher) sendAll(ctx context.Context, channels map[string]Notifier, msg Message) error {
+	var errs []error
+	for name, n := range channels {
+		if err := SendMessage(ctx, n, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendTo delivers the notification to a single enabled channel.
+func (d *Dispatcher) SendTo(ctx context.Context, channel, title, body string) error {
+	d.mu.RLock()
+	n, ok := d.channels[channel]
+	d.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("channel %q: %w", channel, ErrChannelDisabled)
+	}
+	return n.Send(ctx, title, body)
+}
+
+// SendMessageTo delivers msg to a single enabled channel in that channel's format.
+func (d *Dispatcher) SendMessageTo(ctx context.Context, channel string, msg Message) error {
+	d.mu.RLock()
+	n, ok := d.channels[channel]
+	d.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("channel %q: %w", channel, ErrChannelDisabled)
+	}
+	return SendMessage(ctx, n, msg)
+}
+
+// Channels returns the names of the enabled channels, sorted.
+func (d *Dispatcher) Channels() []string {
+	return sortedNames(d.enabled(true))
+}
+
+// RoutineChannels returns the names of the enabled channels not reserved for
+// escalations, sorted.
+func (d *Dispatcher) RoutineChannels() []string {
+	return sortedNames(d.enabled(false))
+}
+
+func sortedNames(channels map[string]Notifier) []string {
+	names := make([]string, 0, len(channels))
+	for name := range channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProxyDirect as a channel's proxy setting connects without a proxy, ignoring the
+// proxy environment variables.
+const ProxyDirect = "direct"
+
+// notifierTimeout bounds one notification request.
+const notifierTimeout = 10 * time.Second
+
+// newHTTPClient returns the client a notifier sends with. proxy is a proxy URL
+// (http, https or socks5), ProxyDirect, or empty to use HTTPS_PROXY, HTTP_PROXY and
+// NO_PROXY from the environment.
+func newHTTPClient(proxy string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	switch proxy = strings.TrimSpace(proxy); proxy {
+	case "":
+		transport.Proxy = http.ProxyFromEnvironment
+	case ProxyDirect:
+		transport.Proxy = nil
+	default:
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q: want a URL such as http://proxy:8080, or %q", proxy, ProxyDirect)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("invalid proxy %q: scheme must be http, https or socks5", proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return &http.Client{Timeout: notifierTimeout, Transport: transport}, nil
+}
@@ -2,6 +2,7 @@ package notify
 
 import (
 	"context"
+	"strings"
 )
 
 // Notifier defines the interface for sending notifications.
@@ -9,6 +10,66 @@ type Notifier interface {
 	Send(ctx context.Context, title, body string) error
 }
 
+// Message is a run notification with the parts channels may lay out differently. The
+// JSON form is how the outbox stores it.
+type Message struct {
+	Title string `json:"title"`
+	// Body holds the summary lines (status, run ID, exit code, error).
+	Body     string `json:"body"`
+	TaskID   string `json:"task_id,omitempty"`
+	RunID    string `json:"run_id,omitempty"`
+	Status   string `json:"status,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	// Excerpt is the last lines of the run's output.
+	Excerpt string `json:"excerpt,omitempty"`
+	// LogURL is a signed link to the full log; empty when no public URL is configured.
+	LogURL string `json:"log_url,omitempty"`
+	// HTML is a rich rendering of the message for channels that can show it (email).
+	HTML string `json:"html,omitempty"`
+}
+
+// Text renders the message as a plain-text body for channels without their own format.
+func (m Message) Text() string {
+	var b strings.Builder
+	b.WriteString(m.Body)
+	if m.Excerpt != "" {
+		b.WriteString("\n\nOutput:\n")
+		b.WriteString(m.Excerpt)
+	}
+	if m.LogURL != "" {
+		b.WriteString("\n\nFull log: ")
+		b.WriteString(m.LogURL)
+	}
+	return b.String()
+}
+
+// MessageSender is implemented by notifiers that format a Message themselves.
+type MessageSender interface {
+	SendMessage(ctx context.Context, msg Message) error
+}
+
+// SendMessage delivers msg through n, as plain text when n has no format of its own.
+func SendMessage(ctx context.Context, n Notifier, msg Message) error {
+	if sender, ok := n.(MessageSender); ok {
+		return sender.SendMessage(ctx, msg)
+	}
+	return n.Send(ctx, msg.Title, msg.Text())
+}
+
+// Escalator is implemented by notifiers that can reach channels reserved for escalations.
+type Escalator interface {
+	Escalate(ctx context.Context, msg Message) error
+}
+
+// Escalate delivers msg through n as an escalation, or as a regular message when n
+// has no escalation channels.
+func Escalate(ctx context.Context, n Notifier, msg Message) error {
+	if escalator, ok := n.(Escalator); ok {
+		return escalator.Escalate(ctx, msg)
+	}
+	return SendMessage(ctx, n, msg)
+}
+
 // MultiNotifier combines multiple notifiers.
 type MultiNotifier struct {
 	notifiers []Notifier
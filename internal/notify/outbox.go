@@ -0,0 +1,236 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"clicrontab/internal/clock"
+)
+
+// DeliveryStatus is the state of one outbox entry.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is a notification waiting for, or done with, delivery to one channel.
+type Delivery struct {
+	ID            string
+	Channel       string
+	Message       Message
+	Status        DeliveryStatus
+	Attempts      int
+	LastError     *string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+}
+
+// OutboxStore persists deliveries so they survive failures and restarts.
+type OutboxStore interface {
+	// EnqueueDeliveries stores new pending deliveries, assigning their IDs.
+	EnqueueDeliveries(ctx context.Context, deliveries []*Delivery) error
+	// DueDeliveries returns pending deliveries whose next attempt is at or before now, oldest first.
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*Delivery, error)
+	// SaveDeliveryAttempt records the outcome of an attempt.
+	SaveDeliveryAttempt(ctx context.Context, delivery *Delivery) error
+	// PruneDeliveries removes finished deliveries created before cutoff.
+	PruneDeliveries(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// Retry policy for outbox deliveries: the first retry waits outboxRetryBase and each
+// further one twice as long, up to outboxRetryMax, for outboxMaxAttempts attempts in all
+// (about two and a half hours).
+const (
+	outboxMaxAttempts = 10
+	outboxRetryBase   = 30 * time.Second
+	outboxRetryMax    = time.Hour
+	outboxSendTimeout = 30 * time.Second
+	outboxPoll        = 15 * time.Second
+	outboxBatch       = 50
+	// outboxRetention is how long finished deliveries stay listed in /v1/notifications.
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+// Outbox makes notifications durable: Send, SendMessage and Escalate store one delivery
+// per channel of the Dispatcher they go to and return, and Run delivers them, retrying failures with
+// exponential backoff. It implements Notifier, MessageSender and Escalator, so it can
+// stand in for the Dispatcher wherever alerts are sent.
+type Outbox struct {
+	dispatcher *Dispatcher
+	store      OutboxStore
+	logger     *slog.Logger
+	clock      clock.Clock
+	wake       chan struct{}
+}
+
+// NewOutbox creates an outbox delivering through dispatcher's channels.
+func NewOutbox(dispatcher *Dispatcher, store OutboxStore, logger *slog.Logger) *Outbox {
+	return &Outbox{dispatcher: dispatcher, store: store, logger: logger, clock: clock.System, wake: make(chan struct{}, 1)}
+}
+
+// SetClock makes delivery times, retries and pruning follow c instead of the system
+// clock.
+func (o *Outbox) SetClock(c clock.Clock) {
+	o.clock = clock.OrSystem(c)
+}
+
+// Send queues a plain notification for every enabled channel.
+func (o *Outbox) Send(ctx context.Context, title, body string) error {
+	return o.SendMessage(ctx, Message{Title: title, Body: body})
+}
+
+// SendMessage queues msg for every enabled channel not reserved for escalations. It
+// fails only when the message cannot be stored; delivery errors are retried and
+// recorded on the delivery.
+func (o *Outbox) SendMessage(ctx context.Context, msg Message) error {
+	return o.queue(ctx, o.dispatcher.RoutineChannels(), msg)
+}
+
+// Escalate queues msg for every enabled channel, including those reserved for
+// escalations.
+func (o *Outbox) Escalate(ctx context.Context, msg Message) error {
+	return o.queue(ctx, o.dispatcher.Channels(), msg)
+}
+
+func (o *Outbox) queue(ctx context.Context, channels []string, msg Message) error {
+	if len(channels) == 0 {
+		return nil
+	}
+	now := o.clock.Now().UTC()
+	deliveries := make([]*Delivery, 0, len(channels))
+	for _, channel := range channels {
+		deliveries = append(deliveries, &Delivery{
+			Channel: channel, Message: msg, Status: DeliveryPending, NextAttemptAt: now, CreatedAt: now,
+		})
+	}
+	if err := o.store.EnqueueDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("queue notification: %w", err)
+	}
+	o.Wake()
+	return nil
+}
+
+// SendMessageTo queues msg for a single channel, or returns ErrChannelDisabled when the
+// channel is not enabled.
+func (o *Outbox) SendMessageTo(ctx context.Context, channel string, msg Message) error {
+	if !slices.Contains(o.dispatcher.Channels(), channel) {
+		return fmt.Errorf("channel %q: %w", channel, ErrChannelDisabled)
+	}
+	now := o.clock.Now().UTC()
+	delivery := &Delivery{Channel: channel, Message: msg, Status: DeliveryPending, NextAttemptAt: now, CreatedAt: now}
+	if err := o.store.EnqueueDeliveries(ctx, []*Delivery{delivery}); err != nil {
+		return fmt.Errorf("queue notification: %w", err)
+	}
+	o.Wake()
+	return nil
+}
+
+// Wake makes Run look for due deliveries now, e.g. after one is requeued.
+func (o *Outbox) Wake() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers due notifications until ctx is done. Deliveries left pending by a
+// previous daemon are picked up on start.
+func (o *Outbox) Run(ctx context.Context) {
+	poll := o.clock.NewTicker(outboxPoll)
+	defer poll.Stop()
+	prune := o.clock.NewTicker(time.Hour)
+	defer prune.Stop()
+	o.prune(ctx)
+	for {
+		o.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.wake:
+		case <-poll.C():
+		case <-prune.C():
+			o.prune(ctx)
+		}
+	}
+}
+
+func (o *Outbox) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		due, err := o.store.DueDeliveries(ctx, o.clock.Now(), outboxBatch)
+		if err != nil {
+			o.logger.Error("list due notifications", "err", err)
+			return
+		}
+		for _, delivery := range due {
+			if err := o.attempt(ctx, delivery); err != nil {
+				// The batch would be listed as due again; retry on the next tick
+				o.logger.Error("save notification delivery", "id", delivery.ID, "err", err)
+				return
+			}
+		}
+		if len(due) < outboxBatch {
+			return
+		}
+	}
+}
+
+// attempt sends one delivery and records the outcome: delivered, scheduled for a retry,
+// or failed once attempts run out or the channel is no longer enabled. It returns the
+// error of recording the outcome.
+func (o *Outbox) attempt(ctx context.Context, delivery *Delivery) error {
+	sendCtx, cancel := context.WithTimeout(ctx, outboxSendTimeout)
+	err := o.dispatcher.SendMessageTo(sendCtx, delivery.Channel, delivery.Message)
+	cancel()
+	if ctx.Err() != nil {
+		// Shutting down; the delivery stays pending for the next start
+		return nil
+	}
+
+	now := o.clock.Now().UTC()
+	delivery.Attempts++
+	switch {
+	case err == nil:
+		delivery.Status = DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = nil
+	case errors.Is(err, ErrChannelDisabled) || delivery.Attempts >= outboxMaxAttempts:
+		msg := err.Error()
+		delivery.Status = DeliveryFailed
+		delivery.LastError = &msg
+		o.logger.Error("notification delivery failed; giving up", "id", delivery.ID, "channel", delivery.Channel,
+			"attempts", delivery.Attempts, "title", delivery.Message.Title, "err", err)
+	default:
+		msg := err.Error()
+		delivery.LastError = &msg
+		delivery.NextAttemptAt = now.Add(retryDelay(delivery.Attempts))
+		o.logger.Warn("notification delivery failed; will retry", "id", delivery.ID, "channel", delivery.Channel,
+			"attempts", delivery.Attempts, "next_attempt_at", delivery.NextAttemptAt, "err", err)
+	}
+	return o.store.SaveDeliveryAttempt(ctx, delivery)
+}
+
+// retryDelay is the wait after the given number of failed attempts.
+func retryDelay(attempts int) time.Duration {
+	delay := outboxRetryBase
+	for i := 1; i < attempts && delay < outboxRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, outboxRetryMax)
+}
+
+func (o *Outbox) prune(ctx context.Context) {
+	n, err := o.store.PruneDeliveries(ctx, o.clock.Now().Add(-outboxRetention))
+	if err != nil {
+		o.logger.Warn("prune notification outbox", "err", err)
+	} else if n > 0 {
+		o.logger.Debug("pruned notification outbox", "deliveries", n)
+	}
+}
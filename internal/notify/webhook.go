@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WebhookNotifier posts notifications as JSON to an arbitrary HTTP endpoint.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a new webhook notifier sending through proxy (see newHTTPClient).
+func NewWebhookNotifier(url, proxy string) (*WebhookNotifier, error) {
+	if url == "" {
+		return nil, fmt.Errorf("webhook url is empty")
+	}
+	client, err := newHTTPClient(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: %w", err)
+	}
+	return &WebhookNotifier{url: url, client: client}, nil
+}
+
+func (n *WebhookNotifier) Send(ctx context.Context, title, body string) error {
+	return n.post(ctx, map[string]any{
+		"source": "clicrontab",
+		"title":  title,
+		"body":   body,
+	})
+}
+
+// SendMessage posts the plain-text body plus the run's fields, so receivers can lay
+// out the excerpt and link themselves.
+func (n *WebhookNotifier) SendMessage(ctx context.Context, msg Message) error {
+	return n.post(ctx, map[string]any{
+		"source":      "clicrontab",
+		"title":       msg.Title,
+		"body":        msg.Text(),
+		"task_id":     msg.TaskID,
+		"run_id":      msg.RunID,
+		"status":      msg.Status,
+		"exit_code":   msg.ExitCode,
+		"log_excerpt": msg.Excerpt,
+		"log_url":     msg.LogURL,
+	})
+}
+
+func (n *WebhookNotifier) post(ctx context.Context, fields map[string]any) error {
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send webhook notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook returned status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,375 @@
+// Package oidc signs web users in through an OpenID Connect provider (Google, Keycloak,
+// ...) or GitHub's OAuth apps, using the authorization code flow with PKCE, and maps the
+// user's groups to a clicrontab role.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Roles a signed-in user can have.
+const (
+	// RoleAdmin may do everything the auth token allows.
+	RoleAdmin = "admin"
+	// RoleViewer may only read: unsafe requests, including MCP calls, are refused.
+	RoleViewer = "viewer"
+)
+
+// GitHubIssuer selects GitHub's OAuth endpoints, which do not implement OIDC. Groups are
+// the user's organizations ("org") and teams ("org/team").
+const GitHubIssuer = "https://github.com"
+
+// ErrNoRole is returned when none of the user's groups maps to a role and there is no
+// default role.
+var ErrNoRole = errors.New("user has no clicrontab role")
+
+// Config describes the provider and how users map to roles.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// Scopes requested; empty uses openid, email, profile (read:user, read:org for GitHub).
+	Scopes []string
+	// GroupsClaim is the ID token or userinfo claim listing the user's groups.
+	GroupsClaim string
+	// Roles maps group names to RoleAdmin or RoleViewer; the highest role wins.
+	Roles map[string]string
+	// DefaultRole is given to users in none of the mapped groups; empty denies them.
+	DefaultRole string
+}
+
+// Identity is a signed-in user.
+type Identity struct {
+	// Name is the email, username or subject, whichever the provider returns first.
+	Name   string
+	Groups []string
+	Role   string
+}
+
+// Provider runs logins against one provider.
+type Provider struct {
+	cfg  Config
+	http *http.Client
+
+	mu        sync.Mutex
+	endpoints *endpoints // discovered on first use
+}
+
+type endpoints struct {
+	Issuer   string `json:"issuer"`
+	Auth     string `json:"authorization_endpoint"`
+	Token    string `json:"token_endpoint"`
+	UserInfo string `json:"userinfo_endpoint"`
+}
+
+// New returns a provider. Discovery happens on the first login, so the daemon starts
+// while the provider is unreachable.
+func New(cfg Config) *Provider {
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+		if cfg.Issuer == GitHubIssuer {
+			cfg.Scopes = []string{"read:user", "read:org"}
+		}
+	}
+	return &Provider{cfg: cfg, http: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// NewSecret returns a random URL-safe string for state, nonce and PKCE verifiers.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AuthURL returns the provider URL to send the browser to.
+func (p *Provider) AuthURL(ctx context.Context, redirectURL, state, nonce, verifier string) (string, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {redirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if !p.github() {
+		query.Set("nonce", nonce)
+	}
+	sep := "?"
+	if strings.Contains(ep.Auth, "?") {
+		sep = "&"
+	}
+	return ep.Auth + sep + query.Encode(), nil
+}
+
+// Exchange redeems the authorization code and returns the user with their role.
+func (p *Provider) Exchange(ctx context.Context, redirectURL, code, nonce, verifier string) (*Identity, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.Token, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		IDToken     string `json:"id_token"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err := p.do(req, &tok); err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	if tok.Error != "" {
+		return nil, fmt.Errorf("token request: %s: %s", tok.Error, tok.Description)
+	}
+
+	var id *Identity
+	if p.github() {
+		id, err = p.githubIdentity(ctx, tok.AccessToken)
+	} else {
+		id, err = p.oidcIdentity(ctx, ep, tok.IDToken, tok.AccessToken, nonce)
+	}
+	if err != nil {
+		return nil, err
+	}
+	id.Role = p.role(id.Groups)
+	if id.Role == "" {
+		return id, ErrNoRole
+	}
+	return id, nil
+}
+
+// oidcIdentity reads the user from the ID token. The token came straight from the token
+// endpoint over TLS, so per OIDC Core 3.1.3.7 its issuer, audience, expiry and nonce are
+// checked but not its signature. Groups missing from the ID token are read from userinfo.
+func (p *Provider) oidcIdentity(ctx context.Context, ep *endpoints, idToken, accessToken, nonce string) (*Identity, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token response has no valid id_token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("decode id_token: %w", err)
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("decode id_token: %w", err)
+	}
+	if iss, _ := claims["iss"].(string); iss != ep.Issuer {
+		return nil, fmt.Errorf("id_token issuer %q does not match %q", iss, ep.Issuer)
+	}
+	if !audienceHas(claims["aud"], p.cfg.ClientID) {
+		return nil, errors.New("id_token was not issued for this client")
+	}
+	if exp, _ := claims["exp"].(float64); time.Now().After(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("id_token expired")
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	if _, ok := claims[p.cfg.GroupsClaim]; !ok && ep.UserInfo != "" && accessToken != "" {
+		var info map[string]any
+		if err := p.getJSON(ctx, ep.UserInfo, accessToken, &info); err != nil {
+			return nil, fmt.Errorf("userinfo: %w", err)
+		}
+		for k, v := range info {
+			if _, ok := claims[k]; !ok {
+				claims[k] = v
+			}
+		}
+	}
+
+	id := &Identity{Groups: stringList(claims[p.cfg.GroupsClaim])}
+	for _, key := range []string{"email", "preferred_username", "sub"} {
+		if name, _ := claims[key].(string); name != "" {
+			id.Name = name
+			break
+		}
+	}
+	return id, nil
+}
+
+func (p *Provider) githubIdentity(ctx context.Context, accessToken string) (*Identity, error) {
+	const api = "https://api.github.com"
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := p.getJSON(ctx, api+"/user", accessToken, &user); err != nil {
+		return nil, fmt.Errorf("github user: %w", err)
+	}
+	var orgs []struct {
+		Login string `json:"login"`
+	}
+	if err := p.getJSON(ctx, api+"/user/orgs?per_page=100", accessToken, &orgs); err != nil {
+		return nil, fmt.Errorf("github orgs: %w", err)
+	}
+	var teams []struct {
+		Slug string `json:"slug"`
+		Org  struct {
+			Login string `json:"login"`
+		} `json:"organization"`
+	}
+	if err := p.getJSON(ctx, api+"/user/teams?per_page=100", accessToken, &teams); err != nil {
+		return nil, fmt.Errorf("github teams: %w", err)
+	}
+	id := &Identity{Name: user.Login}
+	for _, org := range orgs {
+		id.Groups = append(id.Groups, org.Login)
+	}
+	for _, team := range teams {
+		id.Groups = append(id.Groups, team.Org.Login+"/"+team.Slug)
+	}
+	return id, nil
+}
+
+// role returns the highest role any of groups maps to, or the default role.
+func (p *Provider) role(groups []string) string {
+	role := ""
+	for _, group := range groups {
+		switch p.cfg.Roles[group] {
+		case RoleAdmin:
+			return RoleAdmin
+		case RoleViewer:
+			role = RoleViewer
+		}
+	}
+	if role == "" {
+		role = p.cfg.DefaultRole
+	}
+	return role
+}
+
+func (p *Provider) github() bool {
+	return p.cfg.Issuer == GitHubIssuer
+}
+
+// discover loads the provider's endpoints, retrying on the next login after a failure.
+func (p *Provider) discover(ctx context.Context) (*endpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+	if p.github() {
+		p.endpoints = &endpoints{
+			Issuer: GitHubIssuer,
+			Auth:   "https://github.com/login/oauth/authorize",
+			Token:  "https://github.com/login/oauth/access_token",
+		}
+		return p.endpoints, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var ep endpoints
+	if err := p.do(req, &ep); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if ep.Auth == "" || ep.Token == "" {
+		return nil, errors.New("oidc discovery: document lacks authorization or token endpoint")
+	}
+	if ep.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", ep.Issuer, p.cfg.Issuer)
+	}
+	p.endpoints = &ep
+	return p.endpoints, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url, accessToken string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	return p.do(req, v)
+}
+
+func (p *Provider) do(req *http.Request, v any) error {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "clicrontab")
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("status %d: decode response: %w", resp.StatusCode, err)
+	}
+	return nil
+}
+
+func audienceHas(aud any, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []any:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stringList reads a claim that is a list of strings or a single string.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var out []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
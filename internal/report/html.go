@@ -0,0 +1,234 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Chart colors; email clients ignore stylesheets in SVG, so they are set per element.
+const (
+	colorSucceeded = "#2f9e44"
+	colorFailed    = "#e03131"
+	colorSkipped   = "#adb5bd"
+	colorAxis      = "#868e96"
+)
+
+// htmlTaskLimit caps the rows of the task chart and table.
+const htmlTaskLimit = 15
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="margin:0;padding:24px;background:#f8f9fa;font-family:-apple-system,'Segoe UI',Helvetica,Arial,sans-serif;color:#212529">
+<div style="max-width:680px;margin:0 auto;background:#ffffff;border-radius:8px;padding:24px">
+<h1 style="font-size:20px;margin:0 0 4px">Automation report: {{.Range}}</h1>
+<p style="margin:0 0 20px;color:#868e96;font-size:13px">{{.Period}} &middot; generated by clicrontab</p>
+<table role="presentation" style="width:100%;border-collapse:collapse;margin-bottom:24px"><tr>
+{{range .Tiles}}<td style="width:25%;padding:12px;background:#f1f3f5;border:4px solid #ffffff;text-align:center">
+<div style="font-size:22px;font-weight:600;color:{{.Color}}">{{.Value}}</div>
+<div style="font-size:12px;color:#495057">{{.Label}}</div></td>
+{{end}}</tr></table>
+{{if .DayChart}}<h2 style="font-size:16px;margin:0 0 8px">Runs per day</h2>
+{{.DayChart}}
+<p style="margin:4px 0 24px;font-size:12px;color:#495057"><span style="color:{{.Colors.Succeeded}}">&#9632;</span> succeeded &nbsp; <span style="color:{{.Colors.Failed}}">&#9632;</span> failed or timed out &nbsp; <span style="color:{{.Colors.Skipped}}">&#9632;</span> skipped or canceled</p>{{end}}
+{{if .TaskChart}}<h2 style="font-size:16px;margin:0 0 8px">Success rate by task</h2>
+{{.TaskChart}}{{end}}
+{{if .Rows}}<h2 style="font-size:16px;margin:24px 0 8px">Tasks</h2>
+<table style="width:100%;border-collapse:collapse;font-size:13px">
+<tr style="text-align:left;color:#495057;border-bottom:1px solid #dee2e6"><th style="padding:6px 4px">Task</th><th style="padding:6px 4px;text-align:right">Runs</th><th style="padding:6px 4px;text-align:right">Failed</th><th style="padding:6px 4px;text-align:right">Success</th><th style="padding:6px 4px;text-align:right">Avg time</th></tr>
+{{range .Rows}}<tr style="border-bottom:1px solid #f1f3f5"><td style="padding:6px 4px">{{.Name}}</td><td style="padding:6px 4px;text-align:right">{{.Runs}}</td><td style="padding:6px 4px;text-align:right;color:{{if .Failed}}#e03131{{else}}#495057{{end}}">{{.Failed}}</td><td style="padding:6px 4px;text-align:right">{{.Success}}</td><td style="padding:6px 4px;text-align:right">{{.AvgTime}}</td></tr>
+{{end}}</table>{{if .More}}<p style="font-size:12px;color:#868e96">... and {{.More}} more tasks</p>{{end}}{{else}}<p>No runs in this period.</p>{{end}}
+</div>
+</body>
+</html>
+`))
+
+type htmlTile struct {
+	Label, Value, Color string
+}
+
+type htmlRow struct {
+	Name             string
+	Runs, Failed     int
+	Success, AvgTime string
+}
+
+type htmlData struct {
+	Title, Period, Range string
+	Tiles                []htmlTile
+	DayChart, TaskChart  template.HTML
+	Rows                 []htmlRow
+	More                 int
+	Colors               struct{ Succeeded, Failed, Skipped string }
+}
+
+// RenderHTML renders the report as a self-contained HTML page for email: inline styles
+// and server-side SVG charts, no scripts or external resources.
+func RenderHTML(r *Report, loc *time.Location) (string, error) {
+	last := r.To.In(loc).AddDate(0, 0, -1)
+	data := htmlData{
+		Title:  r.Title(),
+		Period: r.Period,
+		Range:  r.From.In(loc).Format("Jan 2") + " – " + last.Format("Jan 2, 2006"),
+	}
+	if r.Kind == KindDaily {
+		data.Range = last.Format("Mon, Jan 2, 2006")
+	}
+	data.Colors.Succeeded, data.Colors.Failed, data.Colors.Skipped = colorSucceeded, colorFailed, colorSkipped
+
+	t := r.Totals
+	rate := "–"
+	if finished := t.Succeeded + t.Failed + t.TimedOut; finished > 0 {
+		rate = fmt.Sprintf("%.1f%%", 100*float64(t.Succeeded)/float64(finished))
+	}
+	failedColor := "#212529"
+	if t.Failed+t.TimedOut > 0 {
+		failedColor = colorFailed
+	}
+	data.Tiles = []htmlTile{
+		{"runs", fmt.Sprint(t.Total), "#212529"},
+		{"success rate", rate, colorSucceeded},
+		{"failed or timed out", fmt.Sprint(t.Failed + t.TimedOut), failedColor},
+		{"run time", formatSeconds(t.RunSeconds), "#212529"},
+	}
+
+	if len(r.Days) > 0 {
+		data.DayChart = dayChart(r.Days)
+	}
+	tasks := append([]TaskSummary(nil), r.Tasks...)
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].Total != tasks[j].Total {
+			return tasks[i].Total > tasks[j].Total
+		}
+		return tasks[i].Name < tasks[j].Name
+	})
+	if len(tasks) > htmlTaskLimit {
+		data.More = len(tasks) - htmlTaskLimit
+		tasks = tasks[:htmlTaskLimit]
+	}
+	if len(tasks) > 0 {
+		data.TaskChart = taskChart(tasks)
+	}
+	for _, task := range tasks {
+		row := htmlRow{Name: task.Name, Runs: task.Total, Failed: task.problems(), Success: "–", AvgTime: "–"}
+		if finished := task.Succeeded + task.problems(); finished > 0 {
+			row.Success = fmt.Sprintf("%.0f%%", 100*float64(task.Succeeded)/float64(finished))
+		}
+		if task.AvgDurationS > 0 {
+			row.AvgTime = formatSeconds(task.AvgDurationS)
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render report: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// dayChart draws a stacked bar per day: succeeded, failed and the rest.
+func dayChart(days []DayTotals) template.HTML {
+	const (
+		width, height = 640, 200
+		left, bottom  = 36, 24
+		plotH         = height - bottom - 8
+	)
+	peak := 0
+	for _, d := range days {
+		peak = max(peak, d.Total)
+	}
+	peak = niceCeil(peak)
+	slot := float64(width-left) / float64(len(days))
+	barW := slot * 0.6
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="100%%" viewBox="0 0 %d %d" role="img" aria-label="Runs per day">`, width, height)
+	ticks := []int{0, peak}
+	if peak > 1 {
+		ticks = []int{0, peak / 2, peak}
+	}
+	for _, tick := range ticks {
+		y := float64(8+plotH) - float64(plotH)*float64(tick)/float64(peak)
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#e9ecef"/>`, left, y, width, y)
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" font-size="11" fill="%s" text-anchor="end">%d</text>`, left-6, y+4, colorAxis, tick)
+	}
+	for i, d := range days {
+		x := float64(left) + slot*float64(i) + (slot-barW)/2
+		y := float64(8 + plotH)
+		for _, seg := range []struct {
+			n     int
+			color string
+		}{
+			{d.Succeeded, colorSucceeded},
+			{d.Failed + d.TimedOut, colorFailed},
+			{d.Skipped + d.Canceled, colorSkipped},
+		} {
+			if seg.n == 0 {
+				continue
+			}
+			h := float64(plotH) * float64(seg.n) / float64(peak)
+			y -= h
+			fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%d</title></rect>`, x, y, barW, h, seg.color, seg.n)
+		}
+		label := d.Date
+		if day, err := time.Parse(DateLayout, d.Date); err == nil {
+			label = day.Format("Mon 2")
+		}
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" font-size="11" fill="%s" text-anchor="middle">%s</text>`, x+barW/2, height-6, colorAxis, label)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// taskChart draws a horizontal bar per task showing its share of successful runs.
+func taskChart(tasks []TaskSummary) template.HTML {
+	const (
+		width, rowH = 640, 22
+		labelW      = 200
+		barMax      = width - labelW - 56
+	)
+	height := rowH*len(tasks) + 4
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="100%%" viewBox="0 0 %d %d" role="img" aria-label="Success rate by task">`, width, height)
+	for i, task := range tasks {
+		y := i*rowH + 2
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="12" fill="#212529" text-anchor="end">%s</text>`, labelW-8, y+14, template.HTMLEscapeString(truncate(task.Name, 28)))
+		finished := task.Succeeded + task.problems()
+		if finished == 0 {
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="#f1f3f5"/>`, labelW, y+3, barMax, rowH-8)
+			fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11" fill="%s">no finished runs</text>`, labelW+6, y+14, colorAxis)
+			continue
+		}
+		// Successes from the left, failures filling the rest of the bar
+		share := float64(task.Succeeded) / float64(finished)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`, labelW, y+3, barMax, rowH-8, colorFailed)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"/>`, labelW, y+3, float64(barMax)*share, rowH-8, colorSucceeded)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11" fill="#495057">%.0f%%</text>`, labelW+barMax+6, y+14, share*100)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// niceCeil rounds n up to 1, 2 or 5 times a power of ten, so axis ticks are round numbers.
+func niceCeil(n int) int {
+	for step := 1; ; step *= 10 {
+		for _, m := range []int{1, 2, 5} {
+			if n <= m*step {
+				return m * step
+			}
+		}
+	}
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,248 @@
+// Package report builds periodic digests of run outcomes, stores them and sends them
+// through the notification channels.
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kind names a report series.
+type Kind string
+
+const (
+	// KindDaily covers one calendar day in the daemon's time zone.
+	KindDaily Kind = "daily"
+	// KindWeekly covers one ISO week, Monday to Sunday, in the daemon's time zone.
+	KindWeekly Kind = "weekly"
+)
+
+// DateLayout is the period format of daily reports.
+const DateLayout = "2006-01-02"
+
+// Valid reports whether k is a known report kind.
+func (k Kind) Valid() bool {
+	return k == KindDaily || k == KindWeekly
+}
+
+// ErrNotFound is returned when no report is stored for a period.
+var ErrNotFound = errors.New("report not found")
+
+// TaskSummary aggregates one task's runs in the report period.
+type TaskSummary struct {
+	TaskID string `json:"task_id"`
+	// Name is the task name, or its ID when it has none or was deleted.
+	Name      string `json:"name"`
+	Total     int    `json:"total"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	TimedOut  int    `json:"timed_out"`
+	Canceled  int    `json:"canceled"`
+	Skipped   int    `json:"skipped"`
+	// RunSeconds, AvgDurationS and MaxDurationS cover runs that started and ended.
+	RunSeconds   float64 `json:"run_seconds"`
+	AvgDurationS float64 `json:"avg_duration_s"`
+	MaxDurationS float64 `json:"max_duration_s"`
+	// CPUSeconds is the CPU time used by all runs; MaxRSSKB the highest peak memory.
+	CPUSeconds float64 `json:"cpu_seconds"`
+	MaxRSSKB   int64   `json:"max_rss_kb"`
+}
+
+// Totals sums the task summaries.
+type Totals struct {
+	Total      int     `json:"total"`
+	Succeeded  int     `json:"succeeded"`
+	Failed     int     `json:"failed"`
+	TimedOut   int     `json:"timed_out"`
+	Canceled   int     `json:"canceled"`
+	Skipped    int     `json:"skipped"`
+	CPUSeconds float64 `json:"cpu_seconds"`
+	// RunSeconds is the wall-clock time spent in runs that started and ended.
+	RunSeconds float64 `json:"run_seconds"`
+}
+
+// DayTotals are the totals of one day of a multi-day report.
+type DayTotals struct {
+	Date string `json:"date"`
+	Totals
+}
+
+// Report is the digest of the runs created in [From, To).
+type Report struct {
+	Kind Kind `json:"kind"`
+	// Period identifies the report within its kind, e.g. "2025-06-01" for a daily report.
+	Period      string        `json:"period"`
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	Totals      Totals        `json:"totals"`
+	Tasks       []TaskSummary `json:"tasks"`
+	// Days breaks a weekly report down by day.
+	Days []DayTotals `json:"days,omitempty"`
+}
+
+// Store reads run outcomes and keeps generated reports.
+type Store interface {
+	// RunSummaries aggregates the runs created in [from, to) by task.
+	RunSummaries(ctx context.Context, from, to time.Time) ([]TaskSummary, error)
+	SaveReport(ctx context.Context, report *Report) error
+	// GetReport returns ErrNotFound when no report is stored for the period.
+	GetReport(ctx context.Context, kind Kind, period string) (*Report, error)
+}
+
+// Bounds returns the start of the kind's period containing t and of the next period, in loc.
+func Bounds(kind Kind, t time.Time, loc *time.Location) (time.Time, time.Time) {
+	t = t.In(loc)
+	from := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	if kind == KindWeekly {
+		from = from.AddDate(0, 0, -int((from.Weekday()+6)%7))
+		return from, from.AddDate(0, 0, 7)
+	}
+	return from, from.AddDate(0, 0, 1)
+}
+
+// Period names the kind's period starting at from: "2025-06-01" for a day, "2025-W22"
+// for an ISO week.
+func Period(kind Kind, from time.Time) string {
+	if kind == KindWeekly {
+		year, week := from.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	}
+	return from.Format(DateLayout)
+}
+
+// ParsePeriod parses a period name of the kind and returns its start in loc.
+func ParsePeriod(kind Kind, value string, loc *time.Location) (time.Time, error) {
+	if kind == KindWeekly {
+		var year, week int
+		if n, _ := fmt.Sscanf(value, "%4d-W%2d", &year, &week); n != 2 || week < 1 || week > 53 || Period(kind, isoWeekStart(year, week, loc)) != value {
+			return time.Time{}, fmt.Errorf("invalid week %q (want YYYY-Www, e.g. 2025-W22)", value)
+		}
+		return isoWeekStart(year, week, loc), nil
+	}
+	day, err := time.ParseInLocation(DateLayout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (want YYYY-MM-DD)", value)
+	}
+	return day, nil
+}
+
+// isoWeekStart returns the Monday of the ISO week: week 1 is the week with January 4th.
+func isoWeekStart(year, week int, loc *time.Location) time.Time {
+	from, _ := Bounds(KindWeekly, time.Date(year, time.January, 4, 0, 0, 0, 0, loc), loc)
+	return from.AddDate(0, 0, 7*(week-1))
+}
+
+// Build aggregates the runs of the kind's period containing t, in loc, as of now.
+func Build(ctx context.Context, store Store, kind Kind, t time.Time, loc *time.Location, now time.Time) (*Report, error) {
+	from, to := Bounds(kind, t, loc)
+	tasks, err := store.RunSummaries(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{
+		Kind:        kind,
+		Period:      Period(kind, from),
+		From:        from.UTC(),
+		To:          to.UTC(),
+		GeneratedAt: now.UTC(),
+		Tasks:       tasks,
+		Totals:      sum(tasks),
+	}
+	if r.Tasks == nil {
+		r.Tasks = []TaskSummary{}
+	}
+	if kind == KindWeekly {
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			daily, err := store.RunSummaries(ctx, day, day.AddDate(0, 0, 1))
+			if err != nil {
+				return nil, err
+			}
+			r.Days = append(r.Days, DayTotals{Date: day.Format(DateLayout), Totals: sum(daily)})
+		}
+	}
+	return r, nil
+}
+
+func sum(tasks []TaskSummary) Totals {
+	var totals Totals
+	for _, t := range tasks {
+		totals.Total += t.Total
+		totals.Succeeded += t.Succeeded
+		totals.Failed += t.Failed
+		totals.TimedOut += t.TimedOut
+		totals.Canceled += t.Canceled
+		totals.Skipped += t.Skipped
+		totals.CPUSeconds += t.CPUSeconds
+		totals.RunSeconds += t.RunSeconds
+	}
+	return totals
+}
+
+// textTaskLimit caps the per-task lines of the notification text.
+const textTaskLimit = 20
+
+// Title is the notification title of the report.
+func (r *Report) Title() string {
+	name := "Daily"
+	if r.Kind == KindWeekly {
+		name = "Weekly"
+	}
+	return fmt.Sprintf("[clicrontab] %s Report %s", name, r.Period)
+}
+
+// Text renders the report as a plain-text digest: the totals, then the tasks with
+// problems first.
+func (r *Report) Text() string {
+	var b strings.Builder
+	t := r.Totals
+	fmt.Fprintf(&b, "%d runs: %d succeeded, %d failed, %d timed out, %d canceled, %d skipped.",
+		t.Total, t.Succeeded, t.Failed, t.TimedOut, t.Canceled, t.Skipped)
+	if t.Total == 0 {
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\nRun time %s, CPU time %s.\n", formatSeconds(t.RunSeconds), formatSeconds(t.CPUSeconds))
+
+	tasks := append([]TaskSummary(nil), r.Tasks...)
+	sort.SliceStable(tasks, func(i, j int) bool {
+		pi, pj := tasks[i].problems(), tasks[j].problems()
+		if pi != pj {
+			return pi > pj
+		}
+		return tasks[i].Name < tasks[j].Name
+	})
+	for i, task := range tasks {
+		if i == textTaskLimit {
+			fmt.Fprintf(&b, "\n... and %d more tasks", len(tasks)-textTaskLimit)
+			break
+		}
+		fmt.Fprintf(&b, "\n- %s: %d/%d succeeded", task.Name, task.Succeeded, task.Total)
+		if n := task.problems(); n > 0 {
+			fmt.Fprintf(&b, ", %d failed", n)
+		}
+		if task.Skipped > 0 {
+			fmt.Fprintf(&b, ", %d skipped", task.Skipped)
+		}
+		if task.MaxDurationS > 0 {
+			fmt.Fprintf(&b, ", avg %s, max %s", formatSeconds(task.AvgDurationS), formatSeconds(task.MaxDurationS))
+		}
+	}
+	return b.String()
+}
+
+// problems counts the runs that need attention.
+func (t TaskSummary) problems() int {
+	return t.Failed + t.TimedOut
+}
+
+func formatSeconds(s float64) string {
+	d := time.Duration(s * float64(time.Second))
+	if d < time.Minute {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,179 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"clicrontab/internal/clock"
+	"clicrontab/internal/notify"
+)
+
+const (
+	// reporterPoll is how often the reporter checks whether a report is due. Polling the
+	// wall clock keeps the report on time across system sleep and clock changes.
+	reporterPoll = time.Minute
+	// reporterTimeout bounds building, storing and sending one report.
+	reporterTimeout = time.Minute
+)
+
+// ParseTimeOfDay parses an "HH:MM" time of day into minutes after midnight.
+func ParseTimeOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q (want HH:MM)", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ParseWeekdayTime parses a weekday and time of day such as "mon 08:00".
+func ParseWeekdayTime(value string) (time.Weekday, int, error) {
+	day, clock, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if ok {
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if !strings.EqualFold(day, weekday.String()[:3]) && !strings.EqualFold(day, weekday.String()) {
+				continue
+			}
+			at, err := ParseTimeOfDay(strings.TrimSpace(clock))
+			if err != nil {
+				break
+			}
+			return weekday, at, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid weekday and time %q (want e.g. mon 08:00)", value)
+}
+
+// ChannelSender delivers a message to one notification channel; notify.Outbox implements it.
+type ChannelSender interface {
+	SendMessageTo(ctx context.Context, channel string, msg notify.Message) error
+}
+
+// schedule generates a kind's report once its period is over, offset into the next period.
+type schedule struct {
+	kind   Kind
+	days   int // offset into the next period
+	minute int
+	send   func(ctx context.Context, r *Report) error // nil only stores the report
+	done   string                                     // period of the last report handled
+}
+
+// Reporter generates reports for the periods that just ended, stores them and sends
+// them. A report missed while the daemon was down is generated on start; older ones
+// are not.
+type Reporter struct {
+	store     Store
+	logger    *slog.Logger
+	location  *time.Location
+	clock     clock.Clock
+	schedules []*schedule
+}
+
+// NewReporter creates a reporter with nothing scheduled.
+func NewReporter(store Store, logger *slog.Logger, location *time.Location) *Reporter {
+	return &Reporter{store: store, logger: logger, location: location, clock: clock.System}
+}
+
+// SetClock makes report periods follow c instead of the system clock.
+func (r *Reporter) SetClock(c clock.Clock) {
+	r.clock = clock.OrSystem(c)
+}
+
+// ScheduleDaily generates the previous day's report at minute after midnight and sends
+// it as plain text through notifier, which may be nil to only store it.
+func (r *Reporter) ScheduleDaily(minute int, notifier notify.Notifier) {
+	s := &schedule{kind: KindDaily, minute: minute}
+	if notifier != nil {
+		s.send = func(ctx context.Context, report *Report) error {
+			return notifier.Send(ctx, report.Title(), report.Text())
+		}
+	}
+	r.schedules = append(r.schedules, s)
+}
+
+// ScheduleWeekly generates the previous week's report on weekday at minute after
+// midnight and mails it, rendered as HTML, through the email channel when that is
+// enabled. sender may be nil to only store it.
+func (r *Reporter) ScheduleWeekly(weekday time.Weekday, minute int, sender ChannelSender) {
+	s := &schedule{kind: KindWeekly, days: int((weekday + 6) % 7), minute: minute}
+	if sender != nil {
+		s.send = func(ctx context.Context, report *Report) error {
+			html, err := RenderHTML(report, r.location)
+			if err != nil {
+				return err
+			}
+			err = sender.SendMessageTo(ctx, notify.ChannelEmail, notify.Message{
+				Title: report.Title(),
+				Body:  report.Text(),
+				HTML:  html,
+			})
+			if errors.Is(err, notify.ErrChannelDisabled) {
+				r.logger.Info("email channel is not enabled; weekly report stored only", "period", report.Period)
+				return nil
+			}
+			return err
+		}
+	}
+	r.schedules = append(r.schedules, s)
+}
+
+// Run generates due reports until ctx is done.
+func (r *Reporter) Run(ctx context.Context) {
+	ticker := r.clock.NewTicker(reporterPoll)
+	defer ticker.Stop()
+	for {
+		for _, s := range r.schedules {
+			r.check(ctx, s, r.clock.Now())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// check generates the report of the latest period whose report time has passed, unless
+// it was already generated.
+func (r *Reporter) check(ctx context.Context, s *schedule, now time.Time) {
+	current, _ := Bounds(s.kind, now, r.location)
+	due, _ := Bounds(s.kind, current.Add(-time.Nanosecond), r.location)
+	if now.Before(current.AddDate(0, 0, s.days).Add(time.Duration(s.minute) * time.Minute)) {
+		due, _ = Bounds(s.kind, due.Add(-time.Nanosecond), r.location)
+	}
+	period := Period(s.kind, due)
+	if period == s.done {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reporterTimeout)
+	defer cancel()
+	if _, err := r.store.GetReport(ctx, s.kind, period); err == nil {
+		s.done = period
+		return
+	} else if !errors.Is(err, ErrNotFound) {
+		r.logger.Warn("load report", "kind", s.kind, "period", period, "err", err)
+		return
+	}
+
+	report, err := Build(ctx, r.store, s.kind, due, r.location, r.clock.Now())
+	if err != nil {
+		r.logger.Warn("build report", "kind", s.kind, "period", period, "err", err)
+		return
+	}
+	if err := r.store.SaveReport(ctx, report); err != nil {
+		r.logger.Warn("save report", "kind", s.kind, "period", period, "err", err)
+		return
+	}
+	s.done = period
+	r.logger.Info("report generated", "kind", s.kind, "period", period, "runs", report.Totals.Total, "failed", report.Totals.Failed+report.Totals.TimedOut)
+	if s.send == nil {
+		return
+	}
+	if err := s.send(ctx, report); err != nil {
+		r.logger.Error("failed to send report", "kind", s.kind, "period", period, "err", err)
+	}
+}
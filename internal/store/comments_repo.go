@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+// InsertTaskComment stores a new comment for a task.
+func (s *Store) InsertTaskComment(ctx context.Context, comment *core.TaskComment) error {
+	comment.CreatedAt = s.now()
+	if _, err := insertTaskComment(ctx, s.DB, "INSERT", comment); err != nil {
+		return fmt.Errorf("insert task comment: %w", err)
+	}
+	return nil
+}
+
+func insertTaskComment(ctx context.Context, db execer, verb string, comment *core.TaskComment) (sql.Result, error) {
+	return db.ExecContext(ctx, verb+` INTO task_comments (id, task_id, author, body, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, comment.ID, comment.TaskID, comment.Author, comment.Body, comment.CreatedAt.UTC().Format(time.RFC3339Nano))
+}
+
+// ListTaskComments returns the task's comments, newest first.
+func (s *Store) ListTaskComments(ctx context.Context, taskID string, limit int) ([]*core.TaskComment, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, task_id, author, body, created_at
+		FROM task_comments
+		WHERE task_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, taskID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list task comments: %w", err)
+	}
+	defer rows.Close()
+	var comments []*core.TaskComment
+	for rows.Next() {
+		var (
+			comment   core.TaskComment
+			createdAt string
+		)
+		if err := rows.Scan(&comment.ID, &comment.TaskID, &comment.Author, &comment.Body, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan task comment: %w", err)
+		}
+		comment.CreatedAt = mustParseTime(createdAt)
+		comments = append(comments, &comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
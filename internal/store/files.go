@@ -0,0 +1,180 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrFileNotFound is returned for unknown file names.
+	ErrFileNotFound = errors.New("file not found")
+	// ErrInvalidFileName is returned for names outside fileNamePattern.
+	ErrInvalidFileName = errors.New("file name must be 1-128 letters, digits, '.', '-' or '_' and not start with '.'")
+	// ErrFileTooLarge is returned when an upload exceeds MaxFileBytes.
+	ErrFileTooLarge = errors.New("file exceeds the size limit")
+	// ErrFilesQuotaExceeded is returned when an upload would take the stored files past
+	// FilesQuotaBytes.
+	ErrFilesQuotaExceeded = errors.New("stored files would exceed the quota")
+)
+
+// fileNamePattern keeps uploaded files directly under the files directory, readable in
+// commands without quoting.
+var fileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]{0,127}$`)
+
+// StoredFile describes an uploaded file.
+type StoredFile struct {
+	Name string
+	// Path is where tasks find the file on the daemon's host.
+	Path       string
+	Size       int64
+	ModifiedAt time.Time
+}
+
+// FilesDir is the directory uploaded files are stored in.
+func (s *Store) FilesDir() string {
+	return filepath.Join(s.StateDir, "files")
+}
+
+// FilePath returns where the named file is stored, or ErrInvalidFileName.
+func (s *Store) FilePath(name string) (string, error) {
+	if !fileNamePattern.MatchString(name) {
+		return "", ErrInvalidFileName
+	}
+	return filepath.Join(s.FilesDir(), name), nil
+}
+
+// SaveFile stores r under name, replacing any file of that name once the upload is
+// complete, and reports whether it did replace one. Uploads over MaxFileBytes fail with
+// ErrFileTooLarge and those that would take the files past FilesQuotaBytes with
+// ErrFilesQuotaExceeded; the previous file is kept then.
+func (s *Store) SaveFile(name string, r io.Reader) (*StoredFile, bool, error) {
+	path, err := s.FilePath(name)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.MkdirAll(s.FilesDir(), 0o755); err != nil {
+		return nil, false, fmt.Errorf("create files dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(s.FilesDir(), ".upload-*")
+	if err != nil {
+		return nil, false, fmt.Errorf("create upload file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	src := r
+	if s.MaxFileBytes > 0 {
+		src = io.LimitReader(r, s.MaxFileBytes+1)
+	}
+	size, err := io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("write upload: %w", err)
+	}
+	if s.MaxFileBytes > 0 && size > s.MaxFileBytes {
+		return nil, false, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, s.MaxFileBytes)
+	}
+
+	s.filesMu.Lock()
+	defer s.filesMu.Unlock()
+	var previous int64
+	info, err := os.Stat(path)
+	replaced := err == nil
+	if replaced {
+		previous = info.Size()
+	}
+	if s.FilesQuotaBytes > 0 {
+		used, err := s.filesSize()
+		if err != nil {
+			return nil, false, err
+		}
+		if used-previous+size > s.FilesQuotaBytes {
+			return nil, false, fmt.Errorf("%w of %d bytes (%d in use)", ErrFilesQuotaExceeded, s.FilesQuotaBytes, used)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, false, fmt.Errorf("store upload: %w", err)
+	}
+	file, err := s.StatFile(name)
+	return file, replaced, err
+}
+
+// StatFile describes the named file.
+func (s *Store) StatFile(name string) (*StoredFile, error) {
+	path, err := s.FilePath(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+	return &StoredFile{Name: name, Path: path, Size: info.Size(), ModifiedAt: info.ModTime().UTC()}, nil
+}
+
+// ListFiles returns the uploaded files, by name.
+func (s *Store) ListFiles() ([]*StoredFile, error) {
+	entries, err := os.ReadDir(s.FilesDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list files: %w", err)
+	}
+	var files []*StoredFile
+	for _, entry := range entries {
+		// Skips uploads in progress
+		if !entry.Type().IsRegular() || !fileNamePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, &StoredFile{
+			Name: entry.Name(), Path: filepath.Join(s.FilesDir(), entry.Name()),
+			Size: info.Size(), ModifiedAt: info.ModTime().UTC(),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// DeleteFile removes the named file.
+func (s *Store) DeleteFile(name string) error {
+	path, err := s.FilePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrFileNotFound
+		}
+		return fmt.Errorf("delete file: %w", err)
+	}
+	return nil
+}
+
+// filesSize sums the sizes of the uploaded files.
+func (s *Store) filesSize() (int64, error) {
+	files, err := s.ListFiles()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	return total, nil
+}
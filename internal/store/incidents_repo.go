@@ -0,0 +1,243 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+var (
+	// ErrIncidentNotFound is returned when an incident does not exist, or a task has no
+	// unresolved one.
+	ErrIncidentNotFound = errors.New("incident not found")
+	// ErrIncidentResolved is returned when acknowledging or resolving a resolved incident.
+	ErrIncidentResolved = errors.New("incident is already resolved")
+)
+
+const incidentColumns = `i.id, i.task_id, COALESCE(NULLIF(t.name, ''), i.task_id), i.status, i.first_run_id, i.last_run_id, i.failures, i.opened_at, i.acknowledged_at, i.acknowledged_by, i.escalated_at, i.resolved_at, i.resolved_by, i.note`
+
+// IncidentFilter narrows ListIncidents; zero values match everything.
+type IncidentFilter struct {
+	TaskID string
+	// Statuses matches incidents in any of the listed statuses.
+	Statuses []core.IncidentStatus
+}
+
+// OpenIncident opens an incident for the task at runID, or counts one more failure on
+// its unresolved one, and returns it.
+func (s *Store) OpenIncident(ctx context.Context, taskID, runID string, at time.Time) (*core.Incident, error) {
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE incidents SET failures = failures + 1, last_run_id = ?
+		WHERE task_id = ? AND status != ?
+	`, runID, taskID, core.IncidentStatusResolved)
+	if err != nil {
+		return nil, fmt.Errorf("update incident: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := s.DB.ExecContext(ctx, `
+			INSERT INTO incidents (id, task_id, status, first_run_id, last_run_id, failures, opened_at)
+			VALUES (?, ?, ?, ?, ?, 1, ?)
+		`, core.NewID(), taskID, core.IncidentStatusOpen, runID, runID, at.UTC().Format(time.RFC3339Nano)); err != nil {
+			return nil, fmt.Errorf("open incident: %w", err)
+		}
+	}
+	return s.GetTaskIncident(ctx, taskID)
+}
+
+// GetIncident returns an incident by ID.
+func (s *Store) GetIncident(ctx context.Context, id string) (*core.Incident, error) {
+	return s.getIncident(ctx, `i.id = ?`, id)
+}
+
+// GetTaskIncident returns the task's unresolved incident, or ErrIncidentNotFound when
+// the task is not failing.
+func (s *Store) GetTaskIncident(ctx context.Context, taskID string) (*core.Incident, error) {
+	return s.getIncident(ctx, `i.task_id = ? AND i.status != ?`, taskID, core.IncidentStatusResolved)
+}
+
+func (s *Store) getIncident(ctx context.Context, where string, args ...any) (*core.Incident, error) {
+	incident, err := scanIncident(s.DB.QueryRowContext(ctx, `
+		SELECT `+incidentColumns+`
+		FROM incidents i LEFT JOIN tasks t ON t.id = i.task_id
+		WHERE `+where, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrIncidentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get incident: %w", err)
+	}
+	return incident, nil
+}
+
+// ListIncidents returns incidents matching filter, most recently opened first.
+func (s *Store) ListIncidents(ctx context.Context, filter IncidentFilter, limit, offset int) ([]*core.Incident, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	where := "1 = 1"
+	var args []any
+	if filter.TaskID != "" {
+		where += " AND i.task_id = ?"
+		args = append(args, filter.TaskID)
+	}
+	if len(filter.Statuses) > 0 {
+		where += " AND i.status IN (?" + strings.Repeat(", ?", len(filter.Statuses)-1) + ")"
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+incidentColumns+`
+		FROM incidents i LEFT JOIN tasks t ON t.id = i.task_id
+		WHERE `+where+`
+		ORDER BY i.opened_at DESC
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("list incidents: %w", err)
+	}
+	return scanIncidents(rows)
+}
+
+// AcknowledgeIncident records that by is looking into the incident, which stops it from
+// being escalated and silences repeat failure alerts until it is resolved. Acknowledging
+// again keeps the first acknowledgement.
+func (s *Store) AcknowledgeIncident(ctx context.Context, id, by string, at time.Time) (*core.Incident, error) {
+	incident, err := s.GetIncident(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch incident.Status {
+	case core.IncidentStatusResolved:
+		return nil, ErrIncidentResolved
+	case core.IncidentStatusAcknowledged:
+		return incident, nil
+	}
+	if _, err := s.DB.ExecContext(ctx, `
+		UPDATE incidents SET status = ?, acknowledged_at = ?, acknowledged_by = ?
+		WHERE id = ? AND status = ?
+	`, core.IncidentStatusAcknowledged, at.UTC().Format(time.RFC3339Nano), by, id, core.IncidentStatusOpen); err != nil {
+		return nil, fmt.Errorf("acknowledge incident: %w", err)
+	}
+	return s.GetIncident(ctx, id)
+}
+
+// ResolveIncident closes the incident. The task's next failure opens a new one.
+func (s *Store) ResolveIncident(ctx context.Context, id, by string, note *string, at time.Time) (*core.Incident, error) {
+	incident, err := s.GetIncident(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if incident.Status == core.IncidentStatusResolved {
+		return nil, ErrIncidentResolved
+	}
+	if _, err := s.DB.ExecContext(ctx, `
+		UPDATE incidents SET status = ?, resolved_at = ?, resolved_by = ?, note = ?
+		WHERE id = ? AND status != ?
+	`, core.IncidentStatusResolved, at.UTC().Format(time.RFC3339Nano), by, note, id, core.IncidentStatusResolved); err != nil {
+		return nil, fmt.Errorf("resolve incident: %w", err)
+	}
+	return s.GetIncident(ctx, id)
+}
+
+// ResolveTaskIncident resolves the task's unresolved incident, if it has one.
+func (s *Store) ResolveTaskIncident(ctx context.Context, taskID, by string, note *string, at time.Time) error {
+	if _, err := s.DB.ExecContext(ctx, `
+		UPDATE incidents SET status = ?, resolved_at = ?, resolved_by = ?, note = ?
+		WHERE task_id = ? AND status != ?
+	`, core.IncidentStatusResolved, at.UTC().Format(time.RFC3339Nano), by, note, taskID, core.IncidentStatusResolved); err != nil {
+		return fmt.Errorf("resolve task incident: %w", err)
+	}
+	return nil
+}
+
+// UnacknowledgedIncidents returns the open incidents of unarchived tasks opened at or
+// before openedBefore that were not escalated yet, oldest first.
+func (s *Store) UnacknowledgedIncidents(ctx context.Context, openedBefore time.Time) ([]*core.Incident, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+incidentColumns+`
+		FROM incidents i JOIN tasks t ON t.id = i.task_id
+		WHERE i.status = ? AND i.escalated_at IS NULL AND i.opened_at <= ? AND t.status != ?
+		ORDER BY i.opened_at
+	`, core.IncidentStatusOpen, openedBefore.UTC().Format(time.RFC3339Nano), core.TaskStatusArchived)
+	if err != nil {
+		return nil, fmt.Errorf("list unacknowledged incidents: %w", err)
+	}
+	return scanIncidents(rows)
+}
+
+// MarkIncidentEscalated records the escalation unless the incident was already
+// escalated, reporting whether it did.
+func (s *Store) MarkIncidentEscalated(ctx context.Context, id string, at time.Time) (bool, error) {
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE incidents SET escalated_at = ? WHERE id = ? AND escalated_at IS NULL
+	`, at.UTC().Format(time.RFC3339Nano), id)
+	if err != nil {
+		return false, fmt.Errorf("mark incident escalated: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func scanIncidents(rows *sql.Rows) ([]*core.Incident, error) {
+	defer rows.Close()
+	var incidents []*core.Incident
+	for rows.Next() {
+		incident, err := scanIncident(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan incident: %w", err)
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, rows.Err()
+}
+
+func scanIncident(scanner interface {
+	Scan(dest ...any) error
+}) (*core.Incident, error) {
+	var (
+		incident       core.Incident
+		openedAt       string
+		acknowledgedAt sql.NullString
+		acknowledgedBy sql.NullString
+		escalatedAt    sql.NullString
+		resolvedAt     sql.NullString
+		resolvedBy     sql.NullString
+		note           sql.NullString
+	)
+	if err := scanner.Scan(&incident.ID, &incident.TaskID, &incident.TaskName, &incident.Status, &incident.FirstRunID, &incident.LastRunID,
+		&incident.Failures, &openedAt, &acknowledgedAt, &acknowledgedBy, &escalatedAt, &resolvedAt, &resolvedBy, &note); err != nil {
+		return nil, err
+	}
+	incident.OpenedAt = mustParseTime(openedAt)
+	if acknowledgedAt.Valid {
+		t := mustParseTime(acknowledgedAt.String)
+		incident.AcknowledgedAt = &t
+	}
+	if escalatedAt.Valid {
+		t := mustParseTime(escalatedAt.String)
+		incident.EscalatedAt = &t
+	}
+	if resolvedAt.Valid {
+		t := mustParseTime(resolvedAt.String)
+		incident.ResolvedAt = &t
+	}
+	if acknowledgedBy.Valid {
+		incident.AcknowledgedBy = &acknowledgedBy.String
+	}
+	if resolvedBy.Valid {
+		incident.ResolvedBy = &resolvedBy.String
+	}
+	if note.Valid {
+		incident.Note = &note.String
+	}
+	return &incident, nil
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockFileName is the file in the state dir held locked by a running daemon.
+const lockFileName = "clicrontabd.lock"
+
+// ErrStateDirLocked is returned by LockStateDir when another daemon holds the state dir.
+var ErrStateDirLocked = errors.New("state dir is in use by another clicrontabd")
+
+// LockInfo describes the daemon holding a state dir lock.
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	Instance  string    `json:"instance,omitempty"`
+	Addr      string    `json:"addr,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// StateLock is an exclusive lock on a state dir, held until Release or process exit.
+type StateLock struct {
+	file *os.File
+	path string
+}
+
+// LockStateDir takes the state dir lock for this process so that two daemons never
+// share a database. The OS drops the lock when the process exits, so a crashed daemon
+// does not leave a stale lock behind. When the lock is held elsewhere the returned
+// error wraps ErrStateDirLocked and describes the holder.
+func LockStateDir(stateDir string, info LockInfo) (*StateLock, error) {
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		return nil, fmt.Errorf("ensure state dir: %w", err)
+	}
+	path := filepath.Join(stateDir, lockFileName)
+	file, err := lockFile(path)
+	if err != nil {
+		if errors.Is(err, ErrStateDirLocked) {
+			return nil, describeLockHolder(path, stateDir)
+		}
+		return nil, fmt.Errorf("lock state dir: %w", err)
+	}
+
+	payload, err := json.Marshal(info)
+	if err == nil {
+		if err = file.Truncate(0); err == nil {
+			_, err = file.WriteAt(payload, 0)
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("write lock file: %w", err)
+	}
+	return &StateLock{file: file, path: path}, nil
+}
+
+// ReadLockInfo returns the holder recorded in the state dir lock file, if any.
+// The file is left behind after a clean exit, so the daemon it names may no longer run.
+func ReadLockInfo(stateDir string) (LockInfo, bool) {
+	var info LockInfo
+	data, err := readLockFile(filepath.Join(stateDir, lockFileName))
+	if err != nil || len(data) == 0 {
+		return info, false
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, false
+	}
+	return info, true
+}
+
+// Release unlocks the state dir.
+func (l *StateLock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+func describeLockHolder(path, stateDir string) error {
+	info, ok := ReadLockInfo(stateDir)
+	if !ok {
+		return fmt.Errorf("%w (lock file %s)", ErrStateDirLocked, path)
+	}
+	holder := fmt.Sprintf("pid %d", info.PID)
+	if info.Instance != "" {
+		holder += fmt.Sprintf(", instance %q", info.Instance)
+	}
+	if info.Addr != "" {
+		holder += ", addr " + info.Addr
+	}
+	return fmt.Errorf("%w: %s (started %s); stop it or use a different --state-dir/--instance",
+		ErrStateDirLocked, holder, info.StartedAt.Format(time.RFC3339))
+}
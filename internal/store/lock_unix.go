@@ -0,0 +1,30 @@
+//go:build !windows
+
+package store
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile opens path and takes a non-blocking exclusive flock on it.
+func lockFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrStateDirLocked
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// readLockFile reads the lock file; flock is advisory so reading is always allowed.
+func readLockFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
@@ -0,0 +1,202 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// maxIndexedLogBytes caps how much of each log (from the end) goes into the index.
+	maxIndexedLogBytes = 1 << 20
+	// logIndexQueueSize bounds pending index jobs; runs beyond it are left unindexed.
+	logIndexQueueSize = 256
+	// logIndexBackfillLimit bounds how many existing unindexed runs are queued at startup.
+	logIndexBackfillLimit = 1000
+)
+
+type logIndexJob struct {
+	runID  string
+	taskID string
+}
+
+// EnableLogIndex creates the FTS5 table for run logs and starts a background indexer that
+// runs until ctx is done. Recently finished runs missing from the index are backfilled.
+func (s *Store) EnableLogIndex(ctx context.Context, logger *slog.Logger) error {
+	if _, err := s.DB.ExecContext(ctx, `
+		CREATE VIRTUAL TABLE IF NOT EXISTS run_logs_fts USING fts5(run_id UNINDEXED, task_id UNINDEXED, content)
+	`); err != nil {
+		return fmt.Errorf("create run log index: %w", err)
+	}
+	s.logIndex = make(chan logIndexJob, logIndexQueueSize)
+
+	pending, err := s.unindexedRuns(ctx, logIndexBackfillLimit)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for _, job := range pending {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := s.indexRunLog(ctx, job); err != nil {
+				logger.Warn("backfill run log index", "run_id", job.runID, "err", err)
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case job := <-s.logIndex:
+				if err := s.indexRunLog(ctx, job); err != nil {
+					logger.Warn("index run log", "run_id", job.runID, "err", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// LogIndexEnabled reports whether run logs are indexed for full-text search.
+func (s *Store) LogIndexEnabled() bool {
+	return s.logIndex != nil
+}
+
+// QueueRunLogIndex schedules a finished run's log for indexing. It never blocks and is a
+// no-op when the index is disabled.
+func (s *Store) QueueRunLogIndex(runID, taskID string) {
+	if s.logIndex == nil {
+		return
+	}
+	select {
+	case s.logIndex <- logIndexJob{runID: runID, taskID: taskID}:
+	default:
+	}
+}
+
+// SearchRunLogs finds runs whose logs contain query, using the full-text index when it is
+// enabled and scanning the most recent runLimit logs otherwise.
+func (s *Store) SearchRunLogs(ctx context.Context, query string, runLimit, snippetLimit int) ([]LogHit, error) {
+	if s.logIndex == nil {
+		return s.GrepRunLogs(ctx, query, runLimit, snippetLimit)
+	}
+	// Quote the query as a single phrase so punctuation such as "deploy.sh" is not parsed
+	// as FTS5 syntax.
+	phrase := `"` + strings.ReplaceAll(query, `"`, `""`) + `"`
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT run_logs_fts.run_id, run_logs_fts.task_id, runs.created_at,
+			snippet(run_logs_fts, 2, '', '', '…', 16)
+		FROM run_logs_fts
+		JOIN runs ON runs.id = run_logs_fts.run_id
+		WHERE run_logs_fts MATCH ?
+		ORDER BY runs.created_at DESC
+		LIMIT ?
+	`, phrase, runLimit)
+	if err != nil {
+		return nil, fmt.Errorf("search run log index: %w", err)
+	}
+	defer rows.Close()
+	var hits []LogHit
+	for rows.Next() {
+		var (
+			hit       LogHit
+			createdAt string
+			snippet   string
+		)
+		if err := rows.Scan(&hit.RunID, &hit.TaskID, &createdAt, &snippet); err != nil {
+			return nil, fmt.Errorf("scan run log hit: %w", err)
+		}
+		hit.CreatedAt = mustParseTime(createdAt)
+		hit.Snippets = []string{truncateSnippet(strings.Join(strings.Fields(snippet), " "))}
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+func (s *Store) unindexedRuns(ctx context.Context, limit int) ([]logIndexJob, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, task_id FROM runs
+		WHERE ended_at IS NOT NULL AND id NOT IN (SELECT run_id FROM run_logs_fts)
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list unindexed runs: %w", err)
+	}
+	defer rows.Close()
+	var jobs []logIndexJob
+	for rows.Next() {
+		var job logIndexJob
+		if err := rows.Scan(&job.runID, &job.taskID); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (s *Store) indexRunLog(ctx context.Context, job logIndexJob) error {
+	content, err := readLogTail(s.RunLogPath(job.runID), maxIndexedLogBytes)
+	if err != nil {
+		if os.IsNotExist(err) {
+			content = ""
+		} else {
+			return err
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if _, err := s.DB.ExecContext(ctx, `DELETE FROM run_logs_fts WHERE run_id = ?`, job.runID); err != nil {
+		return fmt.Errorf("clear run log index: %w", err)
+	}
+	if _, err := s.DB.ExecContext(ctx, `
+		INSERT INTO run_logs_fts (run_id, task_id, content) VALUES (?, ?, ?)
+	`, job.runID, job.taskID, content); err != nil {
+		return fmt.Errorf("insert run log index: %w", err)
+	}
+	return nil
+}
+
+// removeFromLogIndex drops index entries for runs whose logs were pruned.
+func (s *Store) removeFromLogIndex(ctx context.Context, runIDs []string) error {
+	if s.logIndex == nil || len(runIDs) == 0 {
+		return nil
+	}
+	for _, id := range runIDs {
+		if _, err := s.DB.ExecContext(ctx, `DELETE FROM run_logs_fts WHERE run_id = ?`, id); err != nil {
+			return fmt.Errorf("remove run log index: %w", err)
+		}
+	}
+	return nil
+}
+
+// readLogTail reads at most limit bytes from the end of the file at path.
+func readLogTail(path string, limit int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > limit {
+		if _, err := file.Seek(info.Size()-limit, io.SeekStart); err != nil {
+			return "", err
+		}
+	}
+	data, err := io.ReadAll(io.LimitReader(file, limit))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+// ErrMaintenanceWindowNotFound is returned when a maintenance window does not exist.
+var ErrMaintenanceWindowNotFound = errors.New("maintenance window not found")
+
+const maintenanceWindowColumns = `id, task_id, starts_at, ends_at, reason, created_at`
+
+// CreateMaintenanceWindow stores a new maintenance window.
+func (s *Store) CreateMaintenanceWindow(ctx context.Context, window *core.MaintenanceWindow) error {
+	window.CreatedAt = s.now()
+	if _, err := s.DB.ExecContext(ctx, `
+		INSERT INTO maintenance_windows (`+maintenanceWindowColumns+`) VALUES (?, ?, ?, ?, ?, ?)
+	`, window.ID, window.TaskID, window.StartsAt.UTC().Format(time.RFC3339Nano), window.EndsAt.UTC().Format(time.RFC3339Nano),
+		window.Reason, window.CreatedAt.Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("insert maintenance window: %w", err)
+	}
+	return nil
+}
+
+// ListMaintenanceWindows returns the windows ending after endsAfter, or all of them when
+// it is nil, earliest start first.
+func (s *Store) ListMaintenanceWindows(ctx context.Context, endsAfter *time.Time) ([]*core.MaintenanceWindow, error) {
+	where := "1 = 1"
+	var args []any
+	if endsAfter != nil {
+		where = "ends_at > ?"
+		args = append(args, endsAfter.UTC().Format(time.RFC3339Nano))
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+maintenanceWindowColumns+`
+		FROM maintenance_windows
+		WHERE `+where+`
+		ORDER BY starts_at
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list maintenance windows: %w", err)
+	}
+	defer rows.Close()
+	var windows []*core.MaintenanceWindow
+	for rows.Next() {
+		window, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan maintenance window: %w", err)
+		}
+		windows = append(windows, window)
+	}
+	return windows, rows.Err()
+}
+
+// GetMaintenanceWindow returns a maintenance window by ID.
+func (s *Store) GetMaintenanceWindow(ctx context.Context, id string) (*core.MaintenanceWindow, error) {
+	window, err := scanMaintenanceWindow(s.DB.QueryRowContext(ctx, `
+		SELECT `+maintenanceWindowColumns+` FROM maintenance_windows WHERE id = ?
+	`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMaintenanceWindowNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get maintenance window: %w", err)
+	}
+	return window, nil
+}
+
+// DeleteMaintenanceWindow removes a maintenance window, ending it early if it is in
+// effect.
+func (s *Store) DeleteMaintenanceWindow(ctx context.Context, id string) error {
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM maintenance_windows WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete maintenance window: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrMaintenanceWindowNotFound
+	}
+	return nil
+}
+
+// ActiveMaintenanceWindow returns a window covering the task at the given time, the
+// one ending last when several overlap, or nil when there is none.
+func (s *Store) ActiveMaintenanceWindow(ctx context.Context, taskID string, at time.Time) (*core.MaintenanceWindow, error) {
+	ts := at.UTC().Format(time.RFC3339Nano)
+	window, err := scanMaintenanceWindow(s.DB.QueryRowContext(ctx, `
+		SELECT `+maintenanceWindowColumns+`
+		FROM maintenance_windows
+		WHERE starts_at <= ? AND ends_at > ? AND (task_id IS NULL OR task_id = ?)
+		ORDER BY ends_at DESC
+		LIMIT 1
+	`, ts, ts, taskID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get active maintenance window: %w", err)
+	}
+	return window, nil
+}
+
+func scanMaintenanceWindow(scanner interface {
+	Scan(dest ...any) error
+}) (*core.MaintenanceWindow, error) {
+	var (
+		window           core.MaintenanceWindow
+		taskID, reason   sql.NullString
+		startsAt, endsAt string
+		createdAt        string
+	)
+	if err := scanner.Scan(&window.ID, &taskID, &startsAt, &endsAt, &reason, &createdAt); err != nil {
+		return nil, err
+	}
+	if taskID.Valid {
+		window.TaskID = &taskID.String
+	}
+	if reason.Valid {
+		window.Reason = &reason.String
+	}
+	window.StartsAt = mustParseTime(startsAt)
+	window.EndsAt = mustParseTime(endsAt)
+	window.CreatedAt = mustParseTime(createdAt)
+	return &window, nil
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+// QueueDepth returns how many queued runs are waiting for a worker.
+func (s *Store) QueueDepth(ctx context.Context) (int, error) {
+	var n int
+	if err := s.DB.QueryRowContext(ctx, `SELECT COUNT(1) FROM run_queue WHERE claimed_at IS NULL`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count queued runs: %w", err)
+	}
+	return n, nil
+}
+
+// InsertHealthSample records one self-monitoring reading.
+func (s *Store) InsertHealthSample(ctx context.Context, sample *core.HealthSample) error {
+	if _, err := s.DB.ExecContext(ctx, `
+		INSERT INTO daemon_metrics (recorded_at, goroutines, heap_bytes, sys_bytes, db_latency_ms, queue_depth, active_runs)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, sample.RecordedAt.UTC().Format(time.RFC3339Nano), sample.Goroutines, sample.HeapBytes, sample.SysBytes,
+		float64(sample.DBLatency)/float64(time.Millisecond), sample.QueueDepth, sample.ActiveRuns); err != nil {
+		return fmt.Errorf("insert daemon metrics: %w", err)
+	}
+	return nil
+}
+
+// PruneHealthSamples deletes readings recorded before cutoff.
+func (s *Store) PruneHealthSamples(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM daemon_metrics WHERE recorded_at < ?`, cutoff.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, fmt.Errorf("prune daemon metrics: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ListHealthSamples returns readings recorded at or after since, newest first.
+func (s *Store) ListHealthSamples(ctx context.Context, since time.Time, limit int) ([]*core.HealthSample, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT recorded_at, goroutines, heap_bytes, sys_bytes, db_latency_ms, queue_depth, active_runs
+		FROM daemon_metrics
+		WHERE recorded_at >= ?
+		ORDER BY recorded_at DESC
+		LIMIT ?
+	`, since.UTC().Format(time.RFC3339Nano), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query daemon metrics: %w", err)
+	}
+	defer rows.Close()
+	var samples []*core.HealthSample
+	for rows.Next() {
+		var (
+			sample     core.HealthSample
+			recordedAt string
+			latencyMS  float64
+		)
+		if err := rows.Scan(&recordedAt, &sample.Goroutines, &sample.HeapBytes, &sample.SysBytes, &latencyMS, &sample.QueueDepth, &sample.ActiveRuns); err != nil {
+			return nil, fmt.Errorf("scan daemon metrics: %w", err)
+		}
+		sample.RecordedAt = mustParseTime(recordedAt)
+		sample.DBLatency = time.Duration(latencyMS * float64(time.Millisecond))
+		samples = append(samples, &sample)
+	}
+	return samples, rows.Err()
+}
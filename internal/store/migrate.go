@@ -0,0 +1,330 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// migrationFile matches "NNNN_name.sql" (up) and "NNNN_name.down.sql" (down).
+var migrationFile = regexp.MustCompile(`^(\d{4})_([a-z0-9_]+)(\.down)?\.sql$`)
+
+// Migration is one numbered schema change embedded in the binary. Down is empty when
+// the change cannot be rolled back.
+type Migration struct {
+	Version  string // e.g. "0002_add_working_dir"
+	Up       string
+	Down     string
+	Checksum string // sha256 of Up, recorded when applied
+}
+
+// MigrationState describes a known migration and whether the database has it.
+type MigrationState struct {
+	Version    string     `json:"version"`
+	Applied    bool       `json:"applied"`
+	AppliedAt  *time.Time `json:"applied_at,omitempty"`
+	Reversible bool       `json:"reversible"`
+}
+
+// Migrations returns the embedded migrations in order. File names must be unique by
+// number, and every down file needs a matching up file.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+	byVersion := make(map[string]*Migration)
+	numbers := make(map[string]string)
+	for _, entry := range entries {
+		m := migrationFile.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file %s (want NNNN_name.sql or NNNN_name.down.sql)", entry.Name())
+		}
+		version := m[1] + "_" + m[2]
+		if other, ok := numbers[m[1]]; ok && other != version {
+			return nil, fmt.Errorf("migrations %s and %s share number %s", other, version, m[1])
+		}
+		numbers[m[1]] = version
+		data, err := migrations.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version}
+			byVersion[version] = mig
+		}
+		if m[3] != "" {
+			mig.Down = string(data)
+		} else {
+			mig.Up = string(data)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %s has a down file but no up file", mig.Version)
+		}
+		sum := sha256.Sum256([]byte(mig.Up))
+		mig.Checksum = hex.EncodeToString(sum[:])
+		list = append(list, *mig)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// MigrationStatus lists every embedded migration with its state in the database.
+func (s *Store) MigrationStatus(ctx context.Context) ([]MigrationState, error) {
+	known, applied, err := loadMigrationState(ctx, s.DB)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]MigrationState, 0, len(known))
+	for _, mig := range known {
+		state := MigrationState{Version: mig.Version, Reversible: mig.Down != ""}
+		if row, ok := applied[mig.Version]; ok {
+			state.Applied = true
+			if t, err := time.Parse(time.RFC3339Nano, row.appliedAt); err == nil {
+				state.AppliedAt = &t
+			}
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// Migrate applies pending migrations in order, each in its own transaction, and returns
+// the versions applied. With dryRun nothing is written and the pending versions are returned.
+func (s *Store) Migrate(ctx context.Context, dryRun bool) ([]Migration, error) {
+	return migrateUp(ctx, s.DB, dryRun)
+}
+
+// MigrateDown rolls back applied migrations newest first until target is the latest one
+// applied; an empty target rolls back only the latest. It refuses to start when any of
+// them has no down file. With dryRun nothing is written.
+func (s *Store) MigrateDown(ctx context.Context, target string, dryRun bool) ([]Migration, error) {
+	known, applied, err := loadMigrationState(ctx, s.DB)
+	if err != nil {
+		return nil, err
+	}
+	if target != "" {
+		found := false
+		for _, mig := range known {
+			if mig.Version == target {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown migration %s", target)
+		}
+		if _, ok := applied[target]; !ok {
+			return nil, fmt.Errorf("migration %s is not applied", target)
+		}
+	}
+
+	var plan []Migration
+	for i := len(known) - 1; i >= 0; i-- {
+		mig := known[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if mig.Version == target || (target == "" && len(plan) == 1) {
+			break
+		}
+		if mig.Down == "" {
+			return nil, fmt.Errorf("migration %s cannot be rolled back (no down file)", mig.Version)
+		}
+		plan = append(plan, mig)
+	}
+	if dryRun {
+		return plan, nil
+	}
+	for _, mig := range plan {
+		err := applyMigration(ctx, s.DB, mig.Down, `DELETE FROM schema_migrations WHERE version = ?`, mig.Version)
+		if err != nil {
+			return nil, fmt.Errorf("roll back migration %s: %w", mig.Version, err)
+		}
+	}
+	return plan, nil
+}
+
+func migrateUp(ctx context.Context, db *sql.DB, dryRun bool) ([]Migration, error) {
+	if !dryRun {
+		if err := ensureMigrationTable(ctx, db); err != nil {
+			return nil, err
+		}
+	}
+	known, applied, err := loadMigrationState(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, mig := range known {
+		if row, ok := applied[mig.Version]; ok {
+			// Rows recorded before checksums existed get the current one
+			if row.checksum == "" && !dryRun {
+				if _, err := db.ExecContext(ctx, `UPDATE schema_migrations SET checksum = ? WHERE version = ?`,
+					mig.Checksum, mig.Version); err != nil {
+					return nil, fmt.Errorf("record checksum of %s: %w", mig.Version, err)
+				}
+			}
+			continue
+		}
+		pending = append(pending, mig)
+	}
+	if dryRun {
+		return pending, nil
+	}
+	for _, mig := range pending {
+		err := applyMigration(ctx, db, mig.Up,
+			`INSERT INTO schema_migrations(version, applied_at, checksum) VALUES(?, ?, ?)`,
+			mig.Version, time.Now().UTC().Format(time.RFC3339Nano), mig.Checksum)
+		if err != nil {
+			return nil, fmt.Errorf("apply migration %s: %w", mig.Version, err)
+		}
+	}
+	return pending, nil
+}
+
+// applyMigration runs script and the schema_migrations bookkeeping in one transaction,
+// so a failed statement leaves neither the schema nor the record half-changed.
+func applyMigration(ctx context.Context, db *sql.DB, script, record string, args ...any) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("update schema_migrations: %w", err)
+	}
+	return tx.Commit()
+}
+
+type appliedMigration struct {
+	appliedAt string
+	checksum  string
+}
+
+// loadMigrationState reads the embedded migrations and the applied ones, and verifies
+// that the two agree: every applied version must be known to this binary and unchanged
+// since it was applied. It only reads, so a database without schema_migrations (or
+// without its checksum column) is reported as such rather than upgraded.
+func loadMigrationState(ctx context.Context, db *sql.DB) ([]Migration, map[string]appliedMigration, error) {
+	known, err := Migrations()
+	if err != nil {
+		return nil, nil, err
+	}
+	columns, err := migrationTableColumns(ctx, db)
+	if err != nil {
+		return nil, nil, err
+	}
+	applied := make(map[string]appliedMigration)
+	if len(columns) == 0 {
+		return known, applied, nil
+	}
+	checksum := "''"
+	if columns["checksum"] {
+		checksum = "COALESCE(checksum, '')"
+	}
+	rows, err := db.QueryContext(ctx, `SELECT version, applied_at, `+checksum+` FROM schema_migrations`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version string
+		var row appliedMigration
+		if err := rows.Scan(&version, &row.appliedAt, &row.checksum); err != nil {
+			return nil, nil, fmt.Errorf("scan schema_migrations: %w", err)
+		}
+		applied[version] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	byVersion := make(map[string]Migration, len(known))
+	for _, mig := range known {
+		byVersion[mig.Version] = mig
+	}
+	var unknown []string
+	for version, row := range applied {
+		mig, ok := byVersion[version]
+		if !ok {
+			unknown = append(unknown, version)
+			continue
+		}
+		if row.checksum != "" && row.checksum != mig.Checksum {
+			return nil, nil, fmt.Errorf("migration %s was modified after it was applied (recorded checksum %s, embedded %s)",
+				version, shortChecksum(row.checksum), shortChecksum(mig.Checksum))
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, nil, fmt.Errorf("database has migrations this binary does not know (%s); it was upgraded by a newer clicrontabd, roll them back with that version's \"migrate down\" first",
+			strings.Join(unknown, ", "))
+	}
+	return known, applied, nil
+}
+
+// ensureMigrationTable creates schema_migrations, adding the checksum column to tables
+// created before it existed.
+func ensureMigrationTable(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TEXT NOT NULL,
+			checksum TEXT
+		);
+	`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	columns, err := migrationTableColumns(ctx, db)
+	if err != nil {
+		return err
+	}
+	if !columns["checksum"] {
+		if _, err := db.ExecContext(ctx, `ALTER TABLE schema_migrations ADD COLUMN checksum TEXT`); err != nil {
+			return fmt.Errorf("add schema_migrations.checksum: %w", err)
+		}
+	}
+	return nil
+}
+
+// migrationTableColumns returns the columns of schema_migrations; none when it does not exist yet.
+func migrationTableColumns(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name FROM pragma_table_info('schema_migrations')`)
+	if err != nil {
+		return nil, fmt.Errorf("inspect schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+func shortChecksum(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
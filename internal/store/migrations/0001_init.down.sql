@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_tasks_status;
+DROP INDEX IF EXISTS idx_runs_task_id_created_at;
+DROP TABLE IF EXISTS runs;
+DROP TABLE IF EXISTS tasks;
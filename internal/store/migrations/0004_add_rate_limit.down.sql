@@ -0,0 +1,2 @@
+ALTER TABLE runs DROP COLUMN reason;
+ALTER TABLE tasks DROP COLUMN min_interval_seconds;
@@ -0,0 +1,5 @@
+-- Add per-task minimum gap between runs
+ALTER TABLE tasks ADD COLUMN min_interval_seconds INTEGER;
+
+-- Record why a run was skipped (already_running, rate_limited, ...)
+ALTER TABLE runs ADD COLUMN reason TEXT;
@@ -0,0 +1,2 @@
+-- Track the OS process ID of a run's command
+ALTER TABLE runs ADD COLUMN pid INTEGER;
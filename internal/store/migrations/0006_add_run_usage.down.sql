@@ -0,0 +1,2 @@
+ALTER TABLE runs DROP COLUMN cpu_seconds;
+ALTER TABLE runs DROP COLUMN max_rss_kb;
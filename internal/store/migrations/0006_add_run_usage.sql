@@ -0,0 +1,3 @@
+-- Resource usage sampled from a run's process (Unix only)
+ALTER TABLE runs ADD COLUMN max_rss_kb INTEGER;
+ALTER TABLE runs ADD COLUMN cpu_seconds REAL;
@@ -0,0 +1,6 @@
+-- Runtime settings changed via the API (e.g. notification channels)
+CREATE TABLE IF NOT EXISTS settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Automatically pause a task after this many consecutive failures
+ALTER TABLE tasks ADD COLUMN pause_after_failures INTEGER;
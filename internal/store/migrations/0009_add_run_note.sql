@@ -0,0 +1,2 @@
+-- Free-form annotation attached to a run by users or agents
+ALTER TABLE runs ADD COLUMN note TEXT;
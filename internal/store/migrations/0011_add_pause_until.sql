@@ -0,0 +1,2 @@
+-- Paused tasks with pause_until set are resumed automatically once that time passes
+ALTER TABLE tasks ADD COLUMN pause_until TEXT;
@@ -0,0 +1,2 @@
+-- Scheduled occurrence to skip once (set by the skip-next action)
+ALTER TABLE tasks ADD COLUMN skip_next_at TEXT;
@@ -0,0 +1,2 @@
+-- Run the task once when the daemon starts, in addition to its cron schedule
+ALTER TABLE tasks ADD COLUMN run_on_start INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_runs_task_trigger;
+ALTER TABLE runs DROP COLUMN trigger_type;
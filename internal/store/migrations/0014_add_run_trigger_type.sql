@@ -0,0 +1,3 @@
+-- What started each run; runs recorded before this column existed are assumed scheduled
+ALTER TABLE runs ADD COLUMN trigger_type TEXT NOT NULL DEFAULT 'scheduled';
+CREATE INDEX IF NOT EXISTS idx_runs_task_trigger ON runs(task_id, trigger_type);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_run_queue_claim;
+DROP TABLE IF EXISTS run_queue;
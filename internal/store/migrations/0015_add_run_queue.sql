@@ -0,0 +1,12 @@
+-- Runs waiting for a dispatcher worker; a row is claimed when a worker picks the run up
+-- and removed once the run ends
+CREATE TABLE IF NOT EXISTS run_queue (
+    run_id TEXT PRIMARY KEY,
+    task_id TEXT NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    enqueued_at TEXT NOT NULL,
+    expires_at TEXT,
+    claimed_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_queue_claim ON run_queue(claimed_at, priority DESC, enqueued_at);
@@ -0,0 +1,2 @@
+-- Last bytes of a run's combined output, kept after the log file is pruned
+ALTER TABLE runs ADD COLUMN output_tail TEXT;
@@ -0,0 +1,3 @@
+-- Where a task is defined: '' for tasks created through the API or MCP, 'file' for
+-- tasks managed by the declarative tasks file
+ALTER TABLE tasks ADD COLUMN source TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_notification_outbox_created;
+DROP INDEX IF EXISTS idx_notification_outbox_due;
+DROP TABLE IF EXISTS notification_outbox;
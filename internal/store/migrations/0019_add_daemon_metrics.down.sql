@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_daemon_metrics_recorded;
+DROP TABLE IF EXISTS daemon_metrics;
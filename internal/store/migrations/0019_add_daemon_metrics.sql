@@ -0,0 +1,13 @@
+-- Periodic readings of the daemon's own health, written by the self-monitor
+CREATE TABLE IF NOT EXISTS daemon_metrics (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    recorded_at TEXT NOT NULL,
+    goroutines INTEGER NOT NULL,
+    heap_bytes INTEGER NOT NULL,
+    sys_bytes INTEGER NOT NULL,
+    db_latency_ms REAL NOT NULL,
+    queue_depth INTEGER NOT NULL,
+    active_runs INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_daemon_metrics_recorded ON daemon_metrics(recorded_at);
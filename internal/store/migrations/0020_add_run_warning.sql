@@ -0,0 +1,2 @@
+-- Why a finished run looks suspicious, e.g. it took far longer than the task usually does
+ALTER TABLE runs ADD COLUMN warning TEXT;
@@ -0,0 +1,8 @@
+-- Generated run digests, one per kind (e.g. daily) and period (e.g. 2025-06-01)
+CREATE TABLE IF NOT EXISTS reports (
+    kind TEXT NOT NULL,
+    period TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (kind, period)
+);
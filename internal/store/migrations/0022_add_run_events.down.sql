@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_run_events_run_id;
+DROP TABLE IF EXISTS run_events;
@@ -0,0 +1,11 @@
+-- Lifecycle events of a run (queued, started, timeout, killed, finished, ...) in order,
+-- so the sequence that ended a run can be reconstructed
+CREATE TABLE IF NOT EXISTS run_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_events_run_id ON run_events(run_id, id);
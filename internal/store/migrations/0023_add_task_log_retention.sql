@@ -0,0 +1,2 @@
+-- Per-task number of runs whose logs are kept; NULL uses the global CLICRON_LOG_RETENTION
+ALTER TABLE tasks ADD COLUMN log_retention INTEGER;
@@ -0,0 +1,2 @@
+-- Pinned runs keep their logs and are never purged
+ALTER TABLE runs ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0;
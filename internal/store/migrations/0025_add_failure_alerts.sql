@@ -0,0 +1,11 @@
+-- The open failure alert of each failing task, from its first failed run until it
+-- succeeds again, so an alert nobody acknowledges can be escalated
+CREATE TABLE IF NOT EXISTS failure_alerts (
+    task_id TEXT PRIMARY KEY,
+    run_id TEXT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 1,
+    opened_at TEXT NOT NULL,
+    acknowledged_at TEXT,
+    acknowledged_by TEXT,
+    escalated_at TEXT
+);
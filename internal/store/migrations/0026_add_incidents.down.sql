@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS failure_alerts (
+    task_id TEXT PRIMARY KEY,
+    run_id TEXT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 1,
+    opened_at TEXT NOT NULL,
+    acknowledged_at TEXT,
+    acknowledged_by TEXT,
+    escalated_at TEXT
+);
+
+INSERT INTO failure_alerts (task_id, run_id, failures, opened_at, acknowledged_at, acknowledged_by, escalated_at)
+SELECT task_id, first_run_id, failures, opened_at, acknowledged_at, acknowledged_by, escalated_at
+FROM incidents WHERE status != 'resolved';
+
+DROP TABLE IF EXISTS incidents;
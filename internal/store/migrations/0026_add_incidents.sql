@@ -0,0 +1,28 @@
+-- Incidents replace failure alerts and are kept once resolved, giving a history of
+-- each task's outages. A task has at most one unresolved incident.
+CREATE TABLE IF NOT EXISTS incidents (
+    id TEXT PRIMARY KEY,
+    task_id TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    first_run_id TEXT NOT NULL,
+    last_run_id TEXT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 1,
+    opened_at TEXT NOT NULL,
+    acknowledged_at TEXT,
+    acknowledged_by TEXT,
+    escalated_at TEXT,
+    resolved_at TEXT,
+    resolved_by TEXT,
+    note TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_incidents_unresolved ON incidents(task_id) WHERE status != 'resolved';
+CREATE INDEX IF NOT EXISTS idx_incidents_task_opened ON incidents(task_id, opened_at DESC);
+
+INSERT INTO incidents (id, task_id, status, first_run_id, last_run_id, failures, opened_at, acknowledged_at, acknowledged_by, escalated_at)
+SELECT lower(hex(randomblob(16))), task_id,
+       CASE WHEN acknowledged_at IS NULL THEN 'open' ELSE 'acknowledged' END,
+       run_id, run_id, failures, opened_at, acknowledged_at, acknowledged_by, escalated_at
+FROM failure_alerts;
+
+DROP TABLE IF EXISTS failure_alerts;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_maintenance_windows_ends_at;
+DROP TABLE IF EXISTS maintenance_windows;
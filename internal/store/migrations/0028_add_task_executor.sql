@@ -0,0 +1,2 @@
+-- Registered runtime that runs the task; NULL runs the command with the shell
+ALTER TABLE tasks ADD COLUMN executor TEXT;
@@ -0,0 +1,2 @@
+-- JSON request sent by tasks using the http executor
+ALTER TABLE tasks ADD COLUMN http_request TEXT;
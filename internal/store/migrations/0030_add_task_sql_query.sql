@@ -0,0 +1,2 @@
+-- JSON query run by tasks using the sql executor
+ALTER TABLE tasks ADD COLUMN sql_query TEXT;
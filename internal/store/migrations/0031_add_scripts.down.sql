@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_script_id;
+ALTER TABLE tasks DROP COLUMN script_id;
+DROP TABLE IF EXISTS scripts;
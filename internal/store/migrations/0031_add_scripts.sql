@@ -0,0 +1,14 @@
+-- Named scripts stored in the daemon; tasks with script_id run one in place of their command
+CREATE TABLE IF NOT EXISTS scripts (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL,
+    content TEXT NOT NULL,
+    description TEXT,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+ALTER TABLE tasks ADD COLUMN script_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_script_id ON tasks(script_id);
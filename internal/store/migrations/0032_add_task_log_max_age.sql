@@ -0,0 +1,2 @@
+-- Per-task age in days after which run logs are removed; NULL uses the global CLICRON_LOG_MAX_AGE_DAYS
+ALTER TABLE tasks ADD COLUMN log_max_age_days INTEGER;
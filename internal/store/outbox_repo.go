@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"clicrontab/internal/core"
+	"clicrontab/internal/notify"
+)
+
+// ErrDeliveryNotFound is returned when a notification delivery does not exist.
+var ErrDeliveryNotFound = errors.New("notification delivery not found")
+
+const deliveryColumns = `id, channel, message, status, attempts, last_error, next_attempt_at, created_at, delivered_at`
+
+// EnqueueDeliveries stores new outbox entries in one transaction, assigning their IDs.
+func (s *Store) EnqueueDeliveries(ctx context.Context, deliveries []*notify.Delivery) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin enqueue notifications: %w", err)
+	}
+	defer tx.Rollback()
+	for _, d := range deliveries {
+		d.ID = core.NewID()
+		message, err := json.Marshal(d.Message)
+		if err != nil {
+			return fmt.Errorf("encode notification: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO notification_outbox (`+deliveryColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, d.ID, d.Channel, string(message), d.Status, d.Attempts, nullableString(d.LastError),
+			d.NextAttemptAt.UTC().Format(time.RFC3339Nano), d.CreatedAt.UTC().Format(time.RFC3339Nano), nullableTime(d.DeliveredAt)); err != nil {
+			return fmt.Errorf("insert notification: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit notifications: %w", err)
+	}
+	return nil
+}
+
+// DueDeliveries returns pending deliveries whose next attempt is due, oldest first.
+func (s *Store) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*notify.Delivery, error) {
+	return s.queryDeliveries(ctx, `
+		SELECT `+deliveryColumns+` FROM notification_outbox
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, created_at
+		LIMIT ?
+	`, notify.DeliveryPending, now.UTC().Format(time.RFC3339Nano), limit)
+}
+
+// SaveDeliveryAttempt records the status, attempt count and error of a delivery.
+func (s *Store) SaveDeliveryAttempt(ctx context.Context, d *notify.Delivery) error {
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE notification_outbox
+		SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, delivered_at = ?
+		WHERE id = ?
+	`, d.Status, d.Attempts, nullableString(d.LastError), d.NextAttemptAt.UTC().Format(time.RFC3339Nano), nullableTime(d.DeliveredAt), d.ID)
+	if err != nil {
+		return fmt.Errorf("update notification: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// PruneDeliveries deletes delivered and failed entries created before cutoff.
+func (s *Store) PruneDeliveries(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, `
+		DELETE FROM notification_outbox WHERE status != ? AND created_at < ?
+	`, notify.DeliveryPending, cutoff.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, fmt.Errorf("prune notifications: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ListDeliveries returns the newest outbox entries, optionally only those with status.
+func (s *Store) ListDeliveries(ctx context.Context, status notify.DeliveryStatus, limit int) ([]*notify.Delivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	query := `SELECT ` + deliveryColumns + ` FROM notification_outbox`
+	args := []any{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC LIMIT ?`
+	return s.queryDeliveries(ctx, query, append(args, limit)...)
+}
+
+// DeliveryCounts returns how many outbox entries there are in each status.
+func (s *Store) DeliveryCounts(ctx context.Context) (map[notify.DeliveryStatus]int, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT status, COUNT(1) FROM notification_outbox GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("count notifications: %w", err)
+	}
+	defer rows.Close()
+	counts := map[notify.DeliveryStatus]int{
+		notify.DeliveryPending:   0,
+		notify.DeliveryDelivered: 0,
+		notify.DeliveryFailed:    0,
+	}
+	for rows.Next() {
+		var (
+			status string
+			n      int
+		)
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[notify.DeliveryStatus(status)] = n
+	}
+	return counts, rows.Err()
+}
+
+// RequeueDelivery makes a failed delivery pending again with a fresh set of attempts.
+// It returns ErrDeliveryNotFound when no failed delivery has the ID.
+func (s *Store) RequeueDelivery(ctx context.Context, id string, now time.Time) error {
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE notification_outbox SET status = ?, attempts = 0, next_attempt_at = ?
+		WHERE id = ? AND status = ?
+	`, notify.DeliveryPending, now.UTC().Format(time.RFC3339Nano), id, notify.DeliveryFailed)
+	if err != nil {
+		return fmt.Errorf("requeue notification: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+func (s *Store) queryDeliveries(ctx context.Context, query string, args ...any) ([]*notify.Delivery, error) {
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query notifications: %w", err)
+	}
+	defer rows.Close()
+	var deliveries []*notify.Delivery
+	for rows.Next() {
+		var (
+			d                      notify.Delivery
+			message, status        string
+			lastError, deliveredAt sql.NullString
+			nextAttempt, createdAt string
+		)
+		if err := rows.Scan(&d.ID, &d.Channel, &message, &status, &d.Attempts, &lastError, &nextAttempt, &createdAt, &deliveredAt); err != nil {
+			return nil, fmt.Errorf("scan notification: %w", err)
+		}
+		if err := json.Unmarshal([]byte(message), &d.Message); err != nil {
+			return nil, fmt.Errorf("decode notification %s: %w", d.ID, err)
+		}
+		d.Status = notify.DeliveryStatus(status)
+		if lastError.Valid {
+			d.LastError = &lastError.String
+		}
+		d.NextAttemptAt = mustParseTime(nextAttempt)
+		d.CreatedAt = mustParseTime(createdAt)
+		if deliveredAt.Valid {
+			t := mustParseTime(deliveredAt.String)
+			d.DeliveredAt = &t
+		}
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, rows.Err()
+}
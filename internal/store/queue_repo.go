@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+// EnqueueRun inserts a queued run together with its run_queue entry, so a run is never
+// queued without a worker being able to find it.
+func (s *Store) EnqueueRun(ctx context.Context, run *core.Run, entry core.QueueEntry) error {
+	run.CreatedAt = s.now()
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin enqueue: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := insertRun(ctx, tx, "INSERT", run); err != nil {
+		return fmt.Errorf("insert run: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO run_queue (run_id, task_id, priority, enqueued_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, run.ID, run.TaskID, entry.Priority, run.CreatedAt.Format(time.RFC3339Nano), nullableTime(entry.ExpiresAt)); err != nil {
+		return fmt.Errorf("enqueue run: %w", err)
+	}
+	if err := insertRunEvent(ctx, tx, core.RunEvent{RunID: run.ID, Type: core.RunEventQueued, Detail: string(run.TriggerType), At: run.CreatedAt}); err != nil {
+		return fmt.Errorf("insert run event: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit enqueue: %w", err)
+	}
+	return nil
+}
+
+// ClaimQueuedRun marks the next unclaimed, unexpired entry as claimed and returns its
+// run, highest priority first and oldest first within a priority. It returns nil when
+// nothing is waiting.
+func (s *Store) ClaimQueuedRun(ctx context.Context, now time.Time) (*core.Run, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin claim: %w", err)
+	}
+	defer tx.Rollback()
+	stamp := now.UTC().Format(time.RFC3339Nano)
+	var runID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT run_id FROM run_queue
+		WHERE claimed_at IS NULL AND (expires_at IS NULL OR expires_at > ?)
+		ORDER BY priority DESC, enqueued_at
+		LIMIT 1
+	`, stamp).Scan(&runID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select queued run: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE run_queue SET claimed_at = ? WHERE run_id = ?`, stamp, runID); err != nil {
+		return nil, fmt.Errorf("claim queued run: %w", err)
+	}
+	run, err := scanRun(tx.QueryRowContext(ctx, `SELECT `+runColumns+` FROM runs WHERE id = ?`, runID))
+	if err != nil {
+		return nil, fmt.Errorf("load queued run %s: %w", runID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit claim: %w", err)
+	}
+	return run, nil
+}
+
+// RemoveQueuedRun deletes the run's queue entry; with unclaimedOnly it leaves entries a
+// worker has already claimed. It reports whether an entry was removed.
+func (s *Store) RemoveQueuedRun(ctx context.Context, runID string, unclaimedOnly bool) (bool, error) {
+	query := `DELETE FROM run_queue WHERE run_id = ?`
+	if unclaimedOnly {
+		query += ` AND claimed_at IS NULL`
+	}
+	res, err := s.DB.ExecContext(ctx, query, runID)
+	if err != nil {
+		return false, fmt.Errorf("remove queued run: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// ExpiredQueuedRuns returns unclaimed entries whose expires_at has passed.
+func (s *Store) ExpiredQueuedRuns(ctx context.Context, now time.Time) ([]string, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT run_id FROM run_queue
+		WHERE claimed_at IS NULL AND expires_at IS NOT NULL AND expires_at <= ?
+		ORDER BY enqueued_at
+	`, now.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, fmt.Errorf("query expired queued runs: %w", err)
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// RecoverRunQueue keeps the entries a previous daemon left unclaimed for runs that are
+// still queued, so they are dispatched again, and removes the rest: claimed entries
+// belonged to runs it was executing. It returns the IDs of the runs kept queued and is
+// used at startup, before workers start claiming.
+func (s *Store) RecoverRunQueue(ctx context.Context) ([]string, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin recover run queue: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM run_queue
+		WHERE claimed_at IS NOT NULL OR run_id NOT IN (SELECT id FROM runs WHERE status = ?)
+	`, core.RunStatusQueued); err != nil {
+		return nil, fmt.Errorf("remove stale queue entries: %w", err)
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT run_id FROM run_queue ORDER BY priority DESC, enqueued_at`)
+	if err != nil {
+		return nil, fmt.Errorf("list queued runs: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit recover run queue: %w", err)
+	}
+	return ids, nil
+}
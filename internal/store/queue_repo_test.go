@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+func TestRecoverRunQueueKeepsUnclaimedRuns(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	task := &core.Task{ID: "task", Command: "true", Cron: "@daily", Status: core.TaskStatusActive}
+	if err := s.InsertTask(ctx, task); err != nil {
+		t.Fatalf("InsertTask() error = %v", err)
+	}
+	for _, id := range []string{"claimed", "waiting", "finished"} {
+		run := &core.Run{ID: id, TaskID: task.ID, Status: core.RunStatusQueued, TriggerType: core.TriggerScheduled, ScheduledAt: time.Now()}
+		if err := s.EnqueueRun(ctx, run, core.QueueEntry{}); err != nil {
+			t.Fatalf("EnqueueRun(%s) error = %v", id, err)
+		}
+	}
+	if run, err := s.ClaimQueuedRun(ctx, time.Now()); err != nil || run == nil || run.ID != "claimed" {
+		t.Fatalf("ClaimQueuedRun() = %v, %v, want run claimed", run, err)
+	}
+	if _, err := s.DB.ExecContext(ctx, `UPDATE runs SET status = ? WHERE id = 'finished'`, core.RunStatusCanceled); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := s.RecoverRunQueue(ctx)
+	if err != nil {
+		t.Fatalf("RecoverRunQueue() error = %v", err)
+	}
+	if want := []string{"waiting"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("RecoverRunQueue() = %v, want %v", ids, want)
+	}
+	if run, err := s.ClaimQueuedRun(ctx, time.Now()); err != nil || run == nil || run.ID != "waiting" {
+		t.Errorf("ClaimQueuedRun() after recovery = %v, %v, want run waiting", run, err)
+	}
+}
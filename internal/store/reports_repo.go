@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"clicrontab/internal/core"
+	"clicrontab/internal/report"
+)
+
+// durationExpr computes ended_at - started_at in seconds.
+const durationExpr = `(julianday(ended_at) - julianday(started_at)) * 86400.0`
+
+// RunSummaries aggregates the runs created in [from, to) by task, including runs of
+// archived and deleted tasks.
+func (s *Store) RunSummaries(ctx context.Context, from, to time.Time) ([]report.TaskSummary, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT r.task_id, COALESCE(t.name, ''),
+			COUNT(1),
+			COALESCE(SUM(r.status = ?), 0),
+			COALESCE(SUM(r.status = ?), 0),
+			COALESCE(SUM(r.status = ?), 0),
+			COALESCE(SUM(r.status = ?), 0),
+			COALESCE(SUM(r.status = ?), 0),
+			COALESCE(SUM(CASE WHEN started_at IS NOT NULL AND ended_at IS NOT NULL THEN `+durationExpr+` END), 0),
+			COALESCE(AVG(CASE WHEN started_at IS NOT NULL AND ended_at IS NOT NULL THEN `+durationExpr+` END), 0),
+			COALESCE(MAX(CASE WHEN started_at IS NOT NULL AND ended_at IS NOT NULL THEN `+durationExpr+` END), 0),
+			COALESCE(SUM(r.cpu_seconds), 0),
+			COALESCE(MAX(r.max_rss_kb), 0)
+		FROM runs r
+		LEFT JOIN tasks t ON t.id = r.task_id
+		WHERE r.created_at >= ? AND r.created_at < ?
+		GROUP BY r.task_id
+		ORDER BY r.task_id
+	`, core.RunStatusSucceeded, core.RunStatusFailed, core.RunStatusTimedOut, core.RunStatusCanceled, core.RunStatusSkipped,
+		from.UTC().Format(time.RFC3339Nano), to.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, fmt.Errorf("query run summaries: %w", err)
+	}
+	defer rows.Close()
+	var summaries []report.TaskSummary
+	for rows.Next() {
+		var t report.TaskSummary
+		if err := rows.Scan(&t.TaskID, &t.Name, &t.Total, &t.Succeeded, &t.Failed, &t.TimedOut, &t.Canceled, &t.Skipped,
+			&t.RunSeconds, &t.AvgDurationS, &t.MaxDurationS, &t.CPUSeconds, &t.MaxRSSKB); err != nil {
+			return nil, fmt.Errorf("scan run summaries: %w", err)
+		}
+		if t.Name == "" {
+			t.Name = t.TaskID
+		}
+		summaries = append(summaries, t)
+	}
+	return summaries, rows.Err()
+}
+
+// SaveReport stores the report, replacing an earlier one for the same period.
+func (s *Store) SaveReport(ctx context.Context, r *report.Report) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	if _, err := s.DB.ExecContext(ctx, `
+		INSERT INTO reports (kind, period, body, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(kind, period) DO UPDATE SET body = excluded.body, created_at = excluded.created_at
+	`, r.Kind, r.Period, string(body), r.GeneratedAt.UTC().Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("save report: %w", err)
+	}
+	return nil
+}
+
+// GetReport returns the stored report for the period, or report.ErrNotFound.
+func (s *Store) GetReport(ctx context.Context, kind report.Kind, period string) (*report.Report, error) {
+	var body string
+	err := s.DB.QueryRowContext(ctx, `SELECT body FROM reports WHERE kind = ? AND period = ?`, kind, period).Scan(&body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, report.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get report: %w", err)
+	}
+	var r report.Report
+	if err := json.Unmarshal([]byte(body), &r); err != nil {
+		return nil, fmt.Errorf("decode report %s/%s: %w", kind, period, err)
+	}
+	return &r, nil
+}
+
+// ListReports returns the stored reports of a kind, latest period first.
+func (s *Store) ListReports(ctx context.Context, kind report.Kind, limit int) ([]*report.Report, error) {
+	if limit <= 0 {
+		limit = 7
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT body FROM reports WHERE kind = ? ORDER BY period DESC LIMIT ?
+	`, kind, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list reports: %w", err)
+	}
+	defer rows.Close()
+	var reports []*report.Report
+	for rows.Next() {
+		var body string
+		if err := rows.Scan(&body); err != nil {
+			return nil, fmt.Errorf("scan report: %w", err)
+		}
+		var r report.Report
+		if err := json.Unmarshal([]byte(body), &r); err != nil {
+			return nil, fmt.Errorf("decode report: %w", err)
+		}
+		reports = append(reports, &r)
+	}
+	return reports, rows.Err()
+}
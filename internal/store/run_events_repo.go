@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+// AddRunEvent appends a lifecycle event to the run.
+func (s *Store) AddRunEvent(ctx context.Context, event core.RunEvent) error {
+	if event.At.IsZero() {
+		event.At = s.now()
+	}
+	if err := insertRunEvent(ctx, s.DB, event); err != nil {
+		return fmt.Errorf("insert run event: %w", err)
+	}
+	return nil
+}
+
+func insertRunEvent(ctx context.Context, db execer, event core.RunEvent) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO run_events (run_id, type, detail, created_at) VALUES (?, ?, ?, ?)`,
+		event.RunID, event.Type, event.Detail, event.At.UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+// ListRunEvents returns the run's events in the order they were recorded.
+func (s *Store) ListRunEvents(ctx context.Context, runID string) ([]core.RunEvent, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT run_id, type, detail, created_at
+		FROM run_events
+		WHERE run_id = ?
+		ORDER BY id
+	`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("list run events: %w", err)
+	}
+	defer rows.Close()
+	var events []core.RunEvent
+	for rows.Next() {
+		var (
+			event     core.RunEvent
+			eventType string
+			createdAt string
+		)
+		if err := rows.Scan(&event.RunID, &eventType, &event.Detail, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan run event: %w", err)
+		}
+		event.Type = core.RunEventType(eventType)
+		event.At = mustParseTime(createdAt)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,29 +16,121 @@ import (
 
 var ErrRunNotFound = errors.New("run not found")
 
+// runColumns lists the columns read by scanRun, in scan order.
+const runColumns = `id, task_id, status, trigger_type, scheduled_at, started_at, ended_at, exit_code, error, reason, pid, max_rss_kb, cpu_seconds, note, output_tail, warning, pinned, created_at`
+
 func (s *Store) InsertRun(ctx context.Context, run *core.Run) error {
-	now := time.Now().UTC()
-	run.CreatedAt = now
-	_, err := s.DB.ExecContext(ctx, `
-		INSERT INTO runs (id, task_id, status, scheduled_at, started_at, ended_at, exit_code, error, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, run.ID, run.TaskID, run.Status, run.ScheduledAt.UTC().Format(time.RFC3339Nano),
+	run.CreatedAt = s.now()
+	if _, err := insertRun(ctx, s.DB, "INSERT", run); err != nil {
+		return fmt.Errorf("insert run: %w", err)
+	}
+	return nil
+}
+
+// insertRun writes the run as-is, including created_at; verb is "INSERT" or "INSERT OR IGNORE".
+func insertRun(ctx context.Context, db execer, verb string, run *core.Run) (sql.Result, error) {
+	return db.ExecContext(ctx, verb+` INTO runs (`+runColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.ID, run.TaskID, run.Status, run.TriggerType, run.ScheduledAt.UTC().Format(time.RFC3339Nano),
 		nullableTime(run.StartedAt), nullableTime(run.EndedAt), nullableInt(run.ExitCode), nullableString(run.Error),
-		run.CreatedAt.Format(time.RFC3339Nano))
+		nullableString(run.Reason), nullableInt(run.PID), nullableInt64(run.MaxRSSKB), nullableFloat(run.CPUSeconds), nullableString(run.Note), nullableString(run.OutputTail), nullableString(run.Warning), run.Pinned, run.CreatedAt.UTC().Format(time.RFC3339Nano))
+}
+
+// TransitionRun applies update to the run if its stored status is one of from, in a
+// single conditional UPDATE so concurrent transitions cannot both succeed, and records
+// update.Event in the same transaction. It returns
+// ErrRunNotFound for unknown runs and core.ErrInvalidRunTransition when the run is in
+// another status. Callers go through core.RunStateMachine.
+func (s *Store) TransitionRun(ctx context.Context, id string, from []core.RunStatus, update core.RunUpdate) error {
+	if len(from) == 0 {
+		return fmt.Errorf("%w: nothing can move to %s", core.ErrInvalidRunTransition, update.Status)
+	}
+	sets := []string{"status = ?"}
+	args := []any{update.Status}
+	if update.StartedAt != nil {
+		sets = append(sets, "started_at = ?")
+		args = append(args, nullableTime(update.StartedAt))
+	}
+	if update.EndedAt != nil {
+		sets = append(sets, "ended_at = ?")
+		args = append(args, nullableTime(update.EndedAt))
+	}
+	if update.ExitCode != nil {
+		sets = append(sets, "exit_code = ?")
+		args = append(args, *update.ExitCode)
+	}
+	if update.Error != nil {
+		sets = append(sets, "error = ?")
+		args = append(args, *update.Error)
+	}
+	if update.Reason != nil {
+		sets = append(sets, "reason = ?")
+		args = append(args, *update.Reason)
+	}
+	args = append(args, id)
+	for _, status := range from {
+		args = append(args, status)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(from)), ", ")
+	tx, err := s.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("insert run: %w", err)
+		return fmt.Errorf("begin run transition: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE runs SET `+strings.Join(sets, ", ")+` WHERE id = ? AND status IN (`+placeholders+`)`, args...)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update run status to %s: %w", update.Status, err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if rows > 0 {
+		if update.Event != nil {
+			if err := insertRunEvent(ctx, tx, *update.Event); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("insert run event: %w", err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit run transition: %w", err)
+		}
+		return nil
+	}
+	// The connection is released before reading the current status
+	tx.Rollback()
+	var current string
+	if err := s.DB.QueryRowContext(ctx, `SELECT status FROM runs WHERE id = ?`, id).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRunNotFound
+		}
+		return err
+	}
+	return fmt.Errorf("%w: run %s is %s, cannot become %s", core.ErrInvalidRunTransition, id, current, update.Status)
+}
+
+// SetRunPID records the OS process ID of a started run.
+func (s *Store) SetRunPID(ctx context.Context, id string, pid int) error {
+	res, err := s.DB.ExecContext(ctx, `UPDATE runs SET pid = ? WHERE id = ?`, pid, id)
+	if err != nil {
+		return fmt.Errorf("set run pid: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRunNotFound
 	}
 	return nil
 }
 
-func (s *Store) MarkRunStarted(ctx context.Context, id string, startedAt time.Time) error {
-	res, err := s.DB.ExecContext(ctx, `
-		UPDATE runs
-		SET status = ?, started_at = ?
-		WHERE id = ?
-	`, core.RunStatusRunning, startedAt.UTC().Format(time.RFC3339Nano), id)
+// SetRunWarning flags a finished run as suspicious with a short explanation.
+func (s *Store) SetRunWarning(ctx context.Context, id string, warning string) error {
+	res, err := s.DB.ExecContext(ctx, `UPDATE runs SET warning = ? WHERE id = ?`, warning, id)
 	if err != nil {
-		return fmt.Errorf("mark run started: %w", err)
+		return fmt.Errorf("set run warning: %w", err)
 	}
 	rows, err := res.RowsAffected()
 	if err != nil {
@@ -49,14 +142,11 @@ func (s *Store) MarkRunStarted(ctx context.Context, id string, startedAt time.Ti
 	return nil
 }
 
-func (s *Store) MarkRunCompleted(ctx context.Context, id string, status core.RunStatus, endedAt time.Time, exitCode *int, errMsg *string) error {
-	res, err := s.DB.ExecContext(ctx, `
-		UPDATE runs
-		SET status = ?, ended_at = ?, exit_code = ?, error = ?
-		WHERE id = ?
-	`, status, endedAt.UTC().Format(time.RFC3339Nano), nullableInt(exitCode), nullableString(errMsg), id)
+// SetRunOutputTail stores the last bytes of the run's output.
+func (s *Store) SetRunOutputTail(ctx context.Context, id string, tail string) error {
+	res, err := s.DB.ExecContext(ctx, `UPDATE runs SET output_tail = ? WHERE id = ?`, tail, id)
 	if err != nil {
-		return fmt.Errorf("mark run completed: %w", err)
+		return fmt.Errorf("set run output tail: %w", err)
 	}
 	rows, err := res.RowsAffected()
 	if err != nil {
@@ -68,14 +158,40 @@ func (s *Store) MarkRunCompleted(ctx context.Context, id string, status core.Run
 	return nil
 }
 
-func (s *Store) UpdateRunStatus(ctx context.Context, id string, status core.RunStatus, errMsg *string) error {
-	res, err := s.DB.ExecContext(ctx, `
+// UpdateRunUsage records a resource sample, keeping the peak RSS seen so far.
+func (s *Store) UpdateRunUsage(ctx context.Context, id string, usage core.ProcessUsage) error {
+	_, err := s.DB.ExecContext(ctx, `
 		UPDATE runs
-		SET status = ?, error = ?
+		SET max_rss_kb = MAX(COALESCE(max_rss_kb, 0), ?), cpu_seconds = ?
 		WHERE id = ?
-	`, status, nullableString(errMsg), id)
+	`, usage.RSSKB, usage.CPUSeconds, id)
+	if err != nil {
+		return fmt.Errorf("update run usage: %w", err)
+	}
+	return nil
+}
+
+// SetRunNote replaces the run's note; a nil note clears it.
+// SetRunPinned pins or unpins a run; pinned runs are skipped by log pruning and purges.
+func (s *Store) SetRunPinned(ctx context.Context, id string, pinned bool) error {
+	res, err := s.DB.ExecContext(ctx, `UPDATE runs SET pinned = ? WHERE id = ?`, pinned, id)
+	if err != nil {
+		return fmt.Errorf("set run pinned: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRunNotFound
+	}
+	return nil
+}
+
+func (s *Store) SetRunNote(ctx context.Context, id string, note *string) error {
+	res, err := s.DB.ExecContext(ctx, `UPDATE runs SET note = ? WHERE id = ?`, nullableString(note), id)
 	if err != nil {
-		return fmt.Errorf("update run status: %w", err)
+		return fmt.Errorf("set run note: %w", err)
 	}
 	rows, err := res.RowsAffected()
 	if err != nil {
@@ -89,7 +205,7 @@ func (s *Store) UpdateRunStatus(ctx context.Context, id string, status core.RunS
 
 func (s *Store) GetRun(ctx context.Context, id string) (*core.Run, error) {
 	row := s.DB.QueryRowContext(ctx, `
-		SELECT id, task_id, status, scheduled_at, started_at, ended_at, exit_code, error, created_at
+		SELECT `+runColumns+`
 		FROM runs WHERE id = ?
 	`, id)
 	run, err := scanRun(row)
@@ -102,17 +218,53 @@ func (s *Store) GetRun(ctx context.Context, id string) (*core.Run, error) {
 	return run, nil
 }
 
-func (s *Store) ListRuns(ctx context.Context, taskID string, limit, offset int) ([]*core.Run, error) {
+// RunFilter narrows ListRuns; zero-value fields match every run.
+type RunFilter struct {
+	TriggerType core.TriggerType
+	// Statuses matches runs in any of the listed statuses.
+	Statuses []core.RunStatus
+	// Since and Until bound created_at: Since is inclusive, Until exclusive.
+	Since *time.Time
+	Until *time.Time
+	// Pinned, when set, matches only pinned (true) or unpinned (false) runs.
+	Pinned *bool
+}
+
+func (s *Store) ListRuns(ctx context.Context, taskID string, filter RunFilter, limit, offset int) ([]*core.Run, error) {
 	if limit <= 0 {
 		limit = 20
 	}
+	where := "task_id = ?"
+	args := []any{taskID}
+	if filter.TriggerType != "" {
+		where += " AND trigger_type = ?"
+		args = append(args, filter.TriggerType)
+	}
+	if len(filter.Statuses) > 0 {
+		where += " AND status IN (?" + strings.Repeat(", ?", len(filter.Statuses)-1) + ")"
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if filter.Since != nil {
+		where += " AND created_at >= ?"
+		args = append(args, filter.Since.UTC().Format(time.RFC3339Nano))
+	}
+	if filter.Until != nil {
+		where += " AND created_at < ?"
+		args = append(args, filter.Until.UTC().Format(time.RFC3339Nano))
+	}
+	if filter.Pinned != nil {
+		where += " AND pinned = ?"
+		args = append(args, *filter.Pinned)
+	}
 	rows, err := s.DB.QueryContext(ctx, `
-		SELECT id, task_id, status, scheduled_at, started_at, ended_at, exit_code, error, created_at
+		SELECT `+runColumns+`
 		FROM runs
-		WHERE task_id = ?
+		WHERE `+where+`
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
-	`, taskID, limit, offset)
+	`, append(args, limit, offset)...)
 	if err != nil {
 		return nil, fmt.Errorf("list runs: %w", err)
 	}
@@ -131,6 +283,196 @@ func (s *Store) ListRuns(ctx context.Context, taskID string, limit, offset int)
 	return runs, nil
 }
 
+// EachRun calls fn for every run of the task, oldest first. Rows are read in batches so
+// the database connection is released between batches while the caller streams output.
+func (s *Store) EachRun(ctx context.Context, taskID string, batchSize int, fn func(*core.Run) error) error {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	var afterRowID int64
+	for {
+		rows, err := s.DB.QueryContext(ctx, `
+			SELECT rowid, `+runColumns+`
+			FROM runs
+			WHERE task_id = ? AND rowid > ?
+			ORDER BY rowid ASC
+			LIMIT ?
+		`, taskID, afterRowID, batchSize)
+		if err != nil {
+			return fmt.Errorf("list runs for export: %w", err)
+		}
+		var batch []*core.Run
+		for rows.Next() {
+			run, err := scanRun(rowidScanner{rows, &afterRowID})
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			batch = append(batch, run)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		for _, run := range batch {
+			if err := fn(run); err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+	}
+}
+
+// rowidScanner reads a leading rowid column before handing the rest to the wrapped scanner.
+type rowidScanner struct {
+	rows  *sql.Rows
+	rowid *int64
+}
+
+func (r rowidScanner) Scan(dest ...any) error {
+	return r.rows.Scan(append([]any{r.rowid}, dest...)...)
+}
+
+// ListActiveRuns returns all runs that are queued or running, oldest first.
+func (s *Store) ListActiveRuns(ctx context.Context) ([]*core.Run, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+runColumns+`
+		FROM runs
+		WHERE status IN (?, ?)
+		ORDER BY created_at ASC
+	`, core.RunStatusQueued, core.RunStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("list active runs: %w", err)
+	}
+	defer rows.Close()
+	var runs []*core.Run
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// RecentRunStatuses returns the statuses of the task's most recent finished runs, newest first.
+// Skipped runs are ignored since they never executed.
+func (s *Store) RecentRunStatuses(ctx context.Context, taskID string, limit int) ([]core.RunStatus, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT status FROM runs
+		WHERE task_id = ? AND status NOT IN (?, ?, ?)
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, taskID, core.RunStatusQueued, core.RunStatusRunning, core.RunStatusSkipped, limit)
+	if err != nil {
+		return nil, fmt.Errorf("recent run statuses: %w", err)
+	}
+	defer rows.Close()
+	var statuses []core.RunStatus
+	for rows.Next() {
+		var status string
+		if err := rows.Scan(&status); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, core.RunStatus(status))
+	}
+	return statuses, rows.Err()
+}
+
+// RecentRunDurations returns how long the task's most recent succeeded runs took, newest
+// first, leaving out the run excludeRunID.
+func (s *Store) RecentRunDurations(ctx context.Context, taskID, excludeRunID string, limit int) ([]time.Duration, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT (julianday(ended_at) - julianday(started_at)) * 86400000.0 FROM runs
+		WHERE task_id = ? AND id != ? AND status = ? AND started_at IS NOT NULL AND ended_at IS NOT NULL
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, taskID, excludeRunID, core.RunStatusSucceeded, limit)
+	if err != nil {
+		return nil, fmt.Errorf("recent run durations: %w", err)
+	}
+	defer rows.Close()
+	var durations []time.Duration
+	for rows.Next() {
+		var ms float64
+		if err := rows.Scan(&ms); err != nil {
+			return nil, err
+		}
+		durations = append(durations, time.Duration(ms*float64(time.Millisecond)))
+	}
+	return durations, rows.Err()
+}
+
+// LastRunStatuses returns the status of each task's latest finished run, by task ID.
+// Skipped runs are ignored as in RecentRunStatuses; tasks without finished runs are absent.
+func (s *Store) LastRunStatuses(ctx context.Context) (map[string]core.RunStatus, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT task_id, status FROM (
+			SELECT task_id, status, ROW_NUMBER() OVER (PARTITION BY task_id ORDER BY created_at DESC) AS rn
+			FROM runs
+			WHERE status NOT IN (?, ?, ?)
+		) WHERE rn = 1
+	`, core.RunStatusQueued, core.RunStatusRunning, core.RunStatusSkipped)
+	if err != nil {
+		return nil, fmt.Errorf("last run statuses: %w", err)
+	}
+	defer rows.Close()
+	statuses := make(map[string]core.RunStatus)
+	for rows.Next() {
+		var taskID, status string
+		if err := rows.Scan(&taskID, &status); err != nil {
+			return nil, err
+		}
+		statuses[taskID] = core.RunStatus(status)
+	}
+	return statuses, rows.Err()
+}
+
+// CountFailedRunsSince counts the runs created at or after since that failed or timed out.
+func (s *Store) CountFailedRunsSince(ctx context.Context, since time.Time) (int, error) {
+	var n int
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT COUNT(1) FROM runs WHERE status IN (?, ?) AND created_at >= ?
+	`, core.RunStatusFailed, core.RunStatusTimedOut, since.UTC().Format(time.RFC3339Nano)).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count failed runs: %w", err)
+	}
+	return n, nil
+}
+
+// ListEndedRunsWithPID returns finished runs that recorded a PID and ended at or after since.
+func (s *Store) ListEndedRunsWithPID(ctx context.Context, since time.Time) ([]*core.Run, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+runColumns+`
+		FROM runs
+		WHERE pid IS NOT NULL AND ended_at IS NOT NULL AND ended_at >= ?
+		ORDER BY ended_at DESC
+	`, since.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, fmt.Errorf("list ended runs: %w", err)
+	}
+	defer rows.Close()
+	var runs []*core.Run
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
 // RunLogPath returns the absolute path for the run's combined log file.
 func (s *Store) RunLogPath(runID string) string {
 	return filepath.Join(s.StateDir, "runs", runID, "combined.log")
@@ -141,32 +483,168 @@ func (s *Store) EnsureRunLogDir(runID string) error {
 	return os.MkdirAll(filepath.Dir(s.RunLogPath(runID)), 0o755)
 }
 
-// PruneOldRunLogs removes log files beyond the retention limit for a task.
+// PurgeRuns deletes the task's finished runs created before the given time (all of them when
+// before is nil), along with their log index entries and log directories. Queued, running and
+// pinned runs are kept. The rows are deleted in one transaction; log directories are removed after
+// it commits, so a failure there leaves orphaned files rather than runs without logs.
+func (s *Store) PurgeRuns(ctx context.Context, taskID string, before *time.Time) (int, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin purge runs: %w", err)
+	}
+	defer tx.Rollback()
+
+	where := "task_id = ? AND status NOT IN (?, ?) AND pinned = 0"
+	args := []any{taskID, core.RunStatusQueued, core.RunStatusRunning}
+	if before != nil {
+		where += " AND created_at < ?"
+		args = append(args, before.UTC().Format(time.RFC3339Nano))
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM runs WHERE `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("query runs to purge: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM run_events WHERE run_id IN (SELECT id FROM runs WHERE `+where+`)`, args...); err != nil {
+		return 0, fmt.Errorf("delete run events: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM runs WHERE `+where, args...); err != nil {
+		return 0, fmt.Errorf("delete runs: %w", err)
+	}
+	if s.logIndex != nil {
+		for _, id := range ids {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM run_logs_fts WHERE run_id = ?`, id); err != nil {
+				return 0, fmt.Errorf("remove run log index: %w", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit purge runs: %w", err)
+	}
+
+	var removeErr error
+	for _, id := range ids {
+		if err := os.RemoveAll(filepath.Dir(s.RunLogPath(id))); err != nil && removeErr == nil {
+			removeErr = fmt.Errorf("remove run log dir: %w", err)
+		}
+	}
+	return len(ids), removeErr
+}
+
+// PruneOldRunLogs removes a task's run logs beyond its retention limits: the number of
+// latest runs kept, its own log_retention when set and LogRetention otherwise, and the
+// age in days of finished runs, its own log_max_age_days when set and LogMaxAgeDays
+// otherwise. Pinned runs keep their logs and do not count toward the limits.
 func (s *Store) PruneOldRunLogs(ctx context.Context, taskID string) error {
+	_, err := s.pruneRunLogs(ctx, taskID)
+	return err
+}
+
+// PruneExpiredRunLogs applies PruneOldRunLogs to every task, so logs also age out of
+// tasks that no longer run. It returns how many runs had their logs removed.
+func (s *Store) PruneExpiredRunLogs(ctx context.Context) (int, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT id FROM tasks`)
+	if err != nil {
+		return 0, fmt.Errorf("list tasks for log pruning: %w", err)
+	}
+	var taskIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		taskIDs = append(taskIDs, id)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, id := range taskIDs {
+		n, err := s.pruneRunLogs(ctx, id)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// pruneRunLogs implements PruneOldRunLogs, returning how many runs still had a log to
+// remove.
+func (s *Store) pruneRunLogs(ctx context.Context, taskID string) (int, error) {
+	keep, maxAgeDays := s.LogRetention, s.LogMaxAgeDays
+	var keepOverride, maxAgeOverride sql.NullInt64
+	err := s.DB.QueryRowContext(ctx, `SELECT log_retention, log_max_age_days FROM tasks WHERE id = ?`, taskID).Scan(&keepOverride, &maxAgeOverride)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("query task log retention: %w", err)
+	}
+	if keepOverride.Valid && keepOverride.Int64 > 0 {
+		keep = int(keepOverride.Int64)
+	}
+	if maxAgeOverride.Valid && maxAgeOverride.Int64 > 0 {
+		maxAgeDays = int(maxAgeOverride.Int64)
+	}
+	// RFC3339Nano drops trailing zeros, so timestamps are compared as julian days rather
+	// than as text; julianday of the empty cutoff is NULL, so no run is too old
+	var cutoff string
+	if maxAgeDays > 0 {
+		cutoff = s.now().AddDate(0, 0, -maxAgeDays).Format(time.RFC3339Nano)
+	}
 	rows, err := s.DB.QueryContext(ctx, `
 		SELECT id FROM runs
-		WHERE task_id = ?
-		ORDER BY created_at DESC
-		LIMIT -1 OFFSET ?
-	`, taskID, s.LogRetention)
+		WHERE task_id = ? AND pinned = 0 AND (
+			id IN (SELECT id FROM runs WHERE task_id = ? AND pinned = 0 ORDER BY created_at DESC LIMIT -1 OFFSET ?)
+			OR (ended_at IS NOT NULL AND julianday(created_at) < julianday(?))
+		)
+	`, taskID, taskID, keep, cutoff)
 	if err != nil {
-		return fmt.Errorf("query runs for pruning: %w", err)
+		return 0, fmt.Errorf("query runs for pruning: %w", err)
 	}
-	defer rows.Close()
+	var pruned []string
 	for rows.Next() {
 		var id string
 		if err := rows.Scan(&id); err != nil {
-			return err
+			rows.Close()
+			return 0, err
 		}
 		path := s.RunLogPath(id)
-		_ = os.Remove(path)
+		// Logs removed by an earlier pass are already out of the index
+		if err := os.Remove(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
 		dir := filepath.Dir(path)
 		entries, err := os.ReadDir(dir)
 		if err == nil && len(entries) == 0 {
 			_ = os.Remove(dir)
 		}
+		pruned = append(pruned, id)
 	}
-	return rows.Err()
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return 0, err
+	}
+	return len(pruned), s.removeFromLogIndex(ctx, pruned)
 }
 
 func scanRun(scanner interface {
@@ -176,21 +654,32 @@ func scanRun(scanner interface {
 		id          string
 		taskID      string
 		status      string
+		triggerType string
 		scheduledAt string
 		startedAt   sql.NullString
 		endedAt     sql.NullString
 		exitCode    sql.NullInt64
 		errMsg      sql.NullString
+		reason      sql.NullString
+		pid         sql.NullInt64
+		maxRSS      sql.NullInt64
+		cpuSeconds  sql.NullFloat64
+		note        sql.NullString
+		outputTail  sql.NullString
+		warning     sql.NullString
+		pinned      bool
 		createdAt   string
 	)
-	if err := scanner.Scan(&id, &taskID, &status, &scheduledAt, &startedAt, &endedAt, &exitCode, &errMsg, &createdAt); err != nil {
+	if err := scanner.Scan(&id, &taskID, &status, &triggerType, &scheduledAt, &startedAt, &endedAt, &exitCode, &errMsg, &reason, &pid, &maxRSS, &cpuSeconds, &note, &outputTail, &warning, &pinned, &createdAt); err != nil {
 		return nil, fmt.Errorf("scan run: %w", err)
 	}
 	run := &core.Run{
 		ID:          id,
 		TaskID:      taskID,
 		Status:      core.RunStatus(status),
+		TriggerType: core.TriggerType(triggerType),
 		ScheduledAt: mustParseTime(scheduledAt),
+		Pinned:      pinned,
 		CreatedAt:   mustParseTime(createdAt),
 	}
 	if startedAt.Valid {
@@ -208,6 +697,28 @@ func scanRun(scanner interface {
 	if errMsg.Valid {
 		run.Error = &errMsg.String
 	}
+	if reason.Valid {
+		run.Reason = &reason.String
+	}
+	if pid.Valid {
+		val := int(pid.Int64)
+		run.PID = &val
+	}
+	if maxRSS.Valid {
+		run.MaxRSSKB = &maxRSS.Int64
+	}
+	if cpuSeconds.Valid {
+		run.CPUSeconds = &cpuSeconds.Float64
+	}
+	if note.Valid {
+		run.Note = &note.String
+	}
+	if outputTail.Valid {
+		run.OutputTail = &outputTail.String
+	}
+	if warning.Valid {
+		run.Warning = &warning.String
+	}
 	return run, nil
 }
 
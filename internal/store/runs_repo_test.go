@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"clicrontab/internal/clock"
+	"clicrontab/internal/core"
+)
+
+func TestPruneExpiredRunLogsAppliesAgeOverride(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	s.SetClock(fake)
+	s.LogRetention = 100
+	s.LogMaxAgeDays = 30
+
+	short := &core.Task{ID: "short", Command: "true", Cron: "@daily", Status: core.TaskStatusActive, LogMaxAgeDays: ptr(7)}
+	global := &core.Task{ID: "global", Command: "true", Cron: "@daily", Status: core.TaskStatusActive}
+	for _, task := range []*core.Task{short, global} {
+		if err := s.InsertTask(ctx, task); err != nil {
+			t.Fatalf("InsertTask() error = %v", err)
+		}
+	}
+	addRun := func(id, taskID string, age time.Duration, finished, pinned bool) {
+		t.Helper()
+		fake.Set(now.Add(-age))
+		run := &core.Run{ID: id, TaskID: taskID, Status: core.RunStatusRunning, TriggerType: core.TriggerScheduled, ScheduledAt: now.Add(-age), Pinned: pinned}
+		if finished {
+			ended := now.Add(-age)
+			run.Status, run.EndedAt = core.RunStatusSucceeded, &ended
+		}
+		if err := s.InsertRun(ctx, run); err != nil {
+			t.Fatalf("InsertRun() error = %v", err)
+		}
+		if err := s.EnsureRunLogDir(id); err != nil {
+			t.Fatalf("EnsureRunLogDir() error = %v", err)
+		}
+		if err := os.WriteFile(s.RunLogPath(id), []byte("output\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	day := 24 * time.Hour
+	addRun("short-old", "short", 40*day, true, false)
+	addRun("short-week", "short", 10*day, true, false)
+	addRun("short-pinned", "short", 10*day, true, true)
+	addRun("short-running", "short", 10*day, false, false)
+	addRun("short-new", "short", day, true, false)
+	addRun("global-old", "global", 40*day, true, false)
+	addRun("global-week", "global", 10*day, true, false)
+	fake.Set(now)
+
+	n, err := s.PruneExpiredRunLogs(ctx)
+	if err != nil {
+		t.Fatalf("PruneExpiredRunLogs() error = %v", err)
+	}
+	if n != 3 {
+		t.Errorf("PruneExpiredRunLogs() = %d, want 3", n)
+	}
+	for id, kept := range map[string]bool{
+		"short-old": false, "short-week": false, "short-pinned": true, "short-running": true, "short-new": true,
+		"global-old": false, "global-week": true,
+	} {
+		_, err := os.Stat(s.RunLogPath(id))
+		if exists := err == nil; exists != kept {
+			t.Errorf("log of %s exists = %v, want %v", id, exists, kept)
+		}
+	}
+	if n, err := s.PruneExpiredRunLogs(ctx); err != nil || n != 0 {
+		t.Errorf("second PruneExpiredRunLogs() = %d, %v, want 0", n, err)
+	}
+
+	// Around the cutoff, fractional seconds must not sort "12:00:00.5Z" before "12:00:00Z"
+	addRun("short-before-cutoff", "short", 7*day+100*time.Millisecond, true, false)
+	addRun("short-after-cutoff", "short", 7*day-500*time.Millisecond, true, false)
+	fake.Set(now)
+	if n, err := s.PruneExpiredRunLogs(ctx); err != nil || n != 1 {
+		t.Errorf("PruneExpiredRunLogs() near the cutoff = %d, %v, want 1", n, err)
+	}
+	if _, err := os.Stat(s.RunLogPath("short-after-cutoff")); err != nil {
+		t.Errorf("log of a run newer than the cutoff was removed: %v", err)
+	}
+	if _, err := os.Stat(s.RunLogPath("short-before-cutoff")); err == nil {
+		t.Error("log of a run older than the cutoff was kept")
+	}
+}
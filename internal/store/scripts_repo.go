@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+// ErrScriptNotFound is core.ErrScriptNotFound, so the executor can recognise it.
+var ErrScriptNotFound = core.ErrScriptNotFound
+
+const scriptColumns = `id, name, type, content, description, created_at, updated_at`
+
+// CreateScript stores a new script.
+func (s *Store) CreateScript(ctx context.Context, script *core.Script) error {
+	now := s.now()
+	script.CreatedAt = now
+	script.UpdatedAt = now
+	if _, err := s.DB.ExecContext(ctx, `
+		INSERT INTO scripts (`+scriptColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, script.ID, script.Name, script.Type, script.Content, nullableString(script.Description),
+		now.Format(time.RFC3339Nano), now.Format(time.RFC3339Nano)); err != nil {
+		if isScriptNameConflict(err) {
+			return core.ErrScriptNameTaken
+		}
+		return fmt.Errorf("insert script: %w", err)
+	}
+	return nil
+}
+
+// ListScripts returns every script, by name.
+func (s *Store) ListScripts(ctx context.Context) ([]*core.Script, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT `+scriptColumns+` FROM scripts ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list scripts: %w", err)
+	}
+	defer rows.Close()
+	var scripts []*core.Script
+	for rows.Next() {
+		script, err := scanScript(rows)
+		if err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts, rows.Err()
+}
+
+// GetScript returns a script by ID.
+func (s *Store) GetScript(ctx context.Context, id string) (*core.Script, error) {
+	script, err := scanScript(s.DB.QueryRowContext(ctx, `SELECT `+scriptColumns+` FROM scripts WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrScriptNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get script: %w", err)
+	}
+	return script, nil
+}
+
+// UpdateScript stores the script's new name, type, content and description. Tasks
+// running it pick up the change on their next run; a rename also updates the command
+// they show.
+func (s *Store) UpdateScript(ctx context.Context, script *core.Script) error {
+	script.UpdatedAt = s.now()
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin update script: %w", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `
+		UPDATE scripts SET name = ?, type = ?, content = ?, description = ?, updated_at = ? WHERE id = ?
+	`, script.Name, script.Type, script.Content, nullableString(script.Description), script.UpdatedAt.Format(time.RFC3339Nano), script.ID)
+	if err != nil {
+		if isScriptNameConflict(err) {
+			return core.ErrScriptNameTaken
+		}
+		return fmt.Errorf("update script: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrScriptNotFound
+	}
+	command := core.ScriptCommand(script.Name)
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE tasks SET command = ?, prompt = ? WHERE script_id = ? AND command != ?
+	`, command, command, script.ID, command); err != nil {
+		return fmt.Errorf("update commands of tasks running script: %w", err)
+	}
+	return tx.Commit()
+}
+
+// DeleteScript removes a script, failing with core.ErrScriptInUse while tasks, archived
+// ones included, still reference it.
+func (s *Store) DeleteScript(ctx context.Context, id string) error {
+	var users int
+	if err := s.DB.QueryRowContext(ctx, `SELECT COUNT(1) FROM tasks WHERE script_id = ?`, id).Scan(&users); err != nil {
+		return fmt.Errorf("count tasks running script: %w", err)
+	}
+	if users > 0 {
+		return fmt.Errorf("%w (%d)", core.ErrScriptInUse, users)
+	}
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM scripts WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete script: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrScriptNotFound
+	}
+	return nil
+}
+
+// ScriptUsage counts the tasks referencing each script, keyed by script ID.
+func (s *Store) ScriptUsage(ctx context.Context) (map[string]int, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT script_id, COUNT(1) FROM tasks WHERE script_id IS NOT NULL GROUP BY script_id`)
+	if err != nil {
+		return nil, fmt.Errorf("count script usage: %w", err)
+	}
+	defer rows.Close()
+	usage := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		usage[id] = n
+	}
+	return usage, rows.Err()
+}
+
+func scanScript(scanner interface {
+	Scan(dest ...any) error
+}) (*core.Script, error) {
+	var (
+		script               core.Script
+		description          sql.NullString
+		createdAt, updatedAt string
+	)
+	if err := scanner.Scan(&script.ID, &script.Name, &script.Type, &script.Content, &description, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	if description.Valid {
+		script.Description = &description.String
+	}
+	script.CreatedAt = mustParseTime(createdAt)
+	script.UpdatedAt = mustParseTime(updatedAt)
+	return &script, nil
+}
+
+func isScriptNameConflict(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: scripts.name")
+}
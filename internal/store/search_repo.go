@@ -0,0 +1,259 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+// maxSnippetLength truncates long log lines in search results.
+const maxSnippetLength = 240
+
+// LogHit describes a run whose log matched a search query.
+type LogHit struct {
+	RunID     string
+	TaskID    string
+	CreatedAt time.Time
+	Snippets  []string
+}
+
+// SearchTasks returns tasks whose name, prompt or command contains query (case-insensitive).
+func (s *Store) SearchTasks(ctx context.Context, query string) ([]*core.Task, error) {
+	pattern := "%" + escapeLike(query) + "%"
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE name LIKE ? ESCAPE '\' OR prompt LIKE ? ESCAPE '\' OR command LIKE ? ESCAPE '\'
+		ORDER BY created_at DESC
+	`, pattern, pattern, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("search tasks: %w", err)
+	}
+	defer rows.Close()
+	var tasks []*core.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GrepRunLogs scans the logs of the most recent runLimit runs for query (case-insensitive),
+// returning up to snippetLimit matching lines per run, newest runs first.
+func (s *Store) GrepRunLogs(ctx context.Context, query string, runLimit, snippetLimit int) ([]LogHit, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, task_id, created_at FROM runs
+		WHERE status NOT IN (?, ?)
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, core.RunStatusQueued, core.RunStatusSkipped, runLimit)
+	if err != nil {
+		return nil, fmt.Errorf("list runs for log search: %w", err)
+	}
+	type runRef struct {
+		id, taskID string
+		createdAt  time.Time
+	}
+	var refs []runRef
+	for rows.Next() {
+		var ref runRef
+		var createdAt string
+		if err := rows.Scan(&ref.id, &ref.taskID, &createdAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan run for log search: %w", err)
+		}
+		ref.createdAt = mustParseTime(createdAt)
+		refs = append(refs, ref)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	needle := strings.ToLower(query)
+	var hits []LogHit
+	for _, ref := range refs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		snippets, err := grepFile(s.RunLogPath(ref.id), needle, snippetLimit)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		if len(snippets) > 0 {
+			hits = append(hits, LogHit{RunID: ref.id, TaskID: ref.taskID, CreatedAt: ref.createdAt, Snippets: snippets})
+		}
+	}
+	return hits, nil
+}
+
+// grepFile returns up to limit lines of path containing the lower-cased needle.
+func grepFile(path, needle string, limit int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var snippets []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(strings.ToLower(line), needle) {
+			snippets = append(snippets, truncateSnippet(line))
+			if len(snippets) >= limit {
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read log %s: %w", path, err)
+	}
+	return snippets, nil
+}
+
+func truncateSnippet(line string) string {
+	line = strings.TrimSpace(line)
+	if len(line) <= maxSnippetLength {
+		return line
+	}
+	cut := maxSnippetLength
+	for cut > 0 && !isRuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + "…"
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+// maxGrepLineLength truncates long lines in GrepRunLog results.
+const maxGrepLineLength = 2048
+
+// LogMatch is a log line matching a GrepRunLog pattern.
+type LogMatch struct {
+	Line   int      // 1-based line number
+	Offset int64    // byte offset of the line in the log
+	Spans  [][2]int // byte ranges of the matches within Text
+	Text   string
+	Before []string // up to context lines preceding the match
+	After  []string // up to context lines following the match
+}
+
+// LogGrep is the result of searching one run log.
+type LogGrep struct {
+	Matches []LogMatch
+	Total   int // matching lines, including those past the limit
+	Lines   int // lines scanned
+}
+
+// GrepRunLog searches the run's log for lines matching pattern, returning the first limit
+// matches with context lines around each. The whole log is streamed so Total counts every
+// matching line. It returns an error wrapping os.ErrNotExist when the run has no log.
+func (s *Store) GrepRunLog(ctx context.Context, runID string, pattern *regexp.Regexp, context, limit int) (*LogGrep, error) {
+	file, err := os.Open(s.RunLogPath(runID))
+	if err != nil {
+		return nil, fmt.Errorf("open log file: %w", err)
+	}
+	defer file.Close()
+
+	result := &LogGrep{}
+	var (
+		reader = bufio.NewReaderSize(file, 64*1024)
+		offset int64
+		before []string
+		open   []int // matches still collecting After lines
+	)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, fmt.Errorf("read log file: %w", readErr)
+		}
+		if line == "" {
+			break
+		}
+		result.Lines++
+		if result.Lines%4096 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		text := strings.TrimRight(line, "\r\n")
+
+		kept := open[:0]
+		for _, i := range open {
+			m := &result.Matches[i]
+			m.After = append(m.After, clipLine(text))
+			if len(m.After) < context {
+				kept = append(kept, i)
+			}
+		}
+		open = kept
+
+		if pattern.MatchString(text) {
+			result.Total++
+			if len(result.Matches) < limit {
+				clipped := clipLine(text)
+				m := LogMatch{Line: result.Lines, Offset: offset, Text: clipped, Before: slices.Clone(before)}
+				for _, span := range pattern.FindAllStringIndex(text, -1) {
+					if span[1] <= len(clipped) {
+						m.Spans = append(m.Spans, [2]int{span[0], span[1]})
+					}
+				}
+				result.Matches = append(result.Matches, m)
+				if context > 0 {
+					open = append(open, len(result.Matches)-1)
+				}
+			}
+		}
+		if context > 0 {
+			if len(before) == context {
+				before = before[1:]
+			}
+			before = append(before, clipLine(text))
+		}
+		offset += int64(len(line))
+		if readErr == io.EOF {
+			break
+		}
+	}
+	return result, nil
+}
+
+// clipLine cuts line to maxGrepLineLength bytes on a rune boundary.
+func clipLine(line string) string {
+	if len(line) <= maxGrepLineLength {
+		return line
+	}
+	cut := maxGrepLineLength
+	for cut > 0 && !isRuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut]
+}
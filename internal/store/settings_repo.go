@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SettingNotifications holds the JSON-encoded notification channel settings.
+const SettingNotifications = "notifications"
+
+// SettingLogLinkSecret holds the generated key for signing log links.
+const SettingLogLinkSecret = "log_link_secret"
+
+// SettingSessionSecret holds the generated key for signing web UI sessions.
+const SettingSessionSecret = "session_secret"
+
+// SettingDaemonStoppedAt holds when the daemon last shut down cleanly, RFC 3339; it is
+// emptied while a daemon runs, so a crash leaves it unset.
+const SettingDaemonStoppedAt = "daemon_stopped_at"
+
+// GetSetting returns the stored value for key; ok is false when the key is unset.
+func (s *Store) GetSetting(ctx context.Context, key string) (string, bool, error) {
+	var value string
+	err := s.DB.QueryRowContext(ctx, `SELECT value FROM settings WHERE key = ?`, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("get setting %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// SetSetting inserts or replaces the value for key.
+func (s *Store) SetSetting(ctx context.Context, key, value string) error {
+	_, err := s.DB.ExecContext(ctx, `
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, key, value, s.now().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("set setting %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+// SnapshotVersion is the format written by ExportSnapshot; ImportSnapshot rejects newer ones.
+const SnapshotVersion = 1
+
+// Snapshot is a backend-independent copy of all tasks, run metadata and comments, used to
+// move state between machines or storage backends.
+type Snapshot struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Scripts    []SnapshotScript  `json:"scripts,omitempty"`
+	Tasks      []SnapshotTask    `json:"tasks"`
+	Runs       []SnapshotRun     `json:"runs"`
+	Comments   []SnapshotComment `json:"comments"`
+}
+
+type SnapshotTask struct {
+	ID                 string            `json:"id"`
+	Name               *string           `json:"name,omitempty"`
+	Prompt             string            `json:"prompt"`
+	Command            string            `json:"command"`
+	Cron               string            `json:"cron"`
+	TimeoutSeconds     *int              `json:"timeout_seconds,omitempty"`
+	WorkingDir         *string           `json:"working_dir,omitempty"`
+	MinIntervalSeconds *int              `json:"min_interval_seconds,omitempty"`
+	PauseAfterFailures *int              `json:"pause_after_failures,omitempty"`
+	LogRetention       *int              `json:"log_retention,omitempty"`
+	LogMaxAgeDays      *int              `json:"log_max_age_days,omitempty"`
+	Executor           *string           `json:"executor,omitempty"`
+	HTTP               *core.HTTPRequest `json:"http,omitempty"`
+	SQL                *core.SQLQuery    `json:"sql,omitempty"`
+	ScriptID           *string           `json:"script_id,omitempty"`
+	RunOnStart         bool              `json:"run_on_start,omitempty"`
+	Status             string            `json:"status"`
+	PauseUntil         *time.Time        `json:"pause_until,omitempty"`
+	Source             string            `json:"source,omitempty"`
+	LastRunAt          *time.Time        `json:"last_run_at,omitempty"`
+	CreatedAt          time.Time         `json:"created_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+}
+
+type SnapshotRun struct {
+	ID          string     `json:"id"`
+	TaskID      string     `json:"task_id"`
+	Status      string     `json:"status"`
+	TriggerType string     `json:"trigger_type"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	EndedAt     *time.Time `json:"ended_at,omitempty"`
+	ExitCode    *int       `json:"exit_code,omitempty"`
+	Error       *string    `json:"error,omitempty"`
+	Reason      *string    `json:"reason,omitempty"`
+	PID         *int       `json:"pid,omitempty"`
+	MaxRSSKB    *int64     `json:"max_rss_kb,omitempty"`
+	CPUSeconds  *float64   `json:"cpu_seconds,omitempty"`
+	Note        *string    `json:"note,omitempty"`
+	OutputTail  *string    `json:"output_tail,omitempty"`
+	Warning     *string    `json:"warning,omitempty"`
+	Pinned      bool       `json:"pinned,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type SnapshotScript struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Content     string    `json:"content"`
+	Description *string   `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type SnapshotComment struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ImportResult counts what ImportSnapshot added; records whose ID already exists are skipped.
+type ImportResult struct {
+	Scripts  int `json:"scripts"`
+	Tasks    int `json:"tasks"`
+	Runs     int `json:"runs"`
+	Comments int `json:"comments"`
+	Skipped  int `json:"skipped"`
+	// RunIDs lists the imported runs, so callers can restore their logs.
+	RunIDs []string `json:"-"`
+}
+
+// ExportSnapshot reads every script, task (archived ones included), run and comment.
+func (s *Store) ExportSnapshot(ctx context.Context) (*Snapshot, error) {
+	snap := &Snapshot{Version: SnapshotVersion, ExportedAt: time.Now().UTC()}
+
+	scripts, err := s.ListScripts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("export scripts: %w", err)
+	}
+	for _, script := range scripts {
+		snap.Scripts = append(snap.Scripts, SnapshotScript{
+			ID: script.ID, Name: script.Name, Type: script.Type, Content: script.Content, Description: script.Description,
+			CreatedAt: script.CreatedAt, UpdatedAt: script.UpdatedAt,
+		})
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `SELECT `+taskColumns+` FROM tasks ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("export tasks: %w", err)
+	}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		snap.Tasks = append(snap.Tasks, SnapshotTask{
+			ID: task.ID, Name: task.Name, Prompt: task.Prompt, Command: task.Command, Cron: task.Cron,
+			TimeoutSeconds: task.TimeoutSeconds, WorkingDir: task.WorkingDir, MinIntervalSeconds: task.MinIntervalSeconds,
+			PauseAfterFailures: task.PauseAfterFailures, LogRetention: task.LogRetention, LogMaxAgeDays: task.LogMaxAgeDays, Executor: task.Executor, HTTP: task.HTTP, SQL: task.SQL, ScriptID: task.ScriptID, RunOnStart: task.RunOnStart, Status: string(task.Status),
+			PauseUntil: task.PauseUntil, Source: task.Source, LastRunAt: task.LastRunAt, CreatedAt: task.CreatedAt, UpdatedAt: task.UpdatedAt,
+		})
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = s.DB.QueryContext(ctx, `SELECT `+runColumns+` FROM runs ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("export runs: %w", err)
+	}
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		snap.Runs = append(snap.Runs, SnapshotRun{
+			ID: run.ID, TaskID: run.TaskID, Status: string(run.Status), TriggerType: string(run.TriggerType),
+			ScheduledAt: run.ScheduledAt, StartedAt: run.StartedAt, EndedAt: run.EndedAt, ExitCode: run.ExitCode,
+			Error: run.Error, Reason: run.Reason, PID: run.PID, MaxRSSKB: run.MaxRSSKB, CPUSeconds: run.CPUSeconds,
+			Note: run.Note, OutputTail: run.OutputTail, Warning: run.Warning, Pinned: run.Pinned, CreatedAt: run.CreatedAt,
+		})
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = s.DB.QueryContext(ctx, `SELECT id, task_id, author, body, created_at FROM task_comments ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("export comments: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			comment   SnapshotComment
+			createdAt string
+		)
+		if err := rows.Scan(&comment.ID, &comment.TaskID, &comment.Author, &comment.Body, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan task comment: %w", err)
+		}
+		comment.CreatedAt = mustParseTime(createdAt)
+		snap.Comments = append(snap.Comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// checkSnapshotIDs rejects a snapshot with an ID core.NewID could not have made, as run
+// IDs name log files and a crafted one such as ".." would escape the runs directory.
+func checkSnapshotIDs(snap *Snapshot) error {
+	check := func(kind, id string) error {
+		if !core.ValidID(id) {
+			return fmt.Errorf("invalid %s ID %q", kind, id)
+		}
+		return nil
+	}
+	for _, sc := range snap.Scripts {
+		if err := check("script", sc.ID); err != nil {
+			return err
+		}
+	}
+	for _, t := range snap.Tasks {
+		if err := check("task", t.ID); err != nil {
+			return err
+		}
+	}
+	for _, r := range snap.Runs {
+		if err := check("run", r.ID); err != nil {
+			return err
+		}
+		if err := check("task", r.TaskID); err != nil {
+			return err
+		}
+	}
+	for _, c := range snap.Comments {
+		if err := check("comment", c.ID); err != nil {
+			return err
+		}
+		if err := check("task", c.TaskID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportSnapshot adds the snapshot's records in one transaction, keeping their IDs and
+// timestamps. Existing IDs are left untouched, so importing the same snapshot twice is
+// harmless. Runs that were still queued or running at export time are stored as canceled,
+// and runs or comments whose task is unknown are skipped. next_run_at is not imported;
+// the scheduler recomputes it on the next Sync.
+func (s *Store) ImportSnapshot(ctx context.Context, snap *Snapshot) (*ImportResult, error) {
+	if snap.Version > SnapshotVersion {
+		return nil, fmt.Errorf("snapshot version %d is newer than supported version %d", snap.Version, SnapshotVersion)
+	}
+	if err := checkSnapshotIDs(snap); err != nil {
+		return nil, err
+	}
+	for _, t := range snap.Tasks {
+		// Backups written before exports kept secrets masked DSN passwords
+		if t.SQL.Masked() {
+			return nil, fmt.Errorf("task %s: the sql DSN password is masked as %q; restore it in the file or use a ${NAME} reference", t.ID, core.DSNMask)
+		}
+	}
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin import: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &ImportResult{}
+	count := func(res sql.Result, imported *int) error {
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			*imported++
+		} else {
+			result.Skipped++
+		}
+		return nil
+	}
+
+	for _, sc := range snap.Scripts {
+		res, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO scripts (`+scriptColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			sc.ID, sc.Name, sc.Type, sc.Content, nullableString(sc.Description),
+			sc.CreatedAt.UTC().Format(time.RFC3339Nano), sc.UpdatedAt.UTC().Format(time.RFC3339Nano))
+		if err != nil {
+			return nil, fmt.Errorf("import script %s: %w", sc.ID, err)
+		}
+		if err := count(res, &result.Scripts); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, t := range snap.Tasks {
+		task := &core.Task{
+			ID: t.ID, Name: t.Name, Prompt: t.Prompt, Command: t.Command, Cron: t.Cron,
+			TimeoutSeconds: t.TimeoutSeconds, WorkingDir: t.WorkingDir, MinIntervalSeconds: t.MinIntervalSeconds,
+			PauseAfterFailures: t.PauseAfterFailures, LogRetention: t.LogRetention, LogMaxAgeDays: t.LogMaxAgeDays, Executor: t.Executor, HTTP: t.HTTP, SQL: t.SQL, ScriptID: t.ScriptID, RunOnStart: t.RunOnStart, Status: core.TaskStatus(t.Status),
+			PauseUntil: t.PauseUntil, Source: t.Source, LastRunAt: t.LastRunAt, CreatedAt: t.CreatedAt, UpdatedAt: t.UpdatedAt,
+		}
+		res, err := insertTask(ctx, tx, "INSERT OR IGNORE", task)
+		if err != nil {
+			return nil, fmt.Errorf("import task %s: %w", t.ID, err)
+		}
+		if err := count(res, &result.Tasks); err != nil {
+			return nil, err
+		}
+	}
+
+	known := make(map[string]bool)
+	isKnown := func(taskID string) (bool, error) {
+		if ok, seen := known[taskID]; seen {
+			return ok, nil
+		}
+		var n int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(1) FROM tasks WHERE id = ?`, taskID).Scan(&n); err != nil {
+			return false, err
+		}
+		known[taskID] = n > 0
+		return n > 0, nil
+	}
+
+	for _, r := range snap.Runs {
+		ok, err := isKnown(r.TaskID)
+		if err != nil {
+			return nil, fmt.Errorf("import run %s: %w", r.ID, err)
+		}
+		if !ok {
+			result.Skipped++
+			continue
+		}
+		run := &core.Run{
+			ID: r.ID, TaskID: r.TaskID, Status: core.RunStatus(r.Status), TriggerType: core.TriggerType(r.TriggerType),
+			ScheduledAt: r.ScheduledAt, StartedAt: r.StartedAt, EndedAt: r.EndedAt, ExitCode: r.ExitCode,
+			Error: r.Error, Reason: r.Reason, PID: r.PID, MaxRSSKB: r.MaxRSSKB, CPUSeconds: r.CPUSeconds,
+			Note: r.Note, OutputTail: r.OutputTail, Warning: r.Warning, Pinned: r.Pinned, CreatedAt: r.CreatedAt,
+		}
+		if run.TriggerType == "" {
+			run.TriggerType = core.TriggerScheduled
+		}
+		if run.Status == core.RunStatusQueued || run.Status == core.RunStatusRunning {
+			// The process belonged to the exporting machine and cannot be tracked here
+			msg := "in progress when the state was exported"
+			run.Status = core.RunStatusCanceled
+			run.Error = &msg
+			run.PID = nil
+		}
+		res, err := insertRun(ctx, tx, "INSERT OR IGNORE", run)
+		if err != nil {
+			return nil, fmt.Errorf("import run %s: %w", r.ID, err)
+		}
+		before := result.Runs
+		if err := count(res, &result.Runs); err != nil {
+			return nil, err
+		}
+		if result.Runs > before {
+			result.RunIDs = append(result.RunIDs, run.ID)
+		}
+	}
+
+	for _, c := range snap.Comments {
+		ok, err := isKnown(c.TaskID)
+		if err != nil {
+			return nil, fmt.Errorf("import comment %s: %w", c.ID, err)
+		}
+		if !ok {
+			result.Skipped++
+			continue
+		}
+		comment := &core.TaskComment{ID: c.ID, TaskID: c.TaskID, Author: c.Author, Body: c.Body, CreatedAt: c.CreatedAt}
+		res, err := insertTaskComment(ctx, tx, "INSERT OR IGNORE", comment)
+		if err != nil {
+			return nil, fmt.Errorf("import comment %s: %w", c.ID, err)
+		}
+		if err := count(res, &result.Comments); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit import: %w", err)
+	}
+	return result, nil
+}
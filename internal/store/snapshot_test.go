@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+func TestSnapshotRoundTripKeepsSecrets(t *testing.T) {
+	ctx := context.Background()
+	source := openTestStore(t)
+	task := &core.Task{
+		ID: "0123456789abcdef0123456789abcdef", Prompt: "postgres: SELECT 1", Command: "postgres: SELECT 1", Cron: "@daily",
+		Status: core.TaskStatusActive, Executor: ptr(core.ExecutorSQL),
+		SQL:  &core.SQLQuery{Driver: "postgres", DSN: "postgres://report:s3cret@db/app?sslmode=disable", Query: "SELECT 1"},
+		HTTP: &core.HTTPRequest{Method: "GET", URL: "https://example.com", Headers: map[string]string{"Authorization": "Bearer t0ken"}},
+	}
+	if err := source.InsertTask(ctx, task); err != nil {
+		t.Fatalf("InsertTask() error = %v", err)
+	}
+	snap, err := source.ExportSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("ExportSnapshot() error = %v", err)
+	}
+	// Go through JSON as a backup file does
+	data, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Snapshot
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+
+	target := openTestStore(t)
+	if _, err := target.ImportSnapshot(ctx, &restored); err != nil {
+		t.Fatalf("ImportSnapshot() error = %v", err)
+	}
+	got, err := target.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.SQL, task.SQL) || !reflect.DeepEqual(got.HTTP, task.HTTP) {
+		t.Errorf("imported sql = %+v, http = %+v, want %+v, %+v", got.SQL, got.HTTP, task.SQL, task.HTTP)
+	}
+
+	// A backup whose DSN password was masked cannot be imported
+	restored.Tasks[0].ID = "fedcba9876543210fedcba9876543210"
+	restored.Tasks[0].SQL = task.SQL.Redacted()
+	if _, err := openTestStore(t).ImportSnapshot(ctx, &restored); err == nil || !strings.Contains(err.Error(), "masked") {
+		t.Errorf("ImportSnapshot() with a masked DSN error = %v, want masked password", err)
+	}
+}
+
+func TestImportSnapshotRejectsInvalidIDs(t *testing.T) {
+	const taskID = "0123456789abcdef0123456789abcdef"
+	valid := func() *Snapshot {
+		at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		return &Snapshot{
+			Version:  SnapshotVersion,
+			Tasks:    []SnapshotTask{{ID: taskID, Command: "echo", Prompt: "echo", Cron: "* * * * *", Status: "active", CreatedAt: at, UpdatedAt: at}},
+			Runs:     []SnapshotRun{{ID: "01hzy3k8m4q2w6e9r7t5y1v3x0", TaskID: taskID, Status: "succeeded", ScheduledAt: at, CreatedAt: at}},
+			Comments: []SnapshotComment{{ID: "1767225600000000000", TaskID: taskID, Body: "ok", CreatedAt: at}},
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(*Snapshot)
+		want   string
+	}{
+		{name: "run ID escaping the runs directory", modify: func(s *Snapshot) { s.Runs[0].ID = ".." }, want: `invalid run ID ".."`},
+		{name: "run ID with a path", modify: func(s *Snapshot) { s.Runs[0].ID = "../../etc/cron.d/x" }, want: "invalid run ID"},
+		{name: "task ID", modify: func(s *Snapshot) { s.Tasks[0].ID = "task/1" }, want: "invalid task ID"},
+		{name: "comment ID", modify: func(s *Snapshot) { s.Comments[0].ID = "" }, want: "invalid comment ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openTestStore(t)
+			snap := valid()
+			tt.modify(snap)
+			_, err := s.ImportSnapshot(context.Background(), snap)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ImportSnapshot() error = %v, want %q", err, tt.want)
+			}
+			if tasks, _ := s.ListTasks(context.Background(), nil); len(tasks) != 0 {
+				t.Errorf("ImportSnapshot() stored %d tasks from a rejected snapshot", len(tasks))
+			}
+		})
+	}
+
+	s := openTestStore(t)
+	result, err := s.ImportSnapshot(context.Background(), valid())
+	if err != nil {
+		t.Fatalf("ImportSnapshot() of valid IDs error = %v", err)
+	}
+	if result.Tasks != 1 || result.Runs != 1 || result.Comments != 1 {
+		t.Errorf("ImportSnapshot() = %+v, want one task, run and comment", result)
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"embed"
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
+	"clicrontab/internal/clock"
+
 	_ "modernc.org/sqlite"
 )
 
@@ -21,10 +24,41 @@ type Store struct {
 	DB           *sql.DB
 	StateDir     string
 	LogRetention int
+
+	// LogMaxAgeDays removes run logs older than this many days; 0 keeps them regardless
+	// of age. A task's log_max_age_days overrides it.
+	LogMaxAgeDays int
+
+	// MaxFileBytes caps one uploaded file and FilesQuotaBytes all of them together; 0
+	// disables a cap.
+	MaxFileBytes    int64
+	FilesQuotaBytes int64
+
+	logIndex chan logIndexJob // nil unless EnableLogIndex was called
+	clock    clock.Clock
+	filesMu  sync.Mutex // serializes the quota check and replace of uploads
 }
 
 // Open opens the SQLite database located under stateDir and runs migrations.
 func Open(ctx context.Context, stateDir string, logRetention int) (*Store, error) {
+	s, err := OpenUnmigrated(ctx, stateDir, logRetention)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := migrateUp(ctx, s.DB, false); err != nil {
+		s.DB.Close()
+		return nil, err
+	}
+	if err := verifyTaskFields(ctx, s.DB); err != nil {
+		s.DB.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// OpenUnmigrated opens the database without applying pending migrations, for tools that
+// inspect or change the schema version themselves.
+func OpenUnmigrated(ctx context.Context, stateDir string, logRetention int) (*Store, error) {
 	if err := os.MkdirAll(stateDir, 0o755); err != nil {
 		return nil, fmt.Errorf("ensure state dir: %w", err)
 	}
@@ -48,70 +82,42 @@ func Open(ctx context.Context, stateDir string, logRetention int) (*Store, error
 		db.Close()
 		return nil, fmt.Errorf("enable WAL: %w", err)
 	}
-	if err := runMigrations(ctx, db); err != nil {
-		db.Close()
-		return nil, err
-	}
 	return &Store{
 		DB:           db,
 		StateDir:     stateDir,
 		LogRetention: logRetention,
+		clock:        clock.System,
 	}, nil
 }
 
-func runMigrations(ctx context.Context, db *sql.DB) error {
-	if _, err := db.ExecContext(ctx, `
-		CREATE TABLE IF NOT EXISTS schema_migrations (
-			version TEXT PRIMARY KEY,
-			applied_at TEXT NOT NULL
-		);
-	`); err != nil {
-		return fmt.Errorf("create schema_migrations: %w", err)
-	}
-	type mig struct {
-		Version string
-		SQL     string
-	}
-	entries := []mig{
-		{Version: "0001_init", SQL: mustReadMigration("migrations/0001_init.sql")},
-		{Version: "0002_add_working_dir", SQL: mustReadMigration("migrations/0002_add_working_dir.sql")},
-		{Version: "0003_add_prompt", SQL: mustReadMigration("migrations/0003_add_prompt.sql")},
-	}
-	for _, entry := range entries {
-		applied, err := isMigrationApplied(ctx, db, entry.Version)
-		if err != nil {
-			return err
-		}
-		if applied {
-			continue
-		}
-		if _, err := db.ExecContext(ctx, entry.SQL); err != nil {
-			return fmt.Errorf("apply migration %s: %w", entry.Version, err)
-		}
-		if _, err := db.ExecContext(ctx, `INSERT INTO schema_migrations(version, applied_at) VALUES(?, ?)`,
-			entry.Version, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
-			return fmt.Errorf("record migration %s: %w", entry.Version, err)
-		}
-	}
-	return nil
+// SetClock makes the timestamps the store stamps on records follow c instead of the
+// system clock.
+func (s *Store) SetClock(c clock.Clock) {
+	s.clock = clock.OrSystem(c)
 }
 
-func isMigrationApplied(ctx context.Context, db *sql.DB, version string) (bool, error) {
-	var count int
-	err := db.QueryRowContext(ctx, `SELECT COUNT(1) FROM schema_migrations WHERE version = ?`, version).Scan(&count)
-	if errors.Is(err, sql.ErrNoRows) {
-		return false, nil
-	}
-	if err != nil {
-		return false, fmt.Errorf("check migration %s: %w", version, err)
-	}
-	return count > 0, nil
+// now returns the current time in UTC as the store's clock reads it.
+func (s *Store) now() time.Time {
+	return s.clock.Now().UTC()
 }
 
-func mustReadMigration(path string) string {
-	data, err := migrations.ReadFile(path)
+// IntegrityCheck runs SQLite's integrity check and returns its report ("ok" when healthy).
+func (s *Store) IntegrityCheck(ctx context.Context) (string, error) {
+	rows, err := s.DB.QueryContext(ctx, `PRAGMA integrity_check`)
 	if err != nil {
-		panic(fmt.Sprintf("read migration %s: %v", path, err))
+		return "", fmt.Errorf("integrity check: %w", err)
+	}
+	defer rows.Close()
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
 	}
-	return string(data)
+	return strings.Join(lines, "; "), nil
 }
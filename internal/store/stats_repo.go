@@ -0,0 +1,195 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+// TaskRunStats aggregates run outcomes and scheduling lag for one task.
+type TaskRunStats struct {
+	TaskID    string
+	Total     int
+	Succeeded int
+	Failed    int
+	Skipped   int
+	Started   int     // runs with a recorded start, i.e. lag samples
+	AvgLagMS  float64 // mean delay between scheduled_at and started_at
+	MaxLagMS  float64
+	Failures  FailureCounts
+	// ExitCodes counts finished runs by exit code; runs without one are left out.
+	ExitCodes map[int]int
+}
+
+// FailureCounts classifies runs that did not succeed, to tell a task that times out
+// from one that crashes or cannot start.
+type FailureCounts struct {
+	NonzeroExit int // failed with an exit code
+	Timeout     int
+	StartError  int // failed without an exit code: the command never ran or was killed
+	Canceled    int
+}
+
+// lagExpr computes started_at - scheduled_at in milliseconds.
+const lagExpr = `(julianday(started_at) - julianday(scheduled_at)) * 86400000.0`
+
+// RunStatsSince aggregates runs created at or after since, grouped by task. Runs of archived
+// tasks are left out.
+func (s *Store) RunStatsSince(ctx context.Context, since time.Time) ([]*TaskRunStats, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT task_id,
+			COUNT(1),
+			COALESCE(SUM(status = ?), 0),
+			COALESCE(SUM(status IN (?, ?)), 0),
+			COALESCE(SUM(status = ?), 0),
+			COUNT(started_at),
+			AVG(CASE WHEN started_at IS NOT NULL THEN `+lagExpr+` END),
+			MAX(CASE WHEN started_at IS NOT NULL THEN `+lagExpr+` END),
+			COALESCE(SUM(status = ? AND exit_code IS NOT NULL), 0),
+			COALESCE(SUM(status = ?), 0),
+			COALESCE(SUM(status = ? AND exit_code IS NULL), 0),
+			COALESCE(SUM(status = ?), 0)
+		FROM runs
+		WHERE created_at >= ?
+			AND task_id NOT IN (SELECT id FROM tasks WHERE status = ?)
+		GROUP BY task_id
+		ORDER BY task_id
+	`, core.RunStatusSucceeded, core.RunStatusFailed, core.RunStatusTimedOut, core.RunStatusSkipped,
+		core.RunStatusFailed, core.RunStatusTimedOut, core.RunStatusFailed, core.RunStatusCanceled,
+		since.UTC().Format(time.RFC3339Nano), core.TaskStatusArchived)
+	if err != nil {
+		return nil, fmt.Errorf("query run stats: %w", err)
+	}
+	defer rows.Close()
+	var stats []*TaskRunStats
+	byTask := make(map[string]*TaskRunStats)
+	for rows.Next() {
+		var (
+			st     TaskRunStats
+			avgLag sql.NullFloat64
+			maxLag sql.NullFloat64
+		)
+		if err := rows.Scan(&st.TaskID, &st.Total, &st.Succeeded, &st.Failed, &st.Skipped, &st.Started, &avgLag, &maxLag,
+			&st.Failures.NonzeroExit, &st.Failures.Timeout, &st.Failures.StartError, &st.Failures.Canceled); err != nil {
+			return nil, fmt.Errorf("scan run stats: %w", err)
+		}
+		st.AvgLagMS = avgLag.Float64
+		st.MaxLagMS = maxLag.Float64
+		st.ExitCodes = make(map[int]int)
+		stats = append(stats, &st)
+		byTask[st.TaskID] = &st
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	codes, err := s.DB.QueryContext(ctx, `
+		SELECT task_id, exit_code, COUNT(1)
+		FROM runs
+		WHERE created_at >= ? AND exit_code IS NOT NULL
+		GROUP BY task_id, exit_code
+	`, since.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, fmt.Errorf("query exit codes: %w", err)
+	}
+	defer codes.Close()
+	for codes.Next() {
+		var (
+			taskID      string
+			code, count int
+		)
+		if err := codes.Scan(&taskID, &code, &count); err != nil {
+			return nil, fmt.Errorf("scan exit codes: %w", err)
+		}
+		// Tasks missing from byTask are archived
+		if st, ok := byTask[taskID]; ok {
+			st.ExitCodes[code] = count
+		}
+	}
+	return stats, codes.Err()
+}
+
+// SkipCountsSince counts the scheduled triggers of each active task created at or after
+// since, and how many of them were skipped, by reason.
+func (s *Store) SkipCountsSince(ctx context.Context, since time.Time) ([]*core.TaskSkipCounts, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT task_id, CASE WHEN status = ? THEN COALESCE(reason, '') END, COUNT(1)
+		FROM runs
+		WHERE created_at >= ? AND trigger_type = ?
+			AND task_id IN (SELECT id FROM tasks WHERE status = ?)
+		GROUP BY 1, 2
+		ORDER BY 1
+	`, core.RunStatusSkipped, since.UTC().Format(time.RFC3339Nano), core.TriggerScheduled, core.TaskStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("query skip counts: %w", err)
+	}
+	defer rows.Close()
+	var counts []*core.TaskSkipCounts
+	for rows.Next() {
+		var (
+			taskID string
+			reason sql.NullString
+			count  int
+		)
+		if err := rows.Scan(&taskID, &reason, &count); err != nil {
+			return nil, fmt.Errorf("scan skip counts: %w", err)
+		}
+		if len(counts) == 0 || counts[len(counts)-1].TaskID != taskID {
+			counts = append(counts, &core.TaskSkipCounts{TaskID: taskID, Skipped: make(map[string]int)})
+		}
+		current := counts[len(counts)-1]
+		current.Triggers += count
+		if reason.Valid {
+			current.Skipped[reason.String] += count
+		}
+	}
+	return counts, rows.Err()
+}
+
+// DayCounts are a task's finished runs on one calendar day.
+type DayCounts struct {
+	Date      string // YYYY-MM-DD in the location passed to RunDayCounts
+	Succeeded int
+	Failed    int // failed or timed out
+}
+
+// RunDayCounts counts the task's succeeded and failed runs created at or after since by
+// calendar day in loc, oldest first. Days without such runs are left out. Runs are
+// bucketed here rather than in SQL, which only knows UTC days.
+func (s *Store) RunDayCounts(ctx context.Context, taskID string, since time.Time, loc *time.Location) ([]DayCounts, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT created_at, status = ?
+		FROM runs
+		WHERE task_id = ? AND created_at >= ? AND status IN (?, ?, ?)
+		ORDER BY created_at
+	`, core.RunStatusSucceeded, taskID, since.UTC().Format(time.RFC3339Nano),
+		core.RunStatusSucceeded, core.RunStatusFailed, core.RunStatusTimedOut)
+	if err != nil {
+		return nil, fmt.Errorf("query run day counts: %w", err)
+	}
+	defer rows.Close()
+	var days []DayCounts
+	for rows.Next() {
+		var (
+			createdAt string
+			succeeded bool
+		)
+		if err := rows.Scan(&createdAt, &succeeded); err != nil {
+			return nil, fmt.Errorf("scan run day counts: %w", err)
+		}
+		date := mustParseTime(createdAt).In(loc).Format("2006-01-02")
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, DayCounts{Date: date})
+		}
+		if succeeded {
+			days[len(days)-1].Succeeded++
+		} else {
+			days[len(days)-1].Failed++
+		}
+	}
+	return days, rows.Err()
+}
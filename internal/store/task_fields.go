@@ -0,0 +1,289 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+// taskField maps one tasks column to its core.Task field. SELECT, INSERT and UPDATE are
+// all generated from taskFields, so a column added by a migration only needs an entry
+// here; verifyTaskFields refuses to start when one is missing.
+type taskField struct {
+	column string
+	// value returns the argument written to the column.
+	value func(t *core.Task) any
+	// scan returns a destination for the column and a func that copies it into the task.
+	// The funcs run in taskFields order after the row is scanned.
+	scan func() (dest any, apply func(t *core.Task))
+	// updated columns are written by UpdateTask; the rest are set on insert or by
+	// dedicated setters (SetTaskSkipNext, UpdateTaskScheduleInfo), so an edit read
+	// before the executor or scheduler stores a run does not overwrite it.
+	updated bool
+}
+
+var taskFields = []taskField{
+	{column: "id", value: func(t *core.Task) any { return t.ID },
+		scan: stringField(func(t *core.Task, v string) { t.ID = v })},
+	{column: "name", updated: true, value: func(t *core.Task) any { return nullableString(t.Name) },
+		scan: nullStringField(func(t *core.Task, v *string) { t.Name = v })},
+	// command is listed before prompt so prompt can fall back to it
+	{column: "command", updated: true, value: func(t *core.Task) any { return t.Command },
+		scan: stringField(func(t *core.Task, v string) { t.Command = v })},
+	{column: "prompt", updated: true, value: func(t *core.Task) any { return t.Prompt },
+		scan: nullStringField(func(t *core.Task, v *string) {
+			// Tasks created before prompts existed use their command
+			if v != nil {
+				t.Prompt = *v
+			} else {
+				t.Prompt = t.Command
+			}
+		})},
+	{column: "cron", updated: true, value: func(t *core.Task) any { return t.Cron },
+		scan: stringField(func(t *core.Task, v string) { t.Cron = v })},
+	{column: "timeout_seconds", updated: true, value: func(t *core.Task) any { return nullableInt(t.TimeoutSeconds) },
+		scan: nullIntField(func(t *core.Task, v *int) { t.TimeoutSeconds = v })},
+	{column: "working_dir", updated: true, value: func(t *core.Task) any { return nullableString(t.WorkingDir) },
+		scan: nullStringField(func(t *core.Task, v *string) { t.WorkingDir = v })},
+	{column: "min_interval_seconds", updated: true, value: func(t *core.Task) any { return nullableInt(t.MinIntervalSeconds) },
+		scan: nullIntField(func(t *core.Task, v *int) { t.MinIntervalSeconds = v })},
+	{column: "pause_after_failures", updated: true, value: func(t *core.Task) any { return nullableInt(t.PauseAfterFailures) },
+		scan: nullIntField(func(t *core.Task, v *int) { t.PauseAfterFailures = v })},
+	{column: "log_retention", updated: true, value: func(t *core.Task) any { return nullableInt(t.LogRetention) },
+		scan: nullIntField(func(t *core.Task, v *int) { t.LogRetention = v })},
+	{column: "log_max_age_days", updated: true, value: func(t *core.Task) any { return nullableInt(t.LogMaxAgeDays) },
+		scan: nullIntField(func(t *core.Task, v *int) { t.LogMaxAgeDays = v })},
+	{column: "executor", updated: true, value: func(t *core.Task) any { return nullableString(t.Executor) },
+		scan: nullStringField(func(t *core.Task, v *string) { t.Executor = v })},
+	{column: "http_request", updated: true, value: func(t *core.Task) any { return nullableJSON(t.HTTP) },
+		scan: jsonField(func(t *core.Task, v *core.HTTPRequest) { t.HTTP = v })},
+	{column: "sql_query", updated: true, value: func(t *core.Task) any { return nullableJSON(t.SQL) },
+		scan: jsonField(func(t *core.Task, v *core.SQLQuery) { t.SQL = v })},
+	{column: "script_id", updated: true, value: func(t *core.Task) any { return nullableString(t.ScriptID) },
+		scan: nullStringField(func(t *core.Task, v *string) { t.ScriptID = v })},
+	{column: "run_on_start", updated: true, value: func(t *core.Task) any { return t.RunOnStart },
+		scan: boolField(func(t *core.Task, v bool) { t.RunOnStart = v })},
+	{column: "status", updated: true, value: func(t *core.Task) any { return t.Status },
+		scan: stringField(func(t *core.Task, v string) { t.Status = core.TaskStatus(v) })},
+	{column: "pause_until", updated: true, value: func(t *core.Task) any { return nullableTime(t.PauseUntil) },
+		scan: nullTimeField(func(t *core.Task, v *time.Time) { t.PauseUntil = v })},
+	{column: "skip_next_at", value: func(t *core.Task) any { return nullableTime(t.SkipNextAt) },
+		scan: nullTimeField(func(t *core.Task, v *time.Time) { t.SkipNextAt = v })},
+	{column: "source", value: func(t *core.Task) any { return t.Source },
+		scan: stringField(func(t *core.Task, v string) { t.Source = v })},
+	{column: "last_run_at", value: func(t *core.Task) any { return nullableTime(t.LastRunAt) },
+		scan: nullTimeField(func(t *core.Task, v *time.Time) { t.LastRunAt = v })},
+	{column: "next_run_at", value: func(t *core.Task) any { return nullableTime(t.NextRunAt) },
+		scan: nullTimeField(func(t *core.Task, v *time.Time) { t.NextRunAt = v })},
+	{column: "created_at", value: func(t *core.Task) any { return t.CreatedAt.UTC().Format(time.RFC3339Nano) },
+		scan: timeField(func(t *core.Task, v time.Time) { t.CreatedAt = v })},
+	{column: "updated_at", updated: true, value: func(t *core.Task) any { return t.UpdatedAt.UTC().Format(time.RFC3339Nano) },
+		scan: timeField(func(t *core.Task, v time.Time) { t.UpdatedAt = v })},
+}
+
+// taskColumns lists the columns read by scanTask, in scan order.
+var taskColumns = taskColumnList()
+
+// insertTaskSQL and updateTaskSQL are completed by insertTask and UpdateTask.
+var (
+	insertTaskSQL = ` INTO tasks (` + taskColumns + `) VALUES (` + strings.TrimSuffix(strings.Repeat("?, ", len(taskFields)), ", ") + `)`
+	updateTaskSQL = `UPDATE tasks SET ` + taskUpdateAssignments() + ` WHERE id = ?`
+)
+
+func taskColumnList() string {
+	names := make([]string, len(taskFields))
+	for i, f := range taskFields {
+		names[i] = f.column
+	}
+	return strings.Join(names, ", ")
+}
+
+func taskUpdateAssignments() string {
+	var sets []string
+	for _, f := range taskFields {
+		if f.updated {
+			sets = append(sets, f.column+" = ?")
+		}
+	}
+	return strings.Join(sets, ", ")
+}
+
+// taskInsertArgs returns one argument per taskFields entry.
+func taskInsertArgs(task *core.Task) []any {
+	args := make([]any, len(taskFields))
+	for i, f := range taskFields {
+		args[i] = f.value(task)
+	}
+	return args
+}
+
+// taskUpdateArgs returns the arguments for updateTaskSQL, ending with the task ID.
+func taskUpdateArgs(task *core.Task) []any {
+	var args []any
+	for _, f := range taskFields {
+		if f.updated {
+			args = append(args, f.value(task))
+		}
+	}
+	return append(args, task.ID)
+}
+
+func scanTask(scanner interface {
+	Scan(dest ...any) error
+}) (*core.Task, error) {
+	dests := make([]any, len(taskFields))
+	applies := make([]func(*core.Task), len(taskFields))
+	for i, f := range taskFields {
+		dests[i], applies[i] = f.scan()
+	}
+	if err := scanner.Scan(dests...); err != nil {
+		return nil, fmt.Errorf("scan task: %w", err)
+	}
+	task := &core.Task{}
+	for _, apply := range applies {
+		apply(task)
+	}
+	return task, nil
+}
+
+// verifyTaskFields fails when the tasks table has a column taskFields does not map,
+// which would otherwise be silently dropped by every read and rewrite, or lacks one it
+// does.
+func verifyTaskFields(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, `SELECT name FROM pragma_table_info('tasks')`)
+	if err != nil {
+		return fmt.Errorf("inspect tasks table: %w", err)
+	}
+	defer rows.Close()
+	mapped := make(map[string]bool, len(taskFields))
+	for _, f := range taskFields {
+		mapped[f.column] = true
+	}
+	var unmapped, missing []string
+	present := make(map[string]bool, len(taskFields))
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		present[name] = true
+		if !mapped[name] {
+			unmapped = append(unmapped, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, f := range taskFields {
+		if !present[f.column] {
+			missing = append(missing, f.column)
+		}
+	}
+	if len(unmapped) > 0 {
+		return fmt.Errorf("tasks columns not mapped in taskFields: %s", strings.Join(unmapped, ", "))
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("taskFields columns missing from the tasks table: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func stringField(set func(*core.Task, string)) func() (any, func(*core.Task)) {
+	return func() (any, func(*core.Task)) {
+		var v string
+		return &v, func(t *core.Task) { set(t, v) }
+	}
+}
+
+func nullStringField(set func(*core.Task, *string)) func() (any, func(*core.Task)) {
+	return func() (any, func(*core.Task)) {
+		var v sql.NullString
+		return &v, func(t *core.Task) {
+			if v.Valid {
+				set(t, &v.String)
+			} else {
+				set(t, nil)
+			}
+		}
+	}
+}
+
+func nullIntField(set func(*core.Task, *int)) func() (any, func(*core.Task)) {
+	return func() (any, func(*core.Task)) {
+		var v sql.NullInt64
+		return &v, func(t *core.Task) {
+			if v.Valid {
+				val := int(v.Int64)
+				set(t, &val)
+			} else {
+				set(t, nil)
+			}
+		}
+	}
+}
+
+func boolField(set func(*core.Task, bool)) func() (any, func(*core.Task)) {
+	return func() (any, func(*core.Task)) {
+		var v bool
+		return &v, func(t *core.Task) { set(t, v) }
+	}
+}
+
+// nullableJSON encodes an executor configuration stored as JSON; nil is NULL.
+func nullableJSON[T any](v *T) any {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+// jsonField leaves an undecodable configuration unset; the run then fails saying it is
+// missing.
+func jsonField[T any](set func(*core.Task, *T)) func() (any, func(*core.Task)) {
+	return func() (any, func(*core.Task)) {
+		var v sql.NullString
+		return &v, func(t *core.Task) {
+			var decoded T
+			if !v.Valid || json.Unmarshal([]byte(v.String), &decoded) != nil {
+				set(t, nil)
+				return
+			}
+			set(t, &decoded)
+		}
+	}
+}
+
+// timeField and nullTimeField leave unparsable timestamps at their zero value.
+func timeField(set func(*core.Task, time.Time)) func() (any, func(*core.Task)) {
+	return func() (any, func(*core.Task)) {
+		var v string
+		return &v, func(t *core.Task) {
+			if parsed, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				set(t, parsed)
+			}
+		}
+	}
+}
+
+func nullTimeField(set func(*core.Task, *time.Time)) func() (any, func(*core.Task)) {
+	return func() (any, func(*core.Task)) {
+		var v sql.NullString
+		return &v, func(t *core.Task) {
+			if !v.Valid {
+				return
+			}
+			if parsed, err := time.Parse(time.RFC3339Nano, v.String); err == nil {
+				set(t, &parsed)
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(context.Background(), t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { s.DB.Close() })
+	return s
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// fullTask sets every field taskFields maps.
+func fullTask(id string) *core.Task {
+	at := func(hour int) *time.Time {
+		return ptr(time.Date(2026, 2, 28, hour, 30, 15, 123456789, time.UTC))
+	}
+	minRows := 1
+	return &core.Task{
+		ID:                 id,
+		Name:               ptr("nightly report " + id),
+		Prompt:             "summarise {task.name}",
+		Command:            "claude -p 'summarise {task.name}'",
+		Cron:               "30 2 * * *",
+		TimeoutSeconds:     ptr(600),
+		WorkingDir:         ptr("/srv/reports/{date \"2006-01\"}"),
+		MinIntervalSeconds: ptr(60),
+		PauseAfterFailures: ptr(3),
+		LogRetention:       ptr(10),
+		LogMaxAgeDays:      ptr(30),
+		Executor:           ptr(core.ExecutorSQL),
+		HTTP:               &core.HTTPRequest{Method: "POST", URL: "https://example.com/hook", Headers: map[string]string{"X-Token": "t"}, Body: "{}", ExpectStatus: []int{200, 204}},
+		SQL:                &core.SQLQuery{Driver: "sqlite", DSN: ":memory:", Query: "SELECT 1", MinRows: &minRows, ExpectValue: ptr("1")},
+		ScriptID:           ptr("script-1"),
+		RunOnStart:         true,
+		Status:             core.TaskStatusPaused,
+		PauseUntil:         at(1),
+		SkipNextAt:         at(2),
+		Source:             core.TaskSourceFile,
+		LastRunAt:          at(3),
+		NextRunAt:          at(4),
+	}
+}
+
+func TestFullTaskSetsEveryColumn(t *testing.T) {
+	task := fullTask("t1")
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = time.Now()
+	for _, f := range taskFields {
+		if v := f.value(task); v == nil || reflect.ValueOf(v).IsZero() {
+			t.Errorf("fullTask leaves column %s unset; extend it along with taskFields", f.column)
+		}
+	}
+}
+
+func TestTaskRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	if _, err := s.DB.ExecContext(ctx, `INSERT INTO scripts (`+scriptColumns+`) VALUES ('script-1', 'report', 'bash', 'echo', NULL, '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`); err != nil {
+		t.Fatalf("insert script: %v", err)
+	}
+
+	full := fullTask("t1")
+	if err := s.InsertTask(ctx, full); err != nil {
+		t.Fatalf("InsertTask() error = %v", err)
+	}
+	got, err := s.GetTask(ctx, full.ID)
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, full) {
+		t.Errorf("GetTask() after insert =\n%+v\nwant\n%+v", got, full)
+	}
+
+	// Nullable columns read back as nil
+	bare := &core.Task{ID: "t2", Prompt: "echo hi", Command: "echo hi", Cron: "* * * * *", Status: core.TaskStatusActive}
+	if err := s.InsertTask(ctx, bare); err != nil {
+		t.Fatalf("InsertTask() error = %v", err)
+	}
+	if got, err := s.GetTask(ctx, bare.ID); err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	} else if !reflect.DeepEqual(got, bare) {
+		t.Errorf("GetTask() of bare task =\n%+v\nwant\n%+v", got, bare)
+	}
+
+	// UpdateTask writes the edited columns, and leaves the run times and skip mark to
+	// their own setters
+	updated := fullTask("t1")
+	updated.Name = ptr("renamed")
+	updated.Prompt = "new prompt"
+	updated.Command = "echo new"
+	updated.Cron = "0 * * * *"
+	updated.TimeoutSeconds = nil
+	updated.WorkingDir = ptr("/tmp")
+	updated.MinIntervalSeconds = nil
+	updated.PauseAfterFailures = ptr(5)
+	updated.LogRetention = nil
+	updated.LogMaxAgeDays = ptr(7)
+	updated.Executor = nil
+	updated.HTTP = nil
+	updated.SQL = &core.SQLQuery{Driver: "postgres", DSN: "postgres://db/x", Query: "SELECT 2"}
+	updated.ScriptID = nil
+	updated.RunOnStart = false
+	updated.Status = core.TaskStatusActive
+	updated.PauseUntil = nil
+	updated.SkipNextAt = nil
+	updated.Source = ""
+	updated.LastRunAt = nil
+	updated.NextRunAt = nil
+	updated.CreatedAt = full.CreatedAt
+	if err := s.UpdateTask(ctx, updated); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+	want := *updated
+	want.SkipNextAt, want.Source = full.SkipNextAt, full.Source
+	want.LastRunAt, want.NextRunAt = full.LastRunAt, full.NextRunAt
+	if got, err := s.GetTask(ctx, full.ID); err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	} else if !reflect.DeepEqual(got, &want) {
+		t.Errorf("GetTask() after update =\n%+v\nwant\n%+v", got, &want)
+	}
+
+	tasks, err := s.ListTasks(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListTasks() error = %v", err)
+	}
+	byID := make(map[string]*core.Task)
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	if !reflect.DeepEqual(byID["t1"], &want) || !reflect.DeepEqual(byID["t2"], bare) {
+		t.Errorf("ListTasks() = %+v, want the updated and bare tasks", tasks)
+	}
+}
+
+func TestVerifyTaskFieldsDetectsSchemaMismatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		alter string
+		want  string
+	}{
+		{name: "column not in taskFields", alter: `ALTER TABLE tasks ADD COLUMN extra TEXT`, want: "not mapped in taskFields: extra"},
+		{name: "taskFields column not in table", alter: `ALTER TABLE tasks DROP COLUMN run_on_start`, want: "missing from the tasks table: run_on_start"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := openTestStore(t)
+			if err := verifyTaskFields(ctx, s.DB); err != nil {
+				t.Fatalf("verifyTaskFields() on the migrated schema error = %v", err)
+			}
+			if _, err := s.DB.ExecContext(ctx, tt.alter); err != nil {
+				t.Fatalf("%s: %v", tt.alter, err)
+			}
+			err := verifyTaskFields(ctx, s.DB)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("verifyTaskFields() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
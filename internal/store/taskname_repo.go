@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// uniqueTaskNameIndex is the partial index that enforces unique task names when
+// CLICRON_UNIQUE_TASK_NAMES is on. It is managed at startup rather than by a migration
+// so the option can be switched either way.
+const uniqueTaskNameIndex = "idx_tasks_name_unique"
+
+// SetUniqueTaskNames creates or drops the unique index on non-empty task names. Enabling
+// fails, leaving names unconstrained, when existing tasks already share a name.
+func (s *Store) SetUniqueTaskNames(ctx context.Context, enabled bool) error {
+	if !enabled {
+		if _, err := s.DB.ExecContext(ctx, `DROP INDEX IF EXISTS `+uniqueTaskNameIndex); err != nil {
+			return fmt.Errorf("drop unique task name index: %w", err)
+		}
+		return nil
+	}
+	dups, err := s.duplicateTaskNames(ctx)
+	if err != nil {
+		return err
+	}
+	if len(dups) > 0 {
+		return fmt.Errorf("task names used by several tasks, rename them first: %s", strings.Join(dups, ", "))
+	}
+	if _, err := s.DB.ExecContext(ctx, `
+		CREATE UNIQUE INDEX IF NOT EXISTS `+uniqueTaskNameIndex+` ON tasks(name) WHERE name IS NOT NULL AND name <> ''
+	`); err != nil {
+		return fmt.Errorf("create unique task name index: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) duplicateTaskNames(ctx context.Context) ([]string, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT name FROM tasks WHERE name IS NOT NULL AND name <> ''
+		GROUP BY name HAVING COUNT(1) > 1 ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("find duplicate task names: %w", err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	return names, rows.Err()
+}
+
+// isTaskNameConflict reports whether err is a violation of the unique task name index.
+func isTaskNameConflict(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: tasks.name")
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"clicrontab/internal/core"
+)
+
+// minTaskIDPrefix is the shortest ID prefix ResolveTaskRef accepts.
+const minTaskIDPrefix = 4
+
+// maxRefMatches bounds how many candidates an ambiguity error lists.
+const maxRefMatches = 10
+
+// ResolveTaskRef turns a task reference into a task ID. A reference is a full task ID,
+// a task name, or a unique ID prefix of at least minTaskIDPrefix characters, tried in
+// that order. It returns ErrTaskNotFound when nothing matches and an
+// *core.AmbiguousTaskRefError when a name or prefix fits several tasks.
+func (s *Store) ResolveTaskRef(ctx context.Context, ref string) (string, error) {
+	if ref == "" {
+		return "", ErrTaskNotFound
+	}
+	var id string
+	err := s.DB.QueryRowContext(ctx, `SELECT id FROM tasks WHERE id = ?`, ref).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("resolve task %q: %w", ref, err)
+	}
+
+	ids, err := s.taskIDs(ctx, `SELECT id FROM tasks WHERE name = ? ORDER BY created_at LIMIT ?`, ref, maxRefMatches)
+	if err != nil {
+		return "", err
+	}
+	if len(ids) == 0 && len(ref) >= minTaskIDPrefix {
+		ids, err = s.taskIDs(ctx, `SELECT id FROM tasks WHERE substr(id, 1, ?) = ? ORDER BY created_at LIMIT ?`, len(ref), ref, maxRefMatches)
+		if err != nil {
+			return "", err
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", ErrTaskNotFound
+	case 1:
+		return ids[0], nil
+	default:
+		return "", &core.AmbiguousTaskRefError{Ref: ref, Matches: ids}
+	}
+}
+
+func (s *Store) taskIDs(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("resolve task reference: %w", err)
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
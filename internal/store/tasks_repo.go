@@ -10,33 +10,48 @@ import (
 	"clicrontab/internal/core"
 )
 
-var ErrTaskNotFound = errors.New("task not found")
+// ErrTaskNotFound is core.ErrTaskNotFound, so the scheduler can recognise it.
+var ErrTaskNotFound = core.ErrTaskNotFound
 
 func (s *Store) InsertTask(ctx context.Context, task *core.Task) error {
-	now := time.Now().UTC()
+	return createTask(ctx, s.DB, task, s.now())
+}
+
+// createTask stamps the task's timestamps with now and inserts it.
+func createTask(ctx context.Context, db execer, task *core.Task, now time.Time) error {
 	task.CreatedAt = now
 	task.UpdatedAt = now
-	_, err := s.DB.ExecContext(ctx, `
-		INSERT INTO tasks (id, name, prompt, command, cron, timeout_seconds, working_dir, status, last_run_at, next_run_at, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, task.ID, nullableString(task.Name), nullableString(&task.Prompt), task.Command, task.Cron, nullableInt(task.TimeoutSeconds), nullableString(task.WorkingDir),
-		task.Status, nullableTime(task.LastRunAt), nullableTime(task.NextRunAt),
-		task.CreatedAt.Format(time.RFC3339Nano), task.UpdatedAt.Format(time.RFC3339Nano))
-	if err != nil {
+	if _, err := insertTask(ctx, db, "INSERT", task); err != nil {
+		if isTaskNameConflict(err) {
+			return core.ErrTaskNameTaken
+		}
 		return fmt.Errorf("insert task: %w", err)
 	}
 	return nil
 }
 
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// insertTask writes the task as-is, including its timestamps; verb is "INSERT" or
+// "INSERT OR IGNORE".
+func insertTask(ctx context.Context, db execer, verb string, task *core.Task) (sql.Result, error) {
+	return db.ExecContext(ctx, verb+insertTaskSQL, taskInsertArgs(task)...)
+}
+
 func (s *Store) UpdateTask(ctx context.Context, task *core.Task) error {
-	task.UpdatedAt = time.Now().UTC()
-	res, err := s.DB.ExecContext(ctx, `
-		UPDATE tasks
-		SET name = ?, prompt = ?, command = ?, cron = ?, timeout_seconds = ?, working_dir = ?, status = ?, last_run_at = ?, next_run_at = ?, updated_at = ?
-		WHERE id = ?
-	`, nullableString(task.Name), nullableString(&task.Prompt), task.Command, task.Cron, nullableInt(task.TimeoutSeconds), nullableString(task.WorkingDir), task.Status,
-		nullableTime(task.LastRunAt), nullableTime(task.NextRunAt), task.UpdatedAt.Format(time.RFC3339Nano), task.ID)
+	return updateTask(ctx, s.DB, task, s.now())
+}
+
+func updateTask(ctx context.Context, db execer, task *core.Task, now time.Time) error {
+	task.UpdatedAt = now
+	res, err := db.ExecContext(ctx, updateTaskSQL, taskUpdateArgs(task)...)
 	if err != nil {
+		if isTaskNameConflict(err) {
+			return core.ErrTaskNameTaken
+		}
 		return fmt.Errorf("update task: %w", err)
 	}
 	rows, err := res.RowsAffected()
@@ -50,7 +65,11 @@ func (s *Store) UpdateTask(ctx context.Context, task *core.Task) error {
 }
 
 func (s *Store) DeleteTask(ctx context.Context, id string) error {
-	res, err := s.DB.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, id)
+	return deleteTask(ctx, s.DB, id)
+}
+
+func deleteTask(ctx context.Context, db execer, id string) error {
+	res, err := db.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, id)
 	if err != nil {
 		return fmt.Errorf("delete task: %w", err)
 	}
@@ -66,7 +85,7 @@ func (s *Store) DeleteTask(ctx context.Context, id string) error {
 
 func (s *Store) GetTask(ctx context.Context, id string) (*core.Task, error) {
 	row := s.DB.QueryRowContext(ctx, `
-		SELECT id, name, prompt, command, cron, timeout_seconds, working_dir, status, last_run_at, next_run_at, created_at, updated_at
+		SELECT `+taskColumns+`
 		FROM tasks WHERE id = ?
 	`, id)
 	task, err := scanTask(row)
@@ -79,22 +98,25 @@ func (s *Store) GetTask(ctx context.Context, id string) (*core.Task, error) {
 	return task, nil
 }
 
+// ListTasks returns tasks with the given status, newest first. A nil status lists every task
+// except archived ones.
 func (s *Store) ListTasks(ctx context.Context, status *core.TaskStatus) ([]*core.Task, error) {
 	var rows *sql.Rows
 	var err error
 	if status != nil {
 		rows, err = s.DB.QueryContext(ctx, `
-			SELECT id, name, prompt, command, cron, timeout_seconds, working_dir, status, last_run_at, next_run_at, created_at, updated_at
+			SELECT `+taskColumns+`
 			FROM tasks
 			WHERE status = ?
 			ORDER BY created_at DESC
 		`, *status)
 	} else {
 		rows, err = s.DB.QueryContext(ctx, `
-			SELECT id, name, prompt, command, cron, timeout_seconds, working_dir, status, last_run_at, next_run_at, created_at, updated_at
+			SELECT `+taskColumns+`
 			FROM tasks
+			WHERE status != ?
 			ORDER BY created_at DESC
-		`)
+		`, core.TaskStatusArchived)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("query tasks: %w", err)
@@ -114,12 +136,38 @@ func (s *Store) ListTasks(ctx context.Context, status *core.TaskStatus) ([]*core
 	return tasks, nil
 }
 
+// TaskCounts returns how many tasks there are in each status.
+func (s *Store) TaskCounts(ctx context.Context) (map[core.TaskStatus]int, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT status, COUNT(1) FROM tasks GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("count tasks: %w", err)
+	}
+	defer rows.Close()
+	counts := map[core.TaskStatus]int{
+		core.TaskStatusActive:   0,
+		core.TaskStatusPaused:   0,
+		core.TaskStatusError:    0,
+		core.TaskStatusArchived: 0,
+	}
+	for rows.Next() {
+		var (
+			status string
+			n      int
+		)
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[core.TaskStatus(status)] = n
+	}
+	return counts, rows.Err()
+}
+
 func (s *Store) UpdateTaskScheduleInfo(ctx context.Context, id string, lastRunAt, nextRunAt *time.Time) error {
 	_, err := s.DB.ExecContext(ctx, `
 		UPDATE tasks
 		SET last_run_at = ?, next_run_at = ?, updated_at = ?
 		WHERE id = ?
-	`, nullableTime(lastRunAt), nullableTime(nextRunAt), time.Now().UTC().Format(time.RFC3339Nano), id)
+	`, nullableTime(lastRunAt), nullableTime(nextRunAt), s.now().Format(time.RFC3339Nano), id)
 	if err != nil {
 		return fmt.Errorf("update task schedule info: %w", err)
 	}
@@ -131,92 +179,62 @@ func (s *Store) UpdateTaskNextRun(ctx context.Context, id string, nextRunAt *tim
 		UPDATE tasks
 		SET next_run_at = ?, updated_at = ?
 		WHERE id = ?
-	`, nullableTime(nextRunAt), time.Now().UTC().Format(time.RFC3339Nano), id)
+	`, nullableTime(nextRunAt), s.now().Format(time.RFC3339Nano), id)
 	if err != nil {
 		return fmt.Errorf("update next_run_at: %w", err)
 	}
 	return nil
 }
 
+// SetTaskSkipNext sets or clears (nil) the occurrence to skip. It is kept out of
+// UpdateTask so edits to a task do not race with the scheduler consuming the mark.
+func (s *Store) SetTaskSkipNext(ctx context.Context, id string, at *time.Time) error {
+	res, err := s.DB.ExecContext(ctx, `UPDATE tasks SET skip_next_at = ? WHERE id = ?`, nullableTime(at), id)
+	if err != nil {
+		return fmt.Errorf("update skip_next_at: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+// UpdateTaskStatus sets the task status and clears any pause_until, so a pause made
+// through it is indefinite.
 func (s *Store) UpdateTaskStatus(ctx context.Context, id string, status core.TaskStatus) error {
 	_, err := s.DB.ExecContext(ctx, `
 		UPDATE tasks
-		SET status = ?, updated_at = ?
+		SET status = ?, pause_until = NULL, updated_at = ?
 		WHERE id = ?
-	`, status, time.Now().UTC().Format(time.RFC3339Nano), id)
+	`, status, s.now().Format(time.RFC3339Nano), id)
 	if err != nil {
 		return fmt.Errorf("update task status: %w", err)
 	}
 	return nil
 }
 
-func scanTask(scanner interface {
-	Scan(dest ...any) error
-}) (*core.Task, error) {
-	var (
-		id         string
-		name       sql.NullString
-		prompt     sql.NullString
-		command    string
-		cronExpr   string
-		timeout    sql.NullInt64
-		workingDir sql.NullString
-		status     string
-		lastRun    sql.NullString
-		nextRun    sql.NullString
-		createdAt  string
-		updatedAt  string
-	)
-	if err := scanner.Scan(&id, &name, &prompt, &command, &cronExpr, &timeout, &workingDir, &status, &lastRun, &nextRun, &createdAt, &updatedAt); err != nil {
-		return nil, fmt.Errorf("scan task: %w", err)
-	}
-	task := &core.Task{
-		ID:      id,
-		Prompt:  command, // For backward compatibility: if prompt is null, use command
-		Command: command,
-		Cron:    cronExpr,
-		Status:  core.TaskStatus(status),
-	}
-	if prompt.Valid {
-		task.Prompt = prompt.String
-	}
-	if name.Valid {
-		task.Name = &name.String
-	}
-	if timeout.Valid {
-		val := int(timeout.Int64)
-		task.TimeoutSeconds = &val
-	}
-	if workingDir.Valid {
-		task.WorkingDir = &workingDir.String
-	}
-	if lastRun.Valid {
-		if t, err := time.Parse(time.RFC3339Nano, lastRun.String); err == nil {
-			task.LastRunAt = &t
-		}
-	}
-	if nextRun.Valid {
-		if t, err := time.Parse(time.RFC3339Nano, nextRun.String); err == nil {
-			task.NextRunAt = &t
-		}
-	}
-	if t, err := time.Parse(time.RFC3339Nano, createdAt); err == nil {
-		task.CreatedAt = t
+func nullableString(value *string) any {
+	if value == nil {
+		return nil
 	}
-	if t, err := time.Parse(time.RFC3339Nano, updatedAt); err == nil {
-		task.UpdatedAt = t
+	return *value
+}
+
+func nullableInt(value *int) any {
+	if value == nil {
+		return nil
 	}
-	return task, nil
+	return *value
 }
 
-func nullableString(value *string) any {
+func nullableInt64(value *int64) any {
 	if value == nil {
 		return nil
 	}
 	return *value
 }
 
-func nullableInt(value *int) any {
+func nullableFloat(value *float64) any {
 	if value == nil {
 		return nil
 	}
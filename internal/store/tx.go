@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"clicrontab/internal/core"
+)
+
+// Tx is a task mutation in progress. Funcs registered with OnCommit run after the
+// transaction commits, and never when it rolls back, so side effects such as
+// (re)scheduling only ever follow state that was actually stored.
+//
+// The database has a single connection: inside InTx use only the Tx methods, never
+// the Store, or the call blocks until the transaction ends.
+type Tx struct {
+	tx       *sql.Tx
+	now      time.Time // when the transaction began, stamped on the records it writes
+	onCommit []func()
+}
+
+// InTx runs fn in a transaction, committing when it returns nil and rolling back
+// otherwise. OnCommit funcs run in registration order once the commit succeeds.
+func (s *Store) InTx(ctx context.Context, fn func(tx *Tx) error) error {
+	sqlTx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	tx := &Tx{tx: sqlTx, now: s.now()}
+	if err := fn(tx); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	for _, f := range tx.onCommit {
+		f()
+	}
+	return nil
+}
+
+// OnCommit registers f to run after the transaction commits.
+func (t *Tx) OnCommit(f func()) {
+	t.onCommit = append(t.onCommit, f)
+}
+
+// GetTask reads a task inside the transaction.
+func (t *Tx) GetTask(ctx context.Context, id string) (*core.Task, error) {
+	task, err := scanTask(t.tx.QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTaskNotFound
+	}
+	return task, err
+}
+
+// GetScript is Store.GetScript inside the transaction.
+func (t *Tx) GetScript(ctx context.Context, id string) (*core.Script, error) {
+	script, err := scanScript(t.tx.QueryRowContext(ctx, `SELECT `+scriptColumns+` FROM scripts WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrScriptNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get script: %w", err)
+	}
+	return script, nil
+}
+
+// InsertTask is Store.InsertTask inside the transaction.
+func (t *Tx) InsertTask(ctx context.Context, task *core.Task) error {
+	return createTask(ctx, t.tx, task, t.now)
+}
+
+// UpdateTask is Store.UpdateTask inside the transaction.
+func (t *Tx) UpdateTask(ctx context.Context, task *core.Task) error {
+	return updateTask(ctx, t.tx, task, t.now)
+}
+
+// DeleteTask is Store.DeleteTask inside the transaction.
+func (t *Tx) DeleteTask(ctx context.Context, id string) error {
+	return deleteTask(ctx, t.tx, id)
+}
@@ -0,0 +1,36 @@
+// Package systemd implements the parts of the systemd service protocol the daemon uses:
+// socket activation (LISTEN_FDS) and readiness notification (NOTIFY_SOCKET).
+package systemd
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd (SD_LISTEN_FDS_START).
+const listenFdsStart = 3
+
+// activationFds returns the number of sockets passed to this process and their names,
+// and clears the activation variables so child processes do not inherit them.
+func activationFds() (int, []string) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return 0, nil
+	}
+	var names []string
+	if raw := os.Getenv("LISTEN_FDNAMES"); raw != "" {
+		names = strings.Split(raw, ":")
+	}
+	return count, names
+}
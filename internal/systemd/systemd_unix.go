@@ -0,0 +1,60 @@
+//go:build !windows
+
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// Listeners returns the listening sockets passed by systemd socket activation, or nil
+// when the process was not socket activated. Systemd keeps the socket open across
+// daemon restarts, so connections queue instead of being refused while a new daemon
+// starts, and a .socket unit can start the daemon on the first connection.
+func Listeners() ([]net.Listener, error) {
+	count, names := activationFds()
+	listeners := make([]net.Listener, 0, count)
+	for i := 0; i < count; i++ {
+		fd := listenFdsStart + i
+		syscall.CloseOnExec(fd)
+
+		name := fmt.Sprintf("LISTEN_FD_%d", fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(file)
+		file.Close() // FileListener dups the descriptor
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("socket %s: %w", name, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+// Notify sends a state string such as "READY=1" to the service manager. It is a no-op
+// when NOTIFY_SOCKET is unset (not running under a Type=notify unit).
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:] // abstract namespace
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("dial notify socket: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("write notify socket: %w", err)
+	}
+	return nil
+}
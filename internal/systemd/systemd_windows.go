@@ -0,0 +1,16 @@
+//go:build windows
+
+package systemd
+
+import "net"
+
+// Listeners always returns nil on Windows, which has no socket activation.
+func Listeners() ([]net.Listener, error) {
+	activationFds()
+	return nil, nil
+}
+
+// Notify is a no-op on Windows.
+func Notify(state string) error {
+	return nil
+}
@@ -0,0 +1,161 @@
+package taskfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"clicrontab/internal/core"
+)
+
+// Action is what a sync does to one task.
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionArchive Action = "archive"
+)
+
+// ErrUnmanagedTask is matched by Plan errors for a spec whose name is already used by a
+// task created through the API or MCP.
+var ErrUnmanagedTask = errors.New("name is used by a task not managed by the tasks file")
+
+// Change is one step of a sync.
+type Change struct {
+	Action Action
+	Name   string
+	// Task is the task as it will be stored.
+	Task *core.Task
+	// Current is the stored task before the change; nil for creates.
+	Current *core.Task
+	// Fields lists what an update changes.
+	Fields []FieldChange
+}
+
+// FieldChange is one setting an update changes, named by its tasks file key (status
+// for paused). Unset optional settings are nil.
+type FieldChange struct {
+	Field string
+	Old   any
+	New   any
+}
+
+// Plan compares the tasks file with the stored tasks and returns the changes that make
+// the database match the file: specs without a managed task are created, managed tasks
+// that differ from their spec are updated, and managed tasks no longer in the file are
+// archived (keeping their run history). Tasks created through the API or MCP are never
+// touched; a spec whose name one of them uses is an error.
+func Plan(file *File, tasks []*core.Task) ([]Change, error) {
+	managed := make(map[string]*core.Task)
+	unmanaged := make(map[string]*core.Task)
+	for _, task := range tasks {
+		if task.Name == nil {
+			continue
+		}
+		if task.Source == core.TaskSourceFile {
+			// Prefer the live task if an archived one has the same name
+			if prev, ok := managed[*task.Name]; !ok || prev.Status == core.TaskStatusArchived {
+				managed[*task.Name] = task
+			}
+		} else {
+			unmanaged[*task.Name] = task
+		}
+	}
+
+	var changes []Change
+	declared := make(map[string]bool)
+	for _, spec := range file.Tasks {
+		declared[spec.Name] = true
+		current, ok := managed[spec.Name]
+		if !ok {
+			if other, taken := unmanaged[spec.Name]; taken {
+				return nil, fmt.Errorf("task %q: %w (task %s); rename or delete one of them", spec.Name, ErrUnmanagedTask, other.ID)
+			}
+			changes = append(changes, Change{Action: ActionCreate, Name: spec.Name, Task: spec.task(nil)})
+			continue
+		}
+		desired := spec.task(current)
+		if fields := diffTask(current, desired); len(fields) > 0 {
+			changes = append(changes, Change{Action: ActionUpdate, Name: spec.Name, Task: desired, Current: current, Fields: fields})
+		}
+	}
+
+	names := make([]string, 0, len(managed))
+	for name := range managed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		current := managed[name]
+		if declared[name] || current.Status == core.TaskStatusArchived {
+			continue
+		}
+		archived := *current
+		archived.Status = core.TaskStatusArchived
+		archived.PauseUntil = nil
+		archived.NextRunAt = nil
+		changes = append(changes, Change{Action: ActionArchive, Name: name, Task: &archived, Current: current})
+	}
+	return changes, nil
+}
+
+// taskFileFields reads the settings the tasks file controls from a task; Plan diffs
+// tasks on them. shown, when set, is what a change reports instead of value.
+var taskFileFields = []struct {
+	key   string
+	value func(t *core.Task) any
+	shown func(t *core.Task) any
+}{
+	{"command", func(t *core.Task) any { return t.Command }, nil},
+	{"cron", func(t *core.Task) any { return t.Cron }, nil},
+	{"working_dir", func(t *core.Task) any { return deref(t.WorkingDir) }, nil},
+	{"timeout_s", func(t *core.Task) any { return deref(t.TimeoutSeconds) }, nil},
+	{"min_interval_s", func(t *core.Task) any { return deref(t.MinIntervalSeconds) }, nil},
+	{"pause_after_failures", func(t *core.Task) any { return deref(t.PauseAfterFailures) }, nil},
+	{"log_retention", func(t *core.Task) any { return deref(t.LogRetention) }, nil},
+	{"log_max_age_days", func(t *core.Task) any { return deref(t.LogMaxAgeDays) }, nil},
+	{"executor", func(t *core.Task) any { return t.ExecutorName() }, nil},
+	{"http", func(t *core.Task) any { return configKey(t.HTTP) }, nil},
+	// The plan is returned to clients, so it shows the DSN without its password
+	{"sql", func(t *core.Task) any { return configKey(t.SQL) }, func(t *core.Task) any { return configKey(t.SQL.Redacted()) }},
+	{"script_id", func(t *core.Task) any { return deref(t.ScriptID) }, nil},
+	{"run_on_start", func(t *core.Task) any { return t.RunOnStart }, nil},
+	// status rather than paused, so restoring an archived task shows up too
+	{"status", func(t *core.Task) any { return string(t.Status) }, nil},
+}
+
+// diffTask lists the settings that differ between two versions of a task.
+func diffTask(current, desired *core.Task) []FieldChange {
+	var fields []FieldChange
+	for _, field := range taskFileFields {
+		before, after := field.value(current), field.value(desired)
+		if before == after {
+			continue
+		}
+		if field.shown != nil {
+			before, after = field.shown(current), field.shown(desired)
+		}
+		fields = append(fields, FieldChange{Field: field.key, Old: before, New: after})
+	}
+	return fields
+}
+
+// configKey encodes an executor configuration so configurations compare by content;
+// nil for none.
+func configKey[T any](config *T) any {
+	if config == nil {
+		return nil
+	}
+	data, _ := json.Marshal(config)
+	return string(data)
+}
+
+// deref returns *v, or nil for an unset pointer, so unset and set values compare unequal.
+func deref[T any](v *T) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
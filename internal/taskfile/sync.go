@@ -0,0 +1,165 @@
+package taskfile
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"clicrontab/internal/core"
+	"clicrontab/internal/store"
+)
+
+// Syncer applies the tasks file to the database at startup and whenever the file's
+// content changes.
+type Syncer struct {
+	path      string
+	interval  time.Duration
+	store     *store.Store
+	scheduler *core.Scheduler
+	logger    *slog.Logger
+
+	// applyMu serializes syncs from the file watcher and the API
+	applyMu sync.Mutex
+
+	mu      sync.Mutex
+	lastSum [sha256.Size]byte // content last applied
+	lastErr string
+}
+
+// NewSyncer creates a Syncer for the tasks file at path, checked for changes every
+// interval. With an empty path the Syncer only applies files passed to Plan and Apply.
+func NewSyncer(path string, interval time.Duration, st *store.Store, scheduler *core.Scheduler, logger *slog.Logger) *Syncer {
+	return &Syncer{path: path, interval: interval, store: st, scheduler: scheduler, logger: logger}
+}
+
+// Run checks the file and then polls it until ctx is done. A file that is missing or
+// invalid leaves the tasks as they are; the next valid version is applied.
+func (s *Syncer) Run(ctx context.Context) {
+	s.Check(ctx)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Check(ctx)
+		}
+	}
+}
+
+// Check applies the tasks file if its content changed since the last check. A failed
+// sync is retried on the next check but logged only when the error changes.
+func (s *Syncer) Check(ctx context.Context) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		s.fail(fmt.Errorf("read tasks file: %w", err))
+		return
+	}
+	sum := sha256.Sum256(data)
+	s.mu.Lock()
+	unchanged := sum == s.lastSum
+	s.mu.Unlock()
+	if unchanged {
+		return
+	}
+	file, err := Parse(data)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	changes, err := s.Apply(ctx, file)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	s.mu.Lock()
+	s.lastSum, s.lastErr = sum, ""
+	s.mu.Unlock()
+	s.logger.Info("synced tasks file", "path", s.path, "tasks", len(file.Tasks), "changes", len(changes))
+}
+
+// Reload applies the tasks file whether or not its content changed, for when the tasks
+// in the database were changed outside the daemon.
+func (s *Syncer) Reload(ctx context.Context) {
+	s.mu.Lock()
+	s.lastSum = [sha256.Size]byte{}
+	s.mu.Unlock()
+	s.Check(ctx)
+}
+
+func (s *Syncer) fail(err error) {
+	s.mu.Lock()
+	repeated := err.Error() == s.lastErr
+	s.lastErr = err.Error()
+	s.mu.Unlock()
+	if !repeated {
+		s.logger.Error("sync tasks file; keeping current tasks", "path", s.path, "err", err)
+	}
+}
+
+// Load reads and validates the watched tasks file.
+func (s *Syncer) Load() (*File, error) {
+	if s.path == "" {
+		return nil, ErrNoFile
+	}
+	return Load(s.path)
+}
+
+// Plan returns the changes Apply would make for file, without making them.
+func (s *Syncer) Plan(ctx context.Context, file *File) ([]Change, error) {
+	tasks, err := s.store.ListTasks(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("list tasks: %w", err)
+	}
+	return Plan(file, tasks)
+}
+
+// Apply plans the changes for file and stores them in one transaction; the scheduler
+// picks them up once it commits.
+func (s *Syncer) Apply(ctx context.Context, file *File) ([]Change, error) {
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+	changes, err := s.Plan(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	err = s.store.InTx(ctx, func(tx *store.Tx) error {
+		for _, change := range changes {
+			var err error
+			if change.Action == ActionCreate {
+				err = tx.InsertTask(ctx, change.Task)
+			} else {
+				err = tx.UpdateTask(ctx, change.Task)
+			}
+			if err != nil {
+				return fmt.Errorf("%s task %q: %w", change.Action, change.Name, err)
+			}
+			taskID := change.Task.ID
+			tx.OnCommit(func() {
+				if err := s.scheduler.RefreshTask(ctx, taskID); err != nil {
+					s.logger.Error("reschedule task", "task_id", taskID, "err", err)
+				}
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		fields := make([]string, 0, len(change.Fields))
+		for _, field := range change.Fields {
+			fields = append(fields, field.Field)
+		}
+		s.logger.Info("tasks file change", "action", change.Action, "name", change.Name, "task_id", change.Task.ID, "fields", fields)
+	}
+	return changes, nil
+}
@@ -0,0 +1,235 @@
+// Package taskfile keeps tasks declared in a YAML file in sync with the database, so task
+// definitions can live in version control and be deployed like code.
+package taskfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"clicrontab/internal/core"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoFile is returned by Syncer.Load when no tasks file is configured.
+var ErrNoFile = errors.New("no tasks file configured (CLICRON_TASKS_FILE)")
+
+// File is the parsed tasks file:
+//
+//	tasks:
+//	  - name: nightly-backup
+//	    command: ./backup.sh
+//	    cron: "0 3 * * *"
+//	    working_dir: /srv/app
+type File struct {
+	Tasks []Spec `yaml:"tasks"`
+}
+
+// Spec declares one task. Name identifies the task across syncs, so renaming a spec
+// archives the old task and creates a new one. Field names follow the HTTP API.
+type Spec struct {
+	Name               string `yaml:"name"`
+	Command            string `yaml:"command"`
+	Cron               string `yaml:"cron"`
+	WorkingDir         string `yaml:"working_dir"`
+	TimeoutSeconds     int    `yaml:"timeout_s"`
+	MinIntervalSeconds int    `yaml:"min_interval_s"`
+	PauseAfterFailures int    `yaml:"pause_after_failures"`
+	LogRetention       int    `yaml:"log_retention"`
+	LogMaxAgeDays      int    `yaml:"log_max_age_days"`
+	Executor           string `yaml:"executor"`
+	// HTTP is the request of a task using the http executor, which it selects when
+	// executor is unset; such tasks need no command.
+	HTTP *HTTPSpec `yaml:"http"`
+	// SQL is the query of a task using the sql executor, which it selects likewise.
+	SQL        *SQLSpec `yaml:"sql"`
+	RunOnStart bool     `yaml:"run_on_start"`
+	Paused     bool     `yaml:"paused"`
+}
+
+// HTTPSpec declares the request an http task sends.
+type HTTPSpec struct {
+	Method       string            `yaml:"method"`
+	URL          string            `yaml:"url"`
+	Headers      map[string]string `yaml:"headers"`
+	Body         string            `yaml:"body"`
+	ExpectStatus []int             `yaml:"expect_status"`
+}
+
+func (h *HTTPSpec) request() *core.HTTPRequest {
+	if h == nil {
+		return nil
+	}
+	return &core.HTTPRequest{Method: h.Method, URL: h.URL, Headers: h.Headers, Body: h.Body, ExpectStatus: h.ExpectStatus}
+}
+
+// SQLSpec declares the query a sql task runs and the assertions on its result.
+type SQLSpec struct {
+	Driver      string  `yaml:"driver"`
+	DSN         string  `yaml:"dsn"`
+	Query       string  `yaml:"query"`
+	MinRows     *int    `yaml:"min_rows"`
+	MaxRows     *int    `yaml:"max_rows"`
+	ExpectValue *string `yaml:"expect_value"`
+}
+
+func (q *SQLSpec) query() *core.SQLQuery {
+	if q == nil {
+		return nil
+	}
+	return &core.SQLQuery{Driver: q.Driver, DSN: q.DSN, Query: q.Query, MinRows: q.MinRows, MaxRows: q.MaxRows, ExpectValue: q.ExpectValue}
+}
+
+// Load reads and validates the tasks file at path.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tasks file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a tasks file. Unknown keys are rejected so a typo does not
+// silently drop a setting; all invalid specs are reported together. An empty file is an
+// error rather than "no tasks", since it is more likely a file caught mid-write; declare
+// "tasks: []" to remove every task.
+func Parse(data []byte) (*File, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, errors.New("tasks file is empty")
+	}
+	var file File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parse tasks file: %w", err)
+	}
+
+	var problems []string
+	seen := make(map[string]bool)
+	for i := range file.Tasks {
+		spec := &file.Tasks[i]
+		spec.Name = strings.TrimSpace(spec.Name)
+		spec.Command = strings.TrimSpace(spec.Command)
+		spec.Cron = strings.TrimSpace(spec.Cron)
+		spec.WorkingDir = strings.TrimSpace(spec.WorkingDir)
+		spec.Executor = strings.TrimSpace(spec.Executor)
+		if spec.Executor == "" {
+			switch {
+			case spec.HTTP != nil:
+				spec.Executor = core.ExecutorHTTP
+			case spec.SQL != nil:
+				spec.Executor = core.ExecutorSQL
+			}
+		}
+
+		label := fmt.Sprintf("tasks[%d]", i)
+		if spec.Name != "" {
+			label += fmt.Sprintf(" (%s)", spec.Name)
+		}
+		switch {
+		case spec.Name == "":
+			problems = append(problems, label+": name is required")
+		case seen[spec.Name]:
+			problems = append(problems, label+": name is used by an earlier task")
+		}
+		seen[spec.Name] = true
+		probe := spec.task(nil)
+		field := core.ExecutorConfigField(probe.ExecutorName())
+		if spec.HTTP != nil && field != "http" {
+			problems = append(problems, label+": http is only used by the http executor")
+		}
+		if spec.SQL != nil && field != "sql" {
+			problems = append(problems, label+": sql is only used by the sql executor")
+		}
+		var configErr *core.ExecutorConfigError
+		if err := core.ValidateExecutorConfig(probe); errors.As(err, &configErr) {
+			problems = append(problems, fmt.Sprintf("%s: %s: %v", label, configErr.Field, configErr.Err))
+		}
+		if field == "" && spec.Command == "" {
+			problems = append(problems, label+": command is required")
+		}
+		if err := core.ValidateTemplate(spec.Command); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: command: %v", label, err))
+		}
+		if err := core.ValidateWorkingDir(spec.WorkingDir); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: working_dir: %v", label, err))
+		}
+		if spec.Cron == "" {
+			problems = append(problems, label+": cron is required")
+		} else if _, err := core.ParseCron(spec.Cron); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid cron: %v", label, err))
+		}
+		if spec.TimeoutSeconds < 0 || spec.MinIntervalSeconds < 0 || spec.PauseAfterFailures < 0 || spec.LogRetention < 0 || spec.LogMaxAgeDays < 0 {
+			problems = append(problems, label+": timeout_s, min_interval_s, pause_after_failures, log_retention and log_max_age_days must not be negative")
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid tasks file:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return &file, nil
+}
+
+// task returns the task the spec describes, applied on top of current (nil for a new
+// task). Runtime state such as last/next run times is kept.
+func (s Spec) task(current *core.Task) *core.Task {
+	task := &core.Task{ID: core.NewID(), Source: core.TaskSourceFile}
+	if current != nil {
+		copied := *current
+		task = &copied
+	}
+	name := s.Name
+	task.Name = &name
+	task.Command = s.Command
+	task.Prompt = s.Command
+	// Tasks files declare commands; a script set through the API is replaced by it
+	task.ScriptID = nil
+	if task.Cron != s.Cron {
+		// The scheduler computes the new next run once the change is stored
+		task.NextRunAt = nil
+	}
+	task.Cron = s.Cron
+	task.WorkingDir = optionalString(s.WorkingDir)
+	task.TimeoutSeconds = optionalInt(s.TimeoutSeconds)
+	task.MinIntervalSeconds = optionalInt(s.MinIntervalSeconds)
+	task.PauseAfterFailures = optionalInt(s.PauseAfterFailures)
+	task.LogRetention = optionalInt(s.LogRetention)
+	task.LogMaxAgeDays = optionalInt(s.LogMaxAgeDays)
+	task.Executor = optionalString(s.Executor)
+	if s.Executor == core.ExecutorShell {
+		task.Executor = nil
+	}
+	task.HTTP = s.HTTP.request()
+	task.SQL = s.SQL.query()
+	core.PrepareExecutorConfig(task)
+	task.RunOnStart = s.RunOnStart
+	if s.Paused {
+		if task.Status != core.TaskStatusPaused {
+			task.Status = core.TaskStatusPaused
+			task.PauseUntil = nil
+		}
+	} else {
+		task.Status = core.TaskStatusActive
+		task.PauseUntil = nil
+	}
+	if task.Status != core.TaskStatusActive {
+		task.NextRunAt = nil
+	}
+	return task
+}
+
+func optionalString(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func optionalInt(v int) *int {
+	if v <= 0 {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,204 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// maxDownloadSize caps how much of a release asset is read into memory.
+const maxDownloadSize = 200 << 20
+
+// binaryName is the executable inside release archives.
+const binaryName = "clicrontabd"
+
+// ErrNoChecksum is returned by Download when the release publishes no checksum for the asset.
+var ErrNoChecksum = errors.New("release has no checksum for asset")
+
+// Download fetches the asset and returns the clicrontabd executable it contains.
+// The download is verified against the release's checksums.txt when present; if the
+// release has none, the binary is still returned together with ErrNoChecksum.
+func (c *Client) Download(ctx context.Context, release *Release, asset *Asset) ([]byte, error) {
+	data, err := c.fetch(ctx, asset.BrowserDownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", asset.Name, err)
+	}
+
+	var checksumErr error
+	if sums := release.asset(ChecksumsAsset); sums != nil {
+		list, err := c.fetch(ctx, sums.BrowserDownloadURL)
+		if err != nil {
+			return nil, fmt.Errorf("download %s: %w", ChecksumsAsset, err)
+		}
+		if err := verifyChecksum(list, asset.Name, data); err != nil {
+			return nil, err
+		}
+	} else {
+		checksumErr = ErrNoChecksum
+	}
+
+	binary, err := extractBinary(asset.Name, data)
+	if err != nil {
+		return nil, err
+	}
+	return binary, checksumErr
+}
+
+func (c *Client) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "clicrontab-updater")
+
+	// Downloads can be large; rely on ctx for the deadline instead of the API timeout
+	client := *c.HTTP
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("larger than %d bytes", maxDownloadSize)
+	}
+	return data, nil
+}
+
+// verifyChecksum checks data against the "<sha256>  <name>" entry for name in list.
+func verifyChecksum(list []byte, name string, data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(list))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+			return fmt.Errorf("checksum mismatch for %s", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s has no entry for %s", ChecksumsAsset, name)
+}
+
+// extractBinary returns the executable from a .tar.gz or .zip asset, or the asset itself
+// when it is a bare binary.
+func extractBinary(name string, data []byte) ([]byte, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", name, err)
+		}
+		defer gz.Close()
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("read %s: %w", name, err)
+			}
+			if hdr.Typeflag == tar.TypeReg && isBinaryEntry(hdr.Name) {
+				return io.ReadAll(io.LimitReader(tr, maxDownloadSize))
+			}
+		}
+	case strings.HasSuffix(lower, ".zip"):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", name, err)
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || !isBinaryEntry(f.Name) {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("read %s: %w", name, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxDownloadSize))
+		}
+	default:
+		return data, nil
+	}
+	return nil, fmt.Errorf("%s does not contain %s", name, binaryName)
+}
+
+func isBinaryEntry(name string) bool {
+	base := path.Base(filepath.ToSlash(name))
+	return base == binaryName || base == binaryName+".exe"
+}
+
+// Replace swaps the executable at exePath for binary. The new file is written next to
+// the old one and renamed over it, so a failed update leaves the original in place.
+// On Windows the running executable cannot be overwritten, so it is moved to
+// "<exe>.old" first; that file is removed by the next update.
+func Replace(exePath string, binary []byte) error {
+	info, err := os.Stat(exePath)
+	if err != nil {
+		return fmt.Errorf("stat executable: %w", err)
+	}
+
+	dir := filepath.Dir(exePath)
+	tmp, err := os.CreateTemp(dir, ".clicrontabd-update-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write new binary: %w", err)
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0o111); err != nil {
+		return fmt.Errorf("chmod new binary: %w", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		oldPath := exePath + ".old"
+		_ = os.Remove(oldPath)
+		if err := os.Rename(exePath, oldPath); err != nil {
+			return fmt.Errorf("move old binary aside: %w", err)
+		}
+		if err := os.Rename(tmpPath, exePath); err != nil {
+			_ = os.Rename(oldPath, exePath)
+			return fmt.Errorf("install new binary: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.Rename(tmpPath, exePath); err != nil {
+		return fmt.Errorf("install new binary: %w", err)
+	}
+	return nil
+}
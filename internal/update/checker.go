@@ -0,0 +1,81 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"clicrontab/internal/notify"
+)
+
+// Checker periodically looks for a newer release and announces it once per version.
+type Checker struct {
+	client   *Client
+	current  string
+	interval time.Duration
+	notifier notify.Notifier
+	logger   *slog.Logger
+
+	announced string
+}
+
+// NewChecker creates a checker for the running version. notifier may be nil.
+func NewChecker(client *Client, current string, interval time.Duration, notifier notify.Notifier, logger *slog.Logger) *Checker {
+	return &Checker{
+		client:   client,
+		current:  current,
+		interval: interval,
+		notifier: notifier,
+		logger:   logger,
+	}
+}
+
+// Run checks once shortly after start and then every interval until ctx is cancelled.
+func (c *Checker) Run(ctx context.Context) {
+	// Let startup settle before going to the network
+	timer := time.NewTimer(time.Minute)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		c.Check(ctx)
+		timer.Reset(c.interval)
+	}
+}
+
+// Check queries the latest release and logs/notifies when it is newer than the running version.
+func (c *Checker) Check(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	release, err := c.client.Latest(checkCtx)
+	if err != nil {
+		if !errors.Is(err, ErrNoRelease) {
+			c.logger.Warn("update check failed", "err", err)
+		}
+		return
+	}
+	if !IsNewer(c.current, release.TagName) {
+		c.logger.Debug("no update available", "current", c.current, "latest", release.TagName)
+		return
+	}
+
+	c.logger.Info("update available", "current", c.current, "latest", release.TagName, "url", release.HTMLURL)
+	if c.announced == release.TagName || c.notifier == nil {
+		return
+	}
+	c.announced = release.TagName
+
+	title := "[clicrontab] Update Available"
+	body := fmt.Sprintf("clicrontab %s is available (running %s).\nRun `clicrontabd self-update` to install it.\n%s",
+		release.TagName, c.current, release.HTMLURL)
+	if err := c.notifier.Send(checkCtx, title, body); err != nil {
+		c.logger.Warn("send update notification", "err", err)
+	}
+}
@@ -0,0 +1,155 @@
+// Package update checks GitHub releases for newer clicrontab builds and replaces the running binary.
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRepo is the GitHub repository releases are published to.
+const DefaultRepo = "zhaopengme/clicron"
+
+// ChecksumsAsset is the release asset listing "<sha256>  <asset name>" lines.
+const ChecksumsAsset = "checksums.txt"
+
+// ErrNoRelease is returned when the repository has no published release.
+var ErrNoRelease = errors.New("no release published")
+
+// Release is the subset of a GitHub release used by the updater.
+type Release struct {
+	TagName string  `json:"tag_name"`
+	HTMLURL string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a downloadable file attached to a release.
+type Asset struct {
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// Client talks to the GitHub releases API.
+type Client struct {
+	Repo string
+	HTTP *http.Client
+	// BaseURL overrides the API endpoint, e.g. for GitHub Enterprise.
+	BaseURL string
+}
+
+// NewClient creates a client for the given "owner/name" repository.
+func NewClient(repo string) *Client {
+	if repo == "" {
+		repo = DefaultRepo
+	}
+	return &Client{
+		Repo:    repo,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+		BaseURL: "https://api.github.com",
+	}
+}
+
+// Latest returns the newest non-draft, non-prerelease release.
+func (c *Client) Latest(ctx context.Context) (*Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", strings.TrimRight(c.BaseURL, "/"), c.Repo)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create release request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "clicrontab-updater")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch latest release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNoRelease
+	case resp.StatusCode >= 400:
+		return nil, fmt.Errorf("github returned status: %d", resp.StatusCode)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("decode release: %w", err)
+	}
+	if release.TagName == "" {
+		return nil, ErrNoRelease
+	}
+	return &release, nil
+}
+
+// FindAsset returns the release asset built for goos/goarch, e.g. "clicrontabd_linux_amd64.tar.gz".
+func (r *Release) FindAsset(goos, goarch string) (*Asset, error) {
+	platform := goos + "_" + goarch
+	for i := range r.Assets {
+		name := strings.ToLower(r.Assets[i].Name)
+		if !strings.Contains(name, platform) && !strings.Contains(name, goos+"-"+goarch) {
+			continue
+		}
+		if strings.HasSuffix(name, ".sha256") || strings.HasSuffix(name, ".sig") {
+			continue
+		}
+		return &r.Assets[i], nil
+	}
+	return nil, fmt.Errorf("release %s has no asset for %s", r.TagName, platform)
+}
+
+// asset returns the asset with the exact name, if any.
+func (r *Release) asset(name string) *Asset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// IsNewer reports whether latest is a higher version than current.
+// Versions are compared as vMAJOR.MINOR.PATCH; anything unparseable is never newer.
+func IsNewer(current, latest string) bool {
+	cur, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	next, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	for i := range cur {
+		if next[i] != cur[i] {
+			return next[i] > cur[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses "v1.2.3" (pre-release and build suffixes are ignored).
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
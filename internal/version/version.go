@@ -0,0 +1,12 @@
+// Package version holds the build version of clicrontab.
+package version
+
+// Version is the release tag the binary was built from. Release builds set it with
+//
+//	go build -ldflags "-X clicrontab/internal/version.Version=v1.2.3" ./cmd/clicrontabd
+var Version = "dev"
+
+// IsRelease reports whether the binary was built from a tagged release.
+func IsRelease() bool {
+	return Version != "" && Version != "dev"
+}
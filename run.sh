@@ -64,7 +64,7 @@ case "$1" in
         fi
 
         echo "Starting clicrontabd..."
-        nohup go run ./cmd/clicrontabd/main.go >> "$LOG_FILE" 2>&1 &
+        nohup go run ./cmd/clicrontabd >> "$LOG_FILE" 2>&1 &
         sleep 2
 
         if is_running; then
@@ -0,0 +1,80 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// AssetPrefix is the URL path static assets are served under.
+const AssetPrefix = "/assets/"
+
+// Asset is an embedded file ready to serve.
+type Asset struct {
+	// Name is the file name, e.g. "app.js".
+	Name string
+	// URL is the fingerprinted path, e.g. "/assets/app.3f9a1c2b0d4e.js". It changes
+	// whenever the content does, so responses for it can be cached forever.
+	URL     string
+	ETag    string
+	Content []byte
+}
+
+var (
+	assetsOnce sync.Once
+	assets     map[string]*Asset
+	assetsErr  error
+)
+
+// Assets returns the embedded files keyed by name. Fingerprints are hashes of the
+// embedded content, fixed when the binary is built; index.html is rewritten to
+// reference the fingerprinted URLs, so a daemon upgrade never serves a stale script
+// from the browser cache.
+func Assets() (map[string]*Asset, error) {
+	assetsOnce.Do(func() {
+		assets, assetsErr = loadAssets(content)
+	})
+	return assets, assetsErr
+}
+
+func loadAssets(fsys fs.FS) (map[string]*Asset, error) {
+	names, err := fs.Glob(fsys, "*")
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*Asset, len(names))
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		byName[name] = newAsset(name, data)
+	}
+
+	if index, ok := byName["index.html"]; ok {
+		html := index.Content
+		for name, asset := range byName {
+			if name != "index.html" {
+				html = bytes.ReplaceAll(html, []byte(`"`+AssetPrefix+name+`"`), []byte(`"`+asset.URL+`"`))
+			}
+		}
+		byName["index.html"] = newAsset("index.html", html)
+	}
+	return byName, nil
+}
+
+func newAsset(name string, data []byte) *Asset {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:6])
+	ext := path.Ext(name)
+	return &Asset{
+		Name:    name,
+		URL:     AssetPrefix + strings.TrimSuffix(name, ext) + "." + hash + ext,
+		ETag:    `"` + hash + `"`,
+		Content: data,
+	}
+}